@@ -0,0 +1,171 @@
+package main
+
+import (
+	"address-api/internal/cep"
+	"address-api/internal/config"
+	"address-api/internal/database"
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"address-api/internal/schedule"
+	"address-api/internal/territory"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	importUsage     = "usage: import [-dry-run] [-format csv|geojson] <file>"
+	importCEPsUsage = "usage: import-ceps [-dry-run] <file.csv>"
+	holidaysUsage   = "usage: import-holidays [-optional] [-dry-run] <year>"
+)
+
+// runImport implementa o subcomando "import", a versao de linha de comando
+// do POST /streets/import
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only validate the file")
+	format := flags.String("format", "", "csv or geojson (default: from the file extension)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf(importUsage)
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := territory.Parse(*format, file)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitDB(
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+
+	importer := territory.NewImporter(repository.NewTeamRepository(db), repository.NewStreetSegmentRepository(db))
+	report, err := importer.Import(rows, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	printReport(report)
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d rows with errors, nothing was imported", len(report.Errors))
+	}
+	return nil
+}
+
+// runImportCEPs implementa o subcomando "import-ceps", que carrega um dump
+// CSV do DNE/ViaCEP na tabela local de CEPs. As linhas validas sao gravadas
+// mesmo que outras linhas tenham erros.
+func runImportCEPs(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import-ceps", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only validate the file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf(importCEPsUsage)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db, err := database.InitDB(
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+
+	report, err := cep.NewLoader(repository.NewCEPRepository(db)).Load(file, *dryRun)
+	if err != nil {
+		return err
+	}
+	printReport(report)
+	return nil
+}
+
+// runImportHolidays implementa o subcomando "import-holidays", a versao de
+// linha de comando do POST /holidays/import
+func runImportHolidays(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import-holidays", flag.ContinueOnError)
+	optional := flags.Bool("optional", false, "include optional days off (Carnival and Corpus Christi)")
+	dryRun := flags.Bool("dry-run", false, "only print the holidays")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf(holidaysUsage)
+	}
+	year, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf(holidaysUsage)
+	}
+
+	holidays := schedule.NationalHolidays(year, *optional)
+	for _, holiday := range holidays {
+		fmt.Printf("%s %s\n", holiday.Date, holiday.Name)
+	}
+	if *dryRun {
+		return nil
+	}
+
+	db, err := database.InitDB(
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	if err := repository.NewHolidayRepository(db).Upsert(holidays); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d holidays\n", len(holidays))
+	return nil
+}
+
+func printReport(report *models.ImportReport) {
+	for _, rowErr := range report.Errors {
+		if rowErr.Field != "" {
+			fmt.Printf("row %d: %s: %s\n", rowErr.Row, rowErr.Field, rowErr.Message)
+		} else {
+			fmt.Printf("row %d: %s\n", rowErr.Row, rowErr.Message)
+		}
+	}
+	fmt.Printf("rows: %d, valid: %d, created: %d\n", report.TotalRows, report.ValidRows, report.Created)
+}
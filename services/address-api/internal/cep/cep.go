@@ -0,0 +1,19 @@
+// Package cep resolve CEPs para enderecos usando uma copia local da base de
+// CEPs (DNE ou ViaCEP), sem depender de servicos externos.
+package cep
+
+import (
+	"address-api/internal/utils"
+	"errors"
+)
+
+var ErrInvalidCEP = errors.New("CEP must have 8 digits")
+
+// Normalize remove pontuacao e valida que o CEP tem 8 digitos
+func Normalize(value string) (string, error) {
+	cep := utils.NormalizeCEP(value)
+	if len(cep) != 8 {
+		return "", ErrInvalidCEP
+	}
+	return cep, nil
+}
@@ -0,0 +1,231 @@
+package cep
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"shared/normalize"
+	"strings"
+)
+
+var (
+	ErrNoRows      = errors.New("no CEPs to load")
+	ErrInvalidFile = errors.New("invalid CEP file")
+)
+
+const (
+	// batchSize e quantos CEPs sao gravados por vez; a base completa tem
+	// perto de um milhao de linhas e e lida em streaming
+	batchSize = 1000
+	// maxReportedErrors limita o relatorio quando o arquivo tem muitas linhas ruins
+	maxReportedErrors = 100
+)
+
+// columnAliases aceita as colunas do ViaCEP (logradouro, localidade, uf) e
+// os nomes usados nas exportacoes do DNE
+var columnAliases = map[string]string{
+	"cep":             "cep",
+	"logradouro":      "street_name",
+	"street_name":     "street_name",
+	"street":          "street_name",
+	"rua":             "street_name",
+	"tipo_logradouro": "street_type",
+	"tipo":            "street_type",
+	"street_type":     "street_type",
+	"bairro":          "neighborhood",
+	"neighborhood":    "neighborhood",
+	"localidade":      "city",
+	"cidade":          "city",
+	"municipio":       "city",
+	"city":            "city",
+	"uf":              "state",
+	"state":           "state",
+}
+
+var requiredColumns = []string{"cep", "street_name", "city", "state"}
+
+// Loader carrega um CSV da base de CEPs. Diferente da importacao de
+// segmentos, a carga nao e atomica: as linhas validas sao gravadas e as
+// invalidas vao para o relatorio.
+type Loader struct {
+	ceps repository.CEPRepository
+}
+
+func NewLoader(ceps repository.CEPRepository) *Loader {
+	return &Loader{ceps: ceps}
+}
+
+// Load le o CSV e grava os CEPs em lotes, substituindo os ja existentes.
+// Com dryRun apenas valida. Em Created vem o numero de CEPs gravados.
+func (l *Loader) Load(r io.Reader, dryRun bool) (*models.ImportReport, error) {
+	reader, err := newCSVReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid CSV header: %v", ErrInvalidFile, err)
+	}
+	columns := make([]string, len(header))
+	found := map[string]bool{}
+	for i, name := range header {
+		columns[i] = columnAliases[strings.ToLower(strings.TrimSpace(name))]
+		found[columns[i]] = true
+	}
+	var missing []string
+	for _, column := range requiredColumns {
+		if !found[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing CSV columns: %s", ErrInvalidFile, strings.Join(missing, ", "))
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Errors: []models.ImportRowError{}}
+	addError := func(rowErr models.ImportRowError) {
+		if len(report.Errors) < maxReportedErrors {
+			report.Errors = append(report.Errors, rowErr)
+		}
+	}
+
+	// O mesmo CEP pode aparecer duas vezes no arquivo; dentro de um lote vale
+	// a ultima linha, pois o upsert do Postgres nao aceita chaves repetidas
+	batch := make([]models.CEPAddress, 0, batchSize)
+	positions := map[string]int{}
+	flush := func() error {
+		if !dryRun && len(batch) > 0 {
+			if err := l.ceps.Upsert(batch); err != nil {
+				return err
+			}
+			report.Created += len(batch)
+		}
+		batch = batch[:0]
+		clear(positions)
+		return nil
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.TotalRows++
+			addError(models.ImportRowError{Row: line, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			// Erro de leitura do arquivo, nao de uma linha
+			return nil, err
+		}
+
+		values := map[string]string{}
+		empty := true
+		for i, value := range record {
+			if i < len(columns) && columns[i] != "" {
+				values[columns[i]] = strings.TrimSpace(value)
+				empty = empty && values[columns[i]] == ""
+			}
+		}
+		if empty {
+			continue
+		}
+		report.TotalRows++
+
+		address, rowErr := addressFromValues(values)
+		if rowErr != nil {
+			rowErr.Row = line
+			addError(*rowErr)
+			continue
+		}
+		report.ValidRows++
+
+		if i, ok := positions[address.CEP]; ok {
+			batch[i] = address
+			continue
+		}
+		positions[address.CEP] = len(batch)
+		batch = append(batch, address)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if report.TotalRows == 0 {
+		return nil, ErrNoRows
+	}
+	return report, nil
+}
+
+func addressFromValues(values map[string]string) (models.CEPAddress, *models.ImportRowError) {
+	cep, err := Normalize(values["cep"])
+	if err != nil {
+		return models.CEPAddress{}, &models.ImportRowError{Field: "cep", Message: err.Error()}
+	}
+
+	streetType, street := values["street_type"], values["street_name"]
+	if streetType == "" {
+		streetType, street = normalize.SplitStreetType(street)
+	}
+	if street == "" {
+		// CEPs gerais de cidades pequenas nao tem logradouro
+		return models.CEPAddress{}, &models.ImportRowError{Field: "street_name", Message: "street is empty (city-wide CEP)"}
+	}
+
+	address := models.CEPAddress{
+		CEP:                cep,
+		StreetType:         normalize.StreetType(streetType),
+		StreetName:         normalize.StreetName(street),
+		OriginalStreetName: street,
+		Neighborhood:       normalize.Text(values["neighborhood"]),
+		City:               normalize.Text(values["city"]),
+		State:              strings.ToUpper(values["state"]),
+	}
+	if address.City == "" {
+		return address, &models.ImportRowError{Field: "city", Message: "required field is missing"}
+	}
+	if len(address.State) != 2 {
+		return address, &models.ImportRowError{Field: "state", Message: "state must have 2 letters"}
+	}
+	return address, nil
+}
+
+// newCSVReader descarta o BOM do UTF-8 e detecta o separador pela primeira
+// linha, sem carregar o arquivo inteiro na memoria
+func newCSVReader(r io.Reader) (*csv.Reader, error) {
+	buffered := bufio.NewReaderSize(r, 64<<10)
+	if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		buffered.Discard(3)
+	}
+
+	firstLine, err := buffered.Peek(buffered.Size())
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	firstLine, _, _ = bytes.Cut(firstLine, []byte("\n"))
+
+	reader := csv.NewReader(buffered)
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+	return reader, nil
+}
@@ -0,0 +1,92 @@
+package cep
+
+import (
+	"address-api/internal/repository"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	if got, err := Normalize("13560-000"); err != nil || got != "13560000" {
+		t.Errorf("Normalize(13560-000) = %q, %v", got, err)
+	}
+	if _, err := Normalize("13560"); err != ErrInvalidCEP {
+		t.Errorf("expected ErrInvalidCEP for a prefix, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	data := "\xef\xbb\xbfcep;logradouro;complemento;bairro;localidade;uf\n" +
+		"13560-000;Rua das Flores;;Centro;São Carlos;SP\n" +
+		"13560-001;Avenida São Carlos;até 999/1000;Centro;São Carlos;sp\n" +
+		"1356;Rua Sem CEP;;Centro;São Carlos;SP\n" +
+		"13570-000;;;;Ibaté;SP\n" +
+		";;;;;\n" +
+		"13560-000;Rua das Flores;;Vila Nery;São Carlos;SP\n"
+
+	store := repository.NewMemoryStore()
+	loader := NewLoader(store.CEPs())
+
+	t.Run("dry run only validates", func(t *testing.T) {
+		report, err := loader.Load(strings.NewReader(data), true)
+		if err != nil || report.TotalRows != 5 || report.ValidRows != 3 || report.Created != 0 {
+			t.Fatalf("unexpected report %+v (%v)", report, err)
+		}
+		if _, err := store.CEPs().Get("13560000"); err != repository.ErrNotFound {
+			t.Errorf("expected nothing stored, got %v", err)
+		}
+	})
+
+	t.Run("valid rows are stored", func(t *testing.T) {
+		report, err := loader.Load(strings.NewReader(data), false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// O CEP repetido e gravado uma vez so
+		if report.Created != 2 || len(report.Errors) != 2 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if got := report.Errors[0]; got.Row != 4 || got.Field != "cep" {
+			t.Errorf("unexpected CEP error %+v", got)
+		}
+		if got := report.Errors[1]; got.Row != 5 || got.Field != "street_name" {
+			t.Errorf("unexpected city-wide CEP error %+v", got)
+		}
+
+		address, err := store.CEPs().Get("13560000")
+		if err != nil {
+			t.Fatalf("expected CEP to be stored: %v", err)
+		}
+		if address.StreetType != "RUA" || address.StreetName != "FLORES" || address.OriginalStreetName != "das Flores" ||
+			address.Neighborhood != "VILA NERY" || address.City != "SAO CARLOS" || address.State != "SP" {
+			t.Errorf("unexpected address %+v", address)
+		}
+		if avenue, _ := store.CEPs().Get("13560001"); avenue == nil || avenue.StreetType != "AVENIDA" || avenue.StreetName != "SAO CARLOS" {
+			t.Errorf("unexpected avenue %+v", avenue)
+		}
+	})
+
+	t.Run("street type column is used when present", func(t *testing.T) {
+		_, err := loader.Load(strings.NewReader("cep,tipo_logradouro,logradouro,bairro,cidade,uf\n13566-590,Travessa,Um,Jardim,São Carlos,SP\n"), false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if address, _ := store.CEPs().Get("13566590"); address == nil || address.StreetType != "TRAVESSA" || address.StreetName != "1" {
+			t.Errorf("unexpected address %+v", address)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		if _, err := loader.Load(strings.NewReader("cep,bairro\n13560000,Centro\n"), false); !errors.Is(err, ErrInvalidFile) ||
+			!strings.Contains(err.Error(), "street_name, city, state") {
+			t.Errorf("expected missing columns error, got %v", err)
+		}
+		if _, err := loader.Load(strings.NewReader(""), false); err != ErrNoRows {
+			t.Errorf("expected ErrNoRows, got %v", err)
+		}
+		if _, err := loader.Load(strings.NewReader("cep,logradouro,localidade,uf\n"), false); err != ErrNoRows {
+			t.Errorf("expected ErrNoRows for header only, got %v", err)
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package config
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 type Config struct {
 	Port             string
@@ -9,16 +12,34 @@ type Config struct {
 	PostgresPassword string
 	PostgresDB       string
 	PostgresPort     string
+
+	// Origens aceitas pelo CORS separadas por virgula, "*" aceita qualquer uma
+	CORSAllowedOrigins string
+
+	// Aplica as migracoes pendentes antes de verificar o schema na inicializacao
+	MigrateOnStart bool
+
+	// Tempo que registros apagados ficam disponiveis para restauracao
+	DeletedRetention time.Duration
+	PurgeInterval    time.Duration
+
+	// Intervalo entre as aplicacoes das reatribuicoes de segmentos agendadas
+	ReassignmentInterval time.Duration
 }
 
 func Load() *Config {
 	return &Config{
-		Port:             getEnv("ADDRESS_API_PORT", "8083"),
-		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
-		PostgresUser:     getEnv("POSTGRES_USER", "postgres"),
-		PostgresPassword: getEnv("POSTGRES_PASSWORD", "postgres"),
-		PostgresDB:       getEnv("POSTGRES_DB", "addresses"),
-		PostgresPort:     getEnv("POSTGRES_PORT", "5432"),
+		Port:                 getEnv("ADDRESS_API_PORT", "8083"),
+		PostgresHost:         getEnv("POSTGRES_HOST", "localhost"),
+		PostgresUser:         getEnv("POSTGRES_USER", "postgres"),
+		PostgresPassword:     getEnv("POSTGRES_PASSWORD", "postgres"),
+		PostgresDB:           getEnv("POSTGRES_DB", "addresses"),
+		PostgresPort:         getEnv("POSTGRES_PORT", "5432"),
+		CORSAllowedOrigins:   getEnv("CORS_ALLOWED_ORIGINS", "*"),
+		MigrateOnStart:       getEnv("MIGRATE_ON_START", "false") == "true",
+		DeletedRetention:     getEnvDuration("DELETED_RETENTION", 90*24*time.Hour),
+		PurgeInterval:        getEnvDuration("PURGE_INTERVAL", 24*time.Hour),
+		ReassignmentInterval: getEnvDuration("REASSIGNMENT_INTERVAL", time.Hour),
 	}
 }
 
@@ -28,3 +49,12 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
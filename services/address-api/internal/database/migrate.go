@@ -0,0 +1,19 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"shared/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsTable guarda a versao atual do schema. O nome tem o prefixo do
+// servico porque address-api e user-api podem usar o mesmo banco Postgres.
+const migrationsTable = "address_api_schema_migrations"
+
+// NewMigrator retorna um migrator para as migracoes embutidas e os passos de dados
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrationsTable, migrationFiles, dataMigrations)
+}
@@ -0,0 +1,4 @@
+-- update_updated_at_column() and pg_trgm are kept because user-api may use them
+DROP TABLE IF EXISTS street_segments;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS ubs;
@@ -50,7 +50,8 @@ CREATE INDEX IF NOT EXISTS idx_street_segments_city_state ON street_segments(cit
 CREATE INDEX IF NOT EXISTS idx_street_segments_street_name_trgm 
 ON street_segments USING gin (street_name gin_trgm_ops);
 
--- Create function to update updated_at timestamp
+-- Create function to update updated_at timestamp (shared with user-api when
+-- both services use the same database)
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
 BEGIN
@@ -59,39 +60,54 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
--- Create triggers for updated_at
+-- Create triggers for updated_at. Databases built by the old init scripts
+-- or by AutoMigrate may have them already, so they are recreated.
+DROP TRIGGER IF EXISTS update_ubs_updated_at ON ubs;
 CREATE TRIGGER update_ubs_updated_at
     BEFORE UPDATE ON ubs
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_teams_updated_at ON teams;
 CREATE TRIGGER update_teams_updated_at
     BEFORE UPDATE ON teams
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_street_segments_updated_at ON street_segments;
 CREATE TRIGGER update_street_segments_updated_at
     BEFORE UPDATE ON street_segments
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
--- Add check constraints
-ALTER TABLE street_segments 
-    ADD CONSTRAINT check_start_end_number 
-    CHECK (start_number <= end_number);
+-- Add check and unique constraints, unless an existing database has them
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_start_end_number') THEN
+        ALTER TABLE street_segments
+            ADD CONSTRAINT check_start_end_number
+            CHECK (start_number <= end_number);
+    END IF;
 
-ALTER TABLE ubs
-    ADD CONSTRAINT check_state_length
-    CHECK (LENGTH(state) = 2);
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_state_length') THEN
+        ALTER TABLE ubs
+            ADD CONSTRAINT check_state_length
+            CHECK (LENGTH(state) = 2);
+    END IF;
 
-ALTER TABLE street_segments
-    ADD CONSTRAINT check_state_length_segments
-    CHECK (LENGTH(state) = 2);
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_state_length_segments') THEN
+        ALTER TABLE street_segments
+            ADD CONSTRAINT check_state_length_segments
+            CHECK (LENGTH(state) = 2);
+    END IF;
 
--- Add unique constraints where appropriate
-ALTER TABLE teams
-    ADD CONSTRAINT unique_team_name_per_ubs
-    UNIQUE (name, ubs_id);
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'unique_team_name_per_ubs') THEN
+        ALTER TABLE teams
+            ADD CONSTRAINT unique_team_name_per_ubs
+            UNIQUE (name, ubs_id);
+    END IF;
+END
+$$;
 
 -- Add comment documentation
 COMMENT ON TABLE ubs IS 'Basic Health Units (Unidades Básicas de Saúde)';
@@ -0,0 +1,17 @@
+ALTER TABLE street_segments DROP CONSTRAINT IF EXISTS street_segments_team_id_fkey;
+ALTER TABLE street_segments
+    ADD CONSTRAINT street_segments_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_ubs_id_fkey;
+ALTER TABLE teams
+    ADD CONSTRAINT teams_ubs_id_fkey
+    FOREIGN KEY (ubs_id) REFERENCES ubs(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_street_segments_deleted_at;
+DROP INDEX IF EXISTS idx_teams_deleted_at;
+DROP INDEX IF EXISTS idx_ubs_deleted_at;
+
+ALTER TABLE street_segments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE teams DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE ubs DROP COLUMN IF EXISTS deleted_at;
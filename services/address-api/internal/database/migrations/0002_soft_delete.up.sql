@@ -0,0 +1,19 @@
+ALTER TABLE ubs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE street_segments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_ubs_deleted_at ON ubs(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_teams_deleted_at ON teams(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_street_segments_deleted_at ON street_segments(deleted_at);
+
+-- Soft deletion is handled by the application, hard deletes must not cascade
+-- silently from UBS to teams and street segments
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_ubs_id_fkey;
+ALTER TABLE teams
+    ADD CONSTRAINT teams_ubs_id_fkey
+    FOREIGN KEY (ubs_id) REFERENCES ubs(id) ON DELETE RESTRICT;
+
+ALTER TABLE street_segments DROP CONSTRAINT IF EXISTS street_segments_team_id_fkey;
+ALTER TABLE street_segments
+    ADD CONSTRAINT street_segments_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE RESTRICT;
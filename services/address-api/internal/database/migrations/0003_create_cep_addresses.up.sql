@@ -0,0 +1,13 @@
+-- Local copy of the CEP dataset (DNE/ViaCEP dumps), used to resolve a CEP
+-- to its street, neighborhood and city
+CREATE TABLE IF NOT EXISTS cep_addresses (
+    cep CHAR(8) PRIMARY KEY,
+    street_type VARCHAR(50) NOT NULL DEFAULT '',
+    street_name VARCHAR(200) NOT NULL,
+    original_street_name VARCHAR(200) NOT NULL DEFAULT '',
+    neighborhood VARCHAR(100) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL,
+    state CHAR(2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- The previous normalization is not kept, so rolling back only moves the
+-- schema version. Searches keep working with the new names.
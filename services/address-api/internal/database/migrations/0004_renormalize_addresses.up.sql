@@ -0,0 +1,3 @@
+-- Re-normalizes street_segments and cep_addresses with the normalize package
+-- (accents, street types, titles, number words and stop-words). The rules live
+-- in Go, so the work is done by the data step registered in renormalize.go.
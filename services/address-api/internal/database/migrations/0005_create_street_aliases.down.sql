@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_street_segments_street_city_state;
+DROP TABLE IF EXISTS street_aliases;
@@ -0,0 +1,24 @@
+-- Other names a street is known by (old, popular or misspelled). An alias is
+-- linked to a segment but matches every segment of the same street.
+CREATE TABLE IF NOT EXISTS street_aliases (
+    id SERIAL PRIMARY KEY,
+    street_segment_id INTEGER NOT NULL REFERENCES street_segments(id) ON DELETE CASCADE,
+    alias VARCHAR(200) NOT NULL,
+    original_alias VARCHAR(200) NOT NULL DEFAULT '',
+    kind VARCHAR(20) NOT NULL DEFAULT 'popular' CHECK (kind IN ('old', 'popular', 'misspelling')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_alias_per_segment UNIQUE (street_segment_id, alias)
+);
+
+CREATE INDEX IF NOT EXISTS idx_street_aliases_street_segment_id ON street_aliases(street_segment_id);
+CREATE INDEX IF NOT EXISTS idx_street_aliases_alias_trgm
+ON street_aliases USING gin (alias gin_trgm_ops);
+
+-- Aliases are joined to every segment with the same street name
+CREATE INDEX IF NOT EXISTS idx_street_segments_street_city_state ON street_segments(street_name, city, state);
+
+CREATE TRIGGER update_street_aliases_updated_at
+    BEFORE UPDATE ON street_aliases
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
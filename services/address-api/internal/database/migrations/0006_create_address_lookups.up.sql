@@ -0,0 +1,29 @@
+-- Address searches that found no team or found one with low confidence. They
+-- form the review queue until a coordinator resolves or dismisses them.
+CREATE TABLE IF NOT EXISTS address_lookups (
+    id SERIAL PRIMARY KEY,
+    input TEXT NOT NULL DEFAULT '',
+    original_street VARCHAR(200) NOT NULL DEFAULT '',
+    street VARCHAR(200) NOT NULL DEFAULT '',
+    number INTEGER NOT NULL DEFAULT 0,
+    neighborhood VARCHAR(100) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    state VARCHAR(2) NOT NULL DEFAULT '',
+    cep VARCHAR(8) NOT NULL DEFAULT '',
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('not_found', 'ambiguous', 'low_confidence')),
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    candidates JSONB NOT NULL DEFAULT '[]',
+    user_id INTEGER,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    resolution VARCHAR(20) NOT NULL DEFAULT '' CHECK (resolution IN ('', 'segment', 'alias', 'dismissed')),
+    resolved_team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL,
+    resolved_segment_id INTEGER REFERENCES street_segments(id) ON DELETE SET NULL,
+    resolved_alias_id INTEGER REFERENCES street_aliases(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- user_id points to user-api, which may live in another database
+COMMENT ON COLUMN address_lookups.user_id IS 'User (user-api) the search was made for';
+
+CREATE INDEX IF NOT EXISTS idx_address_lookups_pending ON address_lookups(created_at) WHERE resolved_at IS NULL;
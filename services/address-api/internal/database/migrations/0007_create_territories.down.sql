@@ -0,0 +1,2 @@
+-- The postgis extension is kept, other databases on the server may use it
+DROP TABLE IF EXISTS territories;
@@ -0,0 +1,20 @@
+-- Team territories drawn as polygons. A point inside a polygon points to the
+-- team even when its street has no segment yet.
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS territories (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    geometry GEOMETRY(MultiPolygon, 4326) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_territories_team_id ON territories(team_id);
+CREATE INDEX IF NOT EXISTS idx_territories_geometry ON territories USING gist (geometry);
+
+CREATE TRIGGER update_territories_updated_at
+    BEFORE UPDATE ON territories
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,8 @@
+ALTER TABLE ubs
+    DROP CONSTRAINT IF EXISTS ubs_coordinates_check,
+    DROP COLUMN IF EXISTS accessibility,
+    DROP COLUMN IF EXISTS services,
+    DROP COLUMN IF EXISTS opening_hours,
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,16 @@
+-- Location and service details of each UBS, used to point citizens to the
+-- nearest unit. Coordinates are optional: a UBS without them is simply left
+-- out of the nearest search.
+ALTER TABLE ubs
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS phone VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS opening_hours JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS services JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS accessibility JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE ubs
+    ADD CONSTRAINT ubs_coordinates_check CHECK (
+        (latitude IS NULL AND longitude IS NULL)
+        OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+    );
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ubs_closures;
+DROP TABLE IF EXISTS holidays;
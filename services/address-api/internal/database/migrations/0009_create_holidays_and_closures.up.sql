@@ -0,0 +1,27 @@
+-- Exceptions to the weekly UBS opening hours. Holidays close every UBS in
+-- their scope; closures close a single UBS for a range of days.
+CREATE TABLE IF NOT EXISTS holidays (
+    id SERIAL PRIMARY KEY,
+    date DATE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('national', 'state', 'municipal')),
+    state VARCHAR(2) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    optional BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Lets the national calendar import be run again for the same year
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holidays_unique ON holidays(date, scope, state, city);
+
+CREATE TABLE IF NOT EXISTS ubs_closures (
+    id SERIAL PRIMARY KEY,
+    ubs_id INTEGER NOT NULL REFERENCES ubs(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ubs_closures_dates ON ubs_closures(end_date, start_date);
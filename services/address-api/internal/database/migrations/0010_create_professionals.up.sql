@@ -0,0 +1,43 @@
+-- Health professionals and their passage through teams. Memberships are never
+-- deleted: ending one sets end_date, which keeps the team history.
+CREATE TABLE IF NOT EXISTS professionals (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    role VARCHAR(30) NOT NULL CHECK (role IN ('doctor', 'nurse', 'nursing_technician', 'acs', 'dentist', 'oral_health_technician')),
+    cns VARCHAR(15) NOT NULL DEFAULT '',
+    registry VARCHAR(30) NOT NULL DEFAULT '',
+    contact_channel VARCHAR(20) NOT NULL DEFAULT '' CHECK (contact_channel IN ('', 'phone', 'whatsapp', 'email')),
+    contact VARCHAR(100) NOT NULL DEFAULT '',
+    shift VARCHAR(20) NOT NULL DEFAULT '' CHECK (shift IN ('', 'morning', 'afternoon', 'full_time', 'night')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_professionals_deleted_at ON professionals(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_professionals_cns ON professionals(cns) WHERE cns <> '' AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS team_memberships (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    professional_id INTEGER NOT NULL REFERENCES professionals(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+-- A professional has at most one current membership in each team
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_memberships_current ON team_memberships(team_id, professional_id) WHERE end_date IS NULL;
+CREATE INDEX IF NOT EXISTS idx_team_memberships_professional ON team_memberships(professional_id);
+
+CREATE TRIGGER update_professionals_updated_at
+    BEFORE UPDATE ON professionals
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_team_memberships_updated_at
+    BEFORE UPDATE ON team_memberships
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
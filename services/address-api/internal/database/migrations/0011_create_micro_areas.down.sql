@@ -0,0 +1,2 @@
+ALTER TABLE street_segments DROP COLUMN IF EXISTS micro_area_id;
+DROP TABLE IF EXISTS micro_areas;
@@ -0,0 +1,22 @@
+-- Micro-areas split a team's territory between its community health agents
+-- (ACS). Street segments point at the micro-area of their own team.
+CREATE TABLE IF NOT EXISTS micro_areas (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    code VARCHAR(2) NOT NULL CHECK (code ~ '^[0-9]{2}$' AND code <> '00'),
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    agent_id INTEGER REFERENCES professionals(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_micro_areas_team_code ON micro_areas(team_id, code);
+CREATE INDEX IF NOT EXISTS idx_micro_areas_agent ON micro_areas(agent_id);
+
+ALTER TABLE street_segments ADD COLUMN IF NOT EXISTS micro_area_id INTEGER REFERENCES micro_areas(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_street_segments_micro_area ON street_segments(micro_area_id);
+
+CREATE TRIGGER update_micro_areas_updated_at
+    BEFORE UPDATE ON micro_areas
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
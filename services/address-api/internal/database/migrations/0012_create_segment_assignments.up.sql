@@ -0,0 +1,34 @@
+-- History of the teams covering each street segment. valid_to is the last
+-- day of the assignment; NULL means it lasts until the next change. A row
+-- with applied_at NULL is a reassignment scheduled for valid_from, and
+-- street_segments.team_id keeps the current team until it is applied.
+CREATE TABLE IF NOT EXISTS segment_assignments (
+    id SERIAL PRIMARY KEY,
+    street_segment_id INTEGER NOT NULL REFERENCES street_segments(id) ON DELETE CASCADE,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE RESTRICT,
+    previous_team_id INTEGER REFERENCES teams(id) ON DELETE RESTRICT,
+    valid_from DATE NOT NULL,
+    valid_to DATE,
+    reason VARCHAR(200) NOT NULL DEFAULT '',
+    applied_at TIMESTAMP WITH TIME ZONE,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+-- Only one open-ended assignment per segment, the current or the scheduled one
+CREATE UNIQUE INDEX IF NOT EXISTS idx_segment_assignments_open ON segment_assignments(street_segment_id) WHERE valid_to IS NULL;
+CREATE INDEX IF NOT EXISTS idx_segment_assignments_segment ON segment_assignments(street_segment_id, valid_from);
+CREATE INDEX IF NOT EXISTS idx_segment_assignments_pending ON segment_assignments(valid_from) WHERE applied_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_segment_assignments_unnotified ON segment_assignments(applied_at) WHERE notified_at IS NULL AND previous_team_id IS NOT NULL;
+
+-- Existing segments start their history on the day they were created
+INSERT INTO segment_assignments (street_segment_id, team_id, valid_from, applied_at)
+SELECT id, team_id, (COALESCE(created_at, CURRENT_TIMESTAMP) AT TIME ZONE 'America/Sao_Paulo')::date, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM street_segments;
+
+CREATE TRIGGER update_segment_assignments_updated_at
+    BEFORE UPDATE ON segment_assignments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
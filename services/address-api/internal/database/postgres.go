@@ -1,16 +1,14 @@
 package database
 
 import (
-	"address-api/internal/models"
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
 )
 
-var DB *gorm.DB
-
-func InitDB(host, user, password, dbname, port string) (*gorm.DB, error) {
+// Open conecta no banco sem verificar nem alterar o schema
+func Open(host, user, password, dbname, port string) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=America/Sao_Paulo",
 		host, user, password, dbname, port)
 
@@ -18,29 +16,39 @@ func InitDB(host, user, password, dbname, port string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
+	return db, nil
+}
 
-	// Habilitando trigram
-	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm;").Error; err != nil {
-		return nil, fmt.Errorf("failed to create trigram extension: %v", err)
+// InitDB conecta no banco e se recusa a continuar se o schema nao estiver na
+// versao esperada por este binario. Com migrateOnStart as migracoes
+// pendentes sao aplicadas antes.
+func InitDB(host, user, password, dbname, port string, migrateOnStart bool) (*gorm.DB, error) {
+	db, err := Open(host, user, password, dbname, port)
+	if err != nil {
+		return nil, err
 	}
 
-	// Rodando as migracoes
-	err = db.AutoMigrate(&models.UBS{}, &models.Team{}, &models.StreetSegment{})
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %v", err)
+	}
+
+	migrator, err := NewMigrator(sqlDB)
 	if err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %v", err)
+		return nil, err
 	}
 
-	// Criando trigrams para os nomes daas ruas
-	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_street_segments_street_name_trgm 
-        ON street_segments USING gin (street_name gin_trgm_ops);`).Error; err != nil {
-		return nil, fmt.Errorf("failed to create trigram index: %v", err)
+	// Rodando as migracoes
+	if migrateOnStart {
+		if err := migrator.Up(); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %v", err)
+		}
 	}
 
-	DB = db
-	log.Println("Successfully connected to database and created indexes")
-	return DB, nil
-}
+	if err := migrator.CheckVersion(); err != nil {
+		return nil, fmt.Errorf("unexpected database schema: %v", err)
+	}
 
-func GetDB() *gorm.DB {
-	return DB
+	log.Printf("Successfully connected to database (schema version %d)", migrator.LatestVersion())
+	return db, nil
 }
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"address-api/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeDeleted remove definitivamente os registros apagados antes de cutoff.
+// Times e UBS so sao removidos quando nao restam dependentes, nem mesmo
+// dependentes apagados mais recentemente. Um time citado no historico de
+// atribuicoes de um segmento que continua existindo tambem e mantido.
+func PurgeDeleted(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var total int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.StreetSegment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		total += result.RowsAffected
+
+		result = tx.Unscoped().
+			Where("deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM street_segments WHERE street_segments.team_id = teams.id)").
+			Where("NOT EXISTS (SELECT 1 FROM segment_assignments WHERE segment_assignments.team_id = teams.id OR segment_assignments.previous_team_id = teams.id)").
+			Delete(&models.Team{})
+		if result.Error != nil {
+			return result.Error
+		}
+		total += result.RowsAffected
+
+		result = tx.Unscoped().
+			Where("deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM teams WHERE teams.ubs_id = ubs.id)").
+			Delete(&models.UBS{})
+		if result.Error != nil {
+			return result.Error
+		}
+		total += result.RowsAffected
+		return nil
+	})
+	return total, err
+}
+
+// StartPurgeJob roda PurgeDeleted periodicamente em background
+func StartPurgeJob(db *gorm.DB, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := PurgeDeleted(db, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Error purging deleted records: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d deleted records older than %s", purged, retention)
+			}
+			<-ticker.C
+		}
+	}()
+}
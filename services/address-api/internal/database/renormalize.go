@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"shared/migrate"
+	"shared/normalize"
+)
+
+// dataMigrations sao os passos em Go executados depois do script up de uma versao
+var dataMigrations = map[uint]migrate.DataStep{
+	4: renormalizeAddresses,
+}
+
+// normalizedAddress e uma linha de street_segments ou cep_addresses,
+// identificada pelo id ou pelo CEP
+type normalizedAddress struct {
+	key                                                  string
+	original, streetName, streetType, neighborhood, city string
+}
+
+// renormalizeAddresses recalcula as colunas normalizadas a partir dos nomes
+// como foram digitados, para que linhas gravadas com regras antigas casem com
+// as buscas novas. Segmentos apagados logicamente entram tambem, ja que podem
+// ser restaurados.
+func renormalizeAddresses(tx *sql.Tx) error {
+	// As chaves sao lidas como texto e convertidas de volta ao tipo da coluna no update
+	tables := []struct{ table, key, keyType string }{
+		{"street_segments", "id", "bigint"},
+		{"cep_addresses", "cep", "char(8)"},
+	}
+	for _, t := range tables {
+		if err := renormalizeTable(tx, t.table, t.key, t.keyType); err != nil {
+			return fmt.Errorf("failed to re-normalize %s: %v", t.table, err)
+		}
+	}
+	return nil
+}
+
+func renormalizeTable(tx *sql.Tx, table, key, keyType string) error {
+	rows, err := tx.Query(`SELECT ` + key + `::text, original_street_name, street_name, street_type, neighborhood, city FROM ` + table)
+	if err != nil {
+		return err
+	}
+	// O Postgres nao aceita novos comandos na conexao enquanto as linhas
+	// estao sendo lidas, entao tudo e carregado antes de atualizar
+	var addresses []normalizedAddress
+	for rows.Next() {
+		var a normalizedAddress
+		if err := rows.Scan(&a.key, &a.original, &a.streetName, &a.streetType, &a.neighborhood, &a.city); err != nil {
+			rows.Close()
+			return err
+		}
+		addresses = append(addresses, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update := `UPDATE ` + table + ` SET street_name = $1, street_type = $2, neighborhood = $3, city = $4 WHERE ` + key + ` = $5::text::` + keyType
+	for _, a := range addresses {
+		source := a.original
+		if source == "" {
+			source = a.streetName
+		}
+		streetName := normalize.StreetName(source)
+		streetType := normalize.StreetType(a.streetType)
+		neighborhood := normalize.Text(a.neighborhood)
+		city := normalize.Text(a.city)
+		if streetName == a.streetName && streetType == a.streetType && neighborhood == a.neighborhood && city == a.city {
+			continue
+		}
+		if _, err := tx.Exec(update, streetName, streetType, neighborhood, city, a.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
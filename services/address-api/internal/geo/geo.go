@@ -0,0 +1,177 @@
+// Package geo le e valida os poligonos GeoJSON dos territorios e responde se
+// um ponto esta dentro deles. Coordenadas seguem o GeoJSON: longitude,
+// latitude, em WGS 84 (SRID 4326).
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalidGeometry = errors.New("invalid geometry")
+
+// Point e uma coordenada em graus
+type Point struct {
+	Lng float64
+	Lat float64
+}
+
+// Valid diz se a coordenada esta dentro dos limites do WGS 84
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// earthRadius e o raio usado pelo ST_DistanceSphere do PostGIS, para que o
+// MemoryStore e o Postgres ordenem as distancias da mesma forma
+const earthRadius = 6370986.0
+
+// Distance e a distancia em metros entre dois pontos sobre uma esfera
+// (formula de haversine)
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Ring e um anel fechado de posicoes [lng, lat]; o primeiro anel de um
+// poligono e o contorno e os demais sao buracos
+type Ring [][2]float64
+
+type Polygon []Ring
+
+type MultiPolygon []Polygon
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    json.RawMessage `json:"geometry"` // quando vem uma Feature
+}
+
+// Parse le uma geometria GeoJSON Polygon ou MultiPolygon, ou uma Feature com
+// uma delas, e valida os aneis. Polygon vira MultiPolygon de um poligono.
+func Parse(data []byte) (MultiPolygon, error) {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+
+	var shape MultiPolygon
+	switch g.Type {
+	case "Feature":
+		if len(g.Geometry) == 0 || string(g.Geometry) == "null" {
+			return nil, fmt.Errorf("%w: feature has no geometry", ErrInvalidGeometry)
+		}
+		return Parse(g.Geometry)
+	case "Polygon":
+		var polygon Polygon
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		shape = MultiPolygon{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &shape); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected Polygon or MultiPolygon, got %q", ErrInvalidGeometry, g.Type)
+	}
+
+	if err := shape.validate(); err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+func (m MultiPolygon) validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("%w: no polygons", ErrInvalidGeometry)
+	}
+	for i, polygon := range m {
+		if len(polygon) == 0 {
+			return fmt.Errorf("%w: polygon %d has no rings", ErrInvalidGeometry, i+1)
+		}
+		for j, ring := range polygon {
+			if len(ring) < 4 {
+				return fmt.Errorf("%w: polygon %d ring %d needs at least 4 positions", ErrInvalidGeometry, i+1, j+1)
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return fmt.Errorf("%w: polygon %d ring %d is not closed", ErrInvalidGeometry, i+1, j+1)
+			}
+			for _, position := range ring {
+				if !(Point{Lng: position[0], Lat: position[1]}).Valid() {
+					return fmt.Errorf("%w: position %v is out of range", ErrInvalidGeometry, position)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// GeoJSON devolve a geometria como MultiPolygon
+func (m MultiPolygon) GeoJSON() json.RawMessage {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":        "MultiPolygon",
+		"coordinates": m,
+	})
+	return data
+}
+
+// Contains diz se o ponto esta dentro de algum poligono, fora dos buracos
+func (m MultiPolygon) Contains(p Point) bool {
+	for _, polygon := range m {
+		if !polygon[0].contains(p) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if hole.contains(p) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// contains usa o algoritmo do raio: conta quantas arestas uma semirreta
+// horizontal a partir do ponto cruza
+func (r Ring) contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > p.Lat) != (yj > p.Lat) && p.Lng < (xj-xi)*(p.Lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Area e a area plana em graus quadrados, descontando os buracos. Serve so
+// para comparar poligonos proximos, nao como medida de superficie.
+func (m MultiPolygon) Area() float64 {
+	total := 0.0
+	for _, polygon := range m {
+		total += polygon[0].area()
+		for _, hole := range polygon[1:] {
+			total -= hole.area()
+		}
+	}
+	return total
+}
+
+// area usa a formula do laco (shoelace)
+func (r Ring) area() float64 {
+	sum := 0.0
+	for i := 0; i+1 < len(r); i++ {
+		sum += r[i][0]*r[i+1][1] - r[i+1][0]*r[i][1]
+	}
+	return math.Abs(sum) / 2
+}
@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+// square vai de (0,0) a (10,10) com um buraco de (4,4) a (6,6)
+const square = `{"type":"Polygon","coordinates":[
+	[[0,0],[10,0],[10,10],[0,10],[0,0]],
+	[[4,4],[6,4],[6,6],[4,6],[4,4]]
+]}`
+
+func TestParse(t *testing.T) {
+	shape, err := Parse([]byte(square))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(shape) != 1 || len(shape[0]) != 2 {
+		t.Fatalf("expected one polygon with a hole, got %+v", shape)
+	}
+
+	feature := `{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[5,5],[6,5],[6,6],[5,5]]]]}}`
+	if shape, err := Parse([]byte(feature)); err != nil || len(shape) != 2 {
+		t.Errorf("expected a MultiPolygon from the feature, got %+v (%v)", shape, err)
+	}
+
+	invalid := map[string]string{
+		"not json":     `{`,
+		"point":        `{"type":"Point","coordinates":[1,1]}`,
+		"open ring":    `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`,
+		"short ring":   `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`,
+		"out of range": `{"type":"Polygon","coordinates":[[[0,0],[200,0],[1,1],[0,0]]]}`,
+		"empty":        `{"type":"MultiPolygon","coordinates":[]}`,
+		"no geometry":  `{"type":"Feature","geometry":null}`,
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); !errors.Is(err, ErrInvalidGeometry) {
+			t.Errorf("%s: expected ErrInvalidGeometry, got %v", name, err)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	shape, _ := Parse([]byte(square))
+	tests := []struct {
+		point Point
+		want  bool
+	}{
+		{Point{Lng: 2, Lat: 2}, true},
+		{Point{Lng: 5, Lat: 5}, false}, // no buraco
+		{Point{Lng: 8, Lat: 9}, true},
+		{Point{Lng: 11, Lat: 5}, false},
+		{Point{Lng: -1, Lat: -1}, false},
+	}
+	for _, tt := range tests {
+		if got := shape.Contains(tt.point); got != tt.want {
+			t.Errorf("Contains(%+v) = %v, want %v", tt.point, got, tt.want)
+		}
+	}
+
+	if area := shape.Area(); area != 96 {
+		t.Errorf("expected area 96 without the hole, got %v", area)
+	}
+
+	if roundTrip, err := Parse(shape.GeoJSON()); err != nil || !roundTrip.Contains(Point{Lng: 2, Lat: 2}) {
+		t.Errorf("GeoJSON round trip failed: %v", err)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// Praca da Se e Avenida Paulista com Consolacao, em Sao Paulo: cerca de 2,5 km
+	se := Point{Lng: -46.6340, Lat: -23.5505}
+	paulista := Point{Lng: -46.6576, Lat: -23.5567}
+	if d := Distance(se, paulista); d < 2400 || d > 2600 {
+		t.Errorf("expected about 2.5 km, got %.0f m", d)
+	}
+	if d := Distance(se, se); d != 0 {
+		t.Errorf("expected zero distance to the same point, got %f", d)
+	}
+	if Distance(se, paulista) != Distance(paulista, se) {
+		t.Error("expected distance to be symmetric")
+	}
+}
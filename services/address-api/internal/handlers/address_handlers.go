@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/parser"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// parseAddress separa os campos de um endereco escrito em texto livre
+func (h *Handler) parseAddress(w http.ResponseWriter, r *http.Request) {
+	var req models.ParseAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if strings.TrimSpace(req.Text) == "" {
+		respondWithError(w, http.StatusBadRequest, "Address text is required")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    parser.Parse(req.Text),
+	})
+}
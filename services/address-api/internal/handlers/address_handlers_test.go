@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	f := newFixture(t)
+
+	rec, resp := doRequest(t, f.routes(), http.MethodPost, "/addresses/parse",
+		models.ParseAddressRequest{Text: "R. das Flores, nº 123, apto 4 - Centro, 13560-000"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var parsed models.ParsedAddress
+	decodeData(t, resp, &parsed)
+	if parsed.StreetType != "RUA" || parsed.StreetName != "das Flores" || parsed.Number != 123 ||
+		parsed.Complement != "apto 4" || parsed.Neighborhood != "Centro" || parsed.CEP != "13560000" {
+		t.Errorf("unexpected parsed address %+v", parsed)
+	}
+
+	rec, _ = doRequest(t, f.routes(), http.MethodPost, "/addresses/parse", map[string]string{"text": "  "})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for blank text, got %d", rec.Code)
+	}
+}
+
+func TestSearchByFreeText(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantStatus int
+	}{
+		{"text with city and state", url.Values{"q": {"Rua das Flores 10 apto 2, Sao Carlos - SP"}}, http.StatusOK},
+		{"city and state as parameters", url.Values{"q": {"rua das flores, nº 10"}, "city": {"sao carlos"}, "state": {"sp"}}, http.StatusOK},
+		{"CEP in the text", url.Values{"q": {"numero 10, cep 13560-000"}}, http.StatusOK},
+		{"number outside the segment", url.Values{"q": {"Rua das Flores 500, Sao Carlos - SP"}}, http.StatusNotFound},
+		{"text without number", url.Values{"q": {"Rua das Flores, Sao Carlos - SP"}}, http.StatusBadRequest},
+		{"text without city", url.Values{"q": {"Rua das Flores 10"}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			withCEPs(t, f)
+			rec, resp := doRequest(t, f.routes(), http.MethodGet, "/streets/search?"+tt.query.Encode(), nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var result models.AddressSearchResponse
+			decodeData(t, resp, &result)
+			if result.Team.ID != f.team.ID || result.Parsed == nil || result.Parsed.Number != 10 {
+				t.Errorf("unexpected result %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"address-api/internal/cep"
+	"address-api/internal/models"
+	"address-api/internal/parser"
+	"address-api/internal/repository"
+	"address-api/internal/territory"
+	"net/http"
+	"shared/normalize"
+	"strconv"
+	"strings"
+)
+
+// addressSearch e uma busca por endereco, isolada ou de um lote, nas etapas
+// comuns a findTeamByAddress e findTeamsByAddresses: interpretar os campos,
+// completar pela base de CEPs, buscar os segmentos e montar a resposta
+type addressSearch struct {
+	query territory.SearchQuery
+	// number e o numero da casa como foi informado
+	number string
+	// input e a busca como chegou, registrada na fila de revisao
+	input          string
+	userID         *uint
+	parsed         *models.ParsedAddress
+	address        *models.CEPAddress
+	originalStreet string
+}
+
+// searchError e a resposta de erro de um endereco buscado. Com lookup a
+// busca tambem vai para a fila de revisao.
+type searchError struct {
+	status  int
+	message string
+	lookup  *models.AddressLookup
+}
+
+// parse completa a busca com os campos extraidos do texto livre, que tem
+// precedencia menor que os informados. Sem numero da casa no texto a busca
+// so continua se houver um ponto para localizar (withPoint).
+func (s *addressSearch) parse(text string, withPoint bool) *searchError {
+	if text == "" {
+		return nil
+	}
+	result := parser.Parse(text)
+	s.parsed = &result
+	s.number = fillFromParsed(&s.query, s.number, result)
+	if s.number == "" && !withPoint {
+		return &searchError{status: http.StatusBadRequest, message: "Could not find the house number in the address"}
+	}
+	return nil
+}
+
+// byAddress diz se a busca tem o numero da casa e o CEP ou rua, cidade e UF
+func (s *addressSearch) byAddress() bool {
+	query := s.query
+	return s.number != "" && (query.CEP != "" || query.Street != "" && query.City != "" && query.State != "")
+}
+
+// prepareAddresses converte o numero da casa de cada busca, completa as
+// buscas pela base de CEPs, com uma consulta para todas, e normaliza rua,
+// cidade e UF. Devolve o erro de cada busca, nil nas que podem seguir.
+func (h *Handler) prepareAddresses(searches []*addressSearch) ([]*searchError, error) {
+	failures := make([]*searchError, len(searches))
+	ceps := make([]string, len(searches))
+	var wanted []string
+	for i, search := range searches {
+		ceps[i], failures[i] = search.check()
+		if failures[i] == nil && ceps[i] != "" {
+			wanted = append(wanted, ceps[i])
+		}
+	}
+
+	addresses, err := h.ceps.GetMany(wanted)
+	if err != nil {
+		return nil, err
+	}
+	for i, search := range searches {
+		if failures[i] != nil {
+			continue
+		}
+		var address *models.CEPAddress
+		if found, ok := addresses[ceps[i]]; ok {
+			address = &found
+		}
+		failures[i] = search.complete(address)
+	}
+	return failures, nil
+}
+
+// check converte o numero da casa e devolve o CEP com 8 digitos, vazio
+// quando a busca nao tem CEP
+func (s *addressSearch) check() (string, *searchError) {
+	// Conveter o numero da casa para int
+	number, err := strconv.Atoi(s.number)
+	if err != nil {
+		return "", &searchError{status: http.StatusBadRequest, message: "Invalid house number"}
+	}
+	s.query.Number = number
+
+	if s.query.CEP == "" {
+		return "", nil
+	}
+	normalizedCEP, err := cep.Normalize(s.query.CEP)
+	if err != nil {
+		return "", &searchError{status: http.StatusBadRequest, message: "Invalid CEP. Must have 8 digits"}
+	}
+	return normalizedCEP, nil
+}
+
+// complete preenche os campos que faltam com o endereco do CEP, nil quando o
+// CEP nao esta na base, e normaliza rua, cidade e UF, guardando a rua como
+// foi informada
+func (s *addressSearch) complete(address *models.CEPAddress) *searchError {
+	query := &s.query
+	switch {
+	case address != nil:
+		s.address = address
+		fillFromCEP(query, address)
+	case query.CEP != "" && (query.Street == "" || query.City == "" || query.State == ""):
+		// Sem o CEP na base ele ainda serve para desempatar, desde que a rua
+		// tenha sido informada
+		lookup := s.entry(territory.NewLookup(*query, query.Street, territory.LookupNotFound))
+		return &searchError{status: http.StatusNotFound, message: "CEP not found, inform street, city and state", lookup: &lookup}
+	}
+
+	s.originalStreet = query.Street
+	query.Street = normalize.StreetName(query.Street)
+	query.City = normalize.Text(query.City)
+	query.State = strings.ToUpper(query.State)
+	return nil
+}
+
+// addressQueries sao as ruas das buscas, na ordem delas
+func addressQueries(searches []*addressSearch) []repository.AddressQuery {
+	queries := make([]repository.AddressQuery, len(searches))
+	for i, search := range searches {
+		queries[i] = repository.AddressQuery{
+			Street: search.query.Street, Number: search.query.Number, City: search.query.City, State: search.query.State,
+		}
+	}
+	return queries
+}
+
+// searchAddresses busca os segmentos de cada busca, pelo nome da rua e pelos
+// aliases, com uma consulta para todas elas. Os resultados vem na ordem das
+// buscas, ja restritos ao CEP quando ele desempata.
+func (h *Handler) searchAddresses(searches []*addressSearch) ([][]repository.SearchResult, error) {
+	queries := addressQueries(searches)
+	segmentResults, err := h.segments.SearchBatch(queries)
+	if err != nil {
+		return nil, err
+	}
+	aliasResults, err := h.aliases.SearchBatch(queries)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]repository.SearchResult, len(searches))
+	for i, search := range searches {
+		results[i] = territory.MergeAliasResults(segmentResults[i], aliasResults[i])
+		results[i] = territory.NarrowByCEP(search.query.CEP, results[i])
+	}
+	return results, nil
+}
+
+// resolveAsOf troca a equipe de cada resultado pela que cobria o segmento
+// na data
+func (h *Handler) resolveAsOf(results []repository.SearchResult, asOf models.Date) ([]repository.SearchResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+	ids := make([]uint, len(results))
+	for i, result := range results {
+		ids[i] = result.Segment.ID
+	}
+	assignments, err := h.assignments.At(ids, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return territory.AsOf(results, assignments), nil
+}
+
+// notFoundLookups sao os registros na fila de revisao das buscas sem equipe,
+// com os segmentos de cada rua como candidatos, ja que ela pode existir com
+// outra faixa de numeros. As ruas saem de uma consulta para todas as buscas.
+func (h *Handler) notFoundLookups(searches []*addressSearch) []models.AddressLookup {
+	segments, err := h.segments.ListByStreets(addressQueries(searches))
+	lookups := make([]models.AddressLookup, len(searches))
+	for i, search := range searches {
+		lookup := territory.NewLookup(search.query, search.originalStreet, territory.LookupNotFound)
+		if err == nil {
+			lookup.Candidates = territory.StreetCandidates(segments[i])
+		}
+		lookups[i] = search.entry(lookup)
+	}
+	return lookups
+}
+
+// reviewLookup e o registro na fila de revisao de uma resposta ambigua ou
+// com baixa confianca
+func reviewLookup(search *addressSearch, response models.AddressSearchResponse) (models.AddressLookup, bool) {
+	reason, ok := territory.ReviewReason(response)
+	if !ok {
+		return models.AddressLookup{}, false
+	}
+	lookup := territory.NewLookup(search.query, search.originalStreet, reason)
+	lookup.Score = response.Score
+	lookup.Candidates = territory.LookupCandidates(response.Candidates)
+	return search.entry(lookup), true
+}
+
+// entry completa o registro da busca na fila de revisao com a entrada como
+// ela chegou e o usuario
+func (s *addressSearch) entry(lookup models.AddressLookup) models.AddressLookup {
+	lookup.Input = s.input
+	lookup.UserID = s.userID
+	return lookup
+}
+
+// teamDetails completa as respostas com a lista de profissionais e a
+// micro-area. Enderecos de uma mesma rua costumam cair na mesma equipe, por
+// isso as duas ficam guardadas por equipe e por segmento.
+type teamDetails struct {
+	rosters    map[uint][]models.TeamMember
+	microAreas map[uint]*models.AssignedMicroArea
+}
+
+func newTeamDetails() *teamDetails {
+	return &teamDetails{
+		rosters:    map[uint][]models.TeamMember{},
+		microAreas: map[uint]*models.AssignedMicroArea{},
+	}
+}
+
+func (h *Handler) enrich(r *http.Request, details *teamDetails, response *models.AddressSearchResponse) {
+	members, ok := details.rosters[response.Team.ID]
+	if !ok {
+		members = h.roster(r, response.Team.ID)
+		details.rosters[response.Team.ID] = members
+	}
+	response.Roster = members
+
+	area, ok := details.microAreas[response.StreetSegment.ID]
+	if !ok {
+		area = h.microArea(r, response.StreetSegment, members)
+		details.microAreas[response.StreetSegment.ID] = area
+	}
+	response.MicroArea = area
+}
+
+// fillFromParsed completa a busca com os campos extraidos do texto livre e
+// devolve o numero da casa. Sem numero ("s/n") a busca usa 0.
+func fillFromParsed(query *territory.SearchQuery, number string, parsed models.ParsedAddress) string {
+	fields := []struct {
+		target *string
+		value  string
+	}{
+		{&query.Street, parsed.StreetName},
+		{&query.City, parsed.City},
+		{&query.State, parsed.State},
+		{&query.CEP, parsed.CEP},
+		{&query.Neighborhood, parsed.Neighborhood},
+	}
+	for _, field := range fields {
+		if *field.target == "" {
+			*field.target = field.value
+		}
+	}
+
+	switch {
+	case number != "":
+		return number
+	case parsed.Number > 0:
+		return strconv.Itoa(parsed.Number)
+	case parsed.NoNumber:
+		return "0"
+	}
+	return ""
+}
+
+// fillFromCEP completa apenas os campos que o cidadao nao informou
+func fillFromCEP(query *territory.SearchQuery, address *models.CEPAddress) {
+	if query.Street == "" {
+		query.Street = address.StreetName
+	}
+	if query.City == "" {
+		query.City = address.City
+	}
+	if query.State == "" {
+		query.State = address.State
+	}
+	if query.Neighborhood == "" {
+		query.Neighborhood = address.Neighborhood
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/parser"
+	"address-api/internal/territory"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// listStreetAliases lista os aliases da rua do segmento, inclusive os
+// cadastrados em outros segmentos da mesma rua
+func (h *Handler) listStreetAliases(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
+		return
+	}
+
+	segment, err := h.segments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Street segment not found")
+		return
+	}
+
+	aliases, err := h.aliases.ListByStreet(segment.StreetName, segment.City, segment.State)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street aliases")
+		return
+	}
+	if aliases == nil {
+		aliases = []models.StreetAlias{}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    aliases,
+	})
+}
+
+func (h *Handler) createStreetAlias(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
+		return
+	}
+
+	var req models.CreateStreetAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	segment, err := h.segments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Street segment not found")
+		return
+	}
+
+	alias, ok := h.createAlias(w, *segment, req.Alias, req.Kind)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    alias,
+	})
+}
+
+// createAliasFromSearch registra a rua de uma busca sem resultado como alias
+// do segmento que a equipe encontrou manualmente. A busca pode ser so a rua
+// ou o endereco em texto livre, do qual a rua e extraida.
+func (h *Handler) createAliasFromSearch(w http.ResponseWriter, r *http.Request) {
+	var req models.ResolvedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	segment, err := h.segments.Get(req.StreetSegmentID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid street segment ID")
+		return
+	}
+
+	alias, ok := h.createAlias(w, *segment, streetFromSearch(req.Query), req.Kind)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    alias,
+	})
+}
+
+func (h *Handler) deleteStreetAlias(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street alias ID")
+	if !ok {
+		return
+	}
+
+	if _, err := h.aliases.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Street alias not found")
+		return
+	}
+
+	if err := h.aliases.Delete(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete street alias")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Street alias successfully deleted",
+	})
+}
+
+// createAlias valida e grava o alias. Em caso de erro ja responde, com 409
+// se a rua ja tiver um alias com o mesmo nome, e devolve false.
+func (h *Handler) createAlias(w http.ResponseWriter, segment models.StreetSegment, name, kind string) (*models.StreetAlias, bool) {
+	alias, err := territory.BuildStreetAlias(segment, name, kind)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, aliasErrorMessage(err))
+		return nil, false
+	}
+
+	existing, err := h.aliases.ListByStreet(segment.StreetName, segment.City, segment.State)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street aliases")
+		return nil, false
+	}
+	for _, other := range existing {
+		if other.Alias == alias.Alias {
+			respondWithError(w, http.StatusConflict, "Street already has this alias")
+			return nil, false
+		}
+	}
+
+	if err := h.aliases.Create(&alias); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create street alias")
+		return nil, false
+	}
+	return &alias, true
+}
+
+// streetFromSearch tira a rua do texto buscado; sem rua reconhecida o texto
+// inteiro vira o alias
+func streetFromSearch(query string) string {
+	if street := parser.Parse(query).StreetName; street != "" {
+		return street
+	}
+	return query
+}
+
+func aliasErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, territory.ErrMissingField):
+		return "Alias is required"
+	case errors.Is(err, territory.ErrInvalidAliasKind):
+		return "Invalid alias kind. Must be 'old', 'popular' or 'misspelling'"
+	case errors.Is(err, territory.ErrAliasIsStreetName):
+		return "Alias is the street name itself"
+	default:
+		return "Invalid street alias: " + err.Error()
+	}
+}
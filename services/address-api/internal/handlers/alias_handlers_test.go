@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestStreetAliases(t *testing.T) {
+	f := newFixture(t)
+	aliasesPath := fmt.Sprintf("/streets/%d/aliases", f.segment.ID)
+
+	// Segundo trecho da mesma rua: o alias vale para ele tambem
+	second := f.segment
+	second.ID = 0
+	second.StartNumber, second.EndNumber = 100, 199
+	if err := f.store.StreetSegments().Create(&second); err != nil {
+		t.Fatalf("failed to seed street segment: %v", err)
+	}
+
+	search := func(street string, number int) (*http.Response, models.AddressSearchResponse) {
+		t.Helper()
+		query := url.Values{"street": {street}, "number": {fmt.Sprint(number)}, "city": {"Sao Carlos"}, "state": {"SP"}}
+		rec, resp := doRequest(t, f.routes(), http.MethodGet, "/streets/search?"+query.Encode(), nil)
+		var result models.AddressSearchResponse
+		if rec.Code == http.StatusOK {
+			decodeData(t, resp, &result)
+		}
+		return rec.Result(), result
+	}
+
+	if res, _ := search("Rua do Comércio", 150); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the popular name to be unknown at first, got %d", res.StatusCode)
+	}
+
+	t.Run("register alias from a failed search", func(t *testing.T) {
+		rec, resp := doRequest(t, f.routes(), http.MethodPost, "/streets/aliases/from-search", models.ResolvedSearchRequest{
+			Query: "Rua do Comércio, 150 - Centro", StreetSegmentID: f.segment.ID,
+		})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d (%s)", rec.Code, rec.Body.String())
+		}
+		var alias models.StreetAlias
+		decodeData(t, resp, &alias)
+		if alias.Alias != "COMERCIO" || alias.OriginalAlias != "do Comércio" || alias.Kind != "popular" {
+			t.Errorf("unexpected alias %+v", alias)
+		}
+	})
+
+	t.Run("search finds every segment of the street by alias", func(t *testing.T) {
+		res, result := search("Rua do Comércio", 150)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", res.StatusCode)
+		}
+		if result.StreetSegment.ID != second.ID || result.Candidates[0].Alias == nil || result.Score != 0.9 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if reason := result.Candidates[0].Reasons[0]; reason.Field != "alias" || !reason.Matched {
+			t.Errorf("unexpected street reason %+v", reason)
+		}
+
+		// O nome oficial continua com peso total
+		if _, result := search("das Flores", 10); result.Score != 1 || result.Candidates[0].Alias != nil {
+			t.Errorf("expected the street name to win, got %+v", result)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			path       string
+			body       interface{}
+			wantStatus int
+		}{
+			{"duplicate on another segment of the street", fmt.Sprintf("/streets/%d/aliases", second.ID), models.CreateStreetAliasRequest{Alias: "comercio"}, http.StatusConflict},
+			{"street name itself", aliasesPath, models.CreateStreetAliasRequest{Alias: "Rua das Flores", Kind: "old"}, http.StatusBadRequest},
+			{"invalid kind", aliasesPath, models.CreateStreetAliasRequest{Alias: "Rua Velha", Kind: "nickname"}, http.StatusBadRequest},
+			{"missing alias", aliasesPath, map[string]string{"kind": "old"}, http.StatusBadRequest},
+			{"unknown segment", "/streets/999/aliases", models.CreateStreetAliasRequest{Alias: "Rua Velha"}, http.StatusNotFound},
+			{"unknown segment from search", "/streets/aliases/from-search", models.ResolvedSearchRequest{Query: "Rua Velha", StreetSegmentID: 999}, http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec, _ := doRequest(t, f.routes(), http.MethodPost, tt.path, tt.body)
+				if rec.Code != tt.wantStatus {
+					t.Errorf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("list and delete", func(t *testing.T) {
+		rec, resp := doRequest(t, f.routes(), http.MethodPost, aliasesPath, models.CreateStreetAliasRequest{Alias: "Rua Velha", Kind: "old"})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d (%s)", rec.Code, rec.Body.String())
+		}
+		var old models.StreetAlias
+		decodeData(t, resp, &old)
+
+		rec, resp = doRequest(t, f.routes(), http.MethodGet, fmt.Sprintf("/streets/%d/aliases", second.ID), nil)
+		var aliases []models.StreetAlias
+		decodeData(t, resp, &aliases)
+		if rec.Code != http.StatusOK || len(aliases) != 2 || aliases[0].Alias != "COMERCIO" || aliases[1].Alias != "VELHA" {
+			t.Fatalf("unexpected aliases %+v", aliases)
+		}
+
+		path := fmt.Sprintf("/streets/aliases/%d", old.ID)
+		if rec, _ := doRequest(t, f.routes(), http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rec.Code)
+		}
+		if rec, _ := doRequest(t, f.routes(), http.MethodDelete, path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/schedule"
+	"address-api/internal/territory"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// listSegmentAssignments traz o historico de equipes do segmento, incluindo a
+// reatribuicao agendada
+func (h *Handler) listSegmentAssignments(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
+		return
+	}
+	if _, err := h.segments.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Street segment not found")
+		return
+	}
+
+	assignments, err := h.assignments.List(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segment assignments")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    assignments,
+	})
+}
+
+// reassignSegment muda a equipe do segmento a partir de valid_from. Hoje a
+// mudanca e imediata; uma data futura fica agendada ate o dia chegar.
+func (h *Handler) reassignSegment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
+		return
+	}
+
+	var req models.ReassignSegmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	now := h.now()
+	today := schedule.DateOf(now)
+	if req.ValidFrom == "" {
+		req.ValidFrom = today
+	}
+	if _, err := schedule.ParseDate(req.ValidFrom); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	segment, err := h.segments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Street segment not found")
+		return
+	}
+	if _, err := h.teams.Get(req.TeamID); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+
+	assignment := models.SegmentAssignment{
+		StreetSegmentID: segment.ID,
+		TeamID:          req.TeamID,
+		ValidFrom:       req.ValidFrom,
+		Reason:          strings.TrimSpace(req.Reason),
+	}
+	if !h.checkReassignment(w, *segment, assignment, today) {
+		return
+	}
+	if err := h.assignments.Reassign(&assignment, now); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to reassign street segment")
+		return
+	}
+
+	result, err := h.assignments.Get(assignment.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segment assignment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// listReassignments lista as reatribuicoes agendadas ou, com
+// ?status=unnotified, as mudancas ja aplicadas cujos usuarios o user-api
+// ainda nao avisou
+func (h *Handler) listReassignments(w http.ResponseWriter, r *http.Request) {
+	var (
+		assignments []models.SegmentAssignment
+		err         error
+	)
+	switch r.URL.Query().Get("status") {
+	case "", "scheduled":
+		assignments, err = h.assignments.Scheduled()
+	case "unnotified":
+		assignments, err = h.assignments.Unnotified()
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid status. Must be 'scheduled' or 'unnotified'")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch reassignments")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    assignments,
+	})
+}
+
+// cancelReassignment desfaz uma reatribuicao agendada. A que ja foi aplicada
+// so muda com uma nova reatribuicao.
+func (h *Handler) cancelReassignment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid reassignment ID")
+	if !ok {
+		return
+	}
+
+	assignment, err := h.assignments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Reassignment not found")
+		return
+	}
+	if assignment.AppliedAt != nil {
+		respondWithError(w, http.StatusConflict, "Reassignment was already applied")
+		return
+	}
+
+	if err := h.assignments.Cancel(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to cancel reassignment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Reassignment successfully cancelled",
+	})
+}
+
+// markReassignmentNotified e chamado pelo user-api depois de avisar os
+// usuarios afetados pela mudanca de equipe
+func (h *Handler) markReassignmentNotified(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid reassignment ID")
+	if !ok {
+		return
+	}
+
+	assignment, err := h.assignments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Reassignment not found")
+		return
+	}
+	if assignment.AppliedAt == nil {
+		respondWithError(w, http.StatusConflict, "Reassignment was not applied yet")
+		return
+	}
+
+	if err := h.assignments.MarkNotified(id, h.now()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to mark reassignment as notified")
+		return
+	}
+	if assignment, err = h.assignments.Get(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch reassignment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    assignment,
+	})
+}
+
+// checkReassignment valida a mudanca contra o historico do segmento e contra
+// os segmentos da nova equipe na mesma rua
+func (h *Handler) checkReassignment(w http.ResponseWriter, segment models.StreetSegment, assignment models.SegmentAssignment, today models.Date) bool {
+	history, err := h.assignments.List(segment.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segment assignments")
+		return false
+	}
+
+	err = territory.CheckReassignment(history, assignment.TeamID, assignment.ValidFrom, today)
+	switch {
+	case errors.Is(err, territory.ErrPendingReassignment):
+		respondWithError(w, http.StatusConflict, err.Error())
+		return false
+	case err != nil:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	segment.TeamID = assignment.TeamID
+	return h.checkOverlaps(w, segment)
+}
+
+// parseAsOf le ?date= da busca historica
+func parseAsOf(w http.ResponseWriter, r *http.Request) (models.Date, bool) {
+	date := models.Date(r.URL.Query().Get("date"))
+	if date == "" {
+		return "", true
+	}
+	if _, err := schedule.ParseDate(date); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	if r.URL.Query().Get("lat") != "" || r.URL.Query().Get("lng") != "" {
+		respondWithError(w, http.StatusBadRequest, "date cannot be combined with lat and lng, territories have no history")
+		return "", false
+	}
+	return date, true
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/schedule"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReassignments(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+	if err := f.store.Teams().Create(&other); err != nil {
+		t.Fatalf("failed to seed team: %v", err)
+	}
+
+	today := schedule.DateOf(time.Now())
+	nextWeek := schedule.AddDays(today, 7)
+	reassign := fmt.Sprintf("/streets/%d/reassignments", f.segment.ID)
+
+	tests := []struct {
+		name string
+		body models.ReassignSegmentRequest
+		want int
+	}{
+		{"past date", models.ReassignSegmentRequest{TeamID: other.ID, ValidFrom: schedule.AddDays(today, -1)}, http.StatusBadRequest},
+		{"invalid date", models.ReassignSegmentRequest{TeamID: other.ID, ValidFrom: "2024-13-01"}, http.StatusBadRequest},
+		{"same team", models.ReassignSegmentRequest{TeamID: f.team.ID, ValidFrom: nextWeek}, http.StatusBadRequest},
+		{"unknown team", models.ReassignSegmentRequest{TeamID: 999, ValidFrom: nextWeek}, http.StatusBadRequest},
+		{"scheduled", models.ReassignSegmentRequest{TeamID: other.ID, ValidFrom: nextWeek, Reason: "Redivisao do territorio"}, http.StatusCreated},
+		{"already scheduled", models.ReassignSegmentRequest{TeamID: other.ID, ValidFrom: nextWeek}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec, resp := doRequest(t, handler, http.MethodPost, reassign, tt.body); rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, resp.Error)
+			}
+		})
+	}
+
+	// Enquanto agendada, a troca de equipe pelo PUT tambem e recusada
+	rec, _ := doRequest(t, handler, http.MethodPut, fmt.Sprintf("/streets/%d", f.segment.ID), models.CreateStreetSegmentRequest{
+		StreetName: "das Flores", StreetType: "RUA", Neighborhood: "CENTRO", City: "SAO CARLOS", State: "SP",
+		StartNumber: 1, EndNumber: 99, EvenOdd: "all", TeamID: other.ID,
+	})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a team change with a scheduled reassignment, got %d", rec.Code)
+	}
+
+	_, resp := doRequest(t, handler, http.MethodGet, "/reassignments", nil)
+	var scheduled []models.SegmentAssignment
+	decodeData(t, resp, &scheduled)
+	if len(scheduled) != 1 || scheduled[0].TeamID != other.ID || scheduled[0].AppliedAt != nil {
+		t.Fatalf("expected one scheduled reassignment, got %+v", scheduled)
+	}
+
+	search := "/streets/search?street=Rua+das+Flores&number=10&city=Sao+Carlos&state=SP&date="
+	for date, want := range map[models.Date]uint{today: f.team.ID, nextWeek: other.ID} {
+		rec, resp := doRequest(t, handler, http.MethodGet, search+string(date), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d: %s", date, rec.Code, resp.Error)
+		}
+		var found models.AddressSearchResponse
+		decodeData(t, resp, &found)
+		if found.Team.ID != want || found.AsOf != date {
+			t.Errorf("expected team %d on %s, got %d (as_of %q)", want, date, found.Team.ID, found.AsOf)
+		}
+	}
+	if rec, _ := doRequest(t, handler, http.MethodGet, search+string(schedule.AddDays(today, -1)), nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before the segment existed, got %d", rec.Code)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodGet, search+string(today)+"&lat=-22.01&lng=-47.89", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for date with lat and lng, got %d", rec.Code)
+	}
+
+	if _, err := f.store.SegmentAssignments().ApplyDue(nextWeek); err != nil {
+		t.Fatalf("failed to apply reassignments: %v", err)
+	}
+	moved, err := f.store.StreetSegments().Get(f.segment.ID)
+	if err != nil || moved.TeamID != other.ID {
+		t.Fatalf("expected segment moved to team %d, got %+v (%v)", other.ID, moved, err)
+	}
+
+	applied := fmt.Sprintf("/reassignments/%d", scheduled[0].ID)
+	if rec, _ := doRequest(t, handler, http.MethodDelete, applied, nil); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 cancelling an applied reassignment, got %d", rec.Code)
+	}
+
+	_, resp = doRequest(t, handler, http.MethodGet, "/reassignments?status=unnotified", nil)
+	var unnotified []models.SegmentAssignment
+	decodeData(t, resp, &unnotified)
+	if len(unnotified) != 1 || unnotified[0].PreviousTeamID == nil || *unnotified[0].PreviousTeamID != f.team.ID {
+		t.Fatalf("expected the applied reassignment waiting for notification, got %+v", unnotified)
+	}
+	if rec, resp := doRequest(t, handler, http.MethodPost, applied+"/notified", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	_, resp = doRequest(t, handler, http.MethodGet, "/reassignments?status=unnotified", nil)
+	decodeData(t, resp, &unnotified)
+	if len(unnotified) != 0 {
+		t.Errorf("expected no reassignment waiting for notification, got %+v", unnotified)
+	}
+
+	// Uma nova agenda pode ser cancelada, reabrindo a equipe atual
+	rec, resp = doRequest(t, handler, http.MethodPost, reassign, models.ReassignSegmentRequest{TeamID: f.team.ID, ValidFrom: schedule.AddDays(today, 30)})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, resp.Error)
+	}
+	var pending models.SegmentAssignment
+	decodeData(t, resp, &pending)
+	if rec, _ := doRequest(t, handler, http.MethodPost, fmt.Sprintf("/reassignments/%d/notified", pending.ID), nil); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 notifying a scheduled reassignment, got %d", rec.Code)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/reassignments/%d", pending.ID), nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	_, resp = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/streets/%d/assignments", f.segment.ID), nil)
+	var history []models.SegmentAssignment
+	decodeData(t, resp, &history)
+	if len(history) != 2 || history[0].TeamID != f.team.ID || history[0].ValidTo == nil || *history[0].ValidTo != schedule.AddDays(nextWeek, -1) || history[1].ValidTo != nil {
+		t.Errorf("expected the initial team closed the day before the reassignment and the new one open, got %+v", history)
+	}
+}
+
+func TestTeamChangeOnCreationDay(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+	if err := f.store.Teams().Create(&other); err != nil {
+		t.Fatalf("failed to seed team: %v", err)
+	}
+
+	// O segmento da fixture foi criado hoje e muda de equipe no mesmo dia
+	rec, resp := doRequest(t, handler, http.MethodPut, fmt.Sprintf("/streets/%d", f.segment.ID), models.CreateStreetSegmentRequest{
+		StreetName: "das Flores", StreetType: "RUA", Neighborhood: "CENTRO", City: "SAO CARLOS", State: "SP",
+		StartNumber: 1, EndNumber: 99, EvenOdd: "all", TeamID: other.ID,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+
+	// A atribuicao inicial continua no historico, encerrada no mesmo dia
+	today := schedule.DateOf(time.Now())
+	_, resp = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/streets/%d/assignments", f.segment.ID), nil)
+	var history []models.SegmentAssignment
+	decodeData(t, resp, &history)
+	if len(history) != 2 || history[0].TeamID != f.team.ID || history[0].ValidTo == nil || *history[0].ValidTo != today ||
+		history[1].TeamID != other.ID || history[1].PreviousTeamID == nil || *history[1].PreviousTeamID != f.team.ID {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	_, resp = doRequest(t, handler, http.MethodGet, "/reassignments?status=unnotified", nil)
+	var unnotified []models.SegmentAssignment
+	decodeData(t, resp, &unnotified)
+	if len(unnotified) != 1 || unnotified[0].ID != history[1].ID {
+		t.Errorf("expected the team change waiting for notification, got %+v", unnotified)
+	}
+
+	// Na busca por data vale a ultima mudanca do dia
+	rec, resp = doRequest(t, handler, http.MethodGet, "/streets/search?street=Rua+das+Flores&number=10&city=Sao+Carlos&state=SP&date="+string(today), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	var found models.AddressSearchResponse
+	decodeData(t, resp, &found)
+	if found.Team.ID != other.ID {
+		t.Errorf("expected team %d today, got %d", other.ID, found.Team.ID)
+	}
+}
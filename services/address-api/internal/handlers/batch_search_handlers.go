@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/territory"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// maxBatchAddresses limita o tamanho de uma busca em lote
+const maxBatchAddresses = 500
+
+// findTeamsByAddresses e a busca por endereco de findTeamByAddress para
+// varios enderecos, usada pelos recalculos do user-api. Os CEPs, os
+// segmentos, os aliases e as ruas sem equipe de todos os enderecos saem de
+// uma consulta cada, e a resposta traz um resultado por endereco, na ordem
+// do corpo, com o status que a busca isolada teria. Enderecos sem equipe,
+// ambiguos ou com baixa confianca vao para a fila de revisao como na busca
+// isolada, gravados de uma vez. Busca por ponto e por data ficam de fora.
+func (h *Handler) findTeamsByAddresses(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(req.Addresses) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Addresses are required")
+		return
+	}
+	if len(req.Addresses) > maxBatchAddresses {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d addresses per batch", maxBatchAddresses))
+		return
+	}
+
+	results := make([]models.BatchSearchResult, len(req.Addresses))
+	var lookups []models.AddressLookup
+	fail := func(i int, failure *searchError) {
+		if failure.lookup != nil {
+			lookups = append(lookups, *failure.lookup)
+		}
+		results[i].Status, results[i].Error = failure.status, failure.message
+	}
+
+	var searches []*addressSearch
+	var indexes []int
+	for i, address := range req.Addresses {
+		results[i] = models.BatchSearchResult{Index: i}
+		search, failure := parseBatchAddress(address)
+		if failure != nil {
+			fail(i, failure)
+			continue
+		}
+		searches = append(searches, search)
+		indexes = append(indexes, i)
+	}
+
+	failures, err := h.prepareAddresses(searches)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to look up CEPs")
+		return
+	}
+	prepared, preparedIndexes := searches[:0], indexes[:0]
+	for n, search := range searches {
+		if failures[n] != nil {
+			fail(indexes[n], failures[n])
+			continue
+		}
+		prepared = append(prepared, search)
+		preparedIndexes = append(preparedIndexes, indexes[n])
+	}
+	searches, indexes = prepared, preparedIndexes
+
+	found, err := h.searchAddresses(searches)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to search for addresses")
+		return
+	}
+
+	details := newTeamDetails()
+	var missing []*addressSearch
+	for n, search := range searches {
+		i := indexes[n]
+		if len(found[n]) == 0 {
+			missing = append(missing, search)
+			results[i].Status, results[i].Error = http.StatusNotFound, "No team found for this address"
+			continue
+		}
+
+		response := territory.Rank(search.query, found[n], nil)
+		if lookup, ok := reviewLookup(search, response); ok {
+			lookups = append(lookups, lookup)
+		}
+		response.Address = search.address
+		response.Parsed = search.parsed
+		h.enrich(r, details, &response)
+
+		results[i].Status, results[i].Result = http.StatusOK, &response
+	}
+	if len(missing) > 0 {
+		lookups = append(lookups, h.notFoundLookups(missing)...)
+	}
+	h.recordLookups(r, lookups...)
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    results,
+	})
+}
+
+// parseBatchAddress valida um endereco do lote como findTeamByAddress valida
+// os parametros da busca
+func parseBatchAddress(address models.BatchAddress) (*addressSearch, *searchError) {
+	search := &addressSearch{
+		query: territory.SearchQuery{
+			Street:       address.Street,
+			City:         address.City,
+			State:        address.State,
+			CEP:          address.CEP,
+			Neighborhood: address.Neighborhood,
+		},
+		number: address.Number,
+		input:  batchInput(address),
+		userID: address.UserID,
+	}
+	if failure := search.parse(address.Q, false); failure != nil {
+		return search, failure
+	}
+	if !search.byAddress() {
+		return search, &searchError{status: http.StatusBadRequest, message: "Missing required fields: number and either cep or street, city, state"}
+	}
+	return search, nil
+}
+
+// batchInput escreve o endereco do lote como a query string da busca
+// isolada, para que a fila de revisao mostre as duas buscas do mesmo jeito
+func batchInput(address models.BatchAddress) string {
+	values := url.Values{}
+	for _, field := range []struct{ name, value string }{
+		{"q", address.Q},
+		{"street", address.Street},
+		{"number", address.Number},
+		{"city", address.City},
+		{"state", address.State},
+		{"cep", address.CEP},
+		{"neighborhood", address.Neighborhood},
+	} {
+		if field.value != "" {
+			values.Set(field.name, field.value)
+		}
+	}
+	if address.UserID != nil {
+		values.Set("user_id", fmt.Sprint(*address.UserID))
+	}
+	return values.Encode()
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestFindTeamsByAddresses(t *testing.T) {
+	f := newFixture(t)
+	withCEPs(t, f)
+	userID := uint(42)
+
+	rec, resp := doRequest(t, f.routes(), http.MethodPost, "/streets/search/batch", models.BatchSearchRequest{
+		Addresses: []models.BatchAddress{
+			{Street: "Rua das Flores", Number: "10", City: "São Carlos", State: "sp"},
+			{Street: "Rua das Flores", Number: "150", City: "São Carlos", State: "SP", UserID: &userID},
+			{Q: "Rua das Flores 20, São Carlos - SP"},
+			{Street: "Rua das Flores", Number: "dez", City: "São Carlos", State: "SP"},
+			{Street: "Rua das Flores", City: "São Carlos", State: "SP"},
+			{CEP: "13560-000", Number: "10"},
+			{CEP: "01310100", Number: "5"},
+		},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var results []models.BatchSearchResult
+	decodeData(t, resp, &results)
+
+	wantStatus := []int{http.StatusOK, http.StatusNotFound, http.StatusOK, http.StatusBadRequest, http.StatusBadRequest, http.StatusOK, http.StatusNotFound}
+	if len(results) != len(wantStatus) {
+		t.Fatalf("expected %d results, got %+v", len(wantStatus), results)
+	}
+	for i, want := range wantStatus {
+		if results[i].Index != i || results[i].Status != want {
+			t.Errorf("result %d: expected status %d, got %+v", i, want, results[i])
+		}
+	}
+	for _, i := range []int{0, 2, 5} {
+		if result := results[i].Result; result == nil || result.StreetSegment.ID != f.segment.ID || result.Team.ID != f.team.ID {
+			t.Errorf("result %d: expected segment %d, got %+v", i, f.segment.ID, result)
+		}
+	}
+	if results[2].Result.Parsed == nil || results[5].Result.Address == nil || results[1].Error == "" {
+		t.Errorf("expected the parsed address and the error message, got %+v", results)
+	}
+
+	// Como na busca isolada, o endereco sem equipe e o CEP desconhecido sem
+	// rua vao para a revisao
+	lookups, err := f.store.AddressLookups().List(false)
+	if err != nil {
+		t.Fatalf("failed to list lookups: %v", err)
+	}
+	if len(lookups) != 2 {
+		t.Fatalf("expected 2 lookups, got %+v", lookups)
+	}
+	for _, lookup := range lookups {
+		if lookup.Input == "" {
+			t.Errorf("expected the input of lookup %+v", lookup)
+		}
+		if lookup.Number == 150 && (lookup.UserID == nil || *lookup.UserID != userID || len(lookup.Candidates) != 1) {
+			t.Errorf("unexpected lookup %+v", lookup)
+		}
+	}
+
+	t.Run("rejects empty and oversized batches", func(t *testing.T) {
+		oversized := make([]models.BatchAddress, maxBatchAddresses+1)
+		for _, req := range []models.BatchSearchRequest{{}, {Addresses: oversized}} {
+			if rec, _ := doRequest(t, f.routes(), http.MethodPost, "/streets/search/batch", req); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %d addresses, got %d", len(req.Addresses), rec.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/schedule"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Anos aceitos na importacao do calendario nacional
+const (
+	minHolidayYear = 1950
+	maxHolidayYear = 2100
+)
+
+func (h *Handler) listHolidays(w http.ResponseWriter, r *http.Request) {
+	year := 0
+	if value := r.URL.Query().Get("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid year")
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := h.holidays.List(year)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch holidays")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    holidays,
+	})
+}
+
+func (h *Handler) createHoliday(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateHolidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	holiday, err := schedule.BuildHoliday(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := h.holidays.Between(holiday.Date, holiday.Date)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch holidays")
+		return
+	}
+	for _, other := range existing {
+		if other.Scope == holiday.Scope && other.State == holiday.State && other.City == holiday.City {
+			respondWithError(w, http.StatusConflict, "There is already a holiday on this date with the same scope")
+			return
+		}
+	}
+
+	if err := h.holidays.Create(&holiday); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create holiday")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    holiday,
+	})
+}
+
+// importHolidays grava os feriados nacionais do ano, calculados localmente.
+// Pode ser repetida: feriados ja importados sao atualizados, nao duplicados.
+func (h *Handler) importHolidays(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil || year < minHolidayYear || year > maxHolidayYear {
+		respondWithError(w, http.StatusBadRequest, "year must be between "+strconv.Itoa(minHolidayYear)+" and "+strconv.Itoa(maxHolidayYear))
+		return
+	}
+
+	holidays := schedule.NationalHolidays(year, r.URL.Query().Get("optional") == "true")
+	if err := h.holidays.Upsert(holidays); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to import holidays")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.HolidayImportReport{Year: year, Holidays: holidays},
+	})
+}
+
+func (h *Handler) deleteHoliday(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid holiday ID")
+	if !ok {
+		return
+	}
+
+	if err := h.holidays.Delete(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Holiday not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Holiday successfully deleted",
+	})
+}
+
+func (h *Handler) listClosures(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
+		return
+	}
+	if _, err := h.ubs.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "UBS not found")
+		return
+	}
+
+	closures, err := h.closures.List(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch UBS closures")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    closures,
+	})
+}
+
+func (h *Handler) createClosure(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
+		return
+	}
+
+	var req models.CreateUBSClosureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.EndDate == "" {
+		req.EndDate = req.StartDate
+	}
+	start, startErr := schedule.ParseDate(req.StartDate)
+	end, endErr := schedule.ParseDate(req.EndDate)
+	if startErr != nil || endErr != nil {
+		respondWithError(w, http.StatusBadRequest, schedule.ErrInvalidDate.Error())
+		return
+	}
+	if end.Before(start) {
+		respondWithError(w, http.StatusBadRequest, "end_date cannot be before start_date")
+		return
+	}
+
+	if _, err := h.ubs.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "UBS not found")
+		return
+	}
+
+	closure := models.UBSClosure{
+		UBSID:     id,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Reason:    req.Reason,
+	}
+	if err := h.closures.Create(&closure); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create UBS closure")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    closure,
+	})
+}
+
+func (h *Handler) deleteClosure(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid closure ID")
+	if !ok {
+		return
+	}
+
+	if err := h.closures.Delete(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "UBS closure not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "UBS closure successfully deleted",
+	})
+}
+
+// ubsStatus diz se a UBS esta aberta agora, ou no instante de ?at=
+func (h *Handler) ubsStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
+		return
+	}
+
+	at := h.now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid at. Must be an RFC 3339 timestamp, e.g. 2024-03-04T10:00:00-03:00")
+			return
+		}
+		at = parsed
+	}
+
+	ubs, err := h.ubs.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "UBS not found")
+		return
+	}
+
+	holidays, closures, err := h.loadCalendar(at)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch UBS calendar")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    schedule.Status(*ubs, schedule.ForUBS(*ubs, holidays, closures), at),
+	})
+}
+
+// loadCalendar carrega os feriados e fechamentos de todas as UBS que podem
+// pesar na procura pela proxima abertura a partir de at
+func (h *Handler) loadCalendar(at time.Time) ([]models.Holiday, []models.UBSClosure, error) {
+	from := schedule.DateOf(at)
+	to := schedule.DateOf(at.AddDate(0, 0, schedule.LookaheadDays))
+
+	holidays, err := h.holidays.Between(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	closures, err := h.closures.Between(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return holidays, closures, nil
+}
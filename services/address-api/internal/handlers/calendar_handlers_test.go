@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestImportAndListHolidays(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	for i := 0; i < 2; i++ {
+		rec, resp := doRequest(t, handler, http.MethodPost, "/holidays/import?year=2025", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+		}
+	}
+	_, resp := doRequest(t, handler, http.MethodPost, "/holidays/import?year=2026&optional=true", nil)
+	var report models.HolidayImportReport
+	decodeData(t, resp, &report)
+	if report.Year != 2026 || len(report.Holidays) != 13 {
+		t.Errorf("expected 13 holidays in 2026 with the optional ones, got %+v", report)
+	}
+
+	// Importar de novo nao duplica
+	_, resp = doRequest(t, handler, http.MethodGet, "/holidays?year=2025", nil)
+	var holidays []models.Holiday
+	decodeData(t, resp, &holidays)
+	if len(holidays) != 10 || holidays[0].Date != "2025-01-01" {
+		t.Errorf("expected the 10 holidays of 2025 once, got %+v", holidays)
+	}
+
+	for _, target := range []string{"/holidays/import", "/holidays/import?year=1800", "/holidays?year=abc"} {
+		method := http.MethodPost
+		if target == "/holidays?year=abc" {
+			method = http.MethodGet
+		}
+		if rec, _ := doRequest(t, handler, method, target, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestCreateHoliday(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	tests := []struct {
+		name       string
+		body       models.CreateHolidayRequest
+		wantStatus int
+	}{
+		{"municipal", models.CreateHolidayRequest{Date: "2024-11-04", Name: "Aniversário de São Carlos", Scope: "municipal", State: "sp", City: "São Carlos"}, http.StatusCreated},
+		{"duplicate", models.CreateHolidayRequest{Date: "2024-11-04", Name: "Outro nome", Scope: "municipal", State: "SP", City: "SÃO CARLOS"}, http.StatusConflict},
+		{"same day in another city", models.CreateHolidayRequest{Date: "2024-11-04", Name: "Outro", Scope: "municipal", State: "SP", City: "Campinas"}, http.StatusCreated},
+		{"state", models.CreateHolidayRequest{Date: "2024-07-09", Name: "Revolução Constitucionalista", Scope: "state", State: "SP"}, http.StatusCreated},
+		{"municipal without city", models.CreateHolidayRequest{Date: "2024-11-04", Name: "Sem cidade", Scope: "municipal", State: "SP"}, http.StatusBadRequest},
+		{"invalid scope", models.CreateHolidayRequest{Date: "2024-11-04", Name: "Bairro", Scope: "neighborhood", State: "SP"}, http.StatusBadRequest},
+		{"invalid date", models.CreateHolidayRequest{Date: "04/11/2024", Name: "Data", Scope: "state", State: "SP"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, resp := doRequest(t, handler, http.MethodPost, "/holidays", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rec.Code, resp.Error)
+			}
+		})
+	}
+
+	_, resp := doRequest(t, handler, http.MethodGet, "/holidays", nil)
+	var holidays []models.Holiday
+	decodeData(t, resp, &holidays)
+	if len(holidays) != 3 || holidays[1].City != "SÃO CARLOS" {
+		t.Fatalf("expected 3 holidays with normalized city, got %+v", holidays)
+	}
+
+	if rec, _ := doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/holidays/%d", holidays[0].ID), nil); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 deleting a holiday, got %d", rec.Code)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/holidays/%d", holidays[0].ID), nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting it again, got %d", rec.Code)
+	}
+}
+
+func TestUBSStatus(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	// Segunda a sexta, das 7h as 17h
+	f.ubs.OpeningHours = nil
+	for day := 1; day <= 5; day++ {
+		f.ubs.OpeningHours = append(f.ubs.OpeningHours, models.OpeningPeriod{Weekday: day, Opens: "07:00", Closes: "17:00"})
+	}
+	if err := f.store.UBS().Update(&f.ubs); err != nil {
+		t.Fatalf("failed to update UBS: %v", err)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodPost, "/holidays/import?year=2024", nil); rec.Code != http.StatusOK {
+		t.Fatalf("failed to import holidays: %d", rec.Code)
+	}
+
+	closures := fmt.Sprintf("/ubs/%d/closures", f.ubs.ID)
+	rec, resp := doRequest(t, handler, http.MethodPost, closures, models.CreateUBSClosureRequest{StartDate: "2024-04-01", EndDate: "2024-04-02", Reason: "Dedetização"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, resp.Error)
+	}
+	var closure models.UBSClosure
+	decodeData(t, resp, &closure)
+
+	for name, body := range map[string]models.CreateUBSClosureRequest{
+		"invalid date":     {StartDate: "2024-02-30"},
+		"end before start": {StartDate: "2024-04-02", EndDate: "2024-04-01"},
+	} {
+		if rec, _ := doRequest(t, handler, http.MethodPost, closures, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+	if rec, _ := doRequest(t, handler, http.MethodPost, "/ubs/999/closures", models.CreateUBSClosureRequest{StartDate: "2024-04-01"}); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown UBS, got %d", rec.Code)
+	}
+
+	status := func(at string) models.OpeningStatus {
+		t.Helper()
+		rec, resp := doRequest(t, handler, http.MethodGet, fmt.Sprintf("/ubs/%d/status?at=%s", f.ubs.ID, at), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+		}
+		var status models.OpeningStatus
+		decodeData(t, resp, &status)
+		return status
+	}
+
+	// Sexta-feira Santa de 2024, seguida do fim de semana e do fechamento
+	holiday := status("2024-03-29T10:00:00-03:00")
+	if holiday.Open || holiday.Reason != "holiday" || holiday.Detail != "Sexta-feira Santa" {
+		t.Errorf("expected closed for the holiday, got %+v", holiday)
+	}
+	if want := time.Date(2024, 4, 3, 7, 0, 0, 0, time.FixedZone("", -3*3600)); holiday.NextOpening == nil || !holiday.NextOpening.Equal(want) {
+		t.Errorf("expected next opening on %s, got %v", want, holiday.NextOpening)
+	}
+
+	// 13h UTC sao 10h em Sao Paulo
+	open := status("2024-03-27T13:00:00Z")
+	if !open.Open || open.ClosesAt == nil || open.ClosesAt.Hour() != 17 {
+		t.Errorf("expected open until 17h, got %+v", open)
+	}
+
+	if rec, _ := doRequest(t, handler, http.MethodGet, fmt.Sprintf("/ubs/%d/status?at=ontem", f.ubs.ID), nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid at, got %d", rec.Code)
+	}
+
+	if rec, _ := doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/closures/%d", closure.ID), nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 deleting the closure, got %d", rec.Code)
+	}
+	_, resp = doRequest(t, handler, http.MethodGet, closures, nil)
+	var remaining []models.UBSClosure
+	decodeData(t, resp, &remaining)
+	if len(remaining) != 0 {
+		t.Errorf("expected no closures left, got %+v", remaining)
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"address-api/internal/cep"
+	"address-api/internal/models"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// A base completa de CEPs tem dezenas de MB; arquivos maiores devem ser
+// carregados pela linha de comando
+const maxCEPImportSize = 100 << 20
+
+// getCEP devolve o endereco de um CEP, para autocompletar o cadastro
+func (h *Handler) getCEP(w http.ResponseWriter, r *http.Request) {
+	value, err := cep.Normalize(r.PathValue("cep"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid CEP. Must have 8 digits")
+		return
+	}
+
+	address, err := h.ceps.Get(value)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "CEP not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    address,
+	})
+}
+
+// importCEPs carrega um CSV da base de CEPs, enviado no corpo da requisicao
+// ou no campo "file" de um formulario multipart
+func (h *Handler) importCEPs(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCEPImportSize)
+	defer r.Body.Close()
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				respondWithError(w, http.StatusRequestEntityTooLarge, "Import file is too large, use the import-ceps command")
+				return
+			}
+			respondWithError(w, http.StatusBadRequest, "Missing import file in form field \"file\"")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	report, err := cep.NewLoader(h.ceps).Load(body, dryRun)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Import file is too large, use the import-ceps command")
+		case errors.Is(err, cep.ErrNoRows), errors.Is(err, cep.ErrInvalidFile):
+			respondWithError(w, http.StatusBadRequest, "Invalid import file: "+err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Failed to import CEPs")
+		}
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	respondWithJSON(w, status, models.APIResponse{Success: true, Data: report})
+}
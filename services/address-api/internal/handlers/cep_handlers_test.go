@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const cepDataset = "cep,logradouro,bairro,localidade,uf\n" +
+	"13560-000,Rua das Flores,Centro,Sao Carlos,SP\n" +
+	"13573-000,Rua das Flores,Vila Nery,Sao Carlos,SP\n" +
+	"13560-999,Rua Sem Equipe,Centro,Sao Carlos,SP\n"
+
+// withCEPs carrega a base de CEPs de teste pela rota de importacao
+func withCEPs(t *testing.T, f *fixture) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/ceps/import", strings.NewReader(cepDataset))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("failed to import CEPs: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestImportCEPs(t *testing.T) {
+	f := newFixture(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/ceps/import?dry_run=true", strings.NewReader(cepDataset))
+	rec := httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"valid_rows":3`) {
+		t.Fatalf("unexpected dry run response %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/ceps/import", strings.NewReader("cep,bairro\n13560000,Centro\n"))
+	rec = httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing columns, got %d", rec.Code)
+	}
+}
+
+func TestGetCEP(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"known CEP", "/ceps/13560-000", http.StatusOK},
+		{"invalid CEP", "/ceps/1356", http.StatusBadRequest},
+		{"unknown CEP", "/ceps/01310100", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			withCEPs(t, f)
+			rec, resp := doRequest(t, f.routes(), http.MethodGet, tt.path, nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				var address models.CEPAddress
+				decodeData(t, resp, &address)
+				if address.StreetName != "FLORES" || address.Neighborhood != "CENTRO" || address.City != "SAO CARLOS" {
+					t.Errorf("unexpected address %+v", address)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchByCEP(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		check      func(t *testing.T, f *fixture, result models.AddressSearchResponse)
+	}{
+		{
+			name:       "CEP and number are enough",
+			path:       "/streets/search?cep=13560-000&number=10",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, result models.AddressSearchResponse) {
+				if result.Team.ID != f.team.ID || result.Status != "matched" || len(result.Candidates) != 1 {
+					t.Errorf("unexpected result %+v", result)
+				}
+				if result.Address == nil || result.Address.StreetName != "FLORES" {
+					t.Errorf("expected resolved address, got %+v", result.Address)
+				}
+			},
+		},
+		{
+			name:       "CEP prefix narrows the candidates",
+			path:       "/streets/search?cep=13573000&number=10",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, result models.AddressSearchResponse) {
+				if result.Team.ID == f.team.ID || len(result.Candidates) != 1 {
+					t.Errorf("expected only the Vila Nery segment, got %+v", result)
+				}
+			},
+		},
+		{
+			name:       "unknown CEP needs the street",
+			path:       "/streets/search?cep=01310100&number=10",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown CEP with street still searches",
+			path:       "/streets/search?cep=01310100&number=10&street=das+flores&city=sao+carlos&state=sp",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "CEP of a street without team",
+			path:       "/streets/search?cep=13560999&number=10",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid CEP",
+			path:       "/streets/search?cep=abc&number=10",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "number is required",
+			path:       "/streets/search?cep=13560000",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			withCEPs(t, f)
+			other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+			f.store.Teams().Create(&other)
+			f.store.StreetSegments().Create(&models.StreetSegment{
+				StreetName: "FLORES", Neighborhood: "VILA NERY", City: "SAO CARLOS", State: "SP",
+				StartNumber: 1, EndNumber: 99, CEPPrefix: "13573", EvenOdd: "all", TeamID: other.ID,
+			})
+
+			rec, resp := doRequest(t, f.routes(), http.MethodGet, tt.path, nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.check != nil {
+				var result models.AddressSearchResponse
+				decodeData(t, resp, &result)
+				tt.check(t, f, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/territory"
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"shared/normalize"
+	"strings"
+)
+
+// exportTeam exporta o territorio de uma equipe
+func (h *Handler) exportTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
+		return
+	}
+
+	team, err := h.teams.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	segments, err := h.segments.ListByTeams([]uint{team.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segments")
+		return
+	}
+
+	subtitle := team.UBS.Name
+	h.writeExport(w, r, "territorio-"+team.Name, territory.NewSummary(
+		"Território da "+team.Name, subtitle, []models.Team{*team}, segments,
+	), segments)
+}
+
+// exportUBS exporta o territorio de todas as equipes de uma UBS
+func (h *Handler) exportUBS(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
+		return
+	}
+
+	ubs, err := h.ubs.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "UBS not found")
+		return
+	}
+
+	teamIDs := make([]uint, len(ubs.Teams))
+	for i, team := range ubs.Teams {
+		teamIDs[i] = team.ID
+	}
+	segments, err := h.segments.ListByTeams(teamIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segments")
+		return
+	}
+
+	subtitle := fmt.Sprintf("%s - %s/%s", ubs.Address, ubs.City, ubs.State)
+	h.writeExport(w, r, "territorio-"+ubs.Name, territory.NewSummary(
+		"Território da "+ubs.Name, subtitle, ubs.Teams, segments,
+	), segments)
+}
+
+// writeExport responde no formato pedido em ?format= (csv por padrao)
+func (h *Handler) writeExport(w http.ResponseWriter, r *http.Request, name string, summary territory.Summary, segments []models.StreetSegment) {
+	territory.SortForExport(segments)
+
+	// Gera em memoria para poder responder 500 se algo falhar
+	var buf bytes.Buffer
+	var contentType, extension string
+	var err error
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", territory.FormatCSV:
+		contentType, extension = "text/csv; charset=utf-8", "csv"
+		err = territory.WriteCSV(&buf, segments)
+	case territory.FormatGeoJSON:
+		contentType, extension = "application/geo+json", "geojson"
+		err = territory.WriteGeoJSON(&buf, segments)
+	case territory.FormatHTML:
+		contentType, extension = "text/html; charset=utf-8", "html"
+		err = territory.WriteHTML(&buf, summary)
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid format. Must be 'csv', 'geojson' or 'html'")
+		return
+	}
+	if err != nil {
+		log.Printf("Error exporting %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to export territory")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	// O HTML abre no navegador para impressao; os outros formatos sao baixados
+	if extension != "html" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, fileName(name), extension))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// fileName transforma "territorio-UBS Vila São José" em "territorio-ubs-vila-sao-jose"
+func fileName(name string) string {
+	name = strings.ToLower(normalize.Text(name))
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportTerritory(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            func(f *fixture) string
+		wantStatus      int
+		wantContentType string
+		wantFile        string
+		check           func(t *testing.T, body string)
+	}{
+		{
+			name:            "team as CSV",
+			path:            func(f *fixture) string { return fmt.Sprintf("/teams/%d/export", f.team.ID) },
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantFile:        "territorio-equipe-azul.csv",
+			check: func(t *testing.T, body string) {
+				lines := strings.Split(strings.TrimSpace(body), "\n")
+				if len(lines) != 3 {
+					t.Fatalf("expected header and 2 rows, got %q", body)
+				}
+				if !strings.HasPrefix(lines[1], "RUA,das Flores,CENTRO,SAO CARLOS,SP,1,99,13560,all,") ||
+					!strings.HasSuffix(lines[1], ",Equipe Azul,UBS Centro") {
+					t.Errorf("unexpected row %q", lines[1])
+				}
+				if !strings.Contains(lines[2], "das Flores,CENTRO,SAO CARLOS,SP,101,199") {
+					t.Errorf("expected rows ordered by street and number, got %q", lines[2])
+				}
+			},
+		},
+		{
+			name:            "UBS as GeoJSON",
+			path:            func(f *fixture) string { return fmt.Sprintf("/ubs/%d/export?format=geojson", f.ubs.ID) },
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/geo+json",
+			wantFile:        "territorio-ubs-centro.geojson",
+			check: func(t *testing.T, body string) {
+				var collection struct {
+					Type     string `json:"type"`
+					Features []struct {
+						Properties map[string]interface{} `json:"properties"`
+					} `json:"features"`
+				}
+				if err := json.Unmarshal([]byte(body), &collection); err != nil {
+					t.Fatalf("invalid GeoJSON: %v", err)
+				}
+				if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+					t.Fatalf("unexpected collection %s", body)
+				}
+				// O nome da rua sai como no CSV, do jeito que foi cadastrado
+				if collection.Features[0].Properties["team_name"] != "Equipe Azul" || collection.Features[0].Properties["street_name"] != "das Flores" {
+					t.Errorf("unexpected properties %v", collection.Features[0].Properties)
+				}
+			},
+		},
+		{
+			name:            "UBS as printable HTML",
+			path:            func(f *fixture) string { return fmt.Sprintf("/ubs/%d/export?format=html", f.ubs.ID) },
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			check: func(t *testing.T, body string) {
+				for _, want := range []string{"Território da UBS Centro", "Equipe Azul", "2 trechos em 1 ruas", "Equipe Verde", "Nenhum trecho"} {
+					if !strings.Contains(body, want) {
+						t.Errorf("expected %q in summary", want)
+					}
+				}
+			},
+		},
+		{
+			name:       "invalid format",
+			path:       func(f *fixture) string { return fmt.Sprintf("/teams/%d/export?format=pdf", f.team.ID) },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown team",
+			path:       func(*fixture) string { return "/teams/999/export" },
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			// Um segundo trecho da mesma rua e uma equipe sem territorio
+			segment := f.segment
+			segment.ID, segment.StartNumber, segment.EndNumber = 0, 101, 199
+			f.store.StreetSegments().Create(&segment)
+			f.store.Teams().Create(&models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID})
+
+			rec := httptest.NewRecorder()
+			f.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path(f), nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantContentType != "" && rec.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+			}
+			if tt.wantFile != "" && !strings.Contains(rec.Header().Get("Content-Disposition"), tt.wantFile) {
+				t.Errorf("unexpected content disposition %q", rec.Header().Get("Content-Disposition"))
+			}
+			if tt.check != nil {
+				tt.check(t, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"net/http"
+	"shared/middleware"
+	"shared/openapi"
+	"time"
+)
+
+// Handler agrupa os handlers HTTP e os repositorios que eles usam
+type Handler struct {
+	ubs           repository.UBSRepository
+	teams         repository.TeamRepository
+	segments      repository.StreetSegmentRepository
+	ceps          repository.CEPRepository
+	aliases       repository.StreetAliasRepository
+	lookups       repository.AddressLookupRepository
+	territories   repository.TerritoryRepository
+	holidays      repository.HolidayRepository
+	closures      repository.UBSClosureRepository
+	professionals repository.ProfessionalRepository
+	microAreas    repository.MicroAreaRepository
+	assignments   repository.SegmentAssignmentRepository
+	// now e o relogio usado para dizer se uma UBS esta aberta
+	now func() time.Time
+}
+
+func NewHandler(store repository.Store) *Handler {
+	return &Handler{
+		ubs:           store.UBS(),
+		teams:         store.Teams(),
+		segments:      store.StreetSegments(),
+		ceps:          store.CEPs(),
+		aliases:       store.StreetAliases(),
+		lookups:       store.AddressLookups(),
+		territories:   store.Territories(),
+		holidays:      store.Holidays(),
+		closures:      store.UBSClosures(),
+		professionals: store.Professionals(),
+		microAreas:    store.MicroAreas(),
+		assignments:   store.SegmentAssignments(),
+		now:           time.Now,
+	}
+}
+
+// Routes registra as rotas da API, documentando cada uma no OpenAPI, e
+// aplica a cadeia de middlewares comum
+func (h *Handler) Routes(allowedOrigins string) http.Handler {
+	mux := http.NewServeMux()
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Address API",
+		Version:     "1.0.0",
+		Description: "UBS, equipes e segmentos de rua usados para encontrar a equipe responsável por um endereço",
+	})
+	api := openapi.NewRouter(mux, doc)
+
+	id := openapi.Param{Name: "id", In: "path", Type: "integer"}
+	deleted := openapi.Param{Name: "deleted", Type: "boolean", Description: "Lista apenas os registros removidos"}
+	cascade := openapi.Param{Name: "cascade", Type: "boolean", Description: "Remove também os registros dependentes"}
+
+	api.Handle("GET /health", h.health, openapi.Operation{
+		Summary:  "Estado do serviço",
+		Tag:      "health",
+		Response: models.HealthStatus{},
+	})
+
+	api.Handle("GET /ubs", h.listUBS, openapi.Operation{
+		Summary: "Lista as UBS", Tag: "ubs",
+		Params:   []openapi.Param{deleted},
+		Response: []models.UBS{},
+	})
+	api.Handle("POST /ubs", h.createUBS, openapi.Operation{
+		Summary: "Cria uma UBS", Tag: "ubs",
+		Request:  models.CreateUBSRequest{},
+		Response: models.UBS{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("GET /ubs/nearest", h.nearestUBS, openapi.Operation{
+		Summary: "Lista as UBS mais próximas de um ponto, da mais perto para a mais longe", Tag: "ubs",
+		Params: []openapi.Param{
+			{Name: "lat", Type: "number", Required: true},
+			{Name: "lng", Type: "number", Required: true},
+			{Name: "limit", Type: "integer", Description: "Quantidade de UBS (padrão 5, máximo 50)"},
+			{Name: "open_now", Type: "boolean", Description: "Retorna apenas as UBS abertas agora"},
+		},
+		Response: []models.NearbyUBS{},
+	})
+	api.Handle("GET /ubs/{id}", h.getUBS, openapi.Operation{
+		Summary: "Busca uma UBS com suas equipes", Tag: "ubs",
+		Params:   []openapi.Param{id},
+		Response: models.UBS{},
+	})
+	api.Handle("PUT /ubs/{id}", h.updateUBS, openapi.Operation{
+		Summary: "Atualiza uma UBS", Tag: "ubs",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateUBSRequest{},
+		Response: models.UBS{},
+	})
+	api.Handle("DELETE /ubs/{id}", h.deleteUBS, openapi.Operation{
+		Summary: "Remove uma UBS", Tag: "ubs",
+		Params:   []openapi.Param{id, cascade},
+		Response: "",
+	})
+	api.Handle("POST /ubs/{id}/restore", h.restoreUBS, openapi.Operation{
+		Summary: "Restaura uma UBS removida", Tag: "ubs",
+		Params:   []openapi.Param{id},
+		Response: models.UBS{},
+	})
+
+	api.Handle("GET /ubs/{id}/status", h.ubsStatus, openapi.Operation{
+		Summary: "Diz se a UBS está aberta e quando abre de novo", Tag: "ubs",
+		Params:   []openapi.Param{id, {Name: "at", Description: "Instante consultado em RFC 3339 (padrão: agora)"}},
+		Response: models.OpeningStatus{},
+	})
+	api.Handle("GET /ubs/{id}/closures", h.listClosures, openapi.Operation{
+		Summary: "Lista os fechamentos temporários da UBS", Tag: "ubs",
+		Params:   []openapi.Param{id},
+		Response: []models.UBSClosure{},
+	})
+	api.Handle("POST /ubs/{id}/closures", h.createClosure, openapi.Operation{
+		Summary: "Fecha a UBS por alguns dias", Tag: "ubs",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateUBSClosureRequest{},
+		Response: models.UBSClosure{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("DELETE /closures/{id}", h.deleteClosure, openapi.Operation{
+		Summary: "Remove um fechamento temporário", Tag: "ubs",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+
+	api.Handle("GET /holidays", h.listHolidays, openapi.Operation{
+		Summary: "Lista os feriados", Tag: "holidays",
+		Params:   []openapi.Param{{Name: "year", Type: "integer", Description: "Apenas os feriados do ano"}},
+		Response: []models.Holiday{},
+	})
+	api.Handle("POST /holidays", h.createHoliday, openapi.Operation{
+		Summary: "Cadastra um feriado estadual ou municipal", Tag: "holidays",
+		Request:  models.CreateHolidayRequest{},
+		Response: models.Holiday{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("POST /holidays/import", h.importHolidays, openapi.Operation{
+		Summary: "Importa o calendário de feriados nacionais do ano, calculado localmente", Tag: "holidays",
+		Params: []openapi.Param{
+			{Name: "year", Type: "integer", Required: true},
+			{Name: "optional", Type: "boolean", Description: "Inclui os pontos facultativos (Carnaval e Corpus Christi)"},
+		},
+		Response: models.HolidayImportReport{},
+	})
+	api.Handle("DELETE /holidays/{id}", h.deleteHoliday, openapi.Operation{
+		Summary: "Remove um feriado", Tag: "holidays",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+
+	api.Handle("GET /ubs/{id}/export", h.exportUBS, openapi.Operation{
+		Summary: "Exporta os segmentos de rua de todas as equipes da UBS", Tag: "ubs",
+		Params: []openapi.Param{id, {Name: "format", Description: "csv (padrao), geojson ou html"}},
+	})
+
+	api.Handle("GET /teams", h.listTeams, openapi.Operation{
+		Summary: "Lista as equipes", Tag: "teams",
+		Params:   []openapi.Param{deleted},
+		Response: []models.Team{},
+	})
+	api.Handle("POST /teams", h.createTeam, openapi.Operation{
+		Summary: "Cria uma equipe", Tag: "teams",
+		Request:  models.CreateTeamRequest{},
+		Response: models.Team{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("GET /teams/{id}", h.getTeam, openapi.Operation{
+		Summary: "Busca uma equipe", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Response: models.Team{},
+	})
+	api.Handle("PUT /teams/{id}", h.updateTeam, openapi.Operation{
+		Summary: "Atualiza uma equipe", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateTeamRequest{},
+		Response: models.Team{},
+	})
+	api.Handle("DELETE /teams/{id}", h.deleteTeam, openapi.Operation{
+		Summary: "Remove uma equipe", Tag: "teams",
+		Params:   []openapi.Param{id, cascade},
+		Response: "",
+	})
+	api.Handle("POST /teams/{id}/restore", h.restoreTeam, openapi.Operation{
+		Summary: "Restaura uma equipe removida", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Response: models.Team{},
+	})
+
+	api.Handle("GET /teams/{id}/members", h.listTeamMembers, openapi.Operation{
+		Summary: "Lista os profissionais da equipe", Tag: "teams",
+		Params:   []openapi.Param{id, {Name: "history", Type: "boolean", Description: "Inclui os vínculos encerrados"}},
+		Response: []models.TeamMembership{},
+	})
+	api.Handle("POST /teams/{id}/members", h.addTeamMember, openapi.Operation{
+		Summary: "Vincula um profissional à equipe", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Request:  models.AddTeamMemberRequest{},
+		Response: models.TeamMembership{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("POST /memberships/{id}/end", h.endMembership, openapi.Operation{
+		Summary: "Encerra o vínculo de um profissional com a equipe", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Request:  models.EndMembershipRequest{},
+		Response: models.TeamMembership{},
+	})
+
+	api.Handle("GET /teams/{id}/micro-areas", h.listMicroAreas, openapi.Operation{
+		Summary: "Lista as micro-áreas da equipe", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Response: []models.MicroArea{},
+	})
+	api.Handle("POST /teams/{id}/micro-areas", h.createMicroArea, openapi.Operation{
+		Summary: "Cria uma micro-área na equipe", Tag: "teams",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateMicroAreaRequest{},
+		Response: models.MicroArea{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("GET /micro-areas/{id}", h.getMicroArea, openapi.Operation{
+		Summary: "Busca uma micro-área com o ACS e os segmentos de rua", Tag: "micro-areas",
+		Params:   []openapi.Param{id},
+		Response: models.MicroArea{},
+	})
+	api.Handle("PUT /micro-areas/{id}", h.updateMicroArea, openapi.Operation{
+		Summary: "Atualiza o código, o nome e o ACS de uma micro-área", Tag: "micro-areas",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateMicroAreaRequest{},
+		Response: models.MicroArea{},
+	})
+	api.Handle("DELETE /micro-areas/{id}", h.deleteMicroArea, openapi.Operation{
+		Summary: "Remove uma micro-área, mantendo os segmentos na equipe", Tag: "micro-areas",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+	api.Handle("PUT /micro-areas/{id}/segments", h.assignMicroAreaSegments, openapi.Operation{
+		Summary: "Define os segmentos de rua da micro-área", Tag: "micro-areas",
+		Params:   []openapi.Param{id},
+		Request:  models.AssignSegmentsRequest{},
+		Response: models.MicroArea{},
+	})
+
+	api.Handle("GET /professionals", h.listProfessionals, openapi.Operation{
+		Summary: "Lista os profissionais", Tag: "professionals",
+		Params:   []openapi.Param{{Name: "role", Description: "doctor, nurse, nursing_technician, acs, dentist ou oral_health_technician"}},
+		Response: []models.Professional{},
+	})
+	api.Handle("POST /professionals", h.createProfessional, openapi.Operation{
+		Summary: "Cadastra um profissional", Tag: "professionals",
+		Request:  models.CreateProfessionalRequest{},
+		Response: models.Professional{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("GET /professionals/{id}", h.getProfessional, openapi.Operation{
+		Summary: "Busca um profissional com o histórico de equipes", Tag: "professionals",
+		Params:   []openapi.Param{id},
+		Response: models.Professional{},
+	})
+	api.Handle("PUT /professionals/{id}", h.updateProfessional, openapi.Operation{
+		Summary: "Atualiza um profissional", Tag: "professionals",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateProfessionalRequest{},
+		Response: models.Professional{},
+	})
+	api.Handle("DELETE /professionals/{id}", h.deleteProfessional, openapi.Operation{
+		Summary: "Remove um profissional e encerra seus vínculos", Tag: "professionals",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+
+	api.Handle("GET /teams/{id}/export", h.exportTeam, openapi.Operation{
+		Summary: "Exporta os segmentos de rua da equipe", Tag: "teams",
+		Params: []openapi.Param{id, {Name: "format", Description: "csv (padrao), geojson ou html"}},
+	})
+
+	api.Handle("GET /streets", h.listStreetSegments, openapi.Operation{
+		Summary: "Lista os segmentos de rua", Tag: "streets",
+		Params:   []openapi.Param{deleted},
+		Response: []models.StreetSegment{},
+	})
+	api.Handle("POST /streets", h.createStreetSegment, openapi.Operation{
+		Summary: "Cria um segmento de rua", Tag: "streets",
+		Request:  models.CreateStreetSegmentRequest{},
+		Response: models.StreetSegment{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("POST /streets/import", h.importStreetSegments, openapi.Operation{
+		Summary: "Importa segmentos de rua de um CSV ou GeoJSON", Tag: "streets",
+		Params: []openapi.Param{
+			{Name: "format", Description: "csv ou geojson; sem ele o formato vem do Content-Type ou da extensao do arquivo"},
+			{Name: "dry_run", Type: "boolean", Description: "Apenas valida, sem gravar"},
+		},
+		Response: models.ImportReport{},
+		Status:   http.StatusCreated,
+	})
+	// Rota literal tem precedencia sobre /streets/{id}
+	api.Handle("GET /streets/search", h.findTeamByAddress, openapi.Operation{
+		Summary: "Encontra a equipe responsável por um endereço", Tag: "streets",
+		Params: []openapi.Param{
+			{Name: "street", Description: "Nome da rua, com ou sem o tipo; opcional com cep"},
+			{Name: "q", Description: "Endereco em texto livre, como \"Rua das Flores 123 apto 4, Centro\""},
+			{Name: "number", Type: "integer", Description: "Obrigatorio, a menos que venha em q"},
+			{Name: "city", Description: "Opcional com cep"},
+			{Name: "state", Description: "Opcional com cep"},
+			{Name: "cep", Description: "CEP do endereco; completa rua, bairro e cidade pela base local e restringe os candidatos ao prefixo"},
+			{Name: "neighborhood", Description: "Bairro do endereco, usado para desempatar candidatos"},
+			{Name: "user_id", Type: "integer", Description: "Usuario do user-api para quem a busca e feita; fica registrado nas buscas que vao para revisao"},
+			{Name: "lat", Type: "number", Description: "Latitude do endereco; com lng compara o ponto com os territorios das equipes e dispensa rua e numero"},
+			{Name: "lng", Type: "number", Description: "Longitude do endereco"},
+			{Name: "date", Description: "Data YYYY-MM-DD; responde com a equipe que cobria o endereco nesse dia"},
+		},
+		Response: models.AddressSearchResponse{},
+	})
+	api.Handle("POST /streets/search/batch", h.findTeamsByAddresses, openapi.Operation{
+		Summary: "Encontra a equipe responsável por vários endereços de uma vez", Tag: "streets",
+		Request:  models.BatchSearchRequest{},
+		Response: []models.BatchSearchResult{},
+	})
+	api.Handle("GET /streets/analysis", h.analyzeStreetSegments, openapi.Operation{
+		Summary: "Lista sobreposições e buracos entre os segmentos de cada rua", Tag: "streets",
+		Params: []openapi.Param{
+			{Name: "city", Description: "Filtra pela cidade"},
+			{Name: "state", Description: "Filtra pela UF"},
+		},
+		Response: models.TerritoryAnalysis{},
+	})
+	api.Handle("POST /streets/aliases/from-search", h.createAliasFromSearch, openapi.Operation{
+		Summary: "Registra a rua de uma busca sem resultado como alias do segmento encontrado manualmente", Tag: "streets",
+		Request:  models.ResolvedSearchRequest{},
+		Response: models.StreetAlias{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("DELETE /streets/aliases/{id}", h.deleteStreetAlias, openapi.Operation{
+		Summary: "Remove um alias de rua", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+	api.Handle("GET /streets/{id}", h.getStreetSegment, openapi.Operation{
+		Summary: "Busca um segmento de rua", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: models.StreetSegment{},
+	})
+	api.Handle("PUT /streets/{id}", h.updateStreetSegment, openapi.Operation{
+		Summary: "Atualiza um segmento de rua", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateStreetSegmentRequest{},
+		Response: models.StreetSegment{},
+	})
+	api.Handle("DELETE /streets/{id}", h.deleteStreetSegment, openapi.Operation{
+		Summary: "Remove um segmento de rua", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+	api.Handle("POST /streets/{id}/restore", h.restoreStreetSegment, openapi.Operation{
+		Summary: "Restaura um segmento de rua removido", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: models.StreetSegment{},
+	})
+
+	api.Handle("GET /streets/{id}/aliases", h.listStreetAliases, openapi.Operation{
+		Summary: "Lista os nomes alternativos da rua do segmento", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: []models.StreetAlias{},
+	})
+	api.Handle("POST /streets/{id}/aliases", h.createStreetAlias, openapi.Operation{
+		Summary: "Cadastra um nome antigo, popular ou grafia errada da rua do segmento", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Request:  models.CreateStreetAliasRequest{},
+		Response: models.StreetAlias{},
+		Status:   http.StatusCreated,
+	})
+
+	api.Handle("GET /streets/{id}/assignments", h.listSegmentAssignments, openapi.Operation{
+		Summary: "Lista o histórico de equipes do segmento de rua", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: []models.SegmentAssignment{},
+	})
+	api.Handle("POST /streets/{id}/reassignments", h.reassignSegment, openapi.Operation{
+		Summary: "Muda o segmento de equipe agora ou a partir de uma data futura", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Request:  models.ReassignSegmentRequest{},
+		Response: models.SegmentAssignment{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("GET /reassignments", h.listReassignments, openapi.Operation{
+		Summary: "Lista as reatribuições agendadas ou as ainda não avisadas aos usuários", Tag: "streets",
+		Params:   []openapi.Param{{Name: "status", Description: "scheduled (padrão) ou unnotified"}},
+		Response: []models.SegmentAssignment{},
+	})
+	api.Handle("DELETE /reassignments/{id}", h.cancelReassignment, openapi.Operation{
+		Summary: "Cancela uma reatribuição agendada", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+	api.Handle("POST /reassignments/{id}/notified", h.markReassignmentNotified, openapi.Operation{
+		Summary: "Registra que os usuários afetados pela reatribuição foram avisados", Tag: "streets",
+		Params:   []openapi.Param{id},
+		Response: models.SegmentAssignment{},
+	})
+
+	api.Handle("GET /territories", h.listTerritories, openapi.Operation{
+		Summary: "Lista os territórios (polígonos) das equipes", Tag: "territories",
+		Params: []openapi.Param{
+			{Name: "team_id", Type: "integer", Description: "Filtra pela equipe"},
+			{Name: "format", Description: "json (padrao) ou geojson"},
+		},
+		Response: []models.Territory{},
+	})
+	api.Handle("POST /territories", h.createTerritory, openapi.Operation{
+		Summary: "Cria o território de uma equipe a partir de uma Feature GeoJSON", Tag: "territories",
+		Request:  models.TerritoryFeature{},
+		Response: models.Territory{},
+		Status:   http.StatusCreated,
+	})
+	// Rota literal tem precedencia sobre /territories/{id}
+	api.Handle("GET /territories/locate", h.locateTerritories, openapi.Operation{
+		Summary: "Lista os territórios que contêm o ponto", Tag: "territories",
+		Params: []openapi.Param{
+			{Name: "lat", Type: "number", Required: true},
+			{Name: "lng", Type: "number", Required: true},
+		},
+		Response: []models.Territory{},
+	})
+	api.Handle("GET /territories/{id}", h.getTerritory, openapi.Operation{
+		Summary: "Busca um território", Tag: "territories",
+		Params:   []openapi.Param{id},
+		Response: models.Territory{},
+	})
+	api.Handle("PUT /territories/{id}", h.updateTerritory, openapi.Operation{
+		Summary: "Substitui o polígono, o nome ou a equipe de um território", Tag: "territories",
+		Params:   []openapi.Param{id},
+		Request:  models.TerritoryFeature{},
+		Response: models.Territory{},
+	})
+	api.Handle("DELETE /territories/{id}", h.deleteTerritory, openapi.Operation{
+		Summary: "Remove um território", Tag: "territories",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+
+	api.Handle("POST /addresses/parse", h.parseAddress, openapi.Operation{
+		Summary: "Extrai rua, número, complemento, bairro e CEP de um endereço em texto livre", Tag: "addresses",
+		Request:  models.ParseAddressRequest{},
+		Response: models.ParsedAddress{},
+	})
+
+	api.Handle("GET /lookups", h.listLookups, openapi.Operation{
+		Summary: "Fila de revisão: buscas sem equipe, ambíguas ou com baixa confiança", Tag: "lookups",
+		Params:   []openapi.Param{{Name: "resolved", Type: "boolean", Description: "Lista as buscas já resolvidas"}},
+		Response: []models.AddressLookup{},
+	})
+	api.Handle("GET /lookups/{id}", h.getLookup, openapi.Operation{
+		Summary: "Busca um registro da fila de revisão", Tag: "lookups",
+		Params:   []openapi.Param{id},
+		Response: models.AddressLookup{},
+	})
+	api.Handle("POST /lookups/{id}/resolve", h.resolveLookup, openapi.Operation{
+		Summary: "Atribui o endereço a uma equipe criando um segmento ou alias, ou descarta a busca", Tag: "lookups",
+		Params:   []openapi.Param{id},
+		Request:  models.ResolveLookupRequest{},
+		Response: models.AddressLookup{},
+	})
+
+	api.Handle("GET /ceps/{cep}", h.getCEP, openapi.Operation{
+		Summary: "Busca o endereço de um CEP na base local", Tag: "ceps",
+		Params:   []openapi.Param{{Name: "cep", In: "path", Description: "CEP com ou sem pontuação"}},
+		Response: models.CEPAddress{},
+	})
+	api.Handle("POST /ceps/import", h.importCEPs, openapi.Operation{
+		Summary: "Carrega um CSV da base de CEPs (DNE ou ViaCEP)", Tag: "ceps",
+		Params:      []openapi.Param{{Name: "dry_run", Type: "boolean", Description: "Apenas valida, sem gravar"}},
+		ContentType: "text/csv",
+		Response:    models.ImportReport{},
+		Status:      http.StatusCreated,
+	})
+
+	api.ServeDocs()
+
+	return middleware.Chain(mux,
+		middleware.Recovery,
+		middleware.RequestID,
+		middleware.Logging,
+		middleware.CORS(allowedOrigins),
+		middleware.StripTrailingSlash,
+		middleware.JSONErrors,
+	)
+}
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.HealthStatus{Status: "ok"},
+	})
+}
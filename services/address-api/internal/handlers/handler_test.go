@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	f := newFixture(t)
+
+	rec := httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+
+	for path, method := range map[string]string{
+		"/ubs":                  "post",
+		"/teams/{id}":           "put",
+		"/streets/search":       "get",
+		"/streets/{id}/restore": "post",
+		"/health":               "get",
+	} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("expected %s %s to be documented", method, path)
+		}
+	}
+	for _, name := range []string{"CreateStreetSegmentRequest", "AddressSearchResponse", "UBS", "Team"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("expected schema %s", name)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected Swagger UI page, got %d", rec.Code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"address-api/internal/models"
 	"encoding/json"
 	"net/http"
+	"strconv"
 )
 
 func respondWithError(w http.ResponseWriter, code int, message string) {
@@ -19,3 +20,23 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(response)
 }
+
+// parseID le o valor {id} do caminho, respondendo 400 com message quando nao e numerico
+func parseID(w http.ResponseWriter, r *http.Request, message string) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 0)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// wantsDeleted indica se a listagem deve retornar apenas registros removidos
+func wantsDeleted(r *http.Request) bool {
+	return r.URL.Query().Get("deleted") == "true"
+}
+
+// wantsCascade indica se a remocao deve se propagar para os registros dependentes
+func wantsCascade(r *http.Request) bool {
+	return r.URL.Query().Get("cascade") == "true"
+}
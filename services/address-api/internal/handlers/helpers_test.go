@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testResponse espelha models.APIResponse mantendo Data cru para decodificar
+// no tipo esperado por cada teste
+type testResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+type fixture struct {
+	store   *repository.MemoryStore
+	handler *Handler
+	ubs     models.UBS
+	team    models.Team
+	segment models.StreetSegment
+}
+
+// newFixture cria um handler sobre um MemoryStore com uma UBS, um time e um
+// segmento (RUA DAS FLORES, 1-99, todos os numeros) ja cadastrados
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	f := &fixture{
+		store:   store,
+		handler: NewHandler(store),
+		ubs: models.UBS{
+			Name:    "UBS Centro",
+			Address: "Rua Central, 1",
+			City:    "SAO CARLOS",
+			State:   "SP",
+			CEP:     "13560000",
+		},
+	}
+
+	if err := store.UBS().Create(&f.ubs); err != nil {
+		t.Fatalf("failed to seed UBS: %v", err)
+	}
+	f.team = models.Team{Name: "Equipe Azul", UBSID: f.ubs.ID}
+	if err := store.Teams().Create(&f.team); err != nil {
+		t.Fatalf("failed to seed team: %v", err)
+	}
+	f.segment = models.StreetSegment{
+		StreetName:         "FLORES",
+		OriginalStreetName: "das Flores",
+		StreetType:         "RUA",
+		Neighborhood:       "CENTRO",
+		City:               "SAO CARLOS",
+		State:              "SP",
+		StartNumber:        1,
+		EndNumber:          99,
+		CEPPrefix:          "13560",
+		EvenOdd:            "all",
+		TeamID:             f.team.ID,
+	}
+	if err := store.StreetSegments().Create(&f.segment); err != nil {
+		t.Fatalf("failed to seed street segment: %v", err)
+	}
+	return f
+}
+
+// routes devolve o handler completo, com roteamento e middlewares
+func (f *fixture) routes() http.Handler {
+	return f.handler.Routes("*")
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, target string, body interface{}) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		payload, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec, resp
+}
+
+func decodeData(t *testing.T, resp testResponse, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		t.Fatalf("failed to decode response data %s: %v", resp.Data, err)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/territory"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Tamanho maximo do arquivo importado
+const maxImportSize = 10 << 20
+
+// importStreetSegments recebe um CSV ou GeoJSON com varios segmentos, no corpo
+// da requisicao ou como o campo "file" de um formulario multipart
+func (h *Handler) importStreetSegments(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	defer r.Body.Close()
+
+	body, format, err := importFile(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Import file is too large")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer body.Close()
+
+	rows, err := territory.Parse(format, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Import file is too large")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Invalid import file: "+err.Error())
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	report, err := territory.NewImporter(h.teams, h.segments).Import(rows, dryRun)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to import street segments")
+		return
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		respondWithJSON(w, http.StatusUnprocessableEntity, models.APIResponse{
+			Success: false,
+			Data:    report,
+			Error:   fmt.Sprintf("%d rows with errors, nothing was imported", len(report.Errors)),
+		})
+	case dryRun:
+		respondWithJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: report})
+	default:
+		respondWithJSON(w, http.StatusCreated, models.APIResponse{Success: true, Data: report})
+	}
+}
+
+// importFile devolve o arquivo enviado e seu formato, vindo de ?format=, da
+// extensao do arquivo ou do Content-Type. Um formulario acima do limite
+// devolve o *http.MaxBytesError.
+func importFile(r *http.Request) (io.ReadCloser, string, error) {
+	format := r.URL.Query().Get("format")
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, "", err
+			}
+			return nil, "", errors.New("Missing import file in form field \"file\"")
+		}
+		if format == "" {
+			format = formatFromName(header.Filename)
+		}
+		if format == "" {
+			format = formatFromMediaType(header.Header.Get("Content-Type"))
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		format = formatFromMediaType(mediaType)
+	}
+	if format == "" {
+		return nil, "", errors.New("Unknown import format, use ?format=csv or ?format=geojson")
+	}
+	return io.NopCloser(r.Body), format, nil
+}
+
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return territory.FormatCSV
+	case ".geojson", ".json":
+		return territory.FormatGeoJSON
+	}
+	return ""
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch mediaType {
+	case "text/csv":
+		return territory.FormatCSV
+	case "application/geo+json", "application/json":
+		return territory.FormatGeoJSON
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImportStreetSegments(t *testing.T) {
+	csvFile := func(f *fixture, rows ...string) string {
+		data := "street_type,street_name,neighborhood,city,state,start_number,end_number,cep,even_odd,team_id\n"
+		for _, row := range rows {
+			data += fmt.Sprintf(row, f.team.ID) + "\n"
+		}
+		return data
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		body        func(f *fixture) string
+		wantStatus  int
+		wantCreated int
+		wantErrors  int
+		wantStored  int
+	}{
+		{
+			name:   "imports every row",
+			target: "/streets/import?format=csv",
+			body: func(f *fixture) string {
+				return csvFile(f, "Av.,São João,Centro,Sao Carlos,SP,1,99,13560-001,odd,%d", "R,Episcopal,Centro,Sao Carlos,SP,2,100,13560-002,even,%d")
+			},
+			wantStatus:  http.StatusCreated,
+			wantCreated: 2,
+			wantStored:  3,
+		},
+		{
+			name:   "dry run only validates",
+			target: "/streets/import?format=csv&dry_run=true",
+			body: func(f *fixture) string {
+				return csvFile(f, "Av.,São João,Centro,Sao Carlos,SP,1,99,13560-001,odd,%d")
+			},
+			wantStatus: http.StatusOK,
+			wantStored: 1,
+		},
+		{
+			name:   "row errors import nothing",
+			target: "/streets/import?format=csv",
+			body: func(f *fixture) string {
+				return csvFile(f, "Av.,São João,Centro,Sao Carlos,SP,1,99,13560-001,odd,%d", "R,Episcopal,Centro,Sao Carlos,SP,200,100,,all,%d")
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: 1,
+			wantStored: 1,
+		},
+		{
+			name:   "geojson",
+			target: "/streets/import?format=geojson",
+			body: func(f *fixture) string {
+				return fmt.Sprintf(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"street_name":"Episcopal","city":"Sao Carlos","state":"SP","start_number":1,"end_number":50,"team_id":%d}}]}`, f.team.ID)
+			},
+			wantStatus:  http.StatusCreated,
+			wantCreated: 1,
+			wantStored:  2,
+		},
+		{
+			name:       "unknown format",
+			target:     "/streets/import",
+			body:       func(*fixture) string { return "a,b\n" },
+			wantStatus: http.StatusBadRequest,
+			wantStored: 1,
+		},
+		{
+			name:       "missing columns",
+			target:     "/streets/import?format=csv",
+			body:       func(*fixture) string { return "street_name,city\nFlores,Sao Carlos\n" },
+			wantStatus: http.StatusBadRequest,
+			wantStored: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec, resp := doRequest(t, f.routes(), http.MethodPost, tt.target, tt.body(f))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			if resp.Data != nil {
+				var report models.ImportReport
+				decodeData(t, resp, &report)
+				if report.Created != tt.wantCreated || len(report.Errors) != tt.wantErrors {
+					t.Errorf("unexpected report %+v", report)
+				}
+			}
+			if segments, _ := f.store.StreetSegments().List(false); len(segments) != tt.wantStored {
+				t.Errorf("expected %d stored segments, got %d", tt.wantStored, len(segments))
+			}
+		})
+	}
+}
+
+func TestImportStreetSegmentsMultipart(t *testing.T) {
+	f := newFixture(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, _ := form.CreateFormFile("file", "territorio.csv")
+	fmt.Fprintf(file, "logradouro;cidade;uf;inicio;fim;equipe_id\nEpiscopal;Sao Carlos;SP;1;99;%d\n", f.team.ID)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/streets/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data models.ImportReport `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Data.Created != 1 {
+		t.Errorf("unexpected report %+v", resp.Data)
+	}
+}
+
+func TestImportStreetSegmentsMultipartTooLarge(t *testing.T) {
+	f := newFixture(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, _ := form.CreateFormFile("file", "territorio.csv")
+	file.Write(bytes.Repeat([]byte("a"), maxImportSize+1))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/streets/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	f.routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d (%s)", rec.Code, rec.Body.String())
+	}
+}
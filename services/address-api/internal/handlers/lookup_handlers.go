@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/territory"
+	"encoding/json"
+	"log"
+	"net/http"
+	"shared/middleware"
+	"strconv"
+	"time"
+)
+
+// parseUserID le o parametro opcional user_id, o usuario do user-api para
+// quem a busca e feita
+func parseUserID(w http.ResponseWriter, r *http.Request) (*uint, bool) {
+	value := r.URL.Query().Get("user_id")
+	if value == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, false
+	}
+	userID := uint(id)
+	return &userID, true
+}
+
+// recordLookups poe as buscas na fila de revisao, gravadas de uma vez. Se a
+// gravacao falhar a resposta ao cidadao nao muda; o erro fica apenas no log.
+func (h *Handler) recordLookups(r *http.Request, lookups ...models.AddressLookup) {
+	if len(lookups) == 0 {
+		return
+	}
+	requestID := middleware.RequestIDFromContext(r.Context())
+	for i := range lookups {
+		lookups[i].RequestID = requestID
+	}
+	if err := h.lookups.CreateBatch(lookups); err != nil {
+		log.Printf("[%s] failed to record address lookups: %v", requestID, err)
+	}
+}
+
+// listLookups e a fila de revisao: as buscas pendentes, das mais recentes
+// para as mais antigas, ou as ja resolvidas com ?resolved=true
+func (h *Handler) listLookups(w http.ResponseWriter, r *http.Request) {
+	lookups, err := h.lookups.List(r.URL.Query().Get("resolved") == "true")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch address lookups")
+		return
+	}
+	if lookups == nil {
+		lookups = []models.AddressLookup{}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    lookups,
+	})
+}
+
+func (h *Handler) getLookup(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid address lookup ID")
+	if !ok {
+		return
+	}
+
+	lookup, err := h.lookups.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Address lookup not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    lookup,
+	})
+}
+
+// resolveLookup atribui o endereco buscado a uma equipe, criando um segmento
+// ou um alias, ou descarta a busca. Em todos os casos ela sai da fila.
+func (h *Handler) resolveLookup(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid address lookup ID")
+	if !ok {
+		return
+	}
+
+	var req models.ResolveLookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	lookup, err := h.lookups.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Address lookup not found")
+		return
+	}
+	if lookup.ResolvedAt != nil {
+		respondWithError(w, http.StatusConflict, "Address lookup is already resolved")
+		return
+	}
+
+	switch req.Action {
+	case territory.ActionSegment:
+		if _, err := h.teams.Get(req.TeamID); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+			return
+		}
+		segment, err := territory.BuildStreetSegment(territory.LookupSegment(*lookup, req))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, segmentErrorMessage(err))
+			return
+		}
+		if !h.checkOverlaps(w, segment) {
+			return
+		}
+		if err := h.segments.Create(&segment); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to create street segment")
+			return
+		}
+		lookup.Resolution = territory.ActionSegment
+		lookup.ResolvedTeamID = &segment.TeamID
+		lookup.ResolvedSegmentID = &segment.ID
+
+	case territory.ActionAlias:
+		segment, err := h.segments.Get(req.StreetSegmentID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid street segment ID")
+			return
+		}
+		alias, ok := h.createAlias(w, *segment, lookup.OriginalStreet, req.Kind)
+		if !ok {
+			return
+		}
+		lookup.Resolution = territory.ActionAlias
+		lookup.ResolvedTeamID = &segment.TeamID
+		lookup.ResolvedSegmentID = &segment.ID
+		lookup.ResolvedAliasID = &alias.ID
+
+	case territory.ActionDismiss:
+		lookup.Resolution = territory.ResolutionDismissed
+
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid action. Must be 'segment', 'alias' or 'dismiss'")
+		return
+	}
+
+	now := time.Now()
+	lookup.ResolvedAt = &now
+	if err := h.lookups.Update(lookup); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update address lookup")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    lookup,
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestLookupReviewQueue(t *testing.T) {
+	f := newFixture(t)
+
+	search := func(street string, number int) int {
+		t.Helper()
+		query := url.Values{"street": {street}, "number": {fmt.Sprint(number)}, "city": {"Sao Carlos"}, "state": {"SP"}, "user_id": {"42"}}
+		rec, _ := doRequest(t, f.routes(), http.MethodGet, "/streets/search?"+query.Encode(), nil)
+		return rec.Code
+	}
+	pending := func() []models.AddressLookup {
+		t.Helper()
+		rec, resp := doRequest(t, f.routes(), http.MethodGet, "/lookups", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d (%s)", rec.Code, rec.Body.String())
+		}
+		var lookups []models.AddressLookup
+		decodeData(t, resp, &lookups)
+		return lookups
+	}
+	resolve := func(id uint, req models.ResolveLookupRequest) (int, models.AddressLookup) {
+		t.Helper()
+		rec, resp := doRequest(t, f.routes(), http.MethodPost, fmt.Sprintf("/lookups/%d/resolve", id), req)
+		var lookup models.AddressLookup
+		if rec.Code == http.StatusOK {
+			decodeData(t, resp, &lookup)
+		}
+		return rec.Code, lookup
+	}
+
+	if code := search("das Flores", 10); code != http.StatusOK || len(pending()) != 0 {
+		t.Fatalf("confident matches must not be queued (status %d)", code)
+	}
+
+	// Numero fora da faixa, rua desconhecida e rua parecida com outra
+	if search("Rua das Flores", 150) != http.StatusNotFound || search("Travessa Nova", 5) != http.StatusNotFound ||
+		search("Flores do Campo Alto", 10) != http.StatusOK {
+		t.Fatal("unexpected search status")
+	}
+	lookups := pending()
+	if len(lookups) != 3 {
+		t.Fatalf("expected 3 queued lookups, got %+v", lookups)
+	}
+	lowConfidence, unknownStreet, outOfRange := lookups[0], lookups[1], lookups[2]
+
+	t.Run("lookups keep input, user and candidates", func(t *testing.T) {
+		if outOfRange.Reason != "not_found" || outOfRange.Street != "FLORES" || outOfRange.OriginalStreet != "Rua das Flores" ||
+			outOfRange.Number != 150 || outOfRange.City != "SAO CARLOS" || outOfRange.UserID == nil || *outOfRange.UserID != 42 ||
+			outOfRange.RequestID == "" || outOfRange.Input == "" {
+			t.Errorf("unexpected lookup %+v", outOfRange)
+		}
+		if len(outOfRange.Candidates) != 1 || outOfRange.Candidates[0].StreetSegmentID != f.segment.ID || outOfRange.Candidates[0].TeamName != "Equipe Azul" {
+			t.Errorf("expected the street segments as candidates, got %+v", outOfRange.Candidates)
+		}
+		if len(unknownStreet.Candidates) != 0 {
+			t.Errorf("expected no candidates for an unknown street, got %+v", unknownStreet.Candidates)
+		}
+		if lowConfidence.Reason != "low_confidence" || lowConfidence.Score >= 0.5 || len(lowConfidence.Candidates) != 1 {
+			t.Errorf("unexpected low confidence lookup %+v", lowConfidence)
+		}
+	})
+
+	t.Run("resolve by creating a segment", func(t *testing.T) {
+		end := 199
+		code, lookup := resolve(outOfRange.ID, models.ResolveLookupRequest{Action: "segment", TeamID: f.team.ID, EndNumber: &end})
+		if code != http.StatusOK || lookup.Resolution != "segment" || lookup.ResolvedAt == nil || lookup.ResolvedSegmentID == nil {
+			t.Fatalf("unexpected resolution %d %+v", code, lookup)
+		}
+		segment, err := f.store.StreetSegments().Get(*lookup.ResolvedSegmentID)
+		if err != nil || segment.StreetName != "FLORES" || segment.StreetType != "RUA" || segment.StartNumber != 150 ||
+			segment.EndNumber != 199 || segment.EvenOdd != "all" || segment.TeamID != f.team.ID {
+			t.Errorf("unexpected segment %+v (%v)", segment, err)
+		}
+		if search("Rua das Flores", 151) != http.StatusOK {
+			t.Error("expected the new segment to be found")
+		}
+		if code, _ := resolve(outOfRange.ID, models.ResolveLookupRequest{Action: "dismiss"}); code != http.StatusConflict {
+			t.Errorf("expected 409 for an already resolved lookup, got %d", code)
+		}
+	})
+
+	t.Run("resolve by creating an alias", func(t *testing.T) {
+		code, lookup := resolve(unknownStreet.ID, models.ResolveLookupRequest{Action: "alias", StreetSegmentID: f.segment.ID, Kind: "old"})
+		if code != http.StatusOK || lookup.Resolution != "alias" || lookup.ResolvedAliasID == nil || *lookup.ResolvedTeamID != f.team.ID {
+			t.Fatalf("unexpected resolution %d %+v", code, lookup)
+		}
+		if search("Travessa Nova", 5) != http.StatusOK {
+			t.Error("expected the alias to be found")
+		}
+	})
+
+	t.Run("invalid resolutions", func(t *testing.T) {
+		tests := []struct {
+			name string
+			id   uint
+			req  models.ResolveLookupRequest
+			want int
+		}{
+			{"unknown action", lowConfidence.ID, models.ResolveLookupRequest{Action: "assign"}, http.StatusBadRequest},
+			{"segment without team", lowConfidence.ID, models.ResolveLookupRequest{Action: "segment"}, http.StatusBadRequest},
+			{"alias without segment", lowConfidence.ID, models.ResolveLookupRequest{Action: "alias"}, http.StatusBadRequest},
+			{"unknown lookup", 999, models.ResolveLookupRequest{Action: "dismiss"}, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if code, _ := resolve(tt.id, tt.req); code != tt.want {
+					t.Errorf("expected status %d, got %d", tt.want, code)
+				}
+			})
+		}
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		if code, lookup := resolve(lowConfidence.ID, models.ResolveLookupRequest{Action: "dismiss"}); code != http.StatusOK || lookup.Resolution != "dismissed" {
+			t.Fatalf("unexpected resolution %d %+v", code, lookup)
+		}
+		if len(pending()) != 0 {
+			t.Errorf("expected the queue to be empty")
+		}
+		rec, resp := doRequest(t, f.routes(), http.MethodGet, "/lookups?resolved=true", nil)
+		var resolved []models.AddressLookup
+		decodeData(t, resp, &resolved)
+		if rec.Code != http.StatusOK || len(resolved) != 3 {
+			t.Errorf("expected 3 resolved lookups, got %+v", resolved)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/roster"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"shared/middleware"
+)
+
+func (h *Handler) listMicroAreas(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
+		return
+	}
+	if _, err := h.teams.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	areas, err := h.microAreas.List(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch micro-areas")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    areas,
+	})
+}
+
+func (h *Handler) createMicroArea(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
+		return
+	}
+
+	var req models.CreateMicroAreaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if _, err := h.teams.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	area, err := roster.BuildMicroArea(id, req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.checkMicroArea(w, area) {
+		return
+	}
+
+	if err := h.microAreas.Create(&area); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create micro-area")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    area,
+	})
+}
+
+// getMicroArea traz a micro-area com o ACS e os segmentos de rua que cobre
+func (h *Handler) getMicroArea(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid micro-area ID")
+	if !ok {
+		return
+	}
+
+	area, err := h.microAreas.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Micro-area not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    area,
+	})
+}
+
+// updateMicroArea troca codigo, nome e ACS; a equipe da micro-area nao muda
+func (h *Handler) updateMicroArea(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid micro-area ID")
+	if !ok {
+		return
+	}
+
+	var req models.CreateMicroAreaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	area, err := h.microAreas.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Micro-area not found")
+		return
+	}
+
+	updated, err := roster.BuildMicroArea(area.TeamID, req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	updated.ID = area.ID
+	updated.CreatedAt = area.CreatedAt
+	if !h.checkMicroArea(w, updated) {
+		return
+	}
+
+	if err := h.microAreas.Update(&updated); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update micro-area")
+		return
+	}
+
+	result, err := h.microAreas.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch micro-area data")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// deleteMicroArea apaga a micro-area; os segmentos continuam com a equipe,
+// apenas sem micro-area
+func (h *Handler) deleteMicroArea(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid micro-area ID")
+	if !ok {
+		return
+	}
+
+	if err := h.microAreas.Delete(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Micro-area not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Micro-area successfully deleted",
+	})
+}
+
+// assignMicroAreaSegments define os segmentos da micro-area. Todos precisam
+// ser da mesma equipe que ela; os que estavam em outra micro-area mudam.
+func (h *Handler) assignMicroAreaSegments(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid micro-area ID")
+	if !ok {
+		return
+	}
+
+	var req models.AssignSegmentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	area, err := h.microAreas.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Micro-area not found")
+		return
+	}
+
+	for _, segmentID := range req.StreetSegmentIDs {
+		segment, err := h.segments.Get(segmentID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Street segment %d not found", segmentID))
+			return
+		}
+		if segment.TeamID != area.TeamID {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Street segment %d belongs to another team", segmentID))
+			return
+		}
+	}
+
+	if err := h.microAreas.AssignSegments(id, req.StreetSegmentIDs); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to assign street segments")
+		return
+	}
+
+	result, err := h.microAreas.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch micro-area data")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// checkMicroArea responde 409 quando a equipe ja tem outra micro-area com o
+// mesmo codigo e 400 quando o ACS nao esta hoje na equipe
+func (h *Handler) checkMicroArea(w http.ResponseWriter, area models.MicroArea) bool {
+	areas, err := h.microAreas.List(area.TeamID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch micro-areas")
+		return false
+	}
+	for _, other := range areas {
+		if other.Code == area.Code && other.ID != area.ID {
+			respondWithError(w, http.StatusConflict, "Team already has a micro-area with this code")
+			return false
+		}
+	}
+
+	if area.AgentID == nil {
+		return true
+	}
+	memberships, err := h.professionals.Memberships(area.TeamID, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch team members")
+		return false
+	}
+	if err := roster.ValidAgent(*area.AgentID, memberships); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// microArea devolve a micro-area do segmento para a resposta da busca, com o
+// ACS tirado da lista da equipe. Como a lista, uma falha aqui nao derruba a
+// busca.
+func (h *Handler) microArea(r *http.Request, segment models.StreetSegment, members []models.TeamMember) *models.AssignedMicroArea {
+	if segment.MicroAreaID == nil {
+		return nil
+	}
+	area, err := h.microAreas.Get(*segment.MicroAreaID)
+	if err != nil {
+		log.Printf("[%s] failed to fetch micro-area %d: %v", middleware.RequestIDFromContext(r.Context()), *segment.MicroAreaID, err)
+		return nil
+	}
+	return roster.Assign(*area, members)
+}
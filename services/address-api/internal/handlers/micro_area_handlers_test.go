@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMicroAreas(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	agent := models.Professional{Name: "Bia Lima", Role: "acs"}
+	nurse := models.Professional{Name: "Elisa Prado", Role: "nurse"}
+	for _, professional := range []*models.Professional{&agent, &nurse} {
+		if err := f.store.Professionals().Create(professional); err != nil {
+			t.Fatalf("failed to seed professional: %v", err)
+		}
+		membership := models.TeamMembership{TeamID: f.team.ID, ProfessionalID: professional.ID, StartDate: "2024-01-01"}
+		if err := f.store.Professionals().AddMembership(&membership); err != nil {
+			t.Fatalf("failed to seed membership: %v", err)
+		}
+	}
+	other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+	if err := f.store.Teams().Create(&other); err != nil {
+		t.Fatalf("failed to seed team: %v", err)
+	}
+	foreign := models.StreetSegment{StreetName: "PALMEIRAS", City: "SAO CARLOS", State: "SP", StartNumber: 1, EndNumber: 99, EvenOdd: "all", TeamID: other.ID}
+	if err := f.store.StreetSegments().Create(&foreign); err != nil {
+		t.Fatalf("failed to seed street segment: %v", err)
+	}
+
+	areas := fmt.Sprintf("/teams/%d/micro-areas", f.team.ID)
+	tests := []struct {
+		name string
+		body models.CreateMicroAreaRequest
+		want int
+	}{
+		{"valid", models.CreateMicroAreaRequest{Code: "1", Name: "Vila Nova", AgentID: &agent.ID}, http.StatusCreated},
+		{"duplicate code", models.CreateMicroAreaRequest{Code: "01"}, http.StatusConflict},
+		{"invalid code", models.CreateMicroAreaRequest{Code: "A1"}, http.StatusBadRequest},
+		{"agent is not an ACS", models.CreateMicroAreaRequest{Code: "02", AgentID: &nurse.ID}, http.StatusBadRequest},
+		{"without agent", models.CreateMicroAreaRequest{Code: "02"}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec, resp := doRequest(t, handler, http.MethodPost, areas, tt.body); rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, resp.Error)
+			}
+		})
+	}
+
+	_, resp := doRequest(t, handler, http.MethodGet, areas, nil)
+	var list []models.MicroArea
+	decodeData(t, resp, &list)
+	if len(list) != 2 || list[0].Code != "01" || list[0].Agent == nil || list[0].Agent.Name != "Bia Lima" {
+		t.Fatalf("expected micro-areas 01 with its ACS and 02, got %+v", list)
+	}
+	area := list[0]
+
+	segments := fmt.Sprintf("/micro-areas/%d/segments", area.ID)
+	if rec, _ := doRequest(t, handler, http.MethodPut, segments, models.AssignSegmentsRequest{StreetSegmentIDs: []uint{foreign.ID}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a segment of another team, got %d", rec.Code)
+	}
+	rec, resp := doRequest(t, handler, http.MethodPut, segments, models.AssignSegmentsRequest{StreetSegmentIDs: []uint{f.segment.ID}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	decodeData(t, resp, &area)
+	if len(area.Segments) != 1 || area.Segments[0].ID != f.segment.ID {
+		t.Errorf("expected the segment in the micro-area, got %+v", area.Segments)
+	}
+
+	search := "/streets/search?street=Rua+das+Flores&number=10&city=Sao+Carlos&state=SP"
+	_, resp = doRequest(t, handler, http.MethodGet, search, nil)
+	var found models.AddressSearchResponse
+	decodeData(t, resp, &found)
+	if found.MicroArea == nil || found.MicroArea.Code != "01" || found.MicroArea.Agent == nil || found.MicroArea.Agent.ProfessionalID != agent.ID {
+		t.Errorf("expected micro-area 01 with its ACS in the search, got %+v", found.MicroArea)
+	}
+
+	// Mudar o segmento de equipe tira ele da micro-area
+	rec, resp = doRequest(t, handler, http.MethodPut, fmt.Sprintf("/streets/%d", f.segment.ID), models.CreateStreetSegmentRequest{
+		StreetName: "das Flores", StreetType: "RUA", Neighborhood: "CENTRO", City: "SAO CARLOS", State: "SP",
+		StartNumber: 1, EndNumber: 99, EvenOdd: "all", TeamID: other.ID,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	var moved models.StreetSegment
+	decodeData(t, resp, &moved)
+	if moved.MicroAreaID != nil {
+		t.Errorf("expected segment moved to another team to leave the micro-area, got %d", *moved.MicroAreaID)
+	}
+
+	path := fmt.Sprintf("/micro-areas/%d", area.ID)
+	if rec, _ := doRequest(t, handler, http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodGet, path, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/roster"
+	"address-api/internal/schedule"
+	"encoding/json"
+	"log"
+	"net/http"
+	"shared/middleware"
+	"strings"
+)
+
+func (h *Handler) createProfessional(w http.ResponseWriter, r *http.Request) {
+	professional, ok := h.decodeProfessional(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.professionals.Create(&professional); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create professional")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    professional,
+	})
+}
+
+func (h *Handler) listProfessionals(w http.ResponseWriter, r *http.Request) {
+	role := strings.ToLower(r.URL.Query().Get("role"))
+	if role != "" && !roster.ValidRole(role) {
+		respondWithError(w, http.StatusBadRequest, roster.ErrInvalidRole.Error())
+		return
+	}
+
+	professionals, err := h.professionals.List(role)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch professionals")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    professionals,
+	})
+}
+
+// getProfessional traz o profissional com todas as equipes por onde passou
+func (h *Handler) getProfessional(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid professional ID")
+	if !ok {
+		return
+	}
+
+	professional, err := h.professionals.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Professional not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    professional,
+	})
+}
+
+func (h *Handler) updateProfessional(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid professional ID")
+	if !ok {
+		return
+	}
+
+	updated, ok := h.decodeProfessional(w, r)
+	if !ok {
+		return
+	}
+
+	professional, err := h.professionals.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Professional not found")
+		return
+	}
+
+	updated.ID = professional.ID
+	updated.CreatedAt = professional.CreatedAt
+	if err := h.professionals.Update(&updated); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update professional")
+		return
+	}
+	updated.Memberships = professional.Memberships
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    updated,
+	})
+}
+
+// deleteProfessional remove o profissional e encerra hoje os seus vinculos
+// atuais, que continuam no historico das equipes
+func (h *Handler) deleteProfessional(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid professional ID")
+	if !ok {
+		return
+	}
+
+	if err := h.professionals.Delete(id, schedule.DateOf(h.now())); err != nil {
+		respondWithError(w, http.StatusNotFound, "Professional not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Professional successfully deleted",
+	})
+}
+
+// listTeamMembers lista os profissionais atuais da equipe, ou todos os
+// vinculos que ela ja teve com ?history=true
+func (h *Handler) listTeamMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
+		return
+	}
+	if _, err := h.teams.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	memberships, err := h.professionals.Memberships(id, r.URL.Query().Get("history") == "true")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch team members")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    memberships,
+	})
+}
+
+func (h *Handler) addTeamMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
+		return
+	}
+
+	var req models.AddTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.StartDate == "" {
+		req.StartDate = schedule.DateOf(h.now())
+	}
+	if _, err := schedule.ParseDate(req.StartDate); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := h.teams.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Team not found")
+		return
+	}
+	professional, err := h.professionals.Get(req.ProfessionalID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid professional ID")
+		return
+	}
+	for _, membership := range professional.Memberships {
+		if membership.TeamID == id && membership.EndDate == nil {
+			respondWithError(w, http.StatusConflict, "Professional is already a member of this team")
+			return
+		}
+	}
+
+	membership := models.TeamMembership{
+		TeamID:         id,
+		ProfessionalID: professional.ID,
+		StartDate:      req.StartDate,
+	}
+	if err := h.professionals.AddMembership(&membership); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to add team member")
+		return
+	}
+	professional.Memberships = nil
+	membership.Professional = professional
+
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    membership,
+	})
+}
+
+// endMembership tira o profissional da equipe a partir de end_date (padrao
+// hoje), mantendo o vinculo no historico
+func (h *Handler) endMembership(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid membership ID")
+	if !ok {
+		return
+	}
+
+	var req models.EndMembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.EndDate == "" {
+		req.EndDate = schedule.DateOf(h.now())
+	}
+	if _, err := schedule.ParseDate(req.EndDate); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	membership, err := h.professionals.GetMembership(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Membership not found")
+		return
+	}
+	if membership.EndDate != nil {
+		respondWithError(w, http.StatusConflict, "Membership has already ended")
+		return
+	}
+	if err := roster.ValidPeriod(membership.StartDate, req.EndDate); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	membership.EndDate = &req.EndDate
+	if err := h.professionals.UpdateMembership(membership); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to end membership")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    membership,
+	})
+}
+
+// roster devolve os profissionais atuais da equipe para a resposta da busca.
+// Uma falha aqui nao derruba a busca, a equipe so vem sem a lista.
+func (h *Handler) roster(r *http.Request, teamID uint) []models.TeamMember {
+	memberships, err := h.professionals.Memberships(teamID, false)
+	if err != nil {
+		log.Printf("[%s] failed to fetch roster of team %d: %v", middleware.RequestIDFromContext(r.Context()), teamID, err)
+		return nil
+	}
+	return roster.Members(memberships)
+}
+
+func (h *Handler) decodeProfessional(w http.ResponseWriter, r *http.Request) (models.Professional, bool) {
+	var req models.CreateProfessionalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return models.Professional{}, false
+	}
+	defer r.Body.Close()
+
+	professional, err := roster.BuildProfessional(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return models.Professional{}, false
+	}
+	return professional, true
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestProfessionalCRUD(t *testing.T) {
+	f := newFixture(t)
+	handler := f.routes()
+
+	rec, resp := doRequest(t, handler, http.MethodPost, "/professionals", models.CreateProfessionalRequest{
+		Name: "Ana Souza", Role: "doctor", CNS: "170364582900008", Registry: "crm-sp 123456", Shift: "full_time",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, resp.Error)
+	}
+	var doctor models.Professional
+	decodeData(t, resp, &doctor)
+	if doctor.ID == 0 || doctor.Registry != "CRM-SP 123456" {
+		t.Errorf("unexpected professional: %+v", doctor)
+	}
+
+	if rec, _ := doRequest(t, handler, http.MethodPost, "/professionals", models.CreateProfessionalRequest{Name: "Bia", Role: "acs", CNS: "123"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid CNS, got %d", rec.Code)
+	}
+	doRequest(t, handler, http.MethodPost, "/professionals", models.CreateProfessionalRequest{Name: "Bia Lima", Role: "acs"})
+
+	_, resp = doRequest(t, handler, http.MethodGet, "/professionals?role=acs", nil)
+	var list []models.Professional
+	decodeData(t, resp, &list)
+	if len(list) != 1 || list[0].Name != "Bia Lima" {
+		t.Errorf("expected only the ACS, got %+v", list)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodGet, "/professionals?role=surgeon", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown role, got %d", rec.Code)
+	}
+
+	path := fmt.Sprintf("/professionals/%d", doctor.ID)
+	rec, resp = doRequest(t, handler, http.MethodPut, path, models.CreateProfessionalRequest{
+		Name: "Ana Souza", Role: "doctor", ContactChannel: "email", Contact: "Ana@UBS.gov.br",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	decodeData(t, resp, &doctor)
+	if doctor.Contact != "ana@ubs.gov.br" || doctor.Shift != "" {
+		t.Errorf("expected contact updated and shift cleared, got %+v", doctor)
+	}
+
+	if rec, _ := doRequest(t, handler, http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec, _ := doRequest(t, handler, http.MethodGet, path, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestTeamMembershipHistory(t *testing.T) {
+	f := newFixture(t)
+	f.handler.now = func() time.Time { return time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC) }
+	handler := f.routes()
+
+	create := func(name, role string) models.Professional {
+		t.Helper()
+		_, resp := doRequest(t, handler, http.MethodPost, "/professionals", models.CreateProfessionalRequest{Name: name, Role: role})
+		var professional models.Professional
+		decodeData(t, resp, &professional)
+		return professional
+	}
+	doctor := create("Davi Rocha", "doctor")
+	nurse := create("Elisa Prado", "nurse")
+	agent := create("Bia Lima", "acs")
+
+	members := fmt.Sprintf("/teams/%d/members", f.team.ID)
+	add := func(professional models.Professional, start models.Date) (int, models.TeamMembership) {
+		t.Helper()
+		rec, resp := doRequest(t, handler, http.MethodPost, members, models.AddTeamMemberRequest{ProfessionalID: professional.ID, StartDate: start})
+		var membership models.TeamMembership
+		if rec.Code == http.StatusCreated {
+			decodeData(t, resp, &membership)
+		}
+		return rec.Code, membership
+	}
+
+	_, former := add(doctor, "2023-01-02")
+	if code, _ := add(doctor, ""); code != http.StatusConflict {
+		t.Errorf("expected 409 adding a current member again, got %d", code)
+	}
+	add(nurse, "")
+	_, agentMembership := add(agent, "2024-02-01")
+	if agentMembership.StartDate != "2024-02-01" {
+		t.Errorf("expected the informed start date, got %q", agentMembership.StartDate)
+	}
+	if code, _ := add(models.Professional{ID: 999}, ""); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown professional, got %d", code)
+	}
+
+	end := func(id uint, body models.EndMembershipRequest) int {
+		rec, _ := doRequest(t, handler, http.MethodPost, fmt.Sprintf("/memberships/%d/end", id), body)
+		return rec.Code
+	}
+	if code := end(former.ID, models.EndMembershipRequest{EndDate: "2022-12-31"}); code != http.StatusBadRequest {
+		t.Errorf("expected 400 ending before the start, got %d", code)
+	}
+	if code := end(former.ID, models.EndMembershipRequest{}); code != http.StatusOK {
+		t.Fatalf("expected 200 ending the membership, got %d", code)
+	}
+	if code := end(former.ID, models.EndMembershipRequest{}); code != http.StatusConflict {
+		t.Errorf("expected 409 ending it twice, got %d", code)
+	}
+
+	_, resp := doRequest(t, handler, http.MethodGet, members, nil)
+	var current []models.TeamMembership
+	decodeData(t, resp, &current)
+	if len(current) != 2 {
+		t.Errorf("expected the nurse and the ACS as current members, got %+v", current)
+	}
+
+	_, resp = doRequest(t, handler, http.MethodGet, members+"?history=true", nil)
+	var history []models.TeamMembership
+	decodeData(t, resp, &history)
+	if len(history) != 3 || history[0].ProfessionalID != doctor.ID || history[0].EndDate == nil || *history[0].EndDate != "2024-06-10" {
+		t.Errorf("expected the ended doctor membership first in the history, got %+v", history)
+	}
+
+	_, resp = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/professionals/%d", doctor.ID), nil)
+	var withHistory models.Professional
+	decodeData(t, resp, &withHistory)
+	if len(withHistory.Memberships) != 1 || withHistory.Memberships[0].Team == nil || withHistory.Memberships[0].Team.Name != "Equipe Azul" {
+		t.Errorf("expected the team in the professional history, got %+v", withHistory.Memberships)
+	}
+
+	// A busca traz a equipe atual, o enfermeiro antes do ACS
+	_, resp = doRequest(t, handler, http.MethodGet, "/streets/search?street=Rua+das+Flores&number=10&city=Sao+Carlos&state=SP", nil)
+	var search models.AddressSearchResponse
+	decodeData(t, resp, &search)
+	if len(search.Roster) != 2 || search.Roster[0].Name != "Elisa Prado" || search.Roster[1].Role != "acs" {
+		t.Errorf("expected the current roster in the search, got %+v", search.Roster)
+	}
+
+	// Remover o profissional encerra o vinculo atual
+	doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/professionals/%d", agent.ID), nil)
+	_, resp = doRequest(t, handler, http.MethodGet, members, nil)
+	current = nil
+	decodeData(t, resp, &current)
+	if len(current) != 1 || current[0].ProfessionalID != nurse.ID {
+		t.Errorf("expected only the nurse after removing the ACS, got %+v", current)
+	}
+}
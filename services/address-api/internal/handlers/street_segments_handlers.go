@@ -1,41 +1,18 @@
 package handlers
 
 import (
-	"address-api/internal/database"
 	"address-api/internal/models"
-	"address-api/internal/utils"
+	"address-api/internal/repository"
+	"address-api/internal/schedule"
+	"address-api/internal/territory"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
+	"shared/normalize"
 	"strings"
 )
 
-func HandleStreetSegments(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/streets/search" {
-		findTeamByAddress(w, r)
-		return
-	}
-
-	switch r.Method {
-	case http.MethodPost:
-		createStreetSegment(w, r)
-	case http.MethodGet:
-		path := strings.TrimPrefix(r.URL.Path, "/streets/")
-		if path == "" {
-			listStreetSegments(w, r)
-		} else {
-			getStreetSegment(w, r)
-		}
-	case http.MethodPut:
-		updateStreetSegment(w, r)
-	case http.MethodDelete:
-		deleteStreetSegment(w, r)
-	default:
-		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
-
-func createStreetSegment(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) createStreetSegment(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateStreetSegmentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -44,41 +21,22 @@ func createStreetSegment(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Verifica se o time existe
-	var team models.Team
-	if err := database.GetDB().First(&team, req.TeamID).Error; err != nil {
+	if _, err := h.teams.Get(req.TeamID); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
 		return
 	}
 
-	// Normalizando componentes
-	normalizedStreetName := utils.NormalizeStreetName(req.StreetName)
-	normalizedStreetType := utils.NormalizeStreetType(req.StreetType)
-
-	segment := models.StreetSegment{
-		StreetName:         normalizedStreetName,
-		OriginalStreetName: req.StreetName,
-		StreetType:         normalizedStreetType,
-		Neighborhood:       strings.ToUpper(req.Neighborhood),
-		City:               strings.ToUpper(req.City),
-		State:              strings.ToUpper(req.State),
-		StartNumber:        req.StartNumber,
-		EndNumber:          req.EndNumber,
-		CEPPrefix:          utils.NormalizeCEP(req.CEPPrefix),
-		EvenOdd:            strings.ToLower(req.EvenOdd),
-		TeamID:             req.TeamID,
-	}
-
-	if segment.StartNumber > segment.EndNumber {
-		respondWithError(w, http.StatusBadRequest, "Start number cannot be greater than end number")
+	segment, err := territory.BuildStreetSegment(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, segmentErrorMessage(err))
 		return
 	}
 
-	if !isValidEvenOdd(segment.EvenOdd) {
-		respondWithError(w, http.StatusBadRequest, "Invalid even/odd value. Must be 'even', 'odd', or 'all'")
+	if !h.checkOverlaps(w, segment) {
 		return
 	}
 
-	if err := database.GetDB().Create(&segment).Error; err != nil {
+	if err := h.segments.Create(&segment); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create street segment")
 		return
 	}
@@ -89,9 +47,9 @@ func createStreetSegment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func listStreetSegments(w http.ResponseWriter, _ *http.Request) {
-	var segments []models.StreetSegment
-	if err := database.GetDB().Preload("Team.UBS").Find(&segments).Error; err != nil {
+func (h *Handler) listStreetSegments(w http.ResponseWriter, r *http.Request) {
+	segments, err := h.segments.List(wantsDeleted(r))
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segments")
 		return
 	}
@@ -102,10 +60,9 @@ func listStreetSegments(w http.ResponseWriter, _ *http.Request) {
 	})
 }
 
-func updateStreetSegment(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/streets/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid street segment ID")
+func (h *Handler) updateStreetSegment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
 		return
 	}
 
@@ -116,45 +73,55 @@ func updateStreetSegment(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	var segment models.StreetSegment
-	if err := database.GetDB().First(&segment, id).Error; err != nil {
+	segment, err := h.segments.Get(id)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Street segment not found")
 		return
 	}
 
 	// Verifica se o novo time existe
-	if err := database.GetDB().First(&models.Team{}, req.TeamID).Error; err != nil {
+	team, err := h.teams.Get(req.TeamID)
+	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
 		return
 	}
 
-	normalizedStreetName := utils.NormalizeStreetName(req.StreetName)
-	normalizedStreetType := utils.NormalizeStreetType(req.StreetType)
-
-	// Atualiza os campos
-	segment.StreetName = normalizedStreetName
-	segment.OriginalStreetName = req.StreetName
-	segment.StreetType = normalizedStreetType
-	segment.Neighborhood = strings.ToUpper(req.Neighborhood)
-	segment.City = strings.ToUpper(req.City)
-	segment.State = strings.ToUpper(req.State)
-	segment.StartNumber = req.StartNumber
-	segment.EndNumber = req.EndNumber
-	segment.CEPPrefix = utils.NormalizeCEP(req.CEPPrefix)
-	segment.EvenOdd = strings.ToLower(req.EvenOdd)
-	segment.TeamID = req.TeamID
-
-	if segment.StartNumber > segment.EndNumber {
-		respondWithError(w, http.StatusBadRequest, "Start number cannot be greater than end number")
+	updated, err := territory.BuildStreetSegment(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, segmentErrorMessage(err))
 		return
 	}
 
-	if !isValidEvenOdd(segment.EvenOdd) {
-		respondWithError(w, http.StatusBadRequest, "Invalid even/odd value. Must be 'even', 'odd', or 'all'")
+	// Mantem ID e datas do segmento original. A micro-area so vale dentro da
+	// equipe, entao o segmento que muda de equipe sai dela.
+	updated.ID = segment.ID
+	updated.CreatedAt = segment.CreatedAt
+	if updated.TeamID == segment.TeamID {
+		updated.MicroAreaID = segment.MicroAreaID
+	}
+	updated.Team = *team
+
+	// Trocar a equipe por aqui e uma reatribuicao imediata, que fica no
+	// historico do segmento como as feitas por /streets/{id}/reassignments
+	now := h.now()
+	var reassignment *models.SegmentAssignment
+	if updated.TeamID != segment.TeamID {
+		today := schedule.DateOf(now)
+		reassignment = &models.SegmentAssignment{StreetSegmentID: segment.ID, TeamID: updated.TeamID, ValidFrom: today}
+		if !h.checkReassignment(w, updated, *reassignment, today) {
+			return
+		}
+	} else if !h.checkOverlaps(w, updated) {
 		return
 	}
+	segment = &updated
 
-	if err := database.GetDB().Save(&segment).Error; err != nil {
+	if reassignment != nil {
+		err = h.segments.UpdateAndReassign(segment, reassignment, now)
+	} else {
+		err = h.segments.Update(segment)
+	}
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update street segment")
 		return
 	}
@@ -165,20 +132,18 @@ func updateStreetSegment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func deleteStreetSegment(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/streets/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid street segment ID")
+func (h *Handler) deleteStreetSegment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
 		return
 	}
 
-	var segment models.StreetSegment
-	if err := database.GetDB().First(&segment, id).Error; err != nil {
+	if _, err := h.segments.Get(id); err != nil {
 		respondWithError(w, http.StatusNotFound, "Street segment not found")
 		return
 	}
 
-	if err := database.GetDB().Delete(&segment).Error; err != nil {
+	if err := h.segments.Delete(id); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete street segment")
 		return
 	}
@@ -189,16 +154,32 @@ func deleteStreetSegment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getStreetSegment(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/streets/"))
+func (h *Handler) restoreStreetSegment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
+		return
+	}
+
+	segment, err := h.segments.GetDeleted(id)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid street segment ID")
+		respondWithError(w, http.StatusNotFound, "Deleted street segment not found")
 		return
 	}
 
-	var segment models.StreetSegment
-	if err := database.GetDB().Preload("Team.UBS").First(&segment, id).Error; err != nil {
-		respondWithError(w, http.StatusNotFound, "Street segment not found")
+	// Um segmento so pode voltar se o time dele estiver ativo
+	if _, err := h.teams.Get(segment.TeamID); err != nil {
+		respondWithError(w, http.StatusConflict, "Street segment team is deleted, restore the team first")
+		return
+	}
+
+	if err := h.segments.Restore(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore street segment")
+		return
+	}
+
+	segment, err = h.segments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segment data")
 		return
 	}
 
@@ -208,118 +189,197 @@ func getStreetSegment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func isValidEvenOdd(value string) bool {
-	validValues := map[string]bool{
-		"even": true,
-		"odd":  true,
-		"all":  true,
+func (h *Handler) getStreetSegment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid street segment ID")
+	if !ok {
+		return
 	}
-	return validValues[value]
-}
 
-func findTeamByAddress(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	segment, err := h.segments.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Street segment not found")
 		return
 	}
 
-	// Ve os parametros de busca
-	streetName := r.URL.Query().Get("street")
-	numberStr := r.URL.Query().Get("number")
-	city := r.URL.Query().Get("city")
-	state := r.URL.Query().Get("state")
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    segment,
+	})
+}
 
-	if streetName == "" || numberStr == "" || city == "" || state == "" {
-		respondWithError(w, http.StatusBadRequest, "Missing required parameters: street, number, city, state")
-		return
+// checkOverlaps responde 409 com as faixas em conflito quando o segmento
+// invade o territorio de outra equipe na mesma rua
+func (h *Handler) checkOverlaps(w http.ResponseWriter, segment models.StreetSegment) bool {
+	others, err := h.segments.ListByStreet(segment.StreetName, segment.City, segment.State)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check street segment overlaps")
+		return false
 	}
 
-	// Conveter o numero da casa para int
-	number, err := strconv.Atoi(numberStr)
+	overlaps := territory.Overlaps(segment, others)
+	if len(overlaps) == 0 {
+		return true
+	}
+	respondWithJSON(w, http.StatusConflict, models.APIResponse{
+		Success: false,
+		Data:    overlaps,
+		Error:   "Street segment overlaps segments of other teams",
+	})
+	return false
+}
+
+// analyzeStreetSegments lista sobreposicoes e buracos entre os segmentos de
+// cada rua, opcionalmente filtrando por cidade e estado
+func (h *Handler) analyzeStreetSegments(w http.ResponseWriter, r *http.Request) {
+	city := normalize.Text(r.URL.Query().Get("city"))
+	state := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("state")))
+
+	segments, err := h.segments.List(false)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid house number")
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segments")
 		return
 	}
 
-	normalizedStreet := utils.NormalizeStreetName(streetName)
-	normalizedCity := strings.ToUpper(city)
-	normalizedState := strings.ToUpper(state)
-
-	var segments []models.StreetSegment
+	filtered := segments[:0]
+	for _, segment := range segments {
+		if (city == "" || segment.City == city) && (state == "" || segment.State == state) {
+			filtered = append(filtered, segment)
+		}
+	}
 
-	// Usando threshold de similaridade de 0.3 (da para ajustar)
-	// Primeiro, encontra todos os possiveis matches na mesma cidade e estado
-	db := database.GetDB().
-		Preload("Team").
-		Preload("Team.UBS").
-		Where("city = ? AND state = ?", normalizedCity, normalizedState).
-		Where("similarity(street_name, ?) > 0.3", normalizedStreet)
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    territory.Analyze(filtered),
+	})
+}
 
-	db = db.Where(
-		"(start_number <= ? AND end_number >= ?) AND "+
-			"(even_odd = 'all' OR "+
-			"(even_odd = 'even' AND ? % 2 = 0) OR "+
-			"(even_odd = 'odd' AND ? % 2 = 1))",
-		number, number, number, number)
+// segmentErrorMessage traduz os erros de validacao do segmento para a resposta da API
+func segmentErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, territory.ErrInvalidRange):
+		return "Start number cannot be greater than end number"
+	case errors.Is(err, territory.ErrInvalidEvenOdd):
+		return "Invalid even/odd value. Must be 'even', 'odd', or 'all'"
+	default:
+		return "Invalid street segment: " + err.Error()
+	}
+}
 
-	if err := db.Find(&segments).Error; err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to search for address")
+// findTeamByAddress devolve os segmentos que atendem o endereco, ranqueados.
+// Com status "ambiguous" o melhor candidato nao se destaca o suficiente e
+// quem chama deve pedir mais detalhes ao cidadao. Com ?cep= a rua, o bairro
+// e a cidade que faltarem vem da base local de CEPs. Com ?q= o endereco vem
+// em texto livre e os parametros informados tem precedencia sobre ele. Com
+// ?lat= e ?lng= o ponto e comparado com os territorios das equipes, e basta
+// ele quando nao ha rua e numero. Buscas por endereco sem equipe, ambiguas ou
+// com baixa confianca vao para a fila de revisao. Com ?date= a resposta traz a
+// equipe que cobria o endereco naquele dia, sem lista de profissionais nem
+// micro-area, que so existem hoje, e a busca nao vai para a revisao.
+func (h *Handler) findTeamByAddress(w http.ResponseWriter, r *http.Request) {
+	// Ve os parametros de busca
+	params := r.URL.Query()
+	search := &addressSearch{
+		query: territory.SearchQuery{
+			Street:       params.Get("street"),
+			City:         params.Get("city"),
+			State:        params.Get("state"),
+			CEP:          params.Get("cep"),
+			Neighborhood: params.Get("neighborhood"),
+		},
+		number: params.Get("number"),
+		input:  r.URL.RawQuery,
+	}
+
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	search.userID = userID
+	point, ok := parsePoint(w, r)
+	if !ok {
+		return
+	}
+	search.query.Point = point
+	asOf, ok := parseAsOf(w, r)
+	if !ok {
 		return
 	}
 
-	if len(segments) == 0 {
-		respondWithError(w, http.StatusNotFound, "No team found for this address")
+	if failure := search.parse(params.Get("q"), point != nil); failure != nil {
+		respondWithError(w, failure.status, failure.message)
 		return
 	}
+	byAddress := search.byAddress()
+	if !byAddress && point == nil {
+		respondWithError(w, http.StatusBadRequest, "Missing required parameters: number and either cep or street, city, state, or lat and lng")
+		return
+	}
+	// So buscas com rua e sem data vao para a revisao, que resolve
+	// cadastrando a rua
+	review := byAddress && asOf == ""
 
-	// Encontrar o melhor match baseado no nome da rua e similaridade
-	var bestMatch models.StreetSegment
-	bestSimilarity := -1.0
+	var results []repository.SearchResult
+	if byAddress {
+		failures, err := h.prepareAddresses([]*addressSearch{search})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to look up CEP")
+			return
+		}
+		if failure := failures[0]; failure != nil {
+			if failure.lookup != nil && review {
+				h.recordLookups(r, *failure.lookup)
+			}
+			respondWithError(w, failure.status, failure.message)
+			return
+		}
 
-	for _, segment := range segments {
-		similarity := calculateSimilarity(segment.StreetName, normalizedStreet)
-		if similarity > bestSimilarity {
-			bestSimilarity = similarity
-			bestMatch = segment
+		found, err := h.searchAddresses([]*addressSearch{search})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to search for address")
+			return
+		}
+		results = found[0]
+
+		if asOf != "" {
+			results, err = h.resolveAsOf(results, asOf)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to fetch street segment assignments")
+				return
+			}
 		}
 	}
 
-	response := models.AddressSearchResponse{
-		StreetSegment: bestMatch,
-		Team:          bestMatch.Team,
-		UBS:           bestMatch.Team.UBS,
+	var territories []models.Territory
+	if point != nil {
+		var err error
+		territories, err = h.territories.Locate(*point)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to locate territories")
+			return
+		}
 	}
 
-	respondWithJSON(w, http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    response,
-	})
-}
-
-// Calcular similiridade
-func calculateSimilarity(s1, s2 string) float64 {
-	// Contar quantos caracteres batem
-	matches := 0
-	s1Runes := []rune(s1)
-	s2Runes := []rune(s2)
-
-	for i := 0; i < len(s1Runes) && i < len(s2Runes); i++ {
-		if s1Runes[i] == s2Runes[i] {
-			matches++
+	if len(results) == 0 && len(territories) == 0 {
+		if review {
+			h.recordLookups(r, h.notFoundLookups([]*addressSearch{search})...)
 		}
+		respondWithError(w, http.StatusNotFound, "No team found for this address")
+		return
 	}
 
-	// Proporcao de similaridade
-	maxLen := float64(max(len(s1Runes), len(s2Runes)))
-	if maxLen == 0 {
-		return 0
+	response := territory.Rank(search.query, results, territories)
+	if lookup, ok := reviewLookup(search, response); ok && review {
+		h.recordLookups(r, lookup)
 	}
-	return float64(matches) / maxLen
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
+	response.Address = search.address
+	response.Parsed = search.parsed
+	if asOf != "" {
+		response.AsOf = asOf
+	} else {
+		h.enrich(r, newTeamDetails(), &response)
 	}
-	return b
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    response,
+	})
 }
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func validSegmentRequest(teamID uint) models.CreateStreetSegmentRequest {
+	return models.CreateStreetSegmentRequest{
+		StreetName:   "São João",
+		StreetType:   "av.",
+		Neighborhood: "Centro",
+		City:         "São Carlos",
+		State:        "sp",
+		StartNumber:  100,
+		EndNumber:    200,
+		CEPPrefix:    "13560-",
+		EvenOdd:      "EVEN",
+		TeamID:       teamID,
+	}
+}
+
+func TestHandleStreetSegments(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       func(f *fixture) string
+		body       func(f *fixture) interface{}
+		wantStatus int
+		check      func(t *testing.T, f *fixture, resp testResponse)
+	}{
+		{
+			name:       "create normalizes the segment",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/streets/" },
+			body:       func(f *fixture) interface{} { return validSegmentRequest(f.team.ID) },
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, _ *fixture, resp testResponse) {
+				var segment models.StreetSegment
+				decodeData(t, resp, &segment)
+				if segment.StreetName != "SAO JOAO" || segment.StreetType != "AVENIDA" ||
+					segment.EvenOdd != "even" || segment.CEPPrefix != "13560" {
+					t.Errorf("unexpected segment: %+v", segment)
+				}
+			},
+		},
+		{
+			name:   "create rejects unknown team",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/streets/" },
+			body: func(*fixture) interface{} {
+				return validSegmentRequest(999)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create rejects inverted range",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/streets/" },
+			body: func(f *fixture) interface{} {
+				req := validSegmentRequest(f.team.ID)
+				req.StartNumber, req.EndNumber = 300, 200
+				return req
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create rejects invalid parity",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/streets/" },
+			body: func(f *fixture) interface{} {
+				req := validSegmentRequest(f.team.ID)
+				req.EvenOdd = "both"
+				return req
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create rejects overlap with another team",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/streets/" },
+			body: func(f *fixture) interface{} {
+				other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+				f.store.Teams().Create(&other)
+				req := validSegmentRequest(other.ID)
+				req.StreetName, req.StreetType, req.City = "das Flores", "Rua", "Sao Carlos"
+				req.StartNumber, req.EndNumber = 50, 150
+				return req
+			},
+			wantStatus: http.StatusConflict,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var overlaps []models.SegmentOverlap
+				decodeData(t, resp, &overlaps)
+				if len(overlaps) != 1 || overlaps[0].ConflictingSegmentID != f.segment.ID ||
+					overlaps[0].StartNumber != 50 || overlaps[0].EndNumber != 98 {
+					t.Errorf("unexpected overlaps: %+v", overlaps)
+				}
+			},
+		},
+		{
+			name:   "create accepts adjacent range of another team",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/streets/" },
+			body: func(f *fixture) interface{} {
+				other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+				f.store.Teams().Create(&other)
+				req := validSegmentRequest(other.ID)
+				req.StreetName, req.StreetType, req.City = "das Flores", "Rua", "Sao Carlos"
+				req.StartNumber, req.EndNumber = 100, 150
+				return req
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "list returns segments with team",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/streets/" },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var segments []models.StreetSegment
+				decodeData(t, resp, &segments)
+				if len(segments) != 1 || segments[0].Team.ID != f.team.ID {
+					t.Errorf("unexpected segments: %+v", segments)
+				}
+			},
+		},
+		{
+			name:       "get returns segment",
+			method:     http.MethodGet,
+			path:       func(f *fixture) string { return fmt.Sprintf("/streets/%d", f.segment.ID) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "get returns 404 for unknown segment",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/streets/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "update replaces fields",
+			method:     http.MethodPut,
+			path:       func(f *fixture) string { return fmt.Sprintf("/streets/%d", f.segment.ID) },
+			body:       func(f *fixture) interface{} { return validSegmentRequest(f.team.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, _ testResponse) {
+				segment, err := f.store.StreetSegments().Get(f.segment.ID)
+				if err != nil || segment.StreetName != "SAO JOAO" || segment.StartNumber != 100 {
+					t.Errorf("segment not updated: %+v (%v)", segment, err)
+				}
+			},
+		},
+		{
+			name:       "update rejects invalid ID",
+			method:     http.MethodPut,
+			path:       func(*fixture) string { return "/streets/abc" },
+			body:       func(f *fixture) interface{} { return validSegmentRequest(f.team.ID) },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "delete removes segment",
+			method:     http.MethodDelete,
+			path:       func(f *fixture) string { return fmt.Sprintf("/streets/%d", f.segment.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, _ testResponse) {
+				if _, err := f.store.StreetSegments().GetDeleted(f.segment.ID); err != nil {
+					t.Errorf("expected segment to be soft deleted: %v", err)
+				}
+			},
+		},
+		{
+			name:       "delete returns 404 for unknown segment",
+			method:     http.MethodDelete,
+			path:       func(*fixture) string { return "/streets/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "search finds team by address",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/streets/search?street=das+flores&number=10&city=sao+carlos&state=sp" },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var result models.AddressSearchResponse
+				decodeData(t, resp, &result)
+				if result.Team.ID != f.team.ID || result.UBS.ID != f.ubs.ID {
+					t.Errorf("unexpected search result: %+v", result)
+				}
+				if result.Status != "matched" || result.Confidence != "high" || len(result.Candidates) != 1 {
+					t.Errorf("unexpected ranking: %+v", result)
+				}
+			},
+		},
+		{
+			name:   "search flags close candidates of different teams",
+			method: http.MethodGet,
+			path: func(f *fixture) string {
+				other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+				f.store.Teams().Create(&other)
+				f.store.StreetSegments().Create(&models.StreetSegment{
+					StreetName: "FLORES", Neighborhood: "VILA NERY", City: "SAO CARLOS", State: "SP",
+					StartNumber: 1, EndNumber: 99, CEPPrefix: "13573", EvenOdd: "all", TeamID: other.ID,
+				})
+				return "/streets/search?street=das+flores&number=10&city=sao+carlos&state=sp"
+			},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *fixture, resp testResponse) {
+				var result models.AddressSearchResponse
+				decodeData(t, resp, &result)
+				if result.Status != "ambiguous" || len(result.Candidates) != 2 {
+					t.Errorf("expected ambiguous result, got %+v", result)
+				}
+			},
+		},
+		{
+			name:   "search uses CEP to pick the candidate",
+			method: http.MethodGet,
+			path: func(f *fixture) string {
+				other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+				f.store.Teams().Create(&other)
+				f.store.StreetSegments().Create(&models.StreetSegment{
+					StreetName: "FLORES", Neighborhood: "VILA NERY", City: "SAO CARLOS", State: "SP",
+					StartNumber: 1, EndNumber: 99, CEPPrefix: "13573", EvenOdd: "all", TeamID: other.ID,
+				})
+				return "/streets/search?street=das+flores&number=10&city=sao+carlos&state=sp&cep=13560-000"
+			},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var result models.AddressSearchResponse
+				decodeData(t, resp, &result)
+				if result.Status != "matched" || result.Team.ID != f.team.ID {
+					t.Errorf("expected fixture team, got %+v", result)
+				}
+			},
+		},
+		{
+			name:       "search requires all parameters",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/streets/search?street=das+flores" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search rejects invalid number",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/streets/search?street=das+flores&number=dez&city=sao+carlos&state=sp" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "search returns 404 outside the range",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/streets/search?street=das+flores&number=500&city=sao+carlos&state=sp" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "search only accepts GET",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/streets/search" },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			var body interface{}
+			if tt.body != nil {
+				body = tt.body(f)
+			}
+			rec, resp := doRequest(t, f.routes(), tt.method, tt.path(f), body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, f, resp)
+			}
+		})
+	}
+}
+
+func TestAnalyzeStreetSegments(t *testing.T) {
+	f := newFixture(t)
+	other := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+	f.store.Teams().Create(&other)
+	seed := []models.StreetSegment{
+		{StreetName: "FLORES", City: "SAO CARLOS", State: "SP", StartNumber: 90, EndNumber: 150, EvenOdd: "even", TeamID: other.ID},
+		{StreetName: "FLORES", City: "SAO CARLOS", State: "SP", StartNumber: 201, EndNumber: 300, EvenOdd: "all", TeamID: other.ID},
+		{StreetName: "FLORES", City: "ARARAQUARA", State: "SP", StartNumber: 1, EndNumber: 99, EvenOdd: "all", TeamID: other.ID},
+	}
+	for i := range seed {
+		f.store.StreetSegments().Create(&seed[i])
+	}
+
+	rec, resp := doRequest(t, f.routes(), http.MethodGet, "/streets/analysis?city=sao+carlos&state=sp", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	var analysis models.TerritoryAnalysis
+	decodeData(t, resp, &analysis)
+	if analysis.Streets != 1 || analysis.Segments != 3 {
+		t.Errorf("expected only Sao Carlos segments, got %+v", analysis)
+	}
+	if len(analysis.Overlaps) != 1 || analysis.Overlaps[0].StartNumber != 90 || analysis.Overlaps[0].EndNumber != 98 {
+		t.Errorf("unexpected overlaps: %+v", analysis.Overlaps)
+	}
+	// Impares 101-199 e pares 152-200 ficaram sem equipe
+	if len(analysis.Gaps) != 2 || analysis.Gaps[0].EvenOdd != "odd" || analysis.Gaps[0].StartNumber != 101 ||
+		analysis.Gaps[1].EvenOdd != "even" || analysis.Gaps[1].StartNumber != 152 || analysis.Gaps[1].EndNumber != 200 {
+		t.Errorf("unexpected gaps: %+v", analysis.Gaps)
+	}
+}
+
+func TestRestoreStreetSegment(t *testing.T) {
+	f := newFixture(t)
+
+	rec, _ := doRequest(t, f.routes(), http.MethodDelete, fmt.Sprintf("/streets/%d", f.segment.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete failed: %s", rec.Body.String())
+	}
+
+	rec, resp := doRequest(t, f.routes(), http.MethodGet, "/streets/?deleted=true", nil)
+	var deleted []models.StreetSegment
+	decodeData(t, resp, &deleted)
+	if rec.Code != http.StatusOK || len(deleted) != 1 {
+		t.Fatalf("expected 1 deleted segment, got %d", len(deleted))
+	}
+
+	rec, _ = doRequest(t, f.routes(), http.MethodPost, fmt.Sprintf("/streets/%d/restore", f.segment.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore failed: %s", rec.Body.String())
+	}
+	if _, err := f.store.StreetSegments().Get(f.segment.ID); err != nil {
+		t.Errorf("expected segment to be active again: %v", err)
+	}
+}
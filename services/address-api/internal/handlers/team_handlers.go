@@ -1,35 +1,12 @@
 package handlers
 
 import (
-	"address-api/internal/database"
 	"address-api/internal/models"
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
 )
 
-func HandleTeams(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		createTeam(w, r)
-	case http.MethodGet:
-		path := strings.TrimPrefix(r.URL.Path, "/teams/")
-		if path == "" {
-			listTeams(w, r)
-		} else {
-			getTeam(w, r)
-		}
-	case http.MethodPut:
-		updateTeam(w, r)
-	case http.MethodDelete:
-		deleteTeam(w, r)
-	default:
-		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
-
-func createTeam(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) createTeam(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTeamRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -38,8 +15,7 @@ func createTeam(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Verify if UBS exists
-	var ubs models.UBS
-	if err := database.GetDB().First(&ubs, req.UBSID).Error; err != nil {
+	if _, err := h.ubs.Get(req.UBSID); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid UBS ID")
 		return
 	}
@@ -49,26 +25,21 @@ func createTeam(w http.ResponseWriter, r *http.Request) {
 		UBSID: req.UBSID,
 	}
 
-	if err := database.GetDB().Create(&team).Error; err != nil {
+	// The repository fills in the UBS information after creating the team
+	if err := h.teams.Create(&team); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create team")
 		return
 	}
 
-	// Fetch the complete team data with UBS information
-	if err := database.GetDB().Preload("UBS").First(&team, team.ID).Error; err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to fetch team data")
-		return
-	}
-
 	respondWithJSON(w, http.StatusCreated, models.APIResponse{
 		Success: true,
 		Data:    team,
 	})
 }
 
-func listTeams(w http.ResponseWriter, _ *http.Request) {
-	var teams []models.Team
-	if err := database.GetDB().Preload("UBS").Find(&teams).Error; err != nil {
+func (h *Handler) listTeams(w http.ResponseWriter, r *http.Request) {
+	teams, err := h.teams.List(wantsDeleted(r))
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to fetch teams")
 		return
 	}
@@ -79,15 +50,14 @@ func listTeams(w http.ResponseWriter, _ *http.Request) {
 	})
 }
 
-func getTeam(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/teams/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+func (h *Handler) getTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
 		return
 	}
 
-	var team models.Team
-	if err := database.GetDB().Preload("UBS").First(&team, id).Error; err != nil {
+	team, err := h.teams.Get(id)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Team not found")
 		return
 	}
@@ -98,10 +68,9 @@ func getTeam(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func updateTeam(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/teams/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+func (h *Handler) updateTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
 		return
 	}
 
@@ -112,14 +81,15 @@ func updateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	var team models.Team
-	if err := database.GetDB().First(&team, id).Error; err != nil {
+	team, err := h.teams.Get(id)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Team not found")
 		return
 	}
 
 	// Verify if new UBS exists
-	if err := database.GetDB().First(&models.UBS{}, req.UBSID).Error; err != nil {
+	ubs, err := h.ubs.Get(req.UBSID)
+	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid UBS ID")
 		return
 	}
@@ -127,43 +97,44 @@ func updateTeam(w http.ResponseWriter, r *http.Request) {
 	team.Name = req.Name
 	team.UBSID = req.UBSID
 
-	if err := database.GetDB().Save(&team).Error; err != nil {
+	if err := h.teams.Update(team); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update team")
 		return
 	}
 
+	ubs.Teams = nil
+	team.UBS = *ubs
+
 	respondWithJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    team,
 	})
 }
 
-func deleteTeam(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/teams/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+func (h *Handler) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
 		return
 	}
 
-	var team models.Team
-	if err := database.GetDB().First(&team, id).Error; err != nil {
+	if _, err := h.teams.Get(id); err != nil {
 		respondWithError(w, http.StatusNotFound, "Team not found")
 		return
 	}
 
 	// Confere se um time possui segmentos de rua associado
-	var count int64
-	if err := database.GetDB().Model(&models.StreetSegment{}).Where("team_id = ?", id).Count(&count).Error; err != nil {
+	count, err := h.segments.CountByTeam(id)
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to check team dependencies")
 		return
 	}
 
-	if count > 0 {
-		respondWithError(w, http.StatusBadRequest, "Cannot delete team with assigned street segments")
+	if count > 0 && !wantsCascade(r) {
+		respondWithError(w, http.StatusBadRequest, "Cannot delete team with assigned street segments (use ?cascade=true to delete them as well)")
 		return
 	}
 
-	if err := database.GetDB().Delete(&team).Error; err != nil {
+	if err := h.teams.Delete(id); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete team")
 		return
 	}
@@ -173,3 +144,39 @@ func deleteTeam(w http.ResponseWriter, r *http.Request) {
 		Data:    "Team successfully deleted",
 	})
 }
+
+func (h *Handler) restoreTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid team ID")
+	if !ok {
+		return
+	}
+
+	team, err := h.teams.GetDeleted(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Deleted team not found")
+		return
+	}
+
+	// Um time so pode voltar se a UBS dele estiver ativa
+	if _, err := h.ubs.Get(team.UBSID); err != nil {
+		respondWithError(w, http.StatusConflict, "Team UBS is deleted, restore the UBS first")
+		return
+	}
+
+	// Restaura tambem os segmentos removidos na mesma operacao
+	if err := h.teams.Restore(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore team")
+		return
+	}
+
+	team, err = h.teams.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch team data")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    team,
+	})
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHandleTeams(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       func(f *fixture) string
+		body       func(f *fixture) interface{}
+		wantStatus int
+		check      func(t *testing.T, f *fixture, resp testResponse)
+	}{
+		{
+			name:   "create returns team with UBS",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/teams/" },
+			body: func(f *fixture) interface{} {
+				return models.CreateTeamRequest{Name: "Equipe Verde", UBSID: f.ubs.ID}
+			},
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var team models.Team
+				decodeData(t, resp, &team)
+				if team.ID == 0 || team.UBS.ID != f.ubs.ID {
+					t.Errorf("unexpected team: %+v", team)
+				}
+			},
+		},
+		{
+			name:   "create rejects unknown UBS",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/teams/" },
+			body: func(*fixture) interface{} {
+				return models.CreateTeamRequest{Name: "Equipe Verde", UBSID: 999}
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "create rejects invalid payload",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/teams/" },
+			body:       func(*fixture) interface{} { return "[" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "list returns teams",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/teams/" },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *fixture, resp testResponse) {
+				var teams []models.Team
+				decodeData(t, resp, &teams)
+				if len(teams) != 1 || teams[0].UBS.Name != "UBS Centro" {
+					t.Errorf("unexpected teams: %+v", teams)
+				}
+			},
+		},
+		{
+			name:       "get returns team",
+			method:     http.MethodGet,
+			path:       func(f *fixture) string { return fmt.Sprintf("/teams/%d", f.team.ID) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "get rejects invalid ID",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/teams/x" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "get returns 404 for unknown team",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/teams/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update renames team",
+			method: http.MethodPut,
+			path:   func(f *fixture) string { return fmt.Sprintf("/teams/%d", f.team.ID) },
+			body: func(f *fixture) interface{} {
+				return models.CreateTeamRequest{Name: "Equipe Amarela", UBSID: f.ubs.ID}
+			},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, _ testResponse) {
+				team, err := f.store.Teams().Get(f.team.ID)
+				if err != nil || team.Name != "Equipe Amarela" {
+					t.Errorf("team not updated: %+v (%v)", team, err)
+				}
+			},
+		},
+		{
+			name:   "update rejects unknown UBS",
+			method: http.MethodPut,
+			path:   func(f *fixture) string { return fmt.Sprintf("/teams/%d", f.team.ID) },
+			body: func(*fixture) interface{} {
+				return models.CreateTeamRequest{Name: "Equipe Amarela", UBSID: 999}
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "update returns 404 for unknown team",
+			method: http.MethodPut,
+			path:   func(*fixture) string { return "/teams/999" },
+			body: func(f *fixture) interface{} {
+				return models.CreateTeamRequest{Name: "Equipe Amarela", UBSID: f.ubs.ID}
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "delete is blocked by assigned street segments",
+			method:     http.MethodDelete,
+			path:       func(f *fixture) string { return fmt.Sprintf("/teams/%d", f.team.ID) },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "delete with cascade removes street segments",
+			method:     http.MethodDelete,
+			path:       func(f *fixture) string { return fmt.Sprintf("/teams/%d?cascade=true", f.team.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, _ testResponse) {
+				if _, err := f.store.StreetSegments().Get(f.segment.ID); err == nil {
+					t.Error("expected street segment to be deleted")
+				}
+			},
+		},
+		{
+			name:       "restore returns 404 for active team",
+			method:     http.MethodPost,
+			path:       func(f *fixture) string { return fmt.Sprintf("/teams/%d/restore", f.team.ID) },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodPatch,
+			path:       func(*fixture) string { return "/teams/" },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			var body interface{}
+			if tt.body != nil {
+				body = tt.body(f)
+			}
+			rec, resp := doRequest(t, f.routes(), tt.method, tt.path(f), body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, f, resp)
+			}
+		})
+	}
+}
+
+func TestRestoreTeamRequiresActiveUBS(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.store.UBS().Delete(f.ubs.ID); err != nil {
+		t.Fatalf("failed to delete UBS: %v", err)
+	}
+
+	rec, _ := doRequest(t, f.routes(), http.MethodPost, fmt.Sprintf("/teams/%d/restore", f.team.ID), nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusConflict, rec.Code, rec.Body.String())
+	}
+
+	if err := f.store.UBS().Restore(f.ubs.ID); err != nil {
+		t.Fatalf("failed to restore UBS: %v", err)
+	}
+	if _, err := f.store.Teams().Get(f.team.ID); err != nil {
+		t.Errorf("expected team to be restored with its UBS: %v", err)
+	}
+}
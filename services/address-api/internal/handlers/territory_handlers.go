@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"address-api/internal/geo"
+	"address-api/internal/models"
+	"address-api/internal/territory"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// listTerritories lista os poligonos das equipes ativas. Com
+// ?format=geojson devolve uma FeatureCollection para abrir em um mapa.
+func (h *Handler) listTerritories(w http.ResponseWriter, r *http.Request) {
+	var teamID uint
+	if value := r.URL.Query().Get("team_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+			return
+		}
+		teamID = uint(id)
+	}
+
+	territories, err := h.territories.List(teamID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch territories")
+		return
+	}
+	if territories == nil {
+		territories = []models.Territory{}
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "geojson":
+		w.Header().Set("Content-Type", "application/geo+json")
+		w.WriteHeader(http.StatusOK)
+		if err := territory.WriteTerritoriesGeoJSON(w, territories); err != nil {
+			log.Printf("failed to write territories: %v", err)
+		}
+		return
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid format. Must be 'json' or 'geojson'")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    territories,
+	})
+}
+
+func (h *Handler) createTerritory(w http.ResponseWriter, r *http.Request) {
+	area, ok := h.decodeTerritory(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.territories.Create(&area); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create territory")
+		return
+	}
+
+	// Relido para trazer a equipe e a geometria como ficou gravada
+	created, err := h.territories.Get(area.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch territory")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    created,
+	})
+}
+
+func (h *Handler) getTerritory(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid territory ID")
+	if !ok {
+		return
+	}
+
+	area, err := h.territories.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Territory not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    area,
+	})
+}
+
+func (h *Handler) updateTerritory(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid territory ID")
+	if !ok {
+		return
+	}
+
+	existing, err := h.territories.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Territory not found")
+		return
+	}
+
+	area, ok := h.decodeTerritory(w, r)
+	if !ok {
+		return
+	}
+	area.ID = existing.ID
+	area.CreatedAt = existing.CreatedAt
+
+	if err := h.territories.Update(&area); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update territory")
+		return
+	}
+
+	updated, err := h.territories.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch territory")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    updated,
+	})
+}
+
+func (h *Handler) deleteTerritory(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid territory ID")
+	if !ok {
+		return
+	}
+
+	if _, err := h.territories.Get(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Territory not found")
+		return
+	}
+
+	if err := h.territories.Delete(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete territory")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Territory successfully deleted",
+	})
+}
+
+// locateTerritories devolve os territorios que contem o ponto, do menor
+// para o maior
+func (h *Handler) locateTerritories(w http.ResponseWriter, r *http.Request) {
+	point, ok := parsePoint(w, r)
+	if !ok {
+		return
+	}
+	if point == nil {
+		respondWithError(w, http.StatusBadRequest, "Missing required parameters: lat and lng")
+		return
+	}
+
+	territories, err := h.territories.Locate(*point)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to locate territories")
+		return
+	}
+	if territories == nil {
+		territories = []models.Territory{}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    territories,
+	})
+}
+
+// decodeTerritory le a Feature do corpo e confere a geometria e a equipe. Em
+// caso de erro ja responde e devolve false.
+func (h *Handler) decodeTerritory(w http.ResponseWriter, r *http.Request) (models.Territory, bool) {
+	var req models.TerritoryFeature
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return models.Territory{}, false
+	}
+	defer r.Body.Close()
+
+	area, err := territory.NewTerritory(req)
+	if err != nil {
+		message := "Invalid territory"
+		if errors.Is(err, geo.ErrInvalidGeometry) {
+			message = "Invalid territory: " + err.Error()
+		}
+		respondWithError(w, http.StatusBadRequest, message)
+		return models.Territory{}, false
+	}
+
+	if _, err := h.teams.Get(area.TeamID); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+		return models.Territory{}, false
+	}
+	return area, true
+}
+
+// parsePoint le ?lat= e ?lng=. Sem nenhum dos dois devolve nil; com so um
+// deles, ou fora dos limites, responde 400 e devolve false.
+func parsePoint(w http.ResponseWriter, r *http.Request) (*geo.Point, bool) {
+	latValue, lngValue := r.URL.Query().Get("lat"), r.URL.Query().Get("lng")
+	if latValue == "" && lngValue == "" {
+		return nil, true
+	}
+
+	lat, latErr := strconv.ParseFloat(latValue, 64)
+	lng, lngErr := strconv.ParseFloat(lngValue, 64)
+	point := geo.Point{Lat: lat, Lng: lng}
+	if latErr != nil || lngErr != nil || !point.Valid() {
+		respondWithError(w, http.StatusBadRequest, "Invalid coordinates. lat must be between -90 and 90 and lng between -180 and 180")
+		return nil, false
+	}
+	return &point, true
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// square devolve uma Feature com um quadrado de lado 0.02 grau a partir de
+// (lng, lat)
+func square(teamID uint, name string, lng, lat float64) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "Feature",
+		"geometry": map[string]interface{}{
+			"type": "Polygon",
+			"coordinates": [][][2]float64{{
+				{lng, lat}, {lng + 0.02, lat}, {lng + 0.02, lat + 0.02}, {lng, lat + 0.02}, {lng, lat},
+			}},
+		},
+		"properties": map[string]interface{}{"team_id": teamID, "name": name},
+	}
+}
+
+func createTerritory(t *testing.T, f *fixture, feature map[string]interface{}) models.Territory {
+	t.Helper()
+	rec, resp := doRequest(t, f.routes(), http.MethodPost, "/territories", feature)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var territory models.Territory
+	decodeData(t, resp, &territory)
+	return territory
+}
+
+func TestTerritoryCRUD(t *testing.T) {
+	f := newFixture(t)
+
+	territory := createTerritory(t, f, square(f.team.ID, "Centro", -47.90, -22.02))
+	if territory.Team.ID != f.team.ID || territory.Name != "Centro" || territory.Geometry["type"] != "MultiPolygon" {
+		t.Fatalf("unexpected territory %+v", territory)
+	}
+	path := fmt.Sprintf("/territories/%d", territory.ID)
+
+	t.Run("invalid bodies", func(t *testing.T) {
+		open := square(f.team.ID, "Aberto", -47.90, -22.02)
+		open["geometry"] = map[string]interface{}{"type": "Polygon", "coordinates": [][][2]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}}
+		point := square(f.team.ID, "Ponto", -47.90, -22.02)
+		point["geometry"] = map[string]interface{}{"type": "Point", "coordinates": []float64{0, 0}}
+
+		for name, body := range map[string]interface{}{
+			"open ring":    open,
+			"point":        point,
+			"unknown team": square(999, "Outra", -47.90, -22.02),
+		} {
+			if rec, _ := doRequest(t, f.routes(), http.MethodPost, "/territories", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected 400, got %d (%s)", name, rec.Code, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("update replaces name and polygon", func(t *testing.T) {
+		rec, resp := doRequest(t, f.routes(), http.MethodPut, path, square(f.team.ID, "Centro Expandido", -47.91, -22.03))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d (%s)", rec.Code, rec.Body.String())
+		}
+		var updated models.Territory
+		decodeData(t, resp, &updated)
+		if updated.ID != territory.ID || updated.Name != "Centro Expandido" {
+			t.Errorf("unexpected territory %+v", updated)
+		}
+	})
+
+	t.Run("list as GeoJSON", func(t *testing.T) {
+		req := fmt.Sprintf("/territories?team_id=%d&format=geojson", f.team.ID)
+		rec, _ := doRequest(t, f.routes(), http.MethodGet, req, nil)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/geo+json" {
+			t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("deleted team hides its territories", func(t *testing.T) {
+		if err := f.store.Teams().Delete(f.team.ID); err != nil {
+			t.Fatalf("failed to delete team: %v", err)
+		}
+		rec, resp := doRequest(t, f.routes(), http.MethodGet, "/territories", nil)
+		var territories []models.Territory
+		decodeData(t, resp, &territories)
+		if rec.Code != http.StatusOK || len(territories) != 0 {
+			t.Errorf("expected no territories, got %d %+v", rec.Code, territories)
+		}
+		if err := f.store.Teams().Restore(f.team.ID); err != nil {
+			t.Fatalf("failed to restore team: %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if rec, _ := doRequest(t, f.routes(), http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if rec, _ := doRequest(t, f.routes(), http.MethodGet, path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404 after delete, got %d", rec.Code)
+		}
+	})
+}
+
+func TestLocateTerritories(t *testing.T) {
+	f := newFixture(t)
+	createTerritory(t, f, square(f.team.ID, "Centro", -47.90, -22.02))
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFound  int
+	}{
+		{"inside", "lat=-22.01&lng=-47.89", http.StatusOK, 1},
+		{"outside", "lat=-22.01&lng=-47.80", http.StatusOK, 0},
+		{"missing lng", "lat=-22.01", http.StatusBadRequest, 0},
+		{"out of range", "lat=-122.01&lng=-47.89", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, resp := doRequest(t, f.routes(), http.MethodGet, "/territories/locate?"+tt.query, nil)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var territories []models.Territory
+			decodeData(t, resp, &territories)
+			if len(territories) != tt.wantFound {
+				t.Errorf("expected %d territories, got %+v", tt.wantFound, territories)
+			}
+		})
+	}
+}
+
+func TestSearchWithCoordinates(t *testing.T) {
+	f := newFixture(t)
+	green := models.Team{Name: "Equipe Verde", UBSID: f.ubs.ID}
+	if err := f.store.Teams().Create(&green); err != nil {
+		t.Fatalf("failed to seed team: %v", err)
+	}
+	blueArea := createTerritory(t, f, square(f.team.ID, "Centro", -47.90, -22.02))
+	greenArea := createTerritory(t, f, square(green.ID, "Vila Nery", -47.88, -22.02))
+
+	search := func(query url.Values) (int, models.AddressSearchResponse) {
+		t.Helper()
+		rec, resp := doRequest(t, f.routes(), http.MethodGet, "/streets/search?"+query.Encode(), nil)
+		var result models.AddressSearchResponse
+		if rec.Code == http.StatusOK {
+			decodeData(t, resp, &result)
+		}
+		return rec.Code, result
+	}
+	street := url.Values{"street": {"Rua das Flores"}, "number": {"10"}, "city": {"Sao Carlos"}, "state": {"SP"}}
+
+	t.Run("point alone finds the team of the polygon", func(t *testing.T) {
+		status, result := search(url.Values{"lat": {"-22.01"}, "lng": {"-47.87"}})
+		if status != http.StatusOK || result.Team.ID != green.ID || result.StreetSegment.ID != 0 {
+			t.Fatalf("unexpected result %d %+v", status, result)
+		}
+		if candidate := result.Candidates[0]; candidate.TerritoryID != greenArea.ID || candidate.Reasons[0].Field != "territory" {
+			t.Errorf("unexpected candidate %+v", candidate)
+		}
+	})
+
+	t.Run("polygon confirms the street segment", func(t *testing.T) {
+		query := url.Values{"lat": {"-22.01"}, "lng": {"-47.89"}}
+		for k, v := range street {
+			query[k] = v
+		}
+		status, result := search(query)
+		if status != http.StatusOK || result.StreetSegment.ID != f.segment.ID || len(result.Candidates) != 1 {
+			t.Fatalf("unexpected result %d %+v", status, result)
+		}
+		reasons := result.Candidates[0].Reasons
+		if last := reasons[len(reasons)-1]; last.Field != "territory" || !last.Matched || result.Candidates[0].TerritoryID != blueArea.ID {
+			t.Errorf("unexpected reasons %+v", reasons)
+		}
+	})
+
+	t.Run("polygon of another team lowers the segment", func(t *testing.T) {
+		query := url.Values{"lat": {"-22.01"}, "lng": {"-47.87"}}
+		for k, v := range street {
+			query[k] = v
+		}
+		status, result := search(query)
+		if status != http.StatusOK || len(result.Candidates) != 2 {
+			t.Fatalf("unexpected result %d %+v", status, result)
+		}
+		if result.Score != 0.8 || result.Candidates[1].Team.ID != green.ID {
+			t.Errorf("unexpected candidates %+v", result.Candidates)
+		}
+	})
+
+	t.Run("point outside every polygon", func(t *testing.T) {
+		if status, _ := search(url.Values{"lat": {"-22.01"}, "lng": {"-47.70"}}); status != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", status)
+		}
+	})
+}
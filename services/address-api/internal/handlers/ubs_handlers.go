@@ -1,36 +1,25 @@
 package handlers
 
 import (
-	"address-api/internal/database"
+	"address-api/internal/geo"
 	"address-api/internal/models"
+	"address-api/internal/schedule"
 	"address-api/internal/utils"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
-func HandleUBS(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		createUBS(w, r)
-	case http.MethodGet:
-		path := strings.TrimPrefix(r.URL.Path, "/ubs/")
-		if path == "" {
-			listUBS(w, r)
-		} else {
-			getUBS(w, r)
-		}
-	case http.MethodPut:
-		updateUBS(w, r)
-	case http.MethodDelete:
-		deleteUBS(w, r)
-	default:
-		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
+// Quantidade de UBS retornadas pela busca por proximidade
+const (
+	defaultNearestLimit = 5
+	maxNearestLimit     = 50
+)
 
-func createUBS(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) createUBS(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUBSRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -38,15 +27,13 @@ func createUBS(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	ubs := models.UBS{
-		Name:    req.Name,
-		Address: req.Address,
-		City:    strings.ToUpper(req.City),
-		State:   strings.ToUpper(req.State),
-		CEP:     utils.NormalizeCEP(req.CEP),
+	var ubs models.UBS
+	if err := setUBSFields(&ubs, req); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if err := database.GetDB().Create(&ubs).Error; err != nil {
+	if err := h.ubs.Create(&ubs); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create UBS")
 		return
 	}
@@ -57,9 +44,9 @@ func createUBS(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func listUBS(w http.ResponseWriter, _ *http.Request) {
-	var ubsList []models.UBS
-	if err := database.GetDB().Find(&ubsList).Error; err != nil {
+func (h *Handler) listUBS(w http.ResponseWriter, r *http.Request) {
+	ubsList, err := h.ubs.List(wantsDeleted(r))
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to fetch UBS list")
 		return
 	}
@@ -70,15 +57,14 @@ func listUBS(w http.ResponseWriter, _ *http.Request) {
 	})
 }
 
-func getUBS(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/ubs/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid UBS ID")
+func (h *Handler) getUBS(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
 		return
 	}
 
-	var ubs models.UBS
-	if err := database.GetDB().Preload("Teams").First(&ubs, id).Error; err != nil {
+	ubs, err := h.ubs.Get(id)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "UBS not found")
 		return
 	}
@@ -89,10 +75,9 @@ func getUBS(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func updateUBS(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/ubs/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid UBS ID")
+func (h *Handler) updateUBS(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
 		return
 	}
 
@@ -103,19 +88,18 @@ func updateUBS(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	var ubs models.UBS
-	if err := database.GetDB().First(&ubs, id).Error; err != nil {
+	ubs, err := h.ubs.Get(id)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "UBS not found")
 		return
 	}
 
-	ubs.Name = req.Name
-	ubs.Address = req.Address
-	ubs.City = strings.ToUpper(req.City)
-	ubs.State = strings.ToUpper(req.State)
-	ubs.CEP = utils.NormalizeCEP(req.CEP)
+	if err := setUBSFields(ubs, req); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if err := database.GetDB().Save(&ubs).Error; err != nil {
+	if err := h.ubs.Update(ubs); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update UBS")
 		return
 	}
@@ -126,20 +110,30 @@ func updateUBS(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func deleteUBS(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/ubs/"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid UBS ID")
+func (h *Handler) deleteUBS(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
 		return
 	}
 
-	var ubs models.UBS
-	if err := database.GetDB().First(&ubs, id).Error; err != nil {
+	if _, err := h.ubs.Get(id); err != nil {
 		respondWithError(w, http.StatusNotFound, "UBS not found")
 		return
 	}
 
-	if err := database.GetDB().Delete(&ubs).Error; err != nil {
+	// Confere se a UBS possui times associados
+	count, err := h.teams.CountByUBS(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check UBS dependencies")
+		return
+	}
+
+	if count > 0 && !wantsCascade(r) {
+		respondWithError(w, http.StatusBadRequest, "Cannot delete UBS with assigned teams (use ?cascade=true to delete them as well)")
+		return
+	}
+
+	if err := h.ubs.Delete(id); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete UBS")
 		return
 	}
@@ -149,3 +143,137 @@ func deleteUBS(w http.ResponseWriter, r *http.Request) {
 		Data:    "UBS successfully deleted",
 	})
 }
+
+func (h *Handler) restoreUBS(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "Invalid UBS ID")
+	if !ok {
+		return
+	}
+
+	if _, err := h.ubs.GetDeleted(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Deleted UBS not found")
+		return
+	}
+
+	// Restaura tambem os times e segmentos removidos na mesma operacao
+	if err := h.ubs.Restore(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore UBS")
+		return
+	}
+
+	ubs, err := h.ubs.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch UBS data")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    ubs,
+	})
+}
+
+func (h *Handler) nearestUBS(w http.ResponseWriter, r *http.Request) {
+	point, ok := parsePoint(w, r)
+	if !ok {
+		return
+	}
+	if point == nil {
+		respondWithError(w, http.StatusBadRequest, "lat and lng are required")
+		return
+	}
+
+	limit := defaultNearestLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxNearestLimit {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxNearestLimit))
+			return
+		}
+		limit = n
+	}
+
+	// Filtrando pelas abertas, so da para cortar no limite depois de saber
+	// quais estao abertas
+	onlyOpen := r.URL.Query().Get("open_now") == "true"
+	fetch := limit
+	if onlyOpen {
+		fetch = 0
+	}
+	nearby, err := h.ubs.Nearest(*point, fetch)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to search nearest UBS")
+		return
+	}
+
+	now := h.now()
+	holidays, closures, err := h.loadCalendar(now)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch UBS calendar")
+		return
+	}
+
+	result := []models.NearbyUBS{}
+	for _, item := range nearby {
+		item.DistanceMeters = math.Round(item.DistanceMeters)
+		if len(item.UBS.OpeningHours) > 0 {
+			status := schedule.Status(item.UBS, schedule.ForUBS(item.UBS, holidays, closures), now)
+			item.OpenNow, item.NextOpening = &status.Open, status.NextOpening
+		}
+		if onlyOpen && (item.OpenNow == nil || !*item.OpenNow) {
+			continue
+		}
+		result = append(result, item)
+		if len(result) == limit {
+			break
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// setUBSFields copia a requisicao para a UBS, normalizando os campos e
+// validando coordenadas e horario
+func setUBSFields(ubs *models.UBS, req models.CreateUBSRequest) error {
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return errors.New("latitude and longitude must be informed together")
+	}
+	if req.Latitude != nil && !(geo.Point{Lat: *req.Latitude, Lng: *req.Longitude}).Valid() {
+		return errors.New("invalid coordinates: latitude must be between -90 and 90 and longitude between -180 and 180")
+	}
+	if err := schedule.Validate(req.OpeningHours); err != nil {
+		return err
+	}
+
+	ubs.Name = req.Name
+	ubs.Address = req.Address
+	ubs.City = strings.ToUpper(req.City)
+	ubs.State = strings.ToUpper(req.State)
+	ubs.CEP = utils.NormalizeCEP(req.CEP)
+	ubs.Latitude = req.Latitude
+	ubs.Longitude = req.Longitude
+	ubs.Phone = strings.TrimSpace(req.Phone)
+	ubs.OpeningHours = req.OpeningHours
+	ubs.Services = cleanTags(req.Services)
+	ubs.Accessibility = cleanTags(req.Accessibility)
+	return nil
+}
+
+// cleanTags tira espacos, linhas vazias e repeticoes de uma lista informada
+// pelo usuario
+func cleanTags(values []string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		tag := strings.ToLower(strings.TrimSpace(value))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"address-api/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHandleUBS(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       func(f *fixture) string
+		body       interface{}
+		wantStatus int
+		check      func(t *testing.T, f *fixture, resp testResponse)
+	}{
+		{
+			name:   "create normalizes city, state and CEP",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/ubs/" },
+			body: models.CreateUBSRequest{
+				Name: "UBS Norte", Address: "Av. Norte, 10", City: "São Carlos", State: "sp", CEP: "13560-123",
+			},
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, _ *fixture, resp testResponse) {
+				var ubs models.UBS
+				decodeData(t, resp, &ubs)
+				if ubs.ID == 0 || ubs.City != "SÃO CARLOS" || ubs.State != "SP" || ubs.CEP != "13560123" {
+					t.Errorf("unexpected UBS: %+v", ubs)
+				}
+			},
+		},
+		{
+			name:       "create rejects invalid payload",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/ubs/" },
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create rejects latitude without longitude",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/ubs/" },
+			body: models.CreateUBSRequest{
+				Name: "UBS Norte", Address: "Av. Norte, 10", City: "São Carlos", State: "sp", CEP: "13560-123",
+				Latitude: floatPtr(-22.0),
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create rejects opening hours that close before opening",
+			method: http.MethodPost,
+			path:   func(*fixture) string { return "/ubs/" },
+			body: models.CreateUBSRequest{
+				Name: "UBS Norte", Address: "Av. Norte, 10", City: "São Carlos", State: "sp", CEP: "13560-123",
+				OpeningHours: []models.OpeningPeriod{{Weekday: 1, Opens: "17:00", Closes: "07:00"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "list returns active UBS",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/ubs/" },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *fixture, resp testResponse) {
+				var list []models.UBS
+				decodeData(t, resp, &list)
+				if len(list) != 1 {
+					t.Errorf("expected 1 UBS, got %d", len(list))
+				}
+			},
+		},
+		{
+			name:       "get includes teams",
+			method:     http.MethodGet,
+			path:       func(f *fixture) string { return fmt.Sprintf("/ubs/%d", f.ubs.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var ubs models.UBS
+				decodeData(t, resp, &ubs)
+				if len(ubs.Teams) != 1 || ubs.Teams[0].ID != f.team.ID {
+					t.Errorf("expected team %d in UBS, got %+v", f.team.ID, ubs.Teams)
+				}
+			},
+		},
+		{
+			name:       "get rejects invalid ID",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/ubs/abc" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "get returns 404 for unknown UBS",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/ubs/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update replaces fields",
+			method: http.MethodPut,
+			path:   func(f *fixture) string { return fmt.Sprintf("/ubs/%d", f.ubs.ID) },
+			body: models.CreateUBSRequest{
+				Name: "UBS Centro Novo", Address: "Rua Nova, 2", City: "sao carlos", State: "sp", CEP: "13560-999",
+			},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, _ testResponse) {
+				ubs, err := f.store.UBS().Get(f.ubs.ID)
+				if err != nil || ubs.Name != "UBS Centro Novo" || ubs.CEP != "13560999" {
+					t.Errorf("UBS not updated: %+v (%v)", ubs, err)
+				}
+			},
+		},
+		{
+			name:   "update returns 404 for unknown UBS",
+			method: http.MethodPut,
+			path:   func(*fixture) string { return "/ubs/999" },
+			body: models.CreateUBSRequest{
+				Name: "X", Address: "Rua X, 1", City: "SAO CARLOS", State: "SP", CEP: "13560000",
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "update validates the payload",
+			method:     http.MethodPut,
+			path:       func(f *fixture) string { return fmt.Sprintf("/ubs/%d", f.ubs.ID) },
+			body:       models.CreateUBSRequest{Name: "X"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "health",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/health" },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "delete is blocked by assigned teams",
+			method:     http.MethodDelete,
+			path:       func(f *fixture) string { return fmt.Sprintf("/ubs/%d", f.ubs.ID) },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "delete with cascade removes teams and segments",
+			method:     http.MethodDelete,
+			path:       func(f *fixture) string { return fmt.Sprintf("/ubs/%d?cascade=true", f.ubs.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, _ testResponse) {
+				if _, err := f.store.Teams().Get(f.team.ID); err == nil {
+					t.Error("expected team to be deleted")
+				}
+				if _, err := f.store.StreetSegments().Get(f.segment.ID); err == nil {
+					t.Error("expected street segment to be deleted")
+				}
+			},
+		},
+		{
+			name:       "delete returns 404 for unknown UBS",
+			method:     http.MethodDelete,
+			path:       func(*fixture) string { return "/ubs/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "restore returns 404 for active UBS",
+			method:     http.MethodPost,
+			path:       func(f *fixture) string { return fmt.Sprintf("/ubs/%d/restore", f.ubs.ID) },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "restore rejects invalid ID",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/ubs/abc/restore" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodPatch,
+			path:       func(*fixture) string { return "/ubs/" },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "restore requires POST",
+			method:     http.MethodGet,
+			path:       func(f *fixture) string { return fmt.Sprintf("/ubs/%d/restore", f.ubs.ID) },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/clinicas" },
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec, resp := doRequest(t, f.routes(), tt.method, tt.path(f), tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if resp.Success != (tt.wantStatus < 300) {
+				t.Errorf("unexpected success flag %t", resp.Success)
+			}
+			if tt.check != nil {
+				tt.check(t, f, resp)
+			}
+		})
+	}
+}
+
+func TestDeleteAndRestoreUBSCascade(t *testing.T) {
+	f := newFixture(t)
+
+	rec, _ := doRequest(t, f.routes(), http.MethodDelete, fmt.Sprintf("/ubs/%d?cascade=true", f.ubs.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete failed: %s", rec.Body.String())
+	}
+
+	rec, resp := doRequest(t, f.routes(), http.MethodGet, "/ubs/?deleted=true", nil)
+	var deleted []models.UBS
+	decodeData(t, resp, &deleted)
+	if rec.Code != http.StatusOK || len(deleted) != 1 {
+		t.Fatalf("expected 1 deleted UBS, got %d (%s)", len(deleted), rec.Body.String())
+	}
+
+	rec, resp = doRequest(t, f.routes(), http.MethodPost, fmt.Sprintf("/ubs/%d/restore", f.ubs.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore failed: %s", rec.Body.String())
+	}
+	var restored models.UBS
+	decodeData(t, resp, &restored)
+	if len(restored.Teams) != 1 {
+		t.Errorf("expected teams to be restored with the UBS, got %+v", restored.Teams)
+	}
+	if _, err := f.store.StreetSegments().Get(f.segment.ID); err != nil {
+		t.Errorf("expected street segment to be restored with the UBS: %v", err)
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestNearestUBS(t *testing.T) {
+	f := newFixture(t)
+	// Segunda-feira, 10h em Sao Paulo
+	f.handler.now = func() time.Time { return time.Date(2024, 3, 4, 13, 0, 0, 0, time.UTC) }
+	handler := f.routes()
+
+	weekdays := []models.OpeningPeriod{}
+	for day := 1; day <= 5; day++ {
+		weekdays = append(weekdays, models.OpeningPeriod{Weekday: day, Opens: "07:00", Closes: "17:00"})
+	}
+	create := func(req models.CreateUBSRequest) models.UBS {
+		t.Helper()
+		req.Address, req.City, req.State, req.CEP = "Rua Central, 1", "SAO CARLOS", "SP", "13560000"
+		rec, resp := doRequest(t, handler, http.MethodPost, "/ubs", req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create UBS: %d %s", rec.Code, resp.Error)
+		}
+		var ubs models.UBS
+		decodeData(t, resp, &ubs)
+		return ubs
+	}
+
+	// A UBS da fixture nao tem coordenadas e fica de fora
+	near := create(models.CreateUBSRequest{
+		Name: "UBS Vila Prado", Latitude: floatPtr(-22.0200), Longitude: floatPtr(-47.8900),
+		Phone: " 1633333333 ", Services: []string{"Vacinacao", " odontologia", "vacinacao", ""},
+		Accessibility: []string{"rampa"},
+		// Fechada as segundas pela manha
+		OpeningHours: []models.OpeningPeriod{{Weekday: 1, Opens: "13:00", Closes: "19:00"}},
+	})
+	middle := create(models.CreateUBSRequest{
+		Name: "UBS Centro Norte", Latitude: floatPtr(-22.0100), Longitude: floatPtr(-47.8900),
+		OpeningHours: weekdays,
+	})
+	far := create(models.CreateUBSRequest{
+		Name: "UBS Santa Felicia", Latitude: floatPtr(-21.9500), Longitude: floatPtr(-47.8900),
+	})
+
+	if near.Phone != "1633333333" || len(near.Services) != 2 || near.Services[0] != "vacinacao" {
+		t.Errorf("expected phone and services to be cleaned, got %q %v", near.Phone, near.Services)
+	}
+
+	rec, resp := doRequest(t, handler, http.MethodGet, "/ubs/nearest?lat=-22.0210&lng=-47.8900", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	var nearby []models.NearbyUBS
+	decodeData(t, resp, &nearby)
+	if len(nearby) != 3 || nearby[0].UBS.ID != near.ID || nearby[1].UBS.ID != middle.ID || nearby[2].UBS.ID != far.ID {
+		t.Fatalf("expected UBS sorted by distance, got %+v", nearby)
+	}
+	if d := nearby[0].DistanceMeters; d < 100 || d > 120 {
+		t.Errorf("expected about 111 m to the nearest UBS, got %.0f", d)
+	}
+	if nearby[0].OpenNow == nil || *nearby[0].OpenNow {
+		t.Errorf("expected nearest UBS to be closed, got %v", nearby[0].OpenNow)
+	}
+	if nearby[1].OpenNow == nil || !*nearby[1].OpenNow {
+		t.Errorf("expected second UBS to be open, got %v", nearby[1].OpenNow)
+	}
+	if nearby[2].OpenNow != nil {
+		t.Errorf("expected open_now to be omitted without opening hours, got %v", *nearby[2].OpenNow)
+	}
+
+	_, resp = doRequest(t, handler, http.MethodGet, "/ubs/nearest?lat=-22.0210&lng=-47.8900&limit=1&open_now=true", nil)
+	nearby = nil
+	decodeData(t, resp, &nearby)
+	if len(nearby) != 1 || nearby[0].UBS.ID != middle.ID {
+		t.Errorf("expected only the open UBS, got %+v", nearby)
+	}
+
+	for _, target := range []string{
+		"/ubs/nearest",
+		"/ubs/nearest?lat=-22.02",
+		"/ubs/nearest?lat=-95&lng=-47.89",
+		"/ubs/nearest?lat=-22.02&lng=-47.89&limit=0",
+		"/ubs/nearest?lat=-22.02&lng=-47.89&limit=51",
+	} {
+		if rec, _ := doRequest(t, handler, http.MethodGet, target, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
@@ -1,56 +1,383 @@
 package models
 
-import "time"
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 // UBS represents a Basic Health Unit
 type UBS struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	Name          string          `json:"name" gorm:"size:200;not null"`
+	Address       string          `json:"address" gorm:"size:200;not null"`
+	City          string          `json:"city" gorm:"size:100;not null"`
+	State         string          `json:"state" gorm:"size:2;not null"`
+	CEP           string          `json:"cep" gorm:"size:8;not null"`
+	Latitude      *float64        `json:"latitude,omitempty"`
+	Longitude     *float64        `json:"longitude,omitempty"`
+	Phone         string          `json:"phone" gorm:"size:20;not null;default:''"`
+	OpeningHours  []OpeningPeriod `json:"opening_hours,omitempty" gorm:"serializer:json"` // vazio quando o horario nao e conhecido
+	Services      []string        `json:"services,omitempty" gorm:"serializer:json"`      // ex.: vacinacao, odontologia
+	Accessibility []string        `json:"accessibility,omitempty" gorm:"serializer:json"` // ex.: rampa, banheiro_adaptado
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt  `json:"deleted_at,omitempty" gorm:"index"`
+	Teams         []Team          `json:"teams,omitempty" gorm:"foreignKey:UBSID"`
+}
+
+// OpeningPeriod e um intervalo de atendimento em um dia da semana, com
+// horarios HH:MM no fuso de Sao Paulo. Um dia pode ter mais de um intervalo,
+// como manha e tarde.
+type OpeningPeriod struct {
+	Weekday int    `json:"weekday"` // 0 = domingo, como time.Weekday
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
+
+// Holiday e um feriado em que as UBS da sua abrangencia ficam fechadas. Os
+// nacionais sao calculados e importados; os estaduais e municipais sao
+// cadastrados a mao.
+type Holiday struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"size:200;not null"`
-	Address   string    `json:"address" gorm:"size:200;not null"`
-	City      string    `json:"city" gorm:"size:100;not null"`
-	State     string    `json:"state" gorm:"size:2;not null"`
-	CEP       string    `json:"cep" gorm:"size:8;not null"`
+	Date      Date      `json:"date" gorm:"not null"`
+	Name      string    `json:"name" gorm:"size:100;not null"`
+	Scope     string    `json:"scope" gorm:"size:10;not null"` // 'national', 'state' ou 'municipal'
+	State     string    `json:"state,omitempty" gorm:"size:2;not null;default:''"`
+	City      string    `json:"city,omitempty" gorm:"size:100;not null;default:''"`
+	Optional  bool      `json:"optional"` // ponto facultativo, como o Carnaval
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Teams     []Team    `json:"teams,omitempty" gorm:"foreignKey:UBSID"`
 }
 
-// Um time dentro da UBS
-type Team struct {
+// UBSClosure fecha uma UBS por alguns dias, como numa reforma ou
+// dedetizacao. As datas sao inclusivas.
+type UBSClosure struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"size:100;not null"`
 	UBSID     uint      `json:"ubs_id" gorm:"not null"`
-	UBS       UBS       `json:"ubs,omitempty" gorm:"foreignKey:UBSID"`
+	StartDate Date      `json:"start_date" gorm:"not null"`
+	EndDate   Date      `json:"end_date" gorm:"not null"`
+	Reason    string    `json:"reason" gorm:"size:200;not null;default:''"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OpeningStatus diz se a UBS esta aberta em um instante e, se nao estiver,
+// quando abre de novo. Os horarios vem no fuso de Sao Paulo.
+type OpeningStatus struct {
+	UBSID       uint       `json:"ubs_id"`
+	At          time.Time  `json:"at"`
+	Open        bool       `json:"open"`
+	Reason      string     `json:"reason,omitempty"` // fechada por: 'outside_hours', 'holiday', 'closure' ou 'no_hours'
+	Detail      string     `json:"detail,omitempty"` // nome do feriado ou motivo do fechamento
+	ClosesAt    *time.Time `json:"closes_at,omitempty"`
+	NextOpening *time.Time `json:"next_opening,omitempty"`
+}
+
+// Date e um dia do calendario no formato AAAA-MM-DD, sem horario nem fuso.
+// No Postgres a coluna e DATE.
+type Date string
+
+func (Date) GormDataType() string {
+	return "date"
+}
+
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*d = Date(v.Format("2006-01-02"))
+	case string:
+		*d = Date(v[:min(len(v), 10)])
+	case []byte:
+		*d = Date(v[:min(len(v), 10)])
+	default:
+		return fmt.Errorf("cannot scan %T into Date", value)
+	}
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return string(d), nil
+}
+
+// NearbyUBS e uma UBS encontrada pela proximidade de um ponto
+type NearbyUBS struct {
+	UBS            UBS     `json:"ubs"`
+	DistanceMeters float64 `json:"distance_meters"`
+	// OpenNow e NextOpening ficam vazios quando a UBS nao tem horario
+	// cadastrado
+	OpenNow     *bool      `json:"open_now,omitempty"`
+	NextOpening *time.Time `json:"next_opening,omitempty"`
+}
+
+// Um time dentro da UBS
+type Team struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"size:100;not null"`
+	UBSID     uint           `json:"ubs_id" gorm:"not null"`
+	UBS       UBS            `json:"ubs,omitempty" gorm:"foreignKey:UBSID"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// Professional e um profissional de saude que atua nas equipes. O CNS e
+// documento pessoal e nao aparece para o cidadao.
+type Professional struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	Name           string           `json:"name" gorm:"size:200;not null"`
+	Role           string           `json:"role" gorm:"size:30;not null"`                       // doctor, nurse, nursing_technician, acs, dentist ou oral_health_technician
+	CNS            string           `json:"cns" gorm:"column:cns;size:15;not null;default:''"`  // Cartao Nacional de Saude
+	Registry       string           `json:"registry" gorm:"size:30;not null;default:''"`        // registro no conselho, ex.: CRM-SP 123456
+	ContactChannel string           `json:"contact_channel" gorm:"size:20;not null;default:''"` // phone, whatsapp ou email
+	Contact        string           `json:"contact" gorm:"size:100;not null;default:''"`
+	Shift          string           `json:"shift" gorm:"size:20;not null;default:''"` // morning, afternoon, full_time ou night
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt   `json:"deleted_at,omitempty" gorm:"index"`
+	Memberships    []TeamMembership `json:"memberships,omitempty" gorm:"foreignKey:ProfessionalID"`
+}
+
+// TeamMembership e a passagem de um profissional por uma equipe. Sem
+// EndDate, e o vinculo atual.
+type TeamMembership struct {
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	TeamID         uint          `json:"team_id" gorm:"not null"`
+	Team           *Team         `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+	ProfessionalID uint          `json:"professional_id" gorm:"not null"`
+	Professional   *Professional `json:"professional,omitempty" gorm:"foreignKey:ProfessionalID"`
+	StartDate      Date          `json:"start_date" gorm:"not null"`
+	EndDate        *Date         `json:"end_date,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// TeamMember e um profissional atual da equipe como aparece para o cidadao
+type TeamMember struct {
+	ProfessionalID uint   `json:"professional_id"`
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	Registry       string `json:"registry,omitempty"`
+	Shift          string `json:"shift,omitempty"`
+	ContactChannel string `json:"contact_channel,omitempty"`
+	Contact        string `json:"contact,omitempty"`
+	Since          Date   `json:"since"`
+}
+
+// MicroArea e a parte do territorio da equipe acompanhada por um agente
+// comunitario de saude (ACS). Os segmentos de rua da equipe sao divididos
+// entre as suas micro-areas.
+type MicroArea struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	TeamID    uint            `json:"team_id" gorm:"not null"`
+	Team      *Team           `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+	Code      string          `json:"code" gorm:"size:2;not null"` // codigo de dois digitos usado no e-SUS, ex.: 01
+	Name      string          `json:"name" gorm:"size:100;not null;default:''"`
+	AgentID   *uint           `json:"agent_id"` // ACS responsavel; vazio quando a micro-area esta descoberta
+	Agent     *Professional   `json:"agent,omitempty" gorm:"foreignKey:AgentID"`
+	Segments  []StreetSegment `json:"segments,omitempty" gorm:"foreignKey:MicroAreaID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// AssignedMicroArea e a micro-area do endereco buscado como aparece para o
+// cidadao. Agent fica vazio quando o ACS deixou a equipe ou nunca foi
+// designado.
+type AssignedMicroArea struct {
+	ID    uint        `json:"id"`
+	Code  string      `json:"code"`
+	Name  string      `json:"name,omitempty"`
+	Agent *TeamMember `json:"agent,omitempty"`
 }
 
 // StreetSegment representa um segmento de rua que receberá um time
 type StreetSegment struct {
-	ID                 uint      `json:"id" gorm:"primaryKey"`
-	StreetName         string    `json:"street_name" gorm:"size:200;not null"`
-	OriginalStreetName string    `json:"original_street_name" gorm:"size:200;not null"`
-	StreetType         string    `json:"street_type" gorm:"size:50;not null"` // Rua, Avenida, etc.
-	Neighborhood       string    `json:"neighborhood" gorm:"size:100;not null"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	StreetName         string         `json:"street_name" gorm:"size:200;not null"`
+	OriginalStreetName string         `json:"original_street_name" gorm:"size:200;not null"`
+	StreetType         string         `json:"street_type" gorm:"size:50;not null"` // Rua, Avenida, etc.
+	Neighborhood       string         `json:"neighborhood" gorm:"size:100;not null"`
+	City               string         `json:"city" gorm:"size:100;not null"`
+	State              string         `json:"state" gorm:"size:2;not null"`
+	StartNumber        int            `json:"start_number"`
+	EndNumber          int            `json:"end_number"`
+	CEPPrefix          string         `json:"cep_prefix" gorm:"size:5"` // Primeiro 5 digitos do CEP
+	EvenOdd            string         `json:"even_odd" gorm:"size:4"`   // 'even', 'odd', ou 'all'
+	TeamID             uint           `json:"team_id" gorm:"not null"`
+	Team               Team           `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+	MicroAreaID        *uint          `json:"micro_area_id"` // micro-area da mesma equipe; vazio enquanto nao foi dividido
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// SegmentAssignment e o periodo em que uma equipe cobre um segmento de rua.
+// ValidTo e o ultimo dia; sem ele a atribuicao vale ate a proxima mudanca.
+// Uma reatribuicao com ValidFrom no futuro fica agendada, sem AppliedAt, ate
+// o dia chegar.
+type SegmentAssignment struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	StreetSegmentID uint           `json:"street_segment_id" gorm:"not null"`
+	StreetSegment   *StreetSegment `json:"street_segment,omitempty" gorm:"foreignKey:StreetSegmentID"`
+	TeamID          uint           `json:"team_id" gorm:"not null"`
+	Team            *Team          `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+	PreviousTeamID  *uint          `json:"previous_team_id,omitempty"` // vazio na primeira atribuicao do segmento
+	PreviousTeam    *Team          `json:"previous_team,omitempty" gorm:"foreignKey:PreviousTeamID"`
+	ValidFrom       Date           `json:"valid_from" gorm:"not null"`
+	ValidTo         *Date          `json:"valid_to,omitempty"`
+	Reason          string         `json:"reason,omitempty" gorm:"size:200;not null;default:''"`
+	AppliedAt       *time.Time     `json:"applied_at,omitempty"`  // quando o segmento passou para a equipe
+	NotifiedAt      *time.Time     `json:"notified_at,omitempty"` // quando o user-api avisou os usuarios afetados
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// CEPAddress e o endereco de um CEP na base local, carregada de um arquivo
+// do DNE ou do ViaCEP
+type CEPAddress struct {
+	CEP                string    `json:"cep" gorm:"primaryKey;size:8"`
+	StreetType         string    `json:"street_type" gorm:"size:50"`
+	StreetName         string    `json:"street_name" gorm:"size:200;not null"` // normalizado como em StreetSegment
+	OriginalStreetName string    `json:"original_street_name" gorm:"size:200"`
+	Neighborhood       string    `json:"neighborhood" gorm:"size:100"`
 	City               string    `json:"city" gorm:"size:100;not null"`
 	State              string    `json:"state" gorm:"size:2;not null"`
-	StartNumber        int       `json:"start_number"`
-	EndNumber          int       `json:"end_number"`
-	CEPPrefix          string    `json:"cep_prefix" gorm:"size:5"` // Primeiro 5 digitos do CEP
-	EvenOdd            string    `json:"even_odd" gorm:"size:4"`   // 'even', 'odd', ou 'all'
-	TeamID             uint      `json:"team_id" gorm:"not null"`
-	Team               Team      `json:"team,omitempty" gorm:"foreignKey:TeamID"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
 
+// StreetAlias e outro nome pelo qual uma rua e conhecida: o nome antigo, o
+// popular ou uma grafia errada comum. Fica ligado a um segmento, mas vale
+// para todos os segmentos da mesma rua na cidade.
+type StreetAlias struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	StreetSegmentID uint      `json:"street_segment_id" gorm:"not null"`
+	Alias           string    `json:"alias" gorm:"size:200;not null"` // normalizado como StreetName
+	OriginalAlias   string    `json:"original_alias" gorm:"size:200"`
+	Kind            string    `json:"kind" gorm:"size:20;not null"` // 'old', 'popular' ou 'misspelling'
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AddressLookup registra uma busca por endereco que nao encontrou equipe ou
+// que encontrou com pouca confianca. Fica na fila de revisao ate que um
+// coordenador resolva, cadastrando um segmento ou alias, ou descarte.
+type AddressLookup struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	Input          string            `json:"input"`           // parametros da busca como recebidos
+	OriginalStreet string            `json:"original_street"` // rua como informada, antes da normalizacao
+	Street         string            `json:"street"`
+	Number         int               `json:"number"`
+	Neighborhood   string            `json:"neighborhood"`
+	City           string            `json:"city"`
+	State          string            `json:"state"`
+	CEP            string            `json:"cep"`
+	Reason         string            `json:"reason"` // 'not_found', 'ambiguous' ou 'low_confidence'
+	Score          float64           `json:"score"`
+	Candidates     []LookupCandidate `json:"candidates" gorm:"serializer:json"`
+	UserID         *uint             `json:"user_id,omitempty"` // usuario do user-api para quem a busca foi feita
+	RequestID      string            `json:"request_id"`
+	Resolution     string            `json:"resolution,omitempty"` // 'segment', 'alias' ou 'dismissed'
+	ResolvedTeamID *uint             `json:"resolved_team_id,omitempty"`
+	// Segmento ou alias criado na resolucao
+	ResolvedSegmentID *uint      `json:"resolved_segment_id,omitempty"`
+	ResolvedAliasID   *uint      `json:"resolved_alias_id,omitempty"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// Territory e a area de atuacao de uma equipe desenhada no mapa. Complementa
+// os segmentos de rua: um ponto dentro do poligono indica a equipe mesmo
+// quando a rua nao esta cadastrada.
+type Territory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TeamID    uint      `json:"team_id" gorm:"not null"`
+	Team      Team      `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+	Name      string    `json:"name" gorm:"size:100"`
+	Geometry  GeoJSON   `json:"geometry"` // sempre MultiPolygon em WGS 84
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GeoJSON e uma geometria GeoJSON. No Postgres a coluna e do PostGIS, entao
+// ela e gravada com ST_GeomFromGeoJSON e precisa ser lida com ST_AsGeoJSON.
+type GeoJSON map[string]interface{}
+
+func (GeoJSON) GormDataType() string {
+	return "geometry"
+}
+
+func (g GeoJSON) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	data, _ := json.Marshal(g)
+	return clause.Expr{
+		SQL:  "ST_Multi(ST_CollectionExtract(ST_MakeValid(ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)), 3))",
+		Vars: []interface{}{string(data)},
+	}
+}
+
+func (g *GeoJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*g = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), g)
+	case []byte:
+		return json.Unmarshal(v, g)
+	}
+	return fmt.Errorf("cannot scan %T into GeoJSON", value)
+}
+
+// LookupCandidate resume um segmento considerado na busca registrada. Para
+// buscas sem resultado sao os segmentos da rua que nao cobrem o numero.
+type LookupCandidate struct {
+	StreetSegmentID uint    `json:"street_segment_id"`
+	StreetName      string  `json:"street_name"`
+	StartNumber     int     `json:"start_number"`
+	EndNumber       int     `json:"end_number"`
+	EvenOdd         string  `json:"even_odd"`
+	TeamID          uint    `json:"team_id"`
+	TeamName        string  `json:"team_name"`
+	Score           float64 `json:"score"`
+}
+
 // estruturas para Request/Response
 type CreateUBSRequest struct {
-	Name    string `json:"name" binding:"required"`
-	Address string `json:"address" binding:"required"`
-	City    string `json:"city" binding:"required"`
-	State   string `json:"state" binding:"required"`
-	CEP     string `json:"cep" binding:"required"`
+	Name          string          `json:"name" binding:"required"`
+	Address       string          `json:"address" binding:"required"`
+	City          string          `json:"city" binding:"required"`
+	State         string          `json:"state" binding:"required"`
+	CEP           string          `json:"cep" binding:"required"`
+	Latitude      *float64        `json:"latitude"`
+	Longitude     *float64        `json:"longitude"`
+	Phone         string          `json:"phone"`
+	OpeningHours  []OpeningPeriod `json:"opening_hours"`
+	Services      []string        `json:"services"`
+	Accessibility []string        `json:"accessibility"`
+}
+
+type CreateHolidayRequest struct {
+	Date     Date   `json:"date" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Scope    string `json:"scope" binding:"required"` // 'national', 'state' ou 'municipal'
+	State    string `json:"state"`                    // obrigatorio para feriados estaduais e municipais
+	City     string `json:"city"`                     // obrigatorio para feriados municipais
+	Optional bool   `json:"optional"`
+}
+
+type CreateUBSClosureRequest struct {
+	StartDate Date   `json:"start_date" binding:"required"`
+	EndDate   Date   `json:"end_date"` // padrao: o mesmo dia do inicio
+	Reason    string `json:"reason"`
+}
+
+// HolidayImportReport resume a importacao do calendario nacional de um ano
+type HolidayImportReport struct {
+	Year     int       `json:"year"`
+	Holidays []Holiday `json:"holidays"`
 }
 
 type CreateTeamRequest struct {
@@ -58,6 +385,37 @@ type CreateTeamRequest struct {
 	UBSID uint   `json:"ubs_id" binding:"required"`
 }
 
+type CreateProfessionalRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Role           string `json:"role" binding:"required"`
+	CNS            string `json:"cns"`
+	Registry       string `json:"registry"`
+	ContactChannel string `json:"contact_channel"`
+	Contact        string `json:"contact"`
+	Shift          string `json:"shift"`
+}
+
+type AddTeamMemberRequest struct {
+	ProfessionalID uint `json:"professional_id" binding:"required"`
+	StartDate      Date `json:"start_date"` // padrao: hoje
+}
+
+type EndMembershipRequest struct {
+	EndDate Date `json:"end_date"` // padrao: hoje
+}
+
+type CreateMicroAreaRequest struct {
+	Code    string `json:"code" binding:"required"`
+	Name    string `json:"name"`
+	AgentID *uint  `json:"agent_id"` // ACS atual da equipe
+}
+
+// AssignSegmentsRequest define todos os segmentos da micro-area. Os que ja
+// estavam nela e nao vem na lista ficam sem micro-area.
+type AssignSegmentsRequest struct {
+	StreetSegmentIDs []uint `json:"street_segment_ids"`
+}
+
 type CreateStreetSegmentRequest struct {
 	StreetName   string `json:"street_name" binding:"required"`
 	StreetType   string `json:"street_type" binding:"required"`
@@ -71,6 +429,56 @@ type CreateStreetSegmentRequest struct {
 	TeamID       uint   `json:"team_id" binding:"required"`
 }
 
+// ReassignSegmentRequest muda a equipe do segmento a partir de valid_from.
+// Uma data futura agenda a mudanca.
+type ReassignSegmentRequest struct {
+	TeamID    uint   `json:"team_id" binding:"required"`
+	ValidFrom Date   `json:"valid_from"` // padrao: hoje
+	Reason    string `json:"reason"`
+}
+
+type CreateStreetAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+	Kind  string `json:"kind"` // padrao 'popular'
+}
+
+// ResolvedSearchRequest registra a rua de uma busca que falhou como alias do
+// segmento encontrado manualmente
+type ResolvedSearchRequest struct {
+	Query           string `json:"query" binding:"required"` // rua ou endereco em texto livre, como foi buscado
+	StreetSegmentID uint   `json:"street_segment_id" binding:"required"`
+	Kind            string `json:"kind"` // padrao 'popular'
+}
+
+// ResolveLookupRequest resolve uma busca da fila de revisao. Com action
+// 'segment' um segmento da rua buscada e criado para a equipe; por padrao ele
+// cobre apenas o numero buscado. Com 'alias' a rua buscada vira alias do
+// segmento informado. 'dismiss' apenas tira a busca da fila.
+type ResolveLookupRequest struct {
+	Action          string `json:"action" binding:"required"`
+	TeamID          uint   `json:"team_id"`           // obrigatorio com 'segment'
+	StreetSegmentID uint   `json:"street_segment_id"` // obrigatorio com 'alias'
+	Kind            string `json:"kind"`              // tipo do alias, padrao 'popular'
+	StreetType      string `json:"street_type"`
+	StartNumber     *int   `json:"start_number"`
+	EndNumber       *int   `json:"end_number"`
+	EvenOdd         string `json:"even_odd"`
+	CEPPrefix       string `json:"cep_prefix"`
+}
+
+// TerritoryFeature e o corpo para criar ou atualizar um territorio: uma
+// Feature GeoJSON com Polygon ou MultiPolygon
+type TerritoryFeature struct {
+	Type       string              `json:"type"`
+	Geometry   GeoJSON             `json:"geometry" binding:"required"`
+	Properties TerritoryProperties `json:"properties" binding:"required"`
+}
+
+type TerritoryProperties struct {
+	TeamID uint   `json:"team_id" binding:"required"`
+	Name   string `json:"name"`
+}
+
 type AddressSearchRequest struct {
 	StreetName string `json:"street_name" binding:"required"`
 	Number     int    `json:"number" binding:"required"`
@@ -78,10 +486,88 @@ type AddressSearchRequest struct {
 	State      string `json:"state" binding:"required"`
 }
 
+// BatchSearchRequest sao os enderecos de uma busca em lote
+type BatchSearchRequest struct {
+	Addresses []BatchAddress `json:"addresses" binding:"required"`
+}
+
+// BatchAddress e um endereco da busca em lote, com os mesmos campos da busca
+// por endereco de GET /streets/search
+type BatchAddress struct {
+	Q            string `json:"q,omitempty"`
+	Street       string `json:"street,omitempty"`
+	Number       string `json:"number,omitempty"`
+	City         string `json:"city,omitempty"`
+	State        string `json:"state,omitempty"`
+	CEP          string `json:"cep,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	UserID       *uint  `json:"user_id,omitempty"`
+}
+
+// BatchSearchResult e a resposta de um endereco da busca em lote, na mesma
+// posicao em que ele foi enviado. Status e o codigo HTTP que a busca isolada
+// devolveria.
+type BatchSearchResult struct {
+	Index  int                    `json:"index"`
+	Status int                    `json:"status"`
+	Result *AddressSearchResponse `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// ParsedAddress sao os campos extraidos de um endereco em texto livre
+type ParsedAddress struct {
+	Input        string `json:"input"`
+	StreetType   string `json:"street_type,omitempty"`
+	StreetName   string `json:"street_name"`
+	Number       int    `json:"number,omitempty"`
+	NoNumber     bool   `json:"no_number,omitempty"` // "s/n"
+	Complement   string `json:"complement,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	City         string `json:"city,omitempty"`
+	State        string `json:"state,omitempty"`
+	CEP          string `json:"cep,omitempty"`
+}
+
+type ParseAddressRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// AddressSearchResponse traz o melhor candidato nos campos de cima e todos os
+// candidatos encontrados, do mais provavel para o menos provavel
 type AddressSearchResponse struct {
+	StreetSegment StreetSegment      `json:"street_segment"`
+	Team          Team               `json:"team"`
+	UBS           UBS                `json:"ubs"`
+	Address       *CEPAddress        `json:"address,omitempty"`    // endereco do CEP buscado, quando conhecido
+	Parsed        *ParsedAddress     `json:"parsed,omitempty"`     // campos extraidos da busca em texto livre
+	Roster        []TeamMember       `json:"roster,omitempty"`     // profissionais atuais da equipe do melhor candidato
+	MicroArea     *AssignedMicroArea `json:"micro_area,omitempty"` // micro-area do segmento do melhor candidato
+	AsOf          Date               `json:"as_of,omitempty"`      // data da busca historica
+	Status        string             `json:"status"`               // 'matched' ou 'ambiguous'
+	Confidence    string             `json:"confidence"`           // 'high', 'medium' ou 'low'
+	Score         float64            `json:"score"`
+	Candidates    []AddressCandidate `json:"candidates"`
+}
+
+// AddressCandidate e um segmento que atende o endereco buscado, com a pontuacao
+// e os motivos que a explicam
+type AddressCandidate struct {
 	StreetSegment StreetSegment `json:"street_segment"`
 	Team          Team          `json:"team"`
 	UBS           UBS           `json:"ubs"`
+	Similarity    float64       `json:"similarity"`             // similaridade pg_trgm do nome da rua (ou do alias)
+	Alias         *StreetAlias  `json:"alias,omitempty"`        // nome alternativo que casou com a busca
+	TerritoryID   uint          `json:"territory_id,omitempty"` // territorio da equipe que contem o ponto buscado
+	Score         float64       `json:"score"`
+	Confidence    string        `json:"confidence"`
+	Reasons       []MatchReason `json:"reasons"`
+}
+
+// MatchReason explica um criterio comparado entre o endereco e o segmento
+type MatchReason struct {
+	Field   string `json:"field"` // street, alias, number_range, parity, cep_prefix, neighborhood ou territory
+	Matched bool   `json:"matched"`
+	Detail  string `json:"detail"`
 }
 
 type APIResponse struct {
@@ -89,3 +575,60 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 }
+
+// HealthStatus e retornado pela rota de health check
+type HealthStatus struct {
+	Status string `json:"status"`
+}
+
+// ImportReport resume a importacao de segmentos de rua. Com erros nenhuma
+// linha e gravada.
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	Created   int              `json:"created"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// ImportRowError e um problema encontrado em uma linha do arquivo importado
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// SegmentOverlap e uma faixa de numeros reivindicada por segmentos de equipes
+// diferentes na mesma rua
+type SegmentOverlap struct {
+	StreetName           string `json:"street_name"`
+	City                 string `json:"city"`
+	State                string `json:"state"`
+	StartNumber          int    `json:"start_number"`
+	EndNumber            int    `json:"end_number"`
+	EvenOdd              string `json:"even_odd"`
+	SegmentID            uint   `json:"segment_id"`
+	TeamID               uint   `json:"team_id"`
+	ConflictingSegmentID uint   `json:"conflicting_segment_id"`
+	ConflictingTeamID    uint   `json:"conflicting_team_id"`
+}
+
+// SegmentGap e uma faixa de numeros sem equipe entre dois segmentos da mesma rua
+type SegmentGap struct {
+	StreetName        string `json:"street_name"`
+	City              string `json:"city"`
+	State             string `json:"state"`
+	StartNumber       int    `json:"start_number"`
+	EndNumber         int    `json:"end_number"`
+	EvenOdd           string `json:"even_odd"`
+	PreviousSegmentID uint   `json:"previous_segment_id"`
+	NextSegmentID     uint   `json:"next_segment_id"`
+}
+
+// TerritoryAnalysis lista os conflitos e buracos de cobertura do territorio
+type TerritoryAnalysis struct {
+	Streets  int              `json:"streets"`
+	Segments int              `json:"segments"`
+	Overlaps []SegmentOverlap `json:"overlaps"`
+	Gaps     []SegmentGap     `json:"gaps"`
+}
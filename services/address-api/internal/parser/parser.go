@@ -0,0 +1,255 @@
+// Package parser extrai os campos de um endereco escrito em texto livre,
+// como o cidadao manda pelo WhatsApp: "Rua das Flores 123 apto 4".
+package parser
+
+import (
+	"address-api/internal/models"
+	"regexp"
+	"shared/normalize"
+	"strconv"
+	"strings"
+)
+
+var (
+	// CEP com ou sem o rotulo: "13560-000", "CEP: 13560000"
+	cepPattern = regexp.MustCompile(`(?i)(?:\bcep\s*:?\s*)?\b(\d{5})[-.\s]?(\d{3})\b`)
+	// Separadores entre as partes do endereco: virgulas, ponto e virgula e
+	// tracos com espacos (o traco sem espaco faz parte do nome ou do CEP)
+	separatorPattern = regexp.MustCompile(`\s*[,;]\s*|\s+[-–—]\s+`)
+	// UF no final do texto: "São Carlos - SP", "São Carlos/SP", "São Carlos SP"
+	statePattern = regexp.MustCompile(`(?i)(?:^|[\s,/-])([a-z]{2})\s*[.,]?\s*$`)
+	// Numero colado no marcador: "nº123", "n.123"
+	markedNumberPattern = regexp.MustCompile(`(?i)^(?:n[º°o]?\.?|num\.?|numero|número|nro)(\d+)$`)
+	numberPattern       = regexp.MustCompile(`^(\d+)[a-zA-Z]?$`)
+)
+
+var states = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "AM": true, "BA": true, "CE": true, "DF": true,
+	"ES": true, "GO": true, "MA": true, "MT": true, "MS": true, "MG": true, "PA": true,
+	"PB": true, "PR": true, "PE": true, "PI": true, "RJ": true, "RN": true, "RS": true,
+	"RO": true, "RR": true, "SC": true, "SP": true, "SE": true, "TO": true,
+}
+
+// numberMarkers indicam que a proxima palavra e o numero
+var numberMarkers = map[string]bool{
+	"N": true, "N.": true, "Nº": true, "N°": true, "NO": true, "NO.": true, "N.º": true,
+	"NUM": true, "NUM.": true, "NUMERO": true, "NÚMERO": true, "NRO": true,
+}
+
+var noNumberMarkers = map[string]bool{
+	"S/N": true, "S/Nº": true, "S/N°": true, "SN": true, "S.N.": true, "S/NUMERO": true, "S/NÚMERO": true,
+}
+
+// complementKeywords iniciam o complemento quando aparecem depois do numero
+var complementKeywords = map[string]bool{
+	"APTO": true, "APTO.": true, "AP": true, "AP.": true, "APT": true, "APT.": true, "APARTAMENTO": true,
+	"BLOCO": true, "BL": true, "BL.": true, "CASA": true, "CS": true, "FUNDOS": true, "FDS": true,
+	"SALA": true, "SL": true, "SL.": true, "LOTE": true, "LT": true, "LT.": true, "QUADRA": true,
+	"QD": true, "QD.": true, "ANDAR": true, "CONJUNTO": true, "CONJ": true, "CONJ.": true, "CJ": true,
+	"TORRE": true, "EDIFICIO": true, "EDIFÍCIO": true, "ED": true, "ED.": true,
+}
+
+// connectors ligam um numero ao resto do nome, como em "Rua 7 de Setembro"
+var connectors = map[string]bool{
+	"DE": true, "DO": true, "DA": true, "DOS": true, "DAS": true, "E": true,
+}
+
+const neighborhoodKeyword = "BAIRRO"
+
+// Parse extrai tipo, nome e numero da rua, complemento, bairro, cidade, UF e
+// CEP. Campos que nao forem reconhecidos ficam vazios; o texto original e
+// mantido em Input.
+func Parse(text string) models.ParsedAddress {
+	parsed := models.ParsedAddress{Input: text}
+	text = strings.Join(strings.Fields(text), " ")
+
+	if match := cepPattern.FindStringSubmatchIndex(text); match != nil {
+		parsed.CEP = text[match[2]:match[3]] + text[match[4]:match[5]]
+		text = text[:match[0]] + text[match[1]:]
+	}
+	text = strings.Trim(text, " ,;-–—")
+
+	if match := statePattern.FindStringSubmatchIndex(text); match != nil {
+		if state := strings.ToUpper(text[match[2]:match[3]]); states[state] && match[0] > 0 {
+			parsed.State = state
+			text = strings.Trim(text[:match[2]], " ,;/-–—")
+		}
+	}
+
+	var parts []string
+	for _, part := range separatorPattern.Split(text, -1) {
+		if part = strings.Trim(part, " ."); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return parsed
+	}
+
+	rest := parseStreet(&parsed, strings.Fields(parts[0]))
+
+	// Partes sem palavra-chave: o bairro vem antes da cidade. Se a UF foi
+	// informada, a ultima delas e a cidade.
+	var plain []string
+	if rest != "" {
+		plain = append(plain, rest)
+	}
+	for _, part := range parts[1:] {
+		words := strings.Fields(part)
+		switch first := strings.ToUpper(words[0]); {
+		case parsed.Number == 0 && !parsed.NoNumber && takeNumber(&parsed, words):
+			if tail := parseTail(&parsed, words[numberLength(words):]); tail != "" {
+				plain = append(plain, tail)
+			}
+		case complementKeywords[first]:
+			appendComplement(&parsed, part)
+		case first == neighborhoodKeyword && len(words) > 1:
+			parsed.Neighborhood = strings.Join(words[1:], " ")
+		default:
+			plain = append(plain, part)
+		}
+	}
+
+	if parsed.State != "" && len(plain) > 0 {
+		parsed.City = plain[len(plain)-1]
+		plain = plain[:len(plain)-1]
+	}
+	for _, part := range plain {
+		switch {
+		case parsed.Neighborhood == "":
+			parsed.Neighborhood = part
+		case parsed.City == "":
+			parsed.City = part
+		}
+	}
+	return parsed
+}
+
+// parseStreet le tipo, nome e numero da primeira parte do endereco e devolve
+// o que sobrou depois do numero que nao e complemento nem bairro
+func parseStreet(parsed *models.ParsedAddress, words []string) string {
+	// "Moro na Rua das Flores": descarta o que vem antes do tipo
+	for i, word := range words {
+		if isStreetType(word) {
+			words = words[i:]
+			break
+		}
+	}
+	// Tipos de duas palavras, como "Estrada Municipal" ou "Est. Mun."
+	if len(words) > 2 {
+		if streetType, ok := normalize.LookupStreetType(words[0] + " " + words[1]); ok {
+			parsed.StreetType = streetType
+			words = words[2:]
+		}
+	}
+	if streetType, ok := normalize.LookupStreetType(words[0]); ok && parsed.StreetType == "" && len(words) > 1 {
+		parsed.StreetType = streetType
+		words = words[1:]
+	}
+
+	for i := 0; i < len(words); i++ {
+		upper := strings.ToUpper(words[i])
+		switch {
+		case noNumberMarkers[upper]:
+			parsed.NoNumber = true
+		case numberMarkers[upper] && i+1 < len(words) && numberPattern.MatchString(words[i+1]):
+			parsed.Number = atoi(numberPattern.FindStringSubmatch(words[i+1])[1])
+		case markedNumberPattern.MatchString(words[i]):
+			parsed.Number = atoi(markedNumberPattern.FindStringSubmatch(words[i])[1])
+		case i > 0 && numberPattern.MatchString(words[i]) && endsStreet(words, i):
+			parsed.Number = atoi(numberPattern.FindStringSubmatch(words[i])[1])
+		default:
+			continue
+		}
+
+		parsed.StreetName = strings.Join(words[:i], " ")
+		skip := 1
+		if numberMarkers[upper] {
+			skip = 2
+		}
+		return parseTail(parsed, words[i+skip:])
+	}
+
+	parsed.StreetName = strings.Join(words, " ")
+	return ""
+}
+
+// endsStreet diz se o numero na posicao i encerra o nome da rua, e nao faz
+// parte dele como em "Rua 25 de Março"
+func endsStreet(words []string, i int) bool {
+	if i+1 == len(words) {
+		return true
+	}
+	return !connectors[strings.ToUpper(words[i+1])]
+}
+
+// parseTail separa complemento e bairro do que vem depois do numero. Palavras
+// antes de qualquer palavra-chave sao devolvidas, pois podem ser o bairro.
+func parseTail(parsed *models.ParsedAddress, words []string) string {
+	var plain []string
+	for i := 0; i < len(words); i++ {
+		upper := strings.ToUpper(words[i])
+		switch {
+		case upper == neighborhoodKeyword && i+1 < len(words):
+			parsed.Neighborhood = strings.Join(words[i+1:], " ")
+			return strings.Join(plain, " ")
+		case complementKeywords[upper]:
+			// O complemento vai ate o fim ou ate "bairro"
+			end := len(words)
+			for j := i + 1; j < len(words); j++ {
+				if strings.ToUpper(words[j]) == neighborhoodKeyword {
+					end = j
+					break
+				}
+			}
+			appendComplement(parsed, strings.Join(words[i:end], " "))
+			i = end - 1
+		default:
+			plain = append(plain, words[i])
+		}
+	}
+	return strings.Join(plain, " ")
+}
+
+// takeNumber reconhece uma parte que comeca com o numero: "123", "nº 123", "s/n"
+func takeNumber(parsed *models.ParsedAddress, words []string) bool {
+	upper := strings.ToUpper(words[0])
+	switch {
+	case noNumberMarkers[upper]:
+		parsed.NoNumber = true
+	case numberMarkers[upper] && len(words) > 1 && numberPattern.MatchString(words[1]):
+		parsed.Number = atoi(numberPattern.FindStringSubmatch(words[1])[1])
+	case markedNumberPattern.MatchString(words[0]):
+		parsed.Number = atoi(markedNumberPattern.FindStringSubmatch(words[0])[1])
+	case numberPattern.MatchString(words[0]):
+		parsed.Number = atoi(numberPattern.FindStringSubmatch(words[0])[1])
+	default:
+		return false
+	}
+	return true
+}
+
+// numberLength e quantas palavras o numero ocupou em takeNumber
+func numberLength(words []string) int {
+	if numberMarkers[strings.ToUpper(words[0])] {
+		return 2
+	}
+	return 1
+}
+
+func appendComplement(parsed *models.ParsedAddress, complement string) {
+	if parsed.Complement != "" {
+		parsed.Complement += ", "
+	}
+	parsed.Complement += complement
+}
+
+func isStreetType(word string) bool {
+	_, ok := normalize.LookupStreetType(word)
+	return ok
+}
+
+func atoi(digits string) int {
+	n, _ := strconv.Atoi(digits)
+	return n
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"address-api/internal/models"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want models.ParsedAddress
+	}{
+		{
+			"Rua das Flores 123 apto 4",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "das Flores", Number: 123, Complement: "apto 4"},
+		},
+		{
+			"R. das Flores, nº 123, apto 4 - Centro, São Carlos - SP, 13560-000",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "das Flores", Number: 123, Complement: "apto 4",
+				Neighborhood: "Centro", City: "São Carlos", State: "SP", CEP: "13560000"},
+		},
+		{
+			"Av São Carlos, 1500 - Vila Nery",
+			models.ParsedAddress{StreetType: "AVENIDA", StreetName: "São Carlos", Number: 1500, Neighborhood: "Vila Nery"},
+		},
+		{
+			"Rua XV de Novembro s/n",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "XV de Novembro", NoNumber: true},
+		},
+		{
+			"Rua 25 de Março 100 bloco B apto 12 bairro Centro",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "25 de Março", Number: 100,
+				Complement: "bloco B apto 12", Neighborhood: "Centro"},
+		},
+		{
+			"moro na rua das flores numero 45",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "das flores", Number: 45},
+		},
+		{
+			"Praça XV nº10, Centro, São Carlos/SP",
+			models.ParsedAddress{StreetType: "PRACA", StreetName: "XV", Number: 10, Neighborhood: "Centro",
+				City: "São Carlos", State: "SP"},
+		},
+		{
+			"Rua 7 de Setembro",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "7 de Setembro"},
+		},
+		{
+			"Alameda Santos 200 Jardim Paulista",
+			models.ParsedAddress{StreetType: "ALAMEDA", StreetName: "Santos", Number: 200, Neighborhood: "Jardim Paulista"},
+		},
+		{
+			"Rua Casa Verde, 10, casa 2",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "Casa Verde", Number: 10, Complement: "casa 2"},
+		},
+		{
+			"Est. Mun. Luiz Augusto, 3000",
+			models.ParsedAddress{StreetType: "ESTRADA MUNICIPAL", StreetName: "Luiz Augusto", Number: 3000},
+		},
+		{
+			"Rua Largo do Arouche 20",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "Largo do Arouche", Number: 20},
+		},
+		{
+			"Rua das Flores, 123, São Carlos, SP",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "das Flores", Number: 123, City: "São Carlos", State: "SP"},
+		},
+		{
+			"Rua das Flores 10, CEP: 13560-000",
+			models.ParsedAddress{StreetType: "RUA", StreetName: "das Flores", Number: 10, CEP: "13560000"},
+		},
+		{
+			"13560000",
+			models.ParsedAddress{CEP: "13560000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tt.want.Input = tt.text
+			if got := Parse(tt.text); got != tt.want {
+				t.Errorf("Parse(%q)\n got  %+v\n want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1533 @@
+package repository
+
+import (
+	"address-api/internal/geo"
+	"address-api/internal/models"
+	"address-api/internal/schedule"
+	"address-api/internal/utils"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryStore guarda UBS, times e segmentos em memoria. E usado nos testes
+// no lugar do Postgres e segue as mesmas regras de remocao logica.
+type MemoryStore struct {
+	mu       sync.Mutex
+	nextID   uint
+	ubs      map[uint]models.UBS
+	teams    map[uint]models.Team
+	segments map[uint]models.StreetSegment
+	ceps     map[string]models.CEPAddress
+	aliases  map[uint]models.StreetAlias
+	lookups  map[uint]models.AddressLookup
+	areas    map[uint]models.Territory
+	holidays map[uint]models.Holiday
+	closures map[uint]models.UBSClosure
+	staff    map[uint]models.Professional
+	members  map[uint]models.TeamMembership
+	micro    map[uint]models.MicroArea
+	history  map[uint]models.SegmentAssignment
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		ubs:      map[uint]models.UBS{},
+		teams:    map[uint]models.Team{},
+		segments: map[uint]models.StreetSegment{},
+		ceps:     map[string]models.CEPAddress{},
+		aliases:  map[uint]models.StreetAlias{},
+		lookups:  map[uint]models.AddressLookup{},
+		areas:    map[uint]models.Territory{},
+		holidays: map[uint]models.Holiday{},
+		closures: map[uint]models.UBSClosure{},
+		staff:    map[uint]models.Professional{},
+		members:  map[uint]models.TeamMembership{},
+		micro:    map[uint]models.MicroArea{},
+		history:  map[uint]models.SegmentAssignment{},
+	}
+}
+
+func (s *MemoryStore) UBS() UBSRepository    { return &memoryUBSRepository{s} }
+func (s *MemoryStore) Teams() TeamRepository { return &memoryTeamRepository{s} }
+func (s *MemoryStore) StreetSegments() StreetSegmentRepository {
+	return &memoryStreetSegmentRepository{s}
+}
+
+func (s *MemoryStore) CEPs() CEPRepository { return &memoryCEPRepository{s} }
+func (s *MemoryStore) StreetAliases() StreetAliasRepository {
+	return &memoryStreetAliasRepository{s}
+}
+func (s *MemoryStore) AddressLookups() AddressLookupRepository {
+	return &memoryAddressLookupRepository{s}
+}
+
+func (s *MemoryStore) Territories() TerritoryRepository {
+	return &memoryTerritoryRepository{s}
+}
+
+func (s *MemoryStore) Holidays() HolidayRepository {
+	return &memoryHolidayRepository{s}
+}
+
+func (s *MemoryStore) UBSClosures() UBSClosureRepository {
+	return &memoryUBSClosureRepository{s}
+}
+
+func (s *MemoryStore) Professionals() ProfessionalRepository {
+	return &memoryProfessionalRepository{s}
+}
+
+func (s *MemoryStore) MicroAreas() MicroAreaRepository {
+	return &memoryMicroAreaRepository{s}
+}
+
+func (s *MemoryStore) SegmentAssignments() SegmentAssignmentRepository {
+	return &memorySegmentAssignmentRepository{s}
+}
+
+func (s *MemoryStore) newID() uint {
+	s.nextID++
+	return s.nextID
+}
+
+func deletedAt(t time.Time) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: t, Valid: true}
+}
+
+func sortedKeys[T any](m map[uint]T) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// teamWithUBS preenche a UBS do time, mesmo que ela tenha sido apagada
+func (s *MemoryStore) teamWithUBS(team models.Team) models.Team {
+	team.UBS = s.ubs[team.UBSID]
+	return team
+}
+
+func (s *MemoryStore) territoryWithTeam(territory models.Territory) models.Territory {
+	territory.Team = s.teamWithUBS(s.teams[territory.TeamID])
+	return territory
+}
+
+func (s *MemoryStore) segmentWithTeam(segment models.StreetSegment) models.StreetSegment {
+	segment.Team = s.teamWithUBS(s.teams[segment.TeamID])
+	return segment
+}
+
+type memoryUBSRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryUBSRepository) Create(ubs *models.UBS) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	ubs.ID = r.s.newID()
+	ubs.CreatedAt, ubs.UpdatedAt = now, now
+	r.s.ubs[ubs.ID] = *ubs
+	return nil
+}
+
+func (r *memoryUBSRepository) List(deleted bool) ([]models.UBS, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ubsList := []models.UBS{}
+	for _, id := range sortedKeys(r.s.ubs) {
+		if ubs := r.s.ubs[id]; ubs.DeletedAt.Valid == deleted {
+			ubsList = append(ubsList, ubs)
+		}
+	}
+	return ubsList, nil
+}
+
+func (r *memoryUBSRepository) Get(id uint) (*models.UBS, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ubs, ok := r.s.ubs[id]
+	if !ok || ubs.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	for _, teamID := range sortedKeys(r.s.teams) {
+		if team := r.s.teams[teamID]; team.UBSID == id && !team.DeletedAt.Valid {
+			ubs.Teams = append(ubs.Teams, team)
+		}
+	}
+	return &ubs, nil
+}
+
+func (r *memoryUBSRepository) GetDeleted(id uint) (*models.UBS, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ubs, ok := r.s.ubs[id]
+	if !ok || !ubs.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return &ubs, nil
+}
+
+func (r *memoryUBSRepository) Update(ubs *models.UBS) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.ubs[ubs.ID]; !ok {
+		return ErrNotFound
+	}
+	ubs.UpdatedAt = time.Now()
+	stored := *ubs
+	stored.Teams = nil
+	r.s.ubs[ubs.ID] = stored
+	return nil
+}
+
+func (r *memoryUBSRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := deletedAt(time.Now())
+	for teamID, team := range r.s.teams {
+		if team.UBSID != id || team.DeletedAt.Valid {
+			continue
+		}
+		for segmentID, segment := range r.s.segments {
+			if segment.TeamID == teamID && !segment.DeletedAt.Valid {
+				segment.DeletedAt = now
+				r.s.segments[segmentID] = segment
+			}
+		}
+		team.DeletedAt = now
+		r.s.teams[teamID] = team
+	}
+	ubs := r.s.ubs[id]
+	ubs.DeletedAt = now
+	r.s.ubs[id] = ubs
+	return nil
+}
+
+func (r *memoryUBSRepository) Restore(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ubs, ok := r.s.ubs[id]
+	if !ok || !ubs.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	when := ubs.DeletedAt.Time
+	for teamID, team := range r.s.teams {
+		if team.UBSID != id || !team.DeletedAt.Valid || !team.DeletedAt.Time.Equal(when) {
+			continue
+		}
+		for segmentID, segment := range r.s.segments {
+			if segment.TeamID == teamID && segment.DeletedAt.Valid && segment.DeletedAt.Time.Equal(when) {
+				segment.DeletedAt = gorm.DeletedAt{}
+				r.s.segments[segmentID] = segment
+			}
+		}
+		team.DeletedAt = gorm.DeletedAt{}
+		r.s.teams[teamID] = team
+	}
+	ubs.DeletedAt = gorm.DeletedAt{}
+	r.s.ubs[id] = ubs
+	return nil
+}
+
+func (r *memoryUBSRepository) Nearest(point geo.Point, limit int) ([]models.NearbyUBS, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	nearby := []models.NearbyUBS{}
+	for _, id := range sortedKeys(r.s.ubs) {
+		ubs := r.s.ubs[id]
+		if ubs.DeletedAt.Valid || ubs.Latitude == nil || ubs.Longitude == nil {
+			continue
+		}
+		distance := geo.Distance(point, geo.Point{Lng: *ubs.Longitude, Lat: *ubs.Latitude})
+		nearby = append(nearby, models.NearbyUBS{UBS: ubs, DistanceMeters: distance})
+	}
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].DistanceMeters < nearby[j].DistanceMeters
+	})
+	if limit > 0 && len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby, nil
+}
+
+type memoryTeamRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryTeamRepository) Create(team *models.Team) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	team.ID = r.s.newID()
+	team.CreatedAt, team.UpdatedAt = now, now
+	team.UBS = models.UBS{}
+	r.s.teams[team.ID] = *team
+	*team = r.s.teamWithUBS(*team)
+	return nil
+}
+
+func (r *memoryTeamRepository) List(deleted bool) ([]models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	teams := []models.Team{}
+	for _, id := range sortedKeys(r.s.teams) {
+		if team := r.s.teams[id]; team.DeletedAt.Valid == deleted {
+			teams = append(teams, r.s.teamWithUBS(team))
+		}
+	}
+	return teams, nil
+}
+
+func (r *memoryTeamRepository) Get(id uint) (*models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	team, ok := r.s.teams[id]
+	if !ok || team.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	team = r.s.teamWithUBS(team)
+	return &team, nil
+}
+
+func (r *memoryTeamRepository) GetDeleted(id uint) (*models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	team, ok := r.s.teams[id]
+	if !ok || !team.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return &team, nil
+}
+
+func (r *memoryTeamRepository) Update(team *models.Team) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.teams[team.ID]; !ok {
+		return ErrNotFound
+	}
+	team.UpdatedAt = time.Now()
+	stored := *team
+	stored.UBS = models.UBS{}
+	r.s.teams[team.ID] = stored
+	return nil
+}
+
+func (r *memoryTeamRepository) CountByUBS(ubsID uint) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var count int64
+	for _, team := range r.s.teams {
+		if team.UBSID == ubsID && !team.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryTeamRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := deletedAt(time.Now())
+	for segmentID, segment := range r.s.segments {
+		if segment.TeamID == id && !segment.DeletedAt.Valid {
+			segment.DeletedAt = now
+			r.s.segments[segmentID] = segment
+		}
+	}
+	team := r.s.teams[id]
+	team.DeletedAt = now
+	r.s.teams[id] = team
+	return nil
+}
+
+func (r *memoryTeamRepository) Restore(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	team, ok := r.s.teams[id]
+	if !ok || !team.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	when := team.DeletedAt.Time
+	for segmentID, segment := range r.s.segments {
+		if segment.TeamID == id && segment.DeletedAt.Valid && segment.DeletedAt.Time.Equal(when) {
+			segment.DeletedAt = gorm.DeletedAt{}
+			r.s.segments[segmentID] = segment
+		}
+	}
+	team.DeletedAt = gorm.DeletedAt{}
+	r.s.teams[id] = team
+	return nil
+}
+
+type memoryStreetSegmentRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryStreetSegmentRepository) Create(segment *models.StreetSegment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	segment.ID = r.s.newID()
+	segment.CreatedAt, segment.UpdatedAt = now, now
+	segment.Team = models.Team{}
+	r.s.segments[segment.ID] = *segment
+	r.s.assignInitial(*segment, now)
+	return nil
+}
+
+func (r *memoryStreetSegmentRepository) CreateBatch(segments []models.StreetSegment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for i := range segments {
+		segments[i].ID = r.s.newID()
+		segments[i].CreatedAt, segments[i].UpdatedAt = now, now
+		segments[i].Team = models.Team{}
+		r.s.segments[segments[i].ID] = segments[i]
+		r.s.assignInitial(segments[i], now)
+	}
+	return nil
+}
+
+// assignInitial abre o historico do segmento recem-criado
+func (s *MemoryStore) assignInitial(segment models.StreetSegment, now time.Time) {
+	id := s.newID()
+	applied := now
+	s.history[id] = models.SegmentAssignment{
+		ID:              id,
+		StreetSegmentID: segment.ID,
+		TeamID:          segment.TeamID,
+		ValidFrom:       schedule.DateOf(now),
+		AppliedAt:       &applied,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func (r *memoryStreetSegmentRepository) List(deleted bool) ([]models.StreetSegment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	segments := []models.StreetSegment{}
+	for _, id := range sortedKeys(r.s.segments) {
+		if segment := r.s.segments[id]; segment.DeletedAt.Valid == deleted {
+			segments = append(segments, r.s.segmentWithTeam(segment))
+		}
+	}
+	return segments, nil
+}
+
+func (r *memoryStreetSegmentRepository) Get(id uint) (*models.StreetSegment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	segment, ok := r.s.segments[id]
+	if !ok || segment.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	segment = r.s.segmentWithTeam(segment)
+	return &segment, nil
+}
+
+func (r *memoryStreetSegmentRepository) GetDeleted(id uint) (*models.StreetSegment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	segment, ok := r.s.segments[id]
+	if !ok || !segment.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return &segment, nil
+}
+
+func (r *memoryStreetSegmentRepository) Update(segment *models.StreetSegment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.segments[segment.ID]; !ok {
+		return ErrNotFound
+	}
+	segment.UpdatedAt = time.Now()
+	stored := *segment
+	stored.Team = models.Team{}
+	r.s.segments[segment.ID] = stored
+	return nil
+}
+
+func (r *memoryStreetSegmentRepository) UpdateAndReassign(segment *models.StreetSegment, assignment *models.SegmentAssignment, now time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.segments[segment.ID]; !ok {
+		return ErrNotFound
+	}
+	segment.UpdatedAt = now
+	stored := *segment
+	stored.Team = models.Team{}
+	r.s.segments[segment.ID] = stored
+	r.s.reassign(assignment, now)
+	return nil
+}
+
+func (r *memoryStreetSegmentRepository) CountByTeam(teamID uint) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var count int64
+	for _, segment := range r.s.segments {
+		if segment.TeamID == teamID && !segment.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryStreetSegmentRepository) ListByTeams(teamIDs []uint) ([]models.StreetSegment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wanted := map[uint]bool{}
+	for _, id := range teamIDs {
+		wanted[id] = true
+	}
+
+	segments := []models.StreetSegment{}
+	for _, id := range sortedKeys(r.s.segments) {
+		if segment := r.s.segments[id]; wanted[segment.TeamID] && !segment.DeletedAt.Valid {
+			segments = append(segments, r.s.segmentWithTeam(segment))
+		}
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		if segments[i].StreetName != segments[j].StreetName {
+			return segments[i].StreetName < segments[j].StreetName
+		}
+		return segments[i].StartNumber < segments[j].StartNumber
+	})
+	return segments, nil
+}
+
+func (r *memoryStreetSegmentRepository) ListByStreet(street, city, state string) ([]models.StreetSegment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	segments := []models.StreetSegment{}
+	for _, id := range sortedKeys(r.s.segments) {
+		segment := r.s.segments[id]
+		if segment.DeletedAt.Valid || segment.StreetName != street || segment.City != city || segment.State != state {
+			continue
+		}
+		segments = append(segments, r.s.segmentWithTeam(segment))
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].StartNumber < segments[j].StartNumber
+	})
+	return segments, nil
+}
+
+func (r *memoryStreetSegmentRepository) ListByStreets(queries []AddressQuery) ([][]models.StreetSegment, error) {
+	results := make([][]models.StreetSegment, len(queries))
+	for i, query := range queries {
+		segments, err := r.ListByStreet(query.Street, query.City, query.State)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = segments
+	}
+	return results, nil
+}
+
+func (r *memoryStreetSegmentRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	segment, ok := r.s.segments[id]
+	if !ok || segment.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	segment.DeletedAt = deletedAt(time.Now())
+	r.s.segments[id] = segment
+	return nil
+}
+
+func (r *memoryStreetSegmentRepository) Restore(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	segment, ok := r.s.segments[id]
+	if !ok || !segment.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	segment.DeletedAt = gorm.DeletedAt{}
+	r.s.segments[id] = segment
+	return nil
+}
+
+// SearchBatch usa a mesma similaridade de trigramas do pg_trgm e percorre os
+// segmentos uma vez para todos os enderecos, como o join com a lista VALUES
+// no Postgres
+func (r *memoryStreetSegmentRepository) SearchBatch(queries []AddressQuery) ([][]SearchResult, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	results := make([][]SearchResult, len(queries))
+	for _, id := range sortedKeys(r.s.segments) {
+		segment := r.s.segments[id]
+		if segment.DeletedAt.Valid {
+			continue
+		}
+		for i, query := range queries {
+			if segment.City != query.City || segment.State != query.State || !segmentCovers(segment, query.Number) {
+				continue
+			}
+			similarity := utils.Similarity(segment.StreetName, query.Street)
+			if similarity <= searchThreshold {
+				continue
+			}
+			results[i] = append(results[i], SearchResult{Segment: r.s.segmentWithTeam(segment), Similarity: similarity})
+		}
+	}
+	sortResults(results)
+	return results, nil
+}
+
+// segmentCovers diz se a faixa e a paridade do segmento contem o numero
+func segmentCovers(segment models.StreetSegment, number int) bool {
+	return number >= segment.StartNumber && number <= segment.EndNumber && utils.ValidateEvenOdd(number, segment.EvenOdd)
+}
+
+// sortResults ordena os resultados de cada endereco do mais parecido para o
+// menos parecido, e pelo id do segmento no empate
+func sortResults(results [][]SearchResult) {
+	for _, list := range results {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Similarity != list[j].Similarity {
+				return list[i].Similarity > list[j].Similarity
+			}
+			return list[i].Segment.ID < list[j].Segment.ID
+		})
+	}
+}
+
+type memoryStreetAliasRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryStreetAliasRepository) Create(alias *models.StreetAlias) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	alias.ID = r.s.newID()
+	alias.CreatedAt, alias.UpdatedAt = now, now
+	r.s.aliases[alias.ID] = *alias
+	return nil
+}
+
+func (r *memoryStreetAliasRepository) Get(id uint) (*models.StreetAlias, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	alias, ok := r.s.aliases[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &alias, nil
+}
+
+func (r *memoryStreetAliasRepository) ListByStreet(street, city, state string) ([]models.StreetAlias, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var aliases []models.StreetAlias
+	for _, id := range sortedKeys(r.s.aliases) {
+		alias := r.s.aliases[id]
+		linked := r.s.segments[alias.StreetSegmentID]
+		if linked.StreetName == street && linked.City == city && linked.State == state {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.SliceStable(aliases, func(i, j int) bool { return aliases[i].Alias < aliases[j].Alias })
+	return aliases, nil
+}
+
+func (r *memoryStreetAliasRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.aliases[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.s.aliases, id)
+	return nil
+}
+
+// SearchBatch compara a rua de cada endereco com todos os aliases de uma vez,
+// guardando por endereco o alias mais parecido de cada segmento
+func (r *memoryStreetAliasRepository) SearchBatch(queries []AddressQuery) ([][]SearchResult, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	best := make([]map[uint]SearchResult, len(queries))
+	for i := range best {
+		best[i] = map[uint]SearchResult{}
+	}
+	for _, aliasID := range sortedKeys(r.s.aliases) {
+		alias := r.s.aliases[aliasID]
+		linked := r.s.segments[alias.StreetSegmentID]
+		for _, id := range sortedKeys(r.s.segments) {
+			segment := r.s.segments[id]
+			if segment.DeletedAt.Valid || segment.StreetName != linked.StreetName ||
+				segment.City != linked.City || segment.State != linked.State {
+				continue
+			}
+			for i, query := range queries {
+				if segment.City != query.City || segment.State != query.State || !segmentCovers(segment, query.Number) {
+					continue
+				}
+				similarity := utils.Similarity(alias.Alias, query.Street)
+				if similarity <= searchThreshold {
+					continue
+				}
+				if current, ok := best[i][id]; !ok || similarity > current.Similarity {
+					alias := alias
+					best[i][id] = SearchResult{Segment: r.s.segmentWithTeam(segment), Similarity: similarity, Alias: &alias}
+				}
+			}
+		}
+	}
+
+	results := make([][]SearchResult, len(queries))
+	for i := range best {
+		for _, id := range sortedKeys(best[i]) {
+			results[i] = append(results[i], best[i][id])
+		}
+	}
+	sortResults(results)
+	return results, nil
+}
+
+type memoryAddressLookupRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryAddressLookupRepository) Create(lookup *models.AddressLookup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	lookup.ID = r.s.newID()
+	lookup.CreatedAt = time.Now()
+	r.s.lookups[lookup.ID] = *lookup
+	return nil
+}
+
+func (r *memoryAddressLookupRepository) CreateBatch(lookups []models.AddressLookup) error {
+	for i := range lookups {
+		if err := r.Create(&lookups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryAddressLookupRepository) List(resolved bool) ([]models.AddressLookup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var lookups []models.AddressLookup
+	keys := sortedKeys(r.s.lookups)
+	for i := len(keys) - 1; i >= 0; i-- {
+		lookup := r.s.lookups[keys[i]]
+		if (lookup.ResolvedAt != nil) == resolved {
+			lookups = append(lookups, lookup)
+		}
+	}
+	return lookups, nil
+}
+
+func (r *memoryAddressLookupRepository) Get(id uint) (*models.AddressLookup, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	lookup, ok := r.s.lookups[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &lookup, nil
+}
+
+func (r *memoryAddressLookupRepository) Update(lookup *models.AddressLookup) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.lookups[lookup.ID]; !ok {
+		return ErrNotFound
+	}
+	r.s.lookups[lookup.ID] = *lookup
+	return nil
+}
+
+type memoryTerritoryRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryTerritoryRepository) Create(territory *models.Territory) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	territory.ID = r.s.newID()
+	territory.CreatedAt, territory.UpdatedAt = now, now
+	territory.Team = models.Team{}
+	r.s.areas[territory.ID] = *territory
+	return nil
+}
+
+func (r *memoryTerritoryRepository) List(teamID uint) ([]models.Territory, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var territories []models.Territory
+	for _, id := range sortedKeys(r.s.areas) {
+		territory := r.s.areas[id]
+		if teamID != 0 && territory.TeamID != teamID {
+			continue
+		}
+		if team, ok := r.s.teams[territory.TeamID]; ok && !team.DeletedAt.Valid {
+			territories = append(territories, r.s.territoryWithTeam(territory))
+		}
+	}
+	sort.SliceStable(territories, func(i, j int) bool { return territories[i].TeamID < territories[j].TeamID })
+	return territories, nil
+}
+
+func (r *memoryTerritoryRepository) Get(id uint) (*models.Territory, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	territory, ok := r.s.areas[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	territory = r.s.territoryWithTeam(territory)
+	return &territory, nil
+}
+
+func (r *memoryTerritoryRepository) Update(territory *models.Territory) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	existing, ok := r.s.areas[territory.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	territory.CreatedAt = existing.CreatedAt
+	territory.UpdatedAt = time.Now()
+	stored := *territory
+	stored.Team = models.Team{}
+	r.s.areas[territory.ID] = stored
+	return nil
+}
+
+func (r *memoryTerritoryRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.areas[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.s.areas, id)
+	return nil
+}
+
+func (r *memoryTerritoryRepository) Locate(point geo.Point) ([]models.Territory, error) {
+	territories, err := r.List(0)
+	if err != nil {
+		return nil, err
+	}
+
+	type located struct {
+		territory models.Territory
+		area      float64
+	}
+	var found []located
+	for _, territory := range territories {
+		data, err := json.Marshal(territory.Geometry)
+		if err != nil {
+			return nil, err
+		}
+		shape, err := geo.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if shape.Contains(point) {
+			found = append(found, located{territory, shape.Area()})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].area < found[j].area })
+
+	result := make([]models.Territory, len(found))
+	for i, f := range found {
+		result[i] = f.territory
+	}
+	return result, nil
+}
+
+type memoryCEPRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryCEPRepository) Get(cep string) (*models.CEPAddress, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	address, ok := r.s.ceps[cep]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &address, nil
+}
+
+func (r *memoryCEPRepository) GetMany(ceps []string) (map[string]models.CEPAddress, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	byCEP := map[string]models.CEPAddress{}
+	for _, cep := range ceps {
+		if address, ok := r.s.ceps[cep]; ok {
+			byCEP[cep] = address
+		}
+	}
+	return byCEP, nil
+}
+
+func (r *memoryCEPRepository) Upsert(addresses []models.CEPAddress) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for _, address := range addresses {
+		address.CreatedAt, address.UpdatedAt = now, now
+		if existing, ok := r.s.ceps[address.CEP]; ok {
+			address.CreatedAt = existing.CreatedAt
+		}
+		r.s.ceps[address.CEP] = address
+	}
+	return nil
+}
+
+type memoryHolidayRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryHolidayRepository) Create(holiday *models.Holiday) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	holiday.ID = r.s.newID()
+	holiday.CreatedAt = time.Now()
+	r.s.holidays[holiday.ID] = *holiday
+	return nil
+}
+
+func (r *memoryHolidayRepository) List(year int) ([]models.Holiday, error) {
+	prefix := ""
+	if year != 0 {
+		prefix = fmt.Sprintf("%04d-", year)
+	}
+	return r.filter(func(h models.Holiday) bool { return strings.HasPrefix(string(h.Date), prefix) }), nil
+}
+
+func (r *memoryHolidayRepository) Between(from, to models.Date) ([]models.Holiday, error) {
+	return r.filter(func(h models.Holiday) bool { return h.Date >= from && h.Date <= to }), nil
+}
+
+func (r *memoryHolidayRepository) filter(keep func(models.Holiday) bool) []models.Holiday {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	holidays := []models.Holiday{}
+	for _, id := range sortedKeys(r.s.holidays) {
+		if holiday := r.s.holidays[id]; keep(holiday) {
+			holidays = append(holidays, holiday)
+		}
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays
+}
+
+func (r *memoryHolidayRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.holidays[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.s.holidays, id)
+	return nil
+}
+
+func (r *memoryHolidayRepository) Upsert(holidays []models.Holiday) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for i := range holidays {
+		holiday := &holidays[i]
+		for id, existing := range r.s.holidays {
+			if existing.Date == holiday.Date && existing.Scope == holiday.Scope && existing.State == holiday.State && existing.City == holiday.City {
+				holiday.ID, holiday.CreatedAt = id, existing.CreatedAt
+				break
+			}
+		}
+		if holiday.ID == 0 {
+			holiday.ID = r.s.newID()
+			holiday.CreatedAt = time.Now()
+		}
+		r.s.holidays[holiday.ID] = *holiday
+	}
+	return nil
+}
+
+type memoryUBSClosureRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryUBSClosureRepository) Create(closure *models.UBSClosure) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	closure.ID = r.s.newID()
+	closure.CreatedAt = time.Now()
+	r.s.closures[closure.ID] = *closure
+	return nil
+}
+
+func (r *memoryUBSClosureRepository) List(ubsID uint) ([]models.UBSClosure, error) {
+	return r.filter(func(c models.UBSClosure) bool { return c.UBSID == ubsID }), nil
+}
+
+func (r *memoryUBSClosureRepository) Between(from, to models.Date) ([]models.UBSClosure, error) {
+	return r.filter(func(c models.UBSClosure) bool { return c.StartDate <= to && c.EndDate >= from }), nil
+}
+
+func (r *memoryUBSClosureRepository) filter(keep func(models.UBSClosure) bool) []models.UBSClosure {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	closures := []models.UBSClosure{}
+	for _, id := range sortedKeys(r.s.closures) {
+		if closure := r.s.closures[id]; keep(closure) {
+			closures = append(closures, closure)
+		}
+	}
+	sort.SliceStable(closures, func(i, j int) bool { return closures[i].StartDate < closures[j].StartDate })
+	return closures
+}
+
+func (r *memoryUBSClosureRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.closures[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.s.closures, id)
+	return nil
+}
+
+type memoryProfessionalRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryProfessionalRepository) Create(professional *models.Professional) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	professional.ID = r.s.newID()
+	professional.CreatedAt, professional.UpdatedAt = now, now
+	stored := *professional
+	stored.Memberships = nil
+	r.s.staff[professional.ID] = stored
+	return nil
+}
+
+func (r *memoryProfessionalRepository) List(role string) ([]models.Professional, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	professionals := []models.Professional{}
+	for _, id := range sortedKeys(r.s.staff) {
+		professional := r.s.staff[id]
+		if !professional.DeletedAt.Valid && (role == "" || professional.Role == role) {
+			professionals = append(professionals, professional)
+		}
+	}
+	sort.SliceStable(professionals, func(i, j int) bool { return professionals[i].Name < professionals[j].Name })
+	return professionals, nil
+}
+
+func (r *memoryProfessionalRepository) Get(id uint) (*models.Professional, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	professional, ok := r.s.staff[id]
+	if !ok || professional.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	for _, membershipID := range sortedKeys(r.s.members) {
+		if membership := r.s.members[membershipID]; membership.ProfessionalID == id {
+			team := r.s.teamWithUBS(r.s.teams[membership.TeamID])
+			membership.Team = &team
+			professional.Memberships = append(professional.Memberships, membership)
+		}
+	}
+	sort.SliceStable(professional.Memberships, func(i, j int) bool {
+		return professional.Memberships[i].StartDate > professional.Memberships[j].StartDate
+	})
+	return &professional, nil
+}
+
+func (r *memoryProfessionalRepository) Update(professional *models.Professional) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.staff[professional.ID]; !ok {
+		return ErrNotFound
+	}
+	professional.UpdatedAt = time.Now()
+	stored := *professional
+	stored.Memberships = nil
+	r.s.staff[professional.ID] = stored
+	return nil
+}
+
+func (r *memoryProfessionalRepository) Delete(id uint, endDate models.Date) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	professional, ok := r.s.staff[id]
+	if !ok || professional.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	for membershipID, membership := range r.s.members {
+		if membership.ProfessionalID == id && membership.EndDate == nil {
+			end := endDate
+			membership.EndDate = &end
+			r.s.members[membershipID] = membership
+		}
+	}
+	professional.DeletedAt = deletedAt(time.Now())
+	r.s.staff[id] = professional
+	return nil
+}
+
+func (r *memoryProfessionalRepository) AddMembership(membership *models.TeamMembership) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	membership.ID = r.s.newID()
+	membership.CreatedAt, membership.UpdatedAt = now, now
+	stored := *membership
+	stored.Team, stored.Professional = nil, nil
+	r.s.members[membership.ID] = stored
+	return nil
+}
+
+func (r *memoryProfessionalRepository) GetMembership(id uint) (*models.TeamMembership, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	membership, ok := r.s.members[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	professional := r.s.staff[membership.ProfessionalID]
+	membership.Professional = &professional
+	return &membership, nil
+}
+
+func (r *memoryProfessionalRepository) UpdateMembership(membership *models.TeamMembership) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.members[membership.ID]; !ok {
+		return ErrNotFound
+	}
+	membership.UpdatedAt = time.Now()
+	stored := *membership
+	stored.Team, stored.Professional = nil, nil
+	r.s.members[membership.ID] = stored
+	return nil
+}
+
+func (r *memoryProfessionalRepository) Memberships(teamID uint, history bool) ([]models.TeamMembership, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	memberships := []models.TeamMembership{}
+	for _, id := range sortedKeys(r.s.members) {
+		membership := r.s.members[id]
+		if membership.TeamID != teamID || (!history && membership.EndDate != nil) {
+			continue
+		}
+		professional := r.s.staff[membership.ProfessionalID]
+		membership.Professional = &professional
+		memberships = append(memberships, membership)
+	}
+	sort.SliceStable(memberships, func(i, j int) bool { return memberships[i].StartDate < memberships[j].StartDate })
+	return memberships, nil
+}
+
+type memoryMicroAreaRepository struct {
+	s *MemoryStore
+}
+
+// withAgent preenche o ACS da micro-area, mesmo que ele tenha sido removido
+func (r *memoryMicroAreaRepository) withAgent(area models.MicroArea) models.MicroArea {
+	if area.AgentID != nil {
+		if agent, ok := r.s.staff[*area.AgentID]; ok {
+			area.Agent = &agent
+		}
+	}
+	return area
+}
+
+func (r *memoryMicroAreaRepository) Create(area *models.MicroArea) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	area.ID = r.s.newID()
+	area.CreatedAt, area.UpdatedAt = now, now
+	stored := *area
+	stored.Team, stored.Agent, stored.Segments = nil, nil, nil
+	r.s.micro[area.ID] = stored
+	return nil
+}
+
+func (r *memoryMicroAreaRepository) List(teamID uint) ([]models.MicroArea, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	areas := []models.MicroArea{}
+	for _, id := range sortedKeys(r.s.micro) {
+		if area := r.s.micro[id]; area.TeamID == teamID {
+			areas = append(areas, r.withAgent(area))
+		}
+	}
+	sort.SliceStable(areas, func(i, j int) bool { return areas[i].Code < areas[j].Code })
+	return areas, nil
+}
+
+func (r *memoryMicroAreaRepository) Get(id uint) (*models.MicroArea, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	area, ok := r.s.micro[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	area = r.withAgent(area)
+	team := r.s.teamWithUBS(r.s.teams[area.TeamID])
+	area.Team = &team
+	for _, segmentID := range sortedKeys(r.s.segments) {
+		segment := r.s.segments[segmentID]
+		if segment.MicroAreaID != nil && *segment.MicroAreaID == id && !segment.DeletedAt.Valid {
+			area.Segments = append(area.Segments, segment)
+		}
+	}
+	sort.SliceStable(area.Segments, func(i, j int) bool {
+		if area.Segments[i].StreetName != area.Segments[j].StreetName {
+			return area.Segments[i].StreetName < area.Segments[j].StreetName
+		}
+		return area.Segments[i].StartNumber < area.Segments[j].StartNumber
+	})
+	return &area, nil
+}
+
+func (r *memoryMicroAreaRepository) Update(area *models.MicroArea) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.micro[area.ID]; !ok {
+		return ErrNotFound
+	}
+	area.UpdatedAt = time.Now()
+	stored := *area
+	stored.Team, stored.Agent, stored.Segments = nil, nil, nil
+	r.s.micro[area.ID] = stored
+	return nil
+}
+
+func (r *memoryMicroAreaRepository) Delete(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.micro[id]; !ok {
+		return ErrNotFound
+	}
+	for segmentID, segment := range r.s.segments {
+		if segment.MicroAreaID != nil && *segment.MicroAreaID == id {
+			segment.MicroAreaID = nil
+			r.s.segments[segmentID] = segment
+		}
+	}
+	delete(r.s.micro, id)
+	return nil
+}
+
+func (r *memoryMicroAreaRepository) AssignSegments(id uint, segmentIDs []uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wanted := map[uint]bool{}
+	for _, segmentID := range segmentIDs {
+		wanted[segmentID] = true
+	}
+	for segmentID, segment := range r.s.segments {
+		if segment.DeletedAt.Valid {
+			continue
+		}
+		switch {
+		case wanted[segmentID]:
+			areaID := id
+			segment.MicroAreaID = &areaID
+		case segment.MicroAreaID != nil && *segment.MicroAreaID == id:
+			segment.MicroAreaID = nil
+		default:
+			continue
+		}
+		r.s.segments[segmentID] = segment
+	}
+	return nil
+}
+
+type memorySegmentAssignmentRepository struct {
+	s *MemoryStore
+}
+
+func (r *memorySegmentAssignmentRepository) withTeams(assignment models.SegmentAssignment) models.SegmentAssignment {
+	team := r.s.teamWithUBS(r.s.teams[assignment.TeamID])
+	assignment.Team = &team
+	if assignment.PreviousTeamID != nil {
+		previous := r.s.teams[*assignment.PreviousTeamID]
+		assignment.PreviousTeam = &previous
+	}
+	return assignment
+}
+
+func (r *memorySegmentAssignmentRepository) withSegment(assignment models.SegmentAssignment) models.SegmentAssignment {
+	segment := r.s.segments[assignment.StreetSegmentID]
+	assignment.StreetSegment = &segment
+	return r.withTeams(assignment)
+}
+
+// filter devolve as atribuicoes em ordem de inicio
+func (r *memorySegmentAssignmentRepository) filter(keep func(models.SegmentAssignment) bool) []models.SegmentAssignment {
+	assignments := []models.SegmentAssignment{}
+	for _, id := range sortedKeys(r.s.history) {
+		if assignment := r.s.history[id]; keep(assignment) {
+			assignments = append(assignments, assignment)
+		}
+	}
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].ValidFrom < assignments[j].ValidFrom })
+	return assignments
+}
+
+func (r *memorySegmentAssignmentRepository) List(segmentID uint) ([]models.SegmentAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	assignments := r.filter(func(a models.SegmentAssignment) bool { return a.StreetSegmentID == segmentID })
+	for i := range assignments {
+		assignments[i] = r.withTeams(assignments[i])
+	}
+	return assignments, nil
+}
+
+func (r *memorySegmentAssignmentRepository) Get(id uint) (*models.SegmentAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	assignment, ok := r.s.history[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	assignment = r.withTeams(assignment)
+	return &assignment, nil
+}
+
+func (r *memorySegmentAssignmentRepository) At(segmentIDs []uint, date models.Date) (map[uint]models.SegmentAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wanted := map[uint]bool{}
+	for _, id := range segmentIDs {
+		wanted[id] = true
+	}
+	// Num dia com mais de uma mudanca vale a ultima
+	bySegment := map[uint]models.SegmentAssignment{}
+	for _, assignment := range r.filter(func(a models.SegmentAssignment) bool { return wanted[a.StreetSegmentID] }) {
+		if assignment.ValidFrom <= date && (assignment.ValidTo == nil || *assignment.ValidTo >= date) {
+			bySegment[assignment.StreetSegmentID] = r.withTeams(assignment)
+		}
+	}
+	return bySegment, nil
+}
+
+func (r *memorySegmentAssignmentRepository) Reassign(assignment *models.SegmentAssignment, now time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.reassign(assignment, now)
+	return nil
+}
+
+// reassign e Reassign com o lock ja obtido
+func (s *MemoryStore) reassign(assignment *models.SegmentAssignment, now time.Time) {
+	r := &memorySegmentAssignmentRepository{s}
+	open := r.filter(func(a models.SegmentAssignment) bool {
+		return a.StreetSegmentID == assignment.StreetSegmentID && a.ValidTo == nil
+	})
+	if n := len(open); n > 0 {
+		current := open[n-1]
+		if current.ValidFrom == assignment.ValidFrom && current.AppliedAt == nil {
+			assignment.PreviousTeamID = current.PreviousTeamID
+			delete(s.history, current.ID)
+		} else {
+			previousTeam := current.TeamID
+			assignment.PreviousTeamID = &previousTeam
+			validTo := schedule.AddDays(assignment.ValidFrom, -1)
+			if current.ValidFrom == assignment.ValidFrom {
+				validTo = current.ValidFrom
+			}
+			current.ValidTo = &validTo
+			s.history[current.ID] = current
+		}
+	}
+
+	if assignment.ValidFrom <= schedule.DateOf(now) {
+		applied := now
+		assignment.AppliedAt = &applied
+		r.moveSegment(assignment.StreetSegmentID, assignment.TeamID)
+	}
+	assignment.ID = s.newID()
+	assignment.CreatedAt, assignment.UpdatedAt = now, now
+	stored := *assignment
+	stored.StreetSegment, stored.Team, stored.PreviousTeam = nil, nil, nil
+	s.history[assignment.ID] = stored
+}
+
+func (r *memorySegmentAssignmentRepository) moveSegment(segmentID, teamID uint) {
+	segment, ok := r.s.segments[segmentID]
+	if !ok || segment.DeletedAt.Valid || segment.TeamID == teamID {
+		return
+	}
+	segment.TeamID = teamID
+	segment.MicroAreaID = nil
+	r.s.segments[segmentID] = segment
+}
+
+func (r *memorySegmentAssignmentRepository) Scheduled() ([]models.SegmentAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	assignments := r.filter(func(a models.SegmentAssignment) bool { return a.AppliedAt == nil })
+	for i := range assignments {
+		assignments[i] = r.withSegment(assignments[i])
+	}
+	return assignments, nil
+}
+
+func (r *memorySegmentAssignmentRepository) ApplyDue(today models.Date) ([]models.SegmentAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	due := r.filter(func(a models.SegmentAssignment) bool {
+		team, ok := r.s.teams[a.TeamID]
+		return a.AppliedAt == nil && a.ValidFrom <= today && ok && !team.DeletedAt.Valid
+	})
+	now := time.Now()
+	for i := range due {
+		r.moveSegment(due[i].StreetSegmentID, due[i].TeamID)
+		applied := now
+		due[i].AppliedAt = &applied
+		r.s.history[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (r *memorySegmentAssignmentRepository) Cancel(id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	assignment, ok := r.s.history[id]
+	if !ok || assignment.AppliedAt != nil {
+		return ErrNotFound
+	}
+	eve := schedule.AddDays(assignment.ValidFrom, -1)
+	for otherID, other := range r.s.history {
+		if other.StreetSegmentID == assignment.StreetSegmentID && other.ValidTo != nil && *other.ValidTo == eve {
+			other.ValidTo = nil
+			r.s.history[otherID] = other
+		}
+	}
+	delete(r.s.history, id)
+	return nil
+}
+
+func (r *memorySegmentAssignmentRepository) Unnotified() ([]models.SegmentAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	assignments := r.filter(func(a models.SegmentAssignment) bool {
+		return a.AppliedAt != nil && a.NotifiedAt == nil && a.PreviousTeamID != nil
+	})
+	for i := range assignments {
+		assignments[i] = r.withSegment(assignments[i])
+	}
+	return assignments, nil
+}
+
+func (r *memorySegmentAssignmentRepository) MarkNotified(id uint, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	assignment, ok := r.s.history[id]
+	if !ok || assignment.AppliedAt == nil {
+		return ErrNotFound
+	}
+	assignment.NotifiedAt = &at
+	r.s.history[id] = assignment
+	return nil
+}
@@ -0,0 +1,1166 @@
+package repository
+
+import (
+	"address-api/internal/geo"
+	"address-api/internal/models"
+	"address-api/internal/schedule"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func unscoped(tx *gorm.DB) *gorm.DB {
+	return tx.Unscoped()
+}
+
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// postgresStore cria os repositorios sobre a mesma conexao
+type postgresStore struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) UBS() UBSRepository    { return NewUBSRepository(s.db) }
+func (s *postgresStore) Teams() TeamRepository { return NewTeamRepository(s.db) }
+func (s *postgresStore) StreetSegments() StreetSegmentRepository {
+	return NewStreetSegmentRepository(s.db)
+}
+func (s *postgresStore) CEPs() CEPRepository                  { return NewCEPRepository(s.db) }
+func (s *postgresStore) StreetAliases() StreetAliasRepository { return NewStreetAliasRepository(s.db) }
+func (s *postgresStore) AddressLookups() AddressLookupRepository {
+	return NewAddressLookupRepository(s.db)
+}
+func (s *postgresStore) Territories() TerritoryRepository  { return NewTerritoryRepository(s.db) }
+func (s *postgresStore) Holidays() HolidayRepository       { return NewHolidayRepository(s.db) }
+func (s *postgresStore) UBSClosures() UBSClosureRepository { return NewUBSClosureRepository(s.db) }
+func (s *postgresStore) Professionals() ProfessionalRepository {
+	return NewProfessionalRepository(s.db)
+}
+func (s *postgresStore) MicroAreas() MicroAreaRepository { return NewMicroAreaRepository(s.db) }
+func (s *postgresStore) SegmentAssignments() SegmentAssignmentRepository {
+	return NewSegmentAssignmentRepository(s.db)
+}
+
+type postgresUBSRepository struct {
+	db *gorm.DB
+}
+
+func NewUBSRepository(db *gorm.DB) UBSRepository {
+	return &postgresUBSRepository{db: db}
+}
+
+func (r *postgresUBSRepository) Create(ubs *models.UBS) error {
+	return r.db.Create(ubs).Error
+}
+
+func (r *postgresUBSRepository) List(deleted bool) ([]models.UBS, error) {
+	db := r.db
+	if deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var ubsList []models.UBS
+	err := db.Find(&ubsList).Error
+	return ubsList, err
+}
+
+func (r *postgresUBSRepository) Get(id uint) (*models.UBS, error) {
+	var ubs models.UBS
+	if err := r.db.Preload("Teams").First(&ubs, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &ubs, nil
+}
+
+func (r *postgresUBSRepository) GetDeleted(id uint) (*models.UBS, error) {
+	var ubs models.UBS
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&ubs, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &ubs, nil
+}
+
+func (r *postgresUBSRepository) Update(ubs *models.UBS) error {
+	return r.db.Omit("Teams").Save(ubs).Error
+}
+
+func (r *postgresUBSRepository) Delete(id uint) error {
+	// Todos os registros removidos juntos recebem o mesmo deleted_at, o que
+	// permite restaura-los juntos depois
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		teamIDs := tx.Model(&models.Team{}).Select("id").Where("ubs_id = ?", id)
+		if err := tx.Model(&models.StreetSegment{}).Where("team_id IN (?)", teamIDs).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Team{}).Where("ubs_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UBS{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
+}
+
+func (r *postgresUBSRepository) Restore(id uint) error {
+	ubs, err := r.GetDeleted(id)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := ubs.DeletedAt.Time
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		teamIDs := tx.Unscoped().Model(&models.Team{}).Select("id").Where("ubs_id = ? AND deleted_at = ?", id, deletedAt)
+		if err := tx.Unscoped().Model(&models.StreetSegment{}).
+			Where("team_id IN (?) AND deleted_at = ?", teamIDs, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Team{}).
+			Where("ubs_id = ? AND deleted_at = ?", id, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.UBS{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+}
+
+func (r *postgresUBSRepository) Nearest(point geo.Point, limit int) ([]models.NearbyUBS, error) {
+	var rows []struct {
+		ID       uint
+		Distance float64
+	}
+	query := r.db.Model(&models.UBS{}).
+		Select("id, ST_DistanceSphere(ST_MakePoint(longitude, latitude), ST_MakePoint(?, ?)) AS distance", point.Lng, point.Lat).
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Order("distance, id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []models.NearbyUBS{}, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var ubsList []models.UBS
+	if err := r.db.Find(&ubsList, ids).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.UBS, len(ubsList))
+	for _, ubs := range ubsList {
+		byID[ubs.ID] = ubs
+	}
+
+	nearby := make([]models.NearbyUBS, 0, len(rows))
+	for _, row := range rows {
+		if ubs, ok := byID[row.ID]; ok {
+			nearby = append(nearby, models.NearbyUBS{UBS: ubs, DistanceMeters: row.Distance})
+		}
+	}
+	return nearby, nil
+}
+
+type postgresTeamRepository struct {
+	db *gorm.DB
+}
+
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &postgresTeamRepository{db: db}
+}
+
+func (r *postgresTeamRepository) Create(team *models.Team) error {
+	if err := r.db.Create(team).Error; err != nil {
+		return err
+	}
+	// Recarrega o time com os dados da UBS
+	return r.db.Preload("UBS").First(team, team.ID).Error
+}
+
+func (r *postgresTeamRepository) List(deleted bool) ([]models.Team, error) {
+	db := r.db
+	if deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var teams []models.Team
+	err := db.Preload("UBS", unscoped).Find(&teams).Error
+	return teams, err
+}
+
+func (r *postgresTeamRepository) Get(id uint) (*models.Team, error) {
+	var team models.Team
+	if err := r.db.Preload("UBS").First(&team, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &team, nil
+}
+
+func (r *postgresTeamRepository) GetDeleted(id uint) (*models.Team, error) {
+	var team models.Team
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&team, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &team, nil
+}
+
+func (r *postgresTeamRepository) Update(team *models.Team) error {
+	return r.db.Omit("UBS").Save(team).Error
+}
+
+func (r *postgresTeamRepository) CountByUBS(ubsID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Team{}).Where("ubs_id = ?", ubsID).Count(&count).Error
+	return count, err
+}
+
+func (r *postgresTeamRepository) Delete(id uint) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.StreetSegment{}).Where("team_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Team{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
+}
+
+func (r *postgresTeamRepository) Restore(id uint) error {
+	team, err := r.GetDeleted(id)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := team.DeletedAt.Time
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.StreetSegment{}).
+			Where("team_id = ? AND deleted_at = ?", id, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Team{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+}
+
+type postgresStreetSegmentRepository struct {
+	db *gorm.DB
+}
+
+func NewStreetSegmentRepository(db *gorm.DB) StreetSegmentRepository {
+	return &postgresStreetSegmentRepository{db: db}
+}
+
+func (r *postgresStreetSegmentRepository) Create(segment *models.StreetSegment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(segment).Error; err != nil {
+			return err
+		}
+		return createInitialAssignments(tx, []models.StreetSegment{*segment})
+	})
+}
+
+func (r *postgresStreetSegmentRepository) CreateBatch(segments []models.StreetSegment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Team").CreateInBatches(segments, 100).Error; err != nil {
+			return err
+		}
+		return createInitialAssignments(tx, segments)
+	})
+}
+
+// createInitialAssignments abre o historico dos segmentos recem-criados com a
+// equipe atual a partir de hoje
+func createInitialAssignments(tx *gorm.DB, segments []models.StreetSegment) error {
+	now := time.Now()
+	assignments := make([]models.SegmentAssignment, len(segments))
+	for i, segment := range segments {
+		assignments[i] = models.SegmentAssignment{
+			StreetSegmentID: segment.ID,
+			TeamID:          segment.TeamID,
+			ValidFrom:       schedule.DateOf(now),
+			AppliedAt:       &now,
+		}
+	}
+	return tx.CreateInBatches(assignments, 100).Error
+}
+
+func (r *postgresStreetSegmentRepository) List(deleted bool) ([]models.StreetSegment, error) {
+	db := r.db
+	if deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var segments []models.StreetSegment
+	err := db.Preload("Team", unscoped).Preload("Team.UBS", unscoped).Find(&segments).Error
+	return segments, err
+}
+
+func (r *postgresStreetSegmentRepository) Get(id uint) (*models.StreetSegment, error) {
+	var segment models.StreetSegment
+	if err := r.db.Preload("Team.UBS").First(&segment, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &segment, nil
+}
+
+func (r *postgresStreetSegmentRepository) GetDeleted(id uint) (*models.StreetSegment, error) {
+	var segment models.StreetSegment
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&segment, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &segment, nil
+}
+
+func (r *postgresStreetSegmentRepository) Update(segment *models.StreetSegment) error {
+	return r.db.Omit("Team").Save(segment).Error
+}
+
+func (r *postgresStreetSegmentRepository) UpdateAndReassign(segment *models.StreetSegment, assignment *models.SegmentAssignment, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Team").Save(segment).Error; err != nil {
+			return err
+		}
+		return reassign(tx, assignment, now)
+	})
+}
+
+func (r *postgresStreetSegmentRepository) CountByTeam(teamID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.StreetSegment{}).Where("team_id = ?", teamID).Count(&count).Error
+	return count, err
+}
+
+func (r *postgresStreetSegmentRepository) ListByTeams(teamIDs []uint) ([]models.StreetSegment, error) {
+	segments := []models.StreetSegment{}
+	if len(teamIDs) == 0 {
+		return segments, nil
+	}
+	err := r.db.Preload("Team.UBS").
+		Where("team_id IN ?", teamIDs).
+		Order("street_name, start_number").
+		Find(&segments).Error
+	return segments, err
+}
+
+func (r *postgresStreetSegmentRepository) ListByStreet(street, city, state string) ([]models.StreetSegment, error) {
+	segments := []models.StreetSegment{}
+	err := r.db.Preload("Team").
+		Where("street_name = ? AND city = ? AND state = ?", street, city, state).
+		Order("start_number").
+		Find(&segments).Error
+	return segments, err
+}
+
+func (r *postgresStreetSegmentRepository) ListByStreets(queries []AddressQuery) ([][]models.StreetSegment, error) {
+	results := make([][]models.StreetSegment, len(queries))
+	if len(queries) == 0 {
+		return results, nil
+	}
+
+	streets := make([][]interface{}, len(queries))
+	for i, query := range queries {
+		streets[i] = []interface{}{query.Street, query.City, query.State}
+	}
+	var segments []models.StreetSegment
+	err := r.db.Preload("Team").
+		Where("(street_name, city, state) IN ?", streets).
+		Order("start_number").
+		Find(&segments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byStreet := map[AddressQuery][]models.StreetSegment{}
+	for _, segment := range segments {
+		key := AddressQuery{Street: segment.StreetName, City: segment.City, State: segment.State}
+		byStreet[key] = append(byStreet[key], segment)
+	}
+	for i, query := range queries {
+		results[i] = byStreet[AddressQuery{Street: query.Street, City: query.City, State: query.State}]
+		if results[i] == nil {
+			results[i] = []models.StreetSegment{}
+		}
+	}
+	return results, nil
+}
+
+func (r *postgresStreetSegmentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.StreetSegment{}, id).Error
+}
+
+func (r *postgresStreetSegmentRepository) Restore(id uint) error {
+	result := r.db.Unscoped().Model(&models.StreetSegment{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// coversNumber filtra os segmentos da tabela cuja faixa e paridade contem o
+// numero dado pela expressao SQL number, como a coluna de uma tabela
+func coversNumber(table, number string) string {
+	return strings.NewReplacer("$", table+".", "#", number).Replace(
+		"($start_number <= # AND $end_number >= #) AND " +
+			"($even_odd = 'all' OR " +
+			"($even_odd = 'even' AND # % 2 = 0) OR " +
+			"($even_odd = 'odd' AND # % 2 = 1))")
+}
+
+// batchInput monta a tabela input(idx, street, number, city, state) das
+// buscas em lote como uma lista VALUES, e os argumentos dela
+func batchInput(queries []AddressQuery) (string, []interface{}) {
+	rows := make([]string, len(queries))
+	args := make([]interface{}, 0, 5*len(queries))
+	for i, query := range queries {
+		rows[i] = "(?::int, ?::text, ?::int, ?::text, ?::text)"
+		args = append(args, i, query.Street, query.Number, query.City, query.State)
+	}
+	return "(VALUES " + strings.Join(rows, ", ") + ") AS input(idx, street, number, city, state)", args
+}
+
+func (r *postgresStreetSegmentRepository) SearchBatch(queries []AddressQuery) ([][]SearchResult, error) {
+	results := make([][]SearchResult, len(queries))
+	if len(queries) == 0 {
+		return results, nil
+	}
+
+	input, args := batchInput(queries)
+	var scores []struct {
+		Idx        int
+		ID         uint
+		Similarity float64
+	}
+	err := r.db.Raw(`SELECT input.idx, street_segments.id,
+			similarity(street_segments.street_name, input.street) AS similarity
+		FROM `+input+`
+		JOIN street_segments ON street_segments.city = input.city AND street_segments.state = input.state
+		WHERE street_segments.deleted_at IS NULL
+			AND similarity(street_segments.street_name, input.street) > ?
+			AND `+coversNumber("street_segments", "input.number")+`
+		ORDER BY input.idx, similarity DESC, street_segments.id`,
+		append(args, searchThreshold)...).
+		Scan(&scores).Error
+	if err != nil || len(scores) == 0 {
+		return results, err
+	}
+
+	ids := make([]uint, len(scores))
+	for i, score := range scores {
+		ids[i] = score.ID
+	}
+	byID, err := segmentsByID(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, score := range scores {
+		if segment, ok := byID[score.ID]; ok {
+			results[score.Idx] = append(results[score.Idx], SearchResult{Segment: segment, Similarity: score.Similarity})
+		}
+	}
+	return results, nil
+}
+
+// segmentsByID carrega os segmentos com time e UBS
+func segmentsByID(db *gorm.DB, ids []uint) (map[uint]models.StreetSegment, error) {
+	var segments []models.StreetSegment
+	if err := db.Preload("Team.UBS").Where("id IN ?", ids).Find(&segments).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.StreetSegment, len(segments))
+	for _, segment := range segments {
+		byID[segment.ID] = segment
+	}
+	return byID, nil
+}
+
+type postgresStreetAliasRepository struct {
+	db *gorm.DB
+}
+
+func NewStreetAliasRepository(db *gorm.DB) StreetAliasRepository {
+	return &postgresStreetAliasRepository{db: db}
+}
+
+func (r *postgresStreetAliasRepository) Create(alias *models.StreetAlias) error {
+	return r.db.Create(alias).Error
+}
+
+func (r *postgresStreetAliasRepository) Get(id uint) (*models.StreetAlias, error) {
+	var alias models.StreetAlias
+	if err := r.db.First(&alias, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &alias, nil
+}
+
+// streetOfAlias liga o alias aos segmentos da mesma rua do segmento em que
+// ele foi cadastrado, mesmo que esse segmento tenha sido apagado
+const streetOfAlias = "JOIN street_segments linked ON linked.id = street_aliases.street_segment_id"
+
+func (r *postgresStreetAliasRepository) ListByStreet(street, city, state string) ([]models.StreetAlias, error) {
+	var aliases []models.StreetAlias
+	err := r.db.Joins(streetOfAlias).
+		Where("linked.street_name = ? AND linked.city = ? AND linked.state = ?", street, city, state).
+		Order("street_aliases.alias, street_aliases.id").
+		Find(&aliases).Error
+	return aliases, err
+}
+
+func (r *postgresStreetAliasRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.StreetAlias{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresStreetAliasRepository) SearchBatch(queries []AddressQuery) ([][]SearchResult, error) {
+	results := make([][]SearchResult, len(queries))
+	if len(queries) == 0 {
+		return results, nil
+	}
+
+	input, args := batchInput(queries)
+	var scores []struct {
+		Idx        int
+		ID         uint
+		AliasID    uint
+		Similarity float64
+	}
+	err := r.db.Raw(`SELECT idx, id, alias_id, similarity FROM (
+			SELECT DISTINCT ON (input.idx, street_segments.id) input.idx, street_segments.id,
+				street_aliases.id AS alias_id, similarity(street_aliases.alias, input.street) AS similarity
+			FROM `+input+`
+			JOIN street_aliases ON similarity(street_aliases.alias, input.street) > ?
+			`+streetOfAlias+`
+			JOIN street_segments ON street_segments.street_name = linked.street_name
+				AND street_segments.city = linked.city AND street_segments.state = linked.state
+			WHERE street_segments.deleted_at IS NULL
+				AND street_segments.city = input.city AND street_segments.state = input.state
+				AND `+coversNumber("street_segments", "input.number")+`
+			ORDER BY input.idx, street_segments.id, similarity DESC, street_aliases.id
+		) matches ORDER BY idx, similarity DESC, id`,
+		append(args, searchThreshold)...).
+		Scan(&scores).Error
+	if err != nil || len(scores) == 0 {
+		return results, err
+	}
+
+	ids := make([]uint, len(scores))
+	aliasIDs := make([]uint, len(scores))
+	for i, score := range scores {
+		ids[i], aliasIDs[i] = score.ID, score.AliasID
+	}
+	byID, err := segmentsByID(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	var aliases []models.StreetAlias
+	if err := r.db.Where("id IN ?", aliasIDs).Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	aliasByID := make(map[uint]models.StreetAlias, len(aliases))
+	for _, alias := range aliases {
+		aliasByID[alias.ID] = alias
+	}
+
+	for _, score := range scores {
+		segment, ok := byID[score.ID]
+		alias, found := aliasByID[score.AliasID]
+		if ok && found {
+			results[score.Idx] = append(results[score.Idx], SearchResult{Segment: segment, Similarity: score.Similarity, Alias: &alias})
+		}
+	}
+	return results, nil
+}
+
+type postgresAddressLookupRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressLookupRepository(db *gorm.DB) AddressLookupRepository {
+	return &postgresAddressLookupRepository{db: db}
+}
+
+func (r *postgresAddressLookupRepository) Create(lookup *models.AddressLookup) error {
+	return r.db.Create(lookup).Error
+}
+
+func (r *postgresAddressLookupRepository) CreateBatch(lookups []models.AddressLookup) error {
+	if len(lookups) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(lookups, 500).Error
+}
+
+func (r *postgresAddressLookupRepository) List(resolved bool) ([]models.AddressLookup, error) {
+	var lookups []models.AddressLookup
+	query := r.db.Where("resolved_at IS NULL")
+	if resolved {
+		query = r.db.Where("resolved_at IS NOT NULL")
+	}
+	err := query.Order("created_at DESC, id DESC").Find(&lookups).Error
+	return lookups, err
+}
+
+func (r *postgresAddressLookupRepository) Get(id uint) (*models.AddressLookup, error) {
+	var lookup models.AddressLookup
+	if err := r.db.First(&lookup, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &lookup, nil
+}
+
+func (r *postgresAddressLookupRepository) Update(lookup *models.AddressLookup) error {
+	return r.db.Save(lookup).Error
+}
+
+type postgresTerritoryRepository struct {
+	db *gorm.DB
+}
+
+func NewTerritoryRepository(db *gorm.DB) TerritoryRepository {
+	return &postgresTerritoryRepository{db: db}
+}
+
+// territoryColumns le a geometria de volta como GeoJSON
+const territoryColumns = "territories.id, territories.team_id, territories.name, " +
+	"ST_AsGeoJSON(territories.geometry) AS geometry, territories.created_at, territories.updated_at"
+
+// activeTerritories deixa de fora os territorios de equipes apagadas, que
+// voltam junto se a equipe for restaurada
+func (r *postgresTerritoryRepository) activeTerritories() *gorm.DB {
+	return r.db.Model(&models.Territory{}).Select(territoryColumns).
+		Joins("JOIN teams ON teams.id = territories.team_id AND teams.deleted_at IS NULL").
+		Preload("Team.UBS")
+}
+
+func (r *postgresTerritoryRepository) Create(territory *models.Territory) error {
+	return r.db.Omit("Team").Create(territory).Error
+}
+
+func (r *postgresTerritoryRepository) List(teamID uint) ([]models.Territory, error) {
+	query := r.activeTerritories()
+	if teamID != 0 {
+		query = query.Where("territories.team_id = ?", teamID)
+	}
+
+	var territories []models.Territory
+	err := query.Order("territories.team_id, territories.id").Find(&territories).Error
+	return territories, err
+}
+
+func (r *postgresTerritoryRepository) Get(id uint) (*models.Territory, error) {
+	var territory models.Territory
+	err := r.db.Select(territoryColumns).Preload("Team.UBS").First(&territory, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &territory, nil
+}
+
+func (r *postgresTerritoryRepository) Update(territory *models.Territory) error {
+	return r.db.Omit("Team").Save(territory).Error
+}
+
+func (r *postgresTerritoryRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Territory{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresTerritoryRepository) Locate(point geo.Point) ([]models.Territory, error) {
+	var territories []models.Territory
+	err := r.activeTerritories().
+		Where("ST_Covers(territories.geometry, ST_SetSRID(ST_MakePoint(?, ?), 4326))", point.Lng, point.Lat).
+		Order("ST_Area(territories.geometry), territories.id").
+		Find(&territories).Error
+	return territories, err
+}
+
+type postgresCEPRepository struct {
+	db *gorm.DB
+}
+
+func NewCEPRepository(db *gorm.DB) CEPRepository {
+	return &postgresCEPRepository{db: db}
+}
+
+func (r *postgresCEPRepository) Get(cep string) (*models.CEPAddress, error) {
+	var address models.CEPAddress
+	if err := r.db.First(&address, "cep = ?", cep).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &address, nil
+}
+
+func (r *postgresCEPRepository) GetMany(ceps []string) (map[string]models.CEPAddress, error) {
+	byCEP := map[string]models.CEPAddress{}
+	if len(ceps) == 0 {
+		return byCEP, nil
+	}
+	var addresses []models.CEPAddress
+	if err := r.db.Where("cep IN ?", ceps).Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		byCEP[address.CEP] = address
+	}
+	return byCEP, nil
+}
+
+func (r *postgresCEPRepository) Upsert(addresses []models.CEPAddress) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cep"}},
+		DoUpdates: clause.AssignmentColumns([]string{"street_type", "street_name", "original_street_name", "neighborhood", "city", "state", "updated_at"}),
+	}).CreateInBatches(addresses, 500).Error
+}
+
+type postgresHolidayRepository struct {
+	db *gorm.DB
+}
+
+func NewHolidayRepository(db *gorm.DB) HolidayRepository {
+	return &postgresHolidayRepository{db: db}
+}
+
+func (r *postgresHolidayRepository) Create(holiday *models.Holiday) error {
+	return r.db.Create(holiday).Error
+}
+
+func (r *postgresHolidayRepository) List(year int) ([]models.Holiday, error) {
+	db := r.db
+	if year != 0 {
+		db = db.Where("EXTRACT(YEAR FROM date) = ?", year)
+	}
+	var holidays []models.Holiday
+	err := db.Order("date, id").Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *postgresHolidayRepository) Between(from, to models.Date) ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	err := r.db.Where("date BETWEEN ? AND ?", from, to).Order("date, id").Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *postgresHolidayRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Holiday{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresHolidayRepository) Upsert(holidays []models.Holiday) error {
+	if len(holidays) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}, {Name: "scope"}, {Name: "state"}, {Name: "city"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "optional"}),
+	}).Create(&holidays).Error
+}
+
+type postgresUBSClosureRepository struct {
+	db *gorm.DB
+}
+
+func NewUBSClosureRepository(db *gorm.DB) UBSClosureRepository {
+	return &postgresUBSClosureRepository{db: db}
+}
+
+func (r *postgresUBSClosureRepository) Create(closure *models.UBSClosure) error {
+	return r.db.Create(closure).Error
+}
+
+func (r *postgresUBSClosureRepository) List(ubsID uint) ([]models.UBSClosure, error) {
+	var closures []models.UBSClosure
+	err := r.db.Where("ubs_id = ?", ubsID).Order("start_date, id").Find(&closures).Error
+	return closures, err
+}
+
+func (r *postgresUBSClosureRepository) Between(from, to models.Date) ([]models.UBSClosure, error) {
+	var closures []models.UBSClosure
+	err := r.db.Where("start_date <= ? AND end_date >= ?", to, from).Order("start_date, id").Find(&closures).Error
+	return closures, err
+}
+
+func (r *postgresUBSClosureRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.UBSClosure{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type postgresProfessionalRepository struct {
+	db *gorm.DB
+}
+
+func NewProfessionalRepository(db *gorm.DB) ProfessionalRepository {
+	return &postgresProfessionalRepository{db: db}
+}
+
+func (r *postgresProfessionalRepository) Create(professional *models.Professional) error {
+	return r.db.Omit("Memberships").Create(professional).Error
+}
+
+func (r *postgresProfessionalRepository) List(role string) ([]models.Professional, error) {
+	db := r.db
+	if role != "" {
+		db = db.Where("role = ?", role)
+	}
+	var professionals []models.Professional
+	err := db.Order("name, id").Find(&professionals).Error
+	return professionals, err
+}
+
+func (r *postgresProfessionalRepository) Get(id uint) (*models.Professional, error) {
+	var professional models.Professional
+	err := r.db.
+		Preload("Memberships", func(tx *gorm.DB) *gorm.DB { return tx.Order("start_date DESC, id DESC") }).
+		Preload("Memberships.Team", unscoped).
+		First(&professional, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &professional, nil
+}
+
+func (r *postgresProfessionalRepository) Update(professional *models.Professional) error {
+	return r.db.Omit("Memberships").Save(professional).Error
+}
+
+func (r *postgresProfessionalRepository) Delete(id uint, endDate models.Date) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TeamMembership{}).
+			Where("professional_id = ? AND end_date IS NULL", id).
+			Update("end_date", endDate).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Professional{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *postgresProfessionalRepository) AddMembership(membership *models.TeamMembership) error {
+	return r.db.Omit("Team", "Professional").Create(membership).Error
+}
+
+func (r *postgresProfessionalRepository) GetMembership(id uint) (*models.TeamMembership, error) {
+	var membership models.TeamMembership
+	if err := r.db.Preload("Professional", unscoped).First(&membership, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &membership, nil
+}
+
+func (r *postgresProfessionalRepository) UpdateMembership(membership *models.TeamMembership) error {
+	return r.db.Omit("Team", "Professional").Save(membership).Error
+}
+
+func (r *postgresProfessionalRepository) Memberships(teamID uint, history bool) ([]models.TeamMembership, error) {
+	db := r.db.Where("team_id = ?", teamID)
+	if !history {
+		db = db.Where("end_date IS NULL")
+	}
+	var memberships []models.TeamMembership
+	// O historico mostra tambem os profissionais ja removidos
+	err := db.Preload("Professional", unscoped).Order("start_date, id").Find(&memberships).Error
+	return memberships, err
+}
+
+type postgresMicroAreaRepository struct {
+	db *gorm.DB
+}
+
+func NewMicroAreaRepository(db *gorm.DB) MicroAreaRepository {
+	return &postgresMicroAreaRepository{db: db}
+}
+
+func (r *postgresMicroAreaRepository) Create(area *models.MicroArea) error {
+	return r.db.Omit("Team", "Agent", "Segments").Create(area).Error
+}
+
+func (r *postgresMicroAreaRepository) List(teamID uint) ([]models.MicroArea, error) {
+	areas := []models.MicroArea{}
+	err := r.db.Preload("Agent", unscoped).
+		Where("team_id = ?", teamID).
+		Order("code").
+		Find(&areas).Error
+	return areas, err
+}
+
+func (r *postgresMicroAreaRepository) Get(id uint) (*models.MicroArea, error) {
+	var area models.MicroArea
+	err := r.db.Preload("Team.UBS").
+		Preload("Agent", unscoped).
+		Preload("Segments", func(tx *gorm.DB) *gorm.DB { return tx.Order("street_name, start_number") }).
+		First(&area, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &area, nil
+}
+
+func (r *postgresMicroAreaRepository) Update(area *models.MicroArea) error {
+	return r.db.Omit("Team", "Agent", "Segments").Save(area).Error
+}
+
+func (r *postgresMicroAreaRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Inclui os segmentos apagados, que podem ser restaurados depois
+		if err := tx.Unscoped().Model(&models.StreetSegment{}).
+			Where("micro_area_id = ?", id).
+			Update("micro_area_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.MicroArea{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *postgresMicroAreaRepository) AssignSegments(id uint, segmentIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		released := tx.Model(&models.StreetSegment{}).Where("micro_area_id = ?", id)
+		if len(segmentIDs) > 0 {
+			released = released.Where("id NOT IN ?", segmentIDs)
+		}
+		if err := released.Update("micro_area_id", nil).Error; err != nil {
+			return err
+		}
+		if len(segmentIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.StreetSegment{}).
+			Where("id IN ?", segmentIDs).
+			Update("micro_area_id", id).Error
+	})
+}
+
+type postgresSegmentAssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewSegmentAssignmentRepository(db *gorm.DB) SegmentAssignmentRepository {
+	return &postgresSegmentAssignmentRepository{db: db}
+}
+
+// withTeams carrega as equipes da atribuicao, mesmo as ja apagadas, que
+// continuam no historico
+func withTeams(db *gorm.DB) *gorm.DB {
+	return db.Preload("Team", unscoped).Preload("Team.UBS", unscoped).Preload("PreviousTeam", unscoped)
+}
+
+func (r *postgresSegmentAssignmentRepository) List(segmentID uint) ([]models.SegmentAssignment, error) {
+	assignments := []models.SegmentAssignment{}
+	err := withTeams(r.db).
+		Where("street_segment_id = ?", segmentID).
+		Order("valid_from, id").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *postgresSegmentAssignmentRepository) Get(id uint) (*models.SegmentAssignment, error) {
+	var assignment models.SegmentAssignment
+	if err := withTeams(r.db).First(&assignment, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &assignment, nil
+}
+
+func (r *postgresSegmentAssignmentRepository) At(segmentIDs []uint, date models.Date) (map[uint]models.SegmentAssignment, error) {
+	bySegment := map[uint]models.SegmentAssignment{}
+	if len(segmentIDs) == 0 {
+		return bySegment, nil
+	}
+	// Num dia com mais de uma mudanca vale a ultima
+	var assignments []models.SegmentAssignment
+	err := withTeams(r.db).
+		Where("street_segment_id IN ?", segmentIDs).
+		Where("valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)", date, date).
+		Order("valid_from, id").
+		Find(&assignments).Error
+	for _, assignment := range assignments {
+		bySegment[assignment.StreetSegmentID] = assignment
+	}
+	return bySegment, err
+}
+
+func (r *postgresSegmentAssignmentRepository) Reassign(assignment *models.SegmentAssignment, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return reassign(tx, assignment, now)
+	})
+}
+
+// reassign e Reassign dentro da transacao tx
+func reassign(tx *gorm.DB, assignment *models.SegmentAssignment, now time.Time) error {
+	var open models.SegmentAssignment
+	err := tx.Where("street_segment_id = ? AND valid_to IS NULL", assignment.StreetSegmentID).
+		Order("valid_from DESC").
+		First(&open).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return err
+	case open.ValidFrom == assignment.ValidFrom && open.AppliedAt == nil:
+		// A reatribuicao agendada nunca valeu e da lugar a nova
+		assignment.PreviousTeamID = open.PreviousTeamID
+		if err := tx.Delete(&open).Error; err != nil {
+			return err
+		}
+	default:
+		// A atribuicao que ja valeu fica no historico, encerrada na vespera
+		// ou, se comecou no mesmo dia, nele
+		assignment.PreviousTeamID = &open.TeamID
+		validTo := schedule.AddDays(assignment.ValidFrom, -1)
+		if open.ValidFrom == assignment.ValidFrom {
+			validTo = open.ValidFrom
+		}
+		if err := tx.Model(&open).Update("valid_to", validTo).Error; err != nil {
+			return err
+		}
+	}
+
+	if assignment.ValidFrom <= schedule.DateOf(now) {
+		assignment.AppliedAt = &now
+		if err := moveSegment(tx, assignment.StreetSegmentID, assignment.TeamID); err != nil {
+			return err
+		}
+	}
+	return tx.Omit("StreetSegment", "Team", "PreviousTeam").Create(assignment).Error
+}
+
+// moveSegment passa o segmento para a equipe. A micro-area e da equipe
+// antiga, entao o segmento sai dela.
+func moveSegment(tx *gorm.DB, segmentID, teamID uint) error {
+	return tx.Model(&models.StreetSegment{}).
+		Where("id = ? AND team_id <> ?", segmentID, teamID).
+		Updates(map[string]interface{}{"team_id": teamID, "micro_area_id": nil}).Error
+}
+
+func (r *postgresSegmentAssignmentRepository) Scheduled() ([]models.SegmentAssignment, error) {
+	assignments := []models.SegmentAssignment{}
+	err := withTeams(r.db).Preload("StreetSegment").
+		Where("applied_at IS NULL").
+		Order("valid_from, id").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *postgresSegmentAssignmentRepository) ApplyDue(today models.Date) ([]models.SegmentAssignment, error) {
+	var applied []models.SegmentAssignment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Reatribuicoes para equipes apagadas esperam a equipe voltar
+		var due []models.SegmentAssignment
+		if err := tx.Joins("JOIN teams ON teams.id = segment_assignments.team_id AND teams.deleted_at IS NULL").
+			Where("segment_assignments.applied_at IS NULL AND segment_assignments.valid_from <= ?", today).
+			Order("segment_assignments.valid_from, segment_assignments.id").
+			Find(&due).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range due {
+			if err := moveSegment(tx, due[i].StreetSegmentID, due[i].TeamID); err != nil {
+				return err
+			}
+			if err := tx.Model(&due[i]).Update("applied_at", now).Error; err != nil {
+				return err
+			}
+		}
+		applied = due
+		return nil
+	})
+	return applied, err
+}
+
+func (r *postgresSegmentAssignmentRepository) Cancel(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var assignment models.SegmentAssignment
+		if err := tx.Where("applied_at IS NULL").First(&assignment, id).Error; err != nil {
+			return translateError(err)
+		}
+		if err := tx.Model(&models.SegmentAssignment{}).
+			Where("street_segment_id = ? AND valid_to = ?", assignment.StreetSegmentID, schedule.AddDays(assignment.ValidFrom, -1)).
+			Update("valid_to", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&assignment).Error
+	})
+}
+
+func (r *postgresSegmentAssignmentRepository) Unnotified() ([]models.SegmentAssignment, error) {
+	assignments := []models.SegmentAssignment{}
+	err := withTeams(r.db).Preload("StreetSegment", unscoped).
+		Where("applied_at IS NOT NULL AND notified_at IS NULL AND previous_team_id IS NOT NULL").
+		Order("applied_at, id").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *postgresSegmentAssignmentRepository) MarkNotified(id uint, at time.Time) error {
+	result := r.db.Model(&models.SegmentAssignment{}).
+		Where("id = ? AND applied_at IS NOT NULL", id).
+		Update("notified_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"address-api/internal/geo"
+	"address-api/internal/models"
+	"errors"
+	"time"
+)
+
+// ErrNotFound e retornado quando o registro buscado nao existe (ou foi apagado)
+var ErrNotFound = errors.New("record not found")
+
+// Store da acesso a todos os repositorios. NewStore o implementa sobre o
+// Postgres e NewMemoryStore em memoria, para os testes.
+type Store interface {
+	UBS() UBSRepository
+	Teams() TeamRepository
+	StreetSegments() StreetSegmentRepository
+	CEPs() CEPRepository
+	StreetAliases() StreetAliasRepository
+	AddressLookups() AddressLookupRepository
+	Territories() TerritoryRepository
+	Holidays() HolidayRepository
+	UBSClosures() UBSClosureRepository
+	Professionals() ProfessionalRepository
+	MicroAreas() MicroAreaRepository
+	SegmentAssignments() SegmentAssignmentRepository
+}
+
+type UBSRepository interface {
+	Create(ubs *models.UBS) error
+	// List retorna as UBS ativas, ou apenas as apagadas quando deleted e true
+	List(deleted bool) ([]models.UBS, error)
+	// Get retorna uma UBS ativa com seus times
+	Get(id uint) (*models.UBS, error)
+	GetDeleted(id uint) (*models.UBS, error)
+	Update(ubs *models.UBS) error
+	// Delete apaga a UBS junto com seus times e os segmentos deles
+	Delete(id uint) error
+	// Restore restaura a UBS e os registros apagados junto com ela
+	Restore(id uint) error
+	// Nearest retorna as UBS ativas com coordenadas, da mais proxima do ponto
+	// para a mais distante. limit 0 retorna todas.
+	Nearest(point geo.Point, limit int) ([]models.NearbyUBS, error)
+}
+
+type TeamRepository interface {
+	Create(team *models.Team) error
+	List(deleted bool) ([]models.Team, error)
+	// Get retorna um time ativo com sua UBS
+	Get(id uint) (*models.Team, error)
+	GetDeleted(id uint) (*models.Team, error)
+	Update(team *models.Team) error
+	CountByUBS(ubsID uint) (int64, error)
+	// Delete apaga o time junto com seus segmentos de rua
+	Delete(id uint) error
+	// Restore restaura o time e os segmentos apagados junto com ele
+	Restore(id uint) error
+}
+
+type StreetSegmentRepository interface {
+	Create(segment *models.StreetSegment) error
+	// CreateBatch grava todos os segmentos em uma unica transacao, ou nenhum
+	CreateBatch(segments []models.StreetSegment) error
+	List(deleted bool) ([]models.StreetSegment, error)
+	// Get retorna um segmento ativo com seu time e UBS
+	Get(id uint) (*models.StreetSegment, error)
+	GetDeleted(id uint) (*models.StreetSegment, error)
+	Update(segment *models.StreetSegment) error
+	// UpdateAndReassign grava o segmento e a reatribuicao na mesma transacao,
+	// para que a equipe do segmento e o historico nao divirjam
+	UpdateAndReassign(segment *models.StreetSegment, assignment *models.SegmentAssignment, now time.Time) error
+	CountByTeam(teamID uint) (int64, error)
+	// ListByTeams retorna os segmentos ativos dos times, com time e UBS,
+	// ordenados por rua e numeracao
+	ListByTeams(teamIDs []uint) ([]models.StreetSegment, error)
+	// ListByStreet retorna os segmentos ativos com exatamente esse nome de rua
+	// (ja normalizado) na cidade, com seus times
+	ListByStreet(street, city, state string) ([]models.StreetSegment, error)
+	// ListByStreets e ListByStreet para varias ruas de uma vez, com os
+	// segmentos na ordem das buscas
+	ListByStreets(queries []AddressQuery) ([][]models.StreetSegment, error)
+	Delete(id uint) error
+	Restore(id uint) error
+	// SearchBatch retorna, para cada endereco, os segmentos da cidade com
+	// nome parecido com a rua cuja faixa de numeracao (e paridade) contem o
+	// numero, do mais parecido para o menos parecido. Todos os enderecos saem
+	// de uma unica consulta e os resultados vem na ordem deles, vazios para
+	// os enderecos sem segmento.
+	SearchBatch(queries []AddressQuery) ([][]SearchResult, error)
+}
+
+// SegmentAssignmentRepository guarda o historico de equipes de cada
+// segmento. StreetSegmentRepository.Create e CreateBatch ja gravam a primeira
+// atribuicao do segmento.
+type SegmentAssignmentRepository interface {
+	// List retorna o historico do segmento, da atribuicao mais antiga para a
+	// mais recente, incluindo a reatribuicao agendada
+	List(segmentID uint) ([]models.SegmentAssignment, error)
+	Get(id uint) (*models.SegmentAssignment, error)
+	// At retorna, por segmento, a atribuicao valida na data, com equipe e UBS.
+	// Segmentos sem atribuicao na data ficam de fora.
+	At(segmentIDs []uint, date models.Date) (map[uint]models.SegmentAssignment, error)
+	// Reassign grava a nova atribuicao a partir de ValidFrom e encerra na
+	// vespera a atribuicao em aberto, cuja equipe vira PreviousTeamID. Uma
+	// atribuicao ja aplicada que comecou no mesmo dia e encerrada nele; uma
+	// agendada para o mesmo dia e substituida. Quando ValidFrom nao e
+	// posterior ao dia de now, o segmento muda de equipe na mesma transacao.
+	Reassign(assignment *models.SegmentAssignment, now time.Time) error
+	// Scheduled retorna as reatribuicoes ainda nao aplicadas, por data
+	Scheduled() ([]models.SegmentAssignment, error)
+	// ApplyDue aplica as reatribuicoes agendadas ate today e as retorna
+	ApplyDue(today models.Date) ([]models.SegmentAssignment, error)
+	// Cancel apaga uma reatribuicao agendada e reabre a atribuicao anterior
+	Cancel(id uint) error
+	// Unnotified retorna as mudancas de equipe ja aplicadas cujos usuarios
+	// ainda nao foram avisados, com segmento, equipes e UBS
+	Unnotified() ([]models.SegmentAssignment, error)
+	MarkNotified(id uint, at time.Time) error
+}
+
+type CEPRepository interface {
+	// Get retorna o endereco de um CEP com 8 digitos
+	Get(cep string) (*models.CEPAddress, error)
+	// GetMany retorna os enderecos dos CEPs conhecidos, pelo CEP
+	GetMany(ceps []string) (map[string]models.CEPAddress, error)
+	// Upsert grava os enderecos, substituindo os CEPs ja existentes
+	Upsert(addresses []models.CEPAddress) error
+}
+
+type StreetAliasRepository interface {
+	Create(alias *models.StreetAlias) error
+	Get(id uint) (*models.StreetAlias, error)
+	// ListByStreet retorna os aliases ligados a qualquer segmento com esse
+	// nome de rua (ja normalizado) na cidade
+	ListByStreet(street, city, state string) ([]models.StreetAlias, error)
+	Delete(id uint) error
+	// SearchBatch funciona como StreetSegmentRepository.SearchBatch, mas
+	// compara a rua com os aliases. Cada segmento vem uma vez por endereco,
+	// com o alias mais parecido.
+	SearchBatch(queries []AddressQuery) ([][]SearchResult, error)
+}
+
+type AddressLookupRepository interface {
+	Create(lookup *models.AddressLookup) error
+	// CreateBatch grava as buscas de uma vez
+	CreateBatch(lookups []models.AddressLookup) error
+	// List retorna as buscas pendentes, ou apenas as ja resolvidas quando
+	// resolved e true, das mais recentes para as mais antigas
+	List(resolved bool) ([]models.AddressLookup, error)
+	Get(id uint) (*models.AddressLookup, error)
+	Update(lookup *models.AddressLookup) error
+}
+
+type TerritoryRepository interface {
+	Create(territory *models.Territory) error
+	// List retorna os territorios das equipes ativas, de uma equipe so
+	// quando teamID nao e zero
+	List(teamID uint) ([]models.Territory, error)
+	// Get retorna um territorio com sua equipe e UBS
+	Get(id uint) (*models.Territory, error)
+	Update(territory *models.Territory) error
+	Delete(id uint) error
+	// Locate retorna os territorios de equipes ativas que contem o ponto, do
+	// menor para o maior, ja que o menor e o mais especifico
+	Locate(point geo.Point) ([]models.Territory, error)
+}
+
+type ProfessionalRepository interface {
+	Create(professional *models.Professional) error
+	// List retorna os profissionais ativos por nome, de uma funcao so quando
+	// role nao e vazio
+	List(role string) ([]models.Professional, error)
+	// Get retorna o profissional com o historico de equipes, do vinculo mais
+	// recente para o mais antigo
+	Get(id uint) (*models.Professional, error)
+	Update(professional *models.Professional) error
+	// Delete remove o profissional e encerra os vinculos atuais em endDate
+	Delete(id uint, endDate models.Date) error
+
+	AddMembership(membership *models.TeamMembership) error
+	GetMembership(id uint) (*models.TeamMembership, error)
+	UpdateMembership(membership *models.TeamMembership) error
+	// Memberships retorna os vinculos atuais da equipe com os profissionais,
+	// ou todos, incluindo os encerrados, quando history e true
+	Memberships(teamID uint, history bool) ([]models.TeamMembership, error)
+}
+
+type MicroAreaRepository interface {
+	Create(area *models.MicroArea) error
+	// List retorna as micro-areas da equipe por codigo, com o ACS
+	List(teamID uint) ([]models.MicroArea, error)
+	// Get retorna a micro-area com a equipe, o ACS e os segmentos ativos
+	Get(id uint) (*models.MicroArea, error)
+	Update(area *models.MicroArea) error
+	// Delete apaga a micro-area, deixando os seus segmentos sem micro-area
+	Delete(id uint) error
+	// AssignSegments faz de segmentIDs os segmentos da micro-area, em uma
+	// unica transacao. Segmentos de outra micro-area mudam para esta e os
+	// que estavam nela e ficaram fora da lista ficam sem micro-area.
+	AssignSegments(id uint, segmentIDs []uint) error
+}
+
+type HolidayRepository interface {
+	Create(holiday *models.Holiday) error
+	// List retorna os feriados do ano em ordem de data, ou todos quando year
+	// e zero
+	List(year int) ([]models.Holiday, error)
+	// Between retorna os feriados de qualquer abrangencia entre as datas,
+	// inclusive
+	Between(from, to models.Date) ([]models.Holiday, error)
+	Delete(id uint) error
+	// Upsert grava os feriados, substituindo nome e ponto facultativo dos que
+	// ja existem na mesma data e abrangencia
+	Upsert(holidays []models.Holiday) error
+}
+
+type UBSClosureRepository interface {
+	Create(closure *models.UBSClosure) error
+	// List retorna os fechamentos da UBS em ordem de inicio
+	List(ubsID uint) ([]models.UBSClosure, error)
+	// Between retorna os fechamentos de todas as UBS que tocam o intervalo
+	Between(from, to models.Date) ([]models.UBSClosure, error)
+	Delete(id uint) error
+}
+
+// searchThreshold e a similaridade minima para um nome de rua entrar na busca
+const searchThreshold = 0.3
+
+// SearchResult e um segmento encontrado na busca por endereco, com a
+// similaridade (pg_trgm) entre o nome da rua buscado e o do segmento. Quando
+// o segmento foi encontrado por um nome alternativo, Alias e esse nome e a
+// similaridade e com ele.
+type SearchResult struct {
+	Segment    models.StreetSegment
+	Similarity float64
+	Alias      *models.StreetAlias
+}
+
+// AddressQuery e um dos enderecos de uma busca em lote, com rua, cidade e
+// UF ja normalizadas como nos segmentos
+type AddressQuery struct {
+	Street string
+	Number int
+	City   string
+	State  string
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"address-api/internal/config"
+	"address-api/internal/database"
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"os"
+	"testing"
+)
+
+func TestMemorySearchBatch(t *testing.T) {
+	testSearchBatch(t, repository.NewMemoryStore())
+}
+
+// TestPostgresSearchBatch roda a mesma busca no Postgres do
+// docker-compose.test.yaml, dentro de uma transacao desfeita no fim
+func TestPostgresSearchBatch(t *testing.T) {
+	if os.Getenv("TEST_MODE") != "true" {
+		t.Skip("set TEST_MODE=true to run against Postgres")
+	}
+	cfg := config.Load()
+	db, err := database.Open(cfg.PostgresHost, cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresDB, cfg.PostgresPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	testSearchBatch(t, repository.NewStore(tx))
+}
+
+func testSearchBatch(t *testing.T, store repository.Store) {
+	ubs := models.UBS{Name: "UBS Centro", Address: "Rua Episcopal, 1", City: "SAO CARLOS", State: "SP", CEP: "13560000"}
+	if err := store.UBS().Create(&ubs); err != nil {
+		t.Fatalf("failed to seed UBS: %v", err)
+	}
+	team := models.Team{Name: "Equipe Azul", UBSID: ubs.ID}
+	if err := store.Teams().Create(&team); err != nil {
+		t.Fatalf("failed to seed team: %v", err)
+	}
+	segment := func(street string, start, end int, evenOdd string) models.StreetSegment {
+		t.Helper()
+		segment := models.StreetSegment{
+			StreetName: street, OriginalStreetName: street, StreetType: "Rua", Neighborhood: "CENTRO",
+			City: "SAO CARLOS", State: "SP", StartNumber: start, EndNumber: end, EvenOdd: evenOdd, TeamID: team.ID,
+		}
+		if err := store.StreetSegments().Create(&segment); err != nil {
+			t.Fatalf("failed to seed segment: %v", err)
+		}
+		return segment
+	}
+	lower := segment("DAS FLORES", 1, 99, "all")
+	upper := segment("DAS FLORES", 100, 199, "all")
+	episcopal := segment("EPISCOPAL", 1, 99, "even")
+	alias := models.StreetAlias{StreetSegmentID: lower.ID, Alias: "DAS ROSAS", OriginalAlias: "Rua das Rosas", Kind: "old"}
+	if err := store.StreetAliases().Create(&alias); err != nil {
+		t.Fatalf("failed to seed alias: %v", err)
+	}
+
+	query := func(street string, number int) repository.AddressQuery {
+		return repository.AddressQuery{Street: street, Number: number, City: "SAO CARLOS", State: "SP"}
+	}
+	check := func(t *testing.T, results [][]repository.SearchResult, want [][]uint) {
+		t.Helper()
+		if len(results) != len(want) {
+			t.Fatalf("expected %d results, got %d", len(want), len(results))
+		}
+		for i := range want {
+			var got []uint
+			for _, result := range results[i] {
+				got = append(got, result.Segment.ID)
+				if result.Segment.Team.ID != team.ID {
+					t.Errorf("query %d: expected segment %d with its team, got %+v", i, result.Segment.ID, result.Segment.Team)
+				}
+			}
+			if len(got) != len(want[i]) {
+				t.Errorf("query %d: expected segments %v, got %v", i, want[i], got)
+				continue
+			}
+			for j := range got {
+				if got[j] != want[i][j] {
+					t.Errorf("query %d: expected segments %v, got %v", i, want[i], got)
+					break
+				}
+			}
+		}
+	}
+
+	t.Run("segments", func(t *testing.T) {
+		results, err := store.StreetSegments().SearchBatch([]repository.AddressQuery{
+			query("EPISCOPAL", 10),
+			query("DAS FLORES", 10),
+			query("NAO EXISTE", 10),
+			query("DAS FLORES", 10), // repetido
+			query("EPISCOPAL", 11),  // paridade errada
+			query("DAS FLORES", 150),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		check(t, results, [][]uint{{episcopal.ID}, {lower.ID}, nil, {lower.ID}, nil, {upper.ID}})
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		results, err := store.StreetAliases().SearchBatch([]repository.AddressQuery{
+			query("DAS ROSAS", 10),
+			query("NAO EXISTE", 10),
+			query("DAS ROSAS", 10), // repetido
+			query("DAS ROSAS", 150),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// O alias vale para todos os segmentos da mesma rua
+		check(t, results, [][]uint{{lower.ID}, nil, {lower.ID}, {upper.ID}})
+		for _, i := range []int{0, 2, 3} {
+			if found := results[i][0].Alias; found == nil || found.ID != alias.ID {
+				t.Errorf("query %d: expected alias %d, got %+v", i, alias.ID, found)
+			}
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		results, err := store.StreetSegments().SearchBatch(nil)
+		if err != nil || len(results) != 0 {
+			t.Errorf("expected no results, got %v %v", results, err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package roster
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMicroAreaCode = errors.New("code must be a number from 01 to 99")
+	ErrInvalidAgent         = errors.New("agent must be a current ACS of the team")
+)
+
+// BuildMicroArea valida e normaliza uma micro-area da equipe. O codigo sempre
+// fica com dois digitos, como no e-SUS: "1" vira "01".
+func BuildMicroArea(teamID uint, req models.CreateMicroAreaRequest) (models.MicroArea, error) {
+	area := models.MicroArea{
+		TeamID:  teamID,
+		Name:    strings.TrimSpace(req.Name),
+		AgentID: req.AgentID,
+	}
+
+	code := strings.TrimSpace(req.Code)
+	n, err := strconv.Atoi(code)
+	if err != nil || onlyDigits(code) != code || n < 1 || n > 99 {
+		return area, ErrInvalidMicroAreaCode
+	}
+	area.Code = fmt.Sprintf("%02d", n)
+	return area, nil
+}
+
+// ValidAgent confere que o profissional e ACS e esta hoje na equipe dos
+// vinculos informados
+func ValidAgent(agentID uint, memberships []models.TeamMembership) error {
+	for _, membership := range memberships {
+		professional := membership.Professional
+		if membership.EndDate == nil && professional != nil && professional.ID == agentID && professional.Role == RoleACS {
+			return nil
+		}
+	}
+	return ErrInvalidAgent
+}
+
+// Assign monta a micro-area para a resposta da busca, com o ACS tirado da
+// lista atual da equipe. Um ACS que saiu da equipe deixa a micro-area
+// descoberta ate outro ser designado.
+func Assign(area models.MicroArea, members []models.TeamMember) *models.AssignedMicroArea {
+	assigned := &models.AssignedMicroArea{ID: area.ID, Code: area.Code, Name: area.Name}
+	if area.AgentID == nil {
+		return assigned
+	}
+	for _, member := range members {
+		if member.ProfessionalID == *area.AgentID {
+			agent := member
+			assigned.Agent = &agent
+			break
+		}
+	}
+	return assigned
+}
@@ -0,0 +1,169 @@
+// Package roster valida os profissionais das equipes e monta a lista de
+// quem atende cada equipe hoje: medico, enfermeiro, tecnicos e agentes
+// comunitarios de saude (ACS).
+package roster
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Funcoes dos profissionais na Estrategia Saude da Familia
+const (
+	RoleDoctor               = "doctor"
+	RoleNurse                = "nurse"
+	RoleNursingTechnician    = "nursing_technician"
+	RoleACS                  = "acs" // agente comunitario de saude
+	RoleDentist              = "dentist"
+	RoleOralHealthTechnician = "oral_health_technician"
+)
+
+// Canais de contato
+const (
+	ChannelPhone    = "phone"
+	ChannelWhatsApp = "whatsapp"
+	ChannelEmail    = "email"
+)
+
+// roleOrder e a ordem em que a equipe e apresentada ao cidadao
+var roleOrder = map[string]int{
+	RoleDoctor:               0,
+	RoleNurse:                1,
+	RoleNursingTechnician:    2,
+	RoleDentist:              3,
+	RoleOralHealthTechnician: 4,
+	RoleACS:                  5,
+}
+
+var shifts = map[string]bool{"morning": true, "afternoon": true, "full_time": true, "night": true}
+
+var (
+	ErrMissingName    = errors.New("name is required")
+	ErrInvalidRole    = errors.New("role must be 'doctor', 'nurse', 'nursing_technician', 'acs', 'dentist' or 'oral_health_technician'")
+	ErrInvalidCNS     = errors.New("CNS must have 15 digits and a valid check digit")
+	ErrInvalidShift   = errors.New("shift must be 'morning', 'afternoon', 'full_time' or 'night'")
+	ErrInvalidChannel = errors.New("contact_channel must be 'phone', 'whatsapp' or 'email'")
+	ErrInvalidContact = errors.New("contact does not match the contact channel")
+	ErrMissingContact = errors.New("contact and contact_channel must be informed together")
+)
+
+// BuildProfessional valida e normaliza os dados de um profissional
+func BuildProfessional(req models.CreateProfessionalRequest) (models.Professional, error) {
+	professional := models.Professional{
+		Name:           strings.TrimSpace(req.Name),
+		Role:           strings.ToLower(strings.TrimSpace(req.Role)),
+		CNS:            onlyDigits(req.CNS),
+		Registry:       strings.ToUpper(strings.Join(strings.Fields(req.Registry), " ")),
+		ContactChannel: strings.ToLower(strings.TrimSpace(req.ContactChannel)),
+		Contact:        strings.TrimSpace(req.Contact),
+		Shift:          strings.ToLower(strings.TrimSpace(req.Shift)),
+	}
+
+	if professional.Name == "" {
+		return professional, ErrMissingName
+	}
+	if _, ok := roleOrder[professional.Role]; !ok {
+		return professional, ErrInvalidRole
+	}
+	if professional.CNS != "" && !ValidCNS(professional.CNS) {
+		return professional, ErrInvalidCNS
+	}
+	if professional.Shift != "" && !shifts[professional.Shift] {
+		return professional, ErrInvalidShift
+	}
+
+	if (professional.ContactChannel == "") != (professional.Contact == "") {
+		return professional, ErrMissingContact
+	}
+	switch professional.ContactChannel {
+	case "":
+	case ChannelPhone, ChannelWhatsApp:
+		professional.Contact = onlyDigits(professional.Contact)
+		if len(professional.Contact) < 10 || len(professional.Contact) > 13 {
+			return professional, ErrInvalidContact
+		}
+	case ChannelEmail:
+		professional.Contact = strings.ToLower(professional.Contact)
+		if !strings.Contains(professional.Contact, "@") {
+			return professional, ErrInvalidContact
+		}
+	default:
+		return professional, ErrInvalidChannel
+	}
+	return professional, nil
+}
+
+// ValidRole diz se a funcao existe
+func ValidRole(role string) bool {
+	_, ok := roleOrder[role]
+	return ok
+}
+
+// ValidCNS confere o Cartao Nacional de Saude: 15 digitos cuja soma
+// ponderada (pesos de 15 a 1) e multipla de 11. A regra vale para os
+// cartoes definitivos (1 e 2) e provisorios (7, 8 e 9).
+func ValidCNS(cns string) bool {
+	if len(cns) != 15 || onlyDigits(cns) != cns {
+		return false
+	}
+	switch cns[0] {
+	case '1', '2', '7', '8', '9':
+	default:
+		return false
+	}
+	sum := 0
+	for i, digit := range cns {
+		sum += int(digit-'0') * (15 - i)
+	}
+	return sum%11 == 0
+}
+
+// Members monta a lista da equipe a partir dos vinculos atuais, na ordem de
+// apresentacao das funcoes e, dentro de cada uma, por nome
+func Members(memberships []models.TeamMembership) []models.TeamMember {
+	members := []models.TeamMember{}
+	for _, membership := range memberships {
+		if membership.EndDate != nil || membership.Professional == nil {
+			continue
+		}
+		professional := membership.Professional
+		members = append(members, models.TeamMember{
+			ProfessionalID: professional.ID,
+			Name:           professional.Name,
+			Role:           professional.Role,
+			Registry:       professional.Registry,
+			Shift:          professional.Shift,
+			ContactChannel: professional.ContactChannel,
+			Contact:        professional.Contact,
+			Since:          membership.StartDate,
+		})
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Role != members[j].Role {
+			return roleOrder[members[i].Role] < roleOrder[members[j].Role]
+		}
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+// ValidPeriod confere que o vinculo nao termina antes de comecar
+func ValidPeriod(start, end models.Date) error {
+	if end < start {
+		return fmt.Errorf("end_date %s is before start_date %s", end, start)
+	}
+	return nil
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package roster
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestValidCNS(t *testing.T) {
+	tests := map[string]bool{
+		"170364582900008": true,  // definitivo
+		"702002887429583": true,  // provisorio
+		"702002887429584": false, // digito errado
+		"370364582900008": false, // primeiro digito invalido
+		"17036458290000":  false, // curto
+		"17036458290000a": false,
+	}
+	for cns, want := range tests {
+		if got := ValidCNS(cns); got != want {
+			t.Errorf("ValidCNS(%q) = %v, want %v", cns, got, want)
+		}
+	}
+}
+
+func TestBuildProfessional(t *testing.T) {
+	professional, err := BuildProfessional(models.CreateProfessionalRequest{
+		Name:           "  Ana Souza ",
+		Role:           "Doctor",
+		CNS:            "170 3645 8290 0008",
+		Registry:       "crm-sp   123456",
+		ContactChannel: "WhatsApp",
+		Contact:        "(16) 99999-0000",
+		Shift:          "Morning",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := models.Professional{
+		Name: "Ana Souza", Role: RoleDoctor, CNS: "170364582900008", Registry: "CRM-SP 123456",
+		ContactChannel: ChannelWhatsApp, Contact: "16999990000", Shift: "morning",
+	}
+	if professional.Name != want.Name || professional.Role != want.Role || professional.CNS != want.CNS ||
+		professional.Registry != want.Registry || professional.ContactChannel != want.ContactChannel ||
+		professional.Contact != want.Contact || professional.Shift != want.Shift {
+		t.Errorf("expected %+v, got %+v", want, professional)
+	}
+
+	invalid := map[string]struct {
+		req  models.CreateProfessionalRequest
+		want error
+	}{
+		"no name":      {models.CreateProfessionalRequest{Role: "acs"}, ErrMissingName},
+		"role":         {models.CreateProfessionalRequest{Name: "Ana", Role: "surgeon"}, ErrInvalidRole},
+		"cns":          {models.CreateProfessionalRequest{Name: "Ana", Role: "acs", CNS: "123"}, ErrInvalidCNS},
+		"shift":        {models.CreateProfessionalRequest{Name: "Ana", Role: "acs", Shift: "weekend"}, ErrInvalidShift},
+		"channel":      {models.CreateProfessionalRequest{Name: "Ana", Role: "acs", ContactChannel: "fax", Contact: "123"}, ErrInvalidChannel},
+		"email":        {models.CreateProfessionalRequest{Name: "Ana", Role: "acs", ContactChannel: "email", Contact: "ana"}, ErrInvalidContact},
+		"contact only": {models.CreateProfessionalRequest{Name: "Ana", Role: "acs", Contact: "16999990000"}, ErrMissingContact},
+		"short phone":  {models.CreateProfessionalRequest{Name: "Ana", Role: "acs", ContactChannel: "phone", Contact: "9999"}, ErrInvalidContact},
+	}
+	for name, tt := range invalid {
+		if _, err := BuildProfessional(tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	ended := models.Date("2024-01-31")
+	memberships := []models.TeamMembership{
+		{StartDate: "2024-02-01", Professional: &models.Professional{ID: 1, Name: "Bruno", Role: RoleACS}},
+		{StartDate: "2023-01-01", EndDate: &ended, Professional: &models.Professional{ID: 2, Name: "Carla", Role: RoleDoctor}},
+		{StartDate: "2024-02-01", Professional: &models.Professional{ID: 3, Name: "Ana", Role: RoleACS}},
+		{StartDate: "2024-02-01", Professional: &models.Professional{ID: 4, Name: "Davi", Role: RoleDoctor, CNS: "170364582900008"}},
+	}
+
+	members := Members(memberships)
+	if len(members) != 3 {
+		t.Fatalf("expected the 3 current members, got %+v", members)
+	}
+	if members[0].Name != "Davi" || members[1].Name != "Ana" || members[2].Name != "Bruno" {
+		t.Errorf("expected doctor first and ACS by name, got %+v", members)
+	}
+	if members[0].Since != "2024-02-01" {
+		t.Errorf("expected membership start as since, got %q", members[0].Since)
+	}
+}
+
+func TestBuildMicroArea(t *testing.T) {
+	area, err := BuildMicroArea(7, models.CreateMicroAreaRequest{Code: " 3 ", Name: " Vila Nova "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if area.TeamID != 7 || area.Code != "03" || area.Name != "Vila Nova" {
+		t.Errorf("unexpected micro-area %+v", area)
+	}
+
+	for _, code := range []string{"", "0", "100", "1a", "-1", "+2"} {
+		if _, err := BuildMicroArea(7, models.CreateMicroAreaRequest{Code: code}); !errors.Is(err, ErrInvalidMicroAreaCode) {
+			t.Errorf("code %q: expected ErrInvalidMicroAreaCode, got %v", code, err)
+		}
+	}
+}
+
+func TestAgents(t *testing.T) {
+	ended := models.Date("2024-01-31")
+	memberships := []models.TeamMembership{
+		{StartDate: "2024-02-01", Professional: &models.Professional{ID: 1, Name: "Bruno", Role: RoleACS}},
+		{StartDate: "2023-01-01", EndDate: &ended, Professional: &models.Professional{ID: 2, Name: "Carla", Role: RoleACS}},
+		{StartDate: "2024-02-01", Professional: &models.Professional{ID: 3, Name: "Davi", Role: RoleNurse}},
+	}
+
+	if err := ValidAgent(1, memberships); err != nil {
+		t.Errorf("expected current ACS to be valid, got %v", err)
+	}
+	for _, id := range []uint{2, 3, 9} {
+		if err := ValidAgent(id, memberships); !errors.Is(err, ErrInvalidAgent) {
+			t.Errorf("professional %d: expected ErrInvalidAgent, got %v", id, err)
+		}
+	}
+
+	members := Members(memberships)
+	agent, former := uint(1), uint(2)
+	if assigned := Assign(models.MicroArea{ID: 5, Code: "01", AgentID: &agent}, members); assigned.Agent == nil || assigned.Agent.Name != "Bruno" {
+		t.Errorf("expected Bruno as agent, got %+v", assigned)
+	}
+	if assigned := Assign(models.MicroArea{ID: 5, Code: "01", AgentID: &former}, members); assigned.Agent != nil {
+		t.Errorf("expected an ACS who left the team to leave the micro-area uncovered, got %+v", assigned.Agent)
+	}
+}
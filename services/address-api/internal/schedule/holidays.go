@@ -0,0 +1,148 @@
+package schedule
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"shared/normalize"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidScope = errors.New("scope must be 'national', 'state' or 'municipal'")
+	ErrMissingState = errors.New("state is required for state and municipal holidays")
+	ErrMissingCity  = errors.New("city is required for municipal holidays")
+)
+
+const (
+	ScopeNational  = "national"
+	ScopeState     = "state"
+	ScopeMunicipal = "municipal"
+)
+
+// fixedHolidays sao os feriados nacionais de data fixa (Lei 662/1949 e
+// alteracoes)
+var fixedHolidays = []struct {
+	month time.Month
+	day   int
+	name  string
+	since int // ano em que passou a valer
+}{
+	{time.January, 1, "Confraternização Universal", 0},
+	{time.April, 21, "Tiradentes", 0},
+	{time.May, 1, "Dia do Trabalho", 0},
+	{time.September, 7, "Independência do Brasil", 0},
+	{time.October, 12, "Nossa Senhora Aparecida", 0},
+	{time.November, 2, "Finados", 0},
+	{time.November, 15, "Proclamação da República", 0},
+	{time.November, 20, "Dia Nacional de Zumbi e da Consciência Negra", 2024},
+	{time.December, 25, "Natal", 0},
+}
+
+// easterHolidays sao as datas moveis, em dias a partir do domingo de Pascoa.
+// Carnaval e Corpus Christi sao pontos facultativos, mas fecham a maioria dos
+// servicos municipais.
+var easterHolidays = []struct {
+	offset   int
+	name     string
+	optional bool
+}{
+	{-48, "Carnaval", true},
+	{-47, "Carnaval", true},
+	{-2, "Sexta-feira Santa", false},
+	{60, "Corpus Christi", true},
+}
+
+// NationalHolidays calcula o calendario nacional do ano, em ordem de data.
+// Os pontos facultativos so entram com optional.
+func NationalHolidays(year int, optional bool) []models.Holiday {
+	var days []models.Holiday
+	add := func(day time.Time, name string, isOptional bool) {
+		days = append(days, models.Holiday{
+			Date:     DateOf(day),
+			Name:     name,
+			Scope:    ScopeNational,
+			Optional: isOptional,
+		})
+	}
+
+	for _, h := range fixedHolidays {
+		if year >= h.since {
+			add(time.Date(year, h.month, h.day, 0, 0, 0, 0, Location), h.name, false)
+		}
+	}
+	easter := Easter(year)
+	for _, h := range easterHolidays {
+		if optional || !h.optional {
+			add(easter.AddDate(0, 0, h.offset), h.name, h.optional)
+		}
+	}
+
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days
+}
+
+// Easter calcula o domingo de Pascoa no calendario gregoriano (algoritmo de
+// Meeus/Jones/Butcher)
+func Easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, Location)
+}
+
+// Applies diz se o feriado vale para a UBS: os nacionais para todas, os
+// estaduais para as do estado e os municipais para as da cidade
+func Applies(holiday models.Holiday, ubs models.UBS) bool {
+	switch holiday.Scope {
+	case ScopeNational:
+		return true
+	case ScopeState:
+		return holiday.State == ubs.State
+	case ScopeMunicipal:
+		return holiday.State == ubs.State && normalize.Text(holiday.City) == normalize.Text(ubs.City)
+	}
+	return false
+}
+
+// BuildHoliday valida um feriado cadastrado a mao. Os nacionais nao guardam
+// estado nem cidade.
+func BuildHoliday(req models.CreateHolidayRequest) (models.Holiday, error) {
+	if _, err := ParseDate(req.Date); err != nil {
+		return models.Holiday{}, err
+	}
+	holiday := models.Holiday{
+		Date:     req.Date,
+		Name:     strings.TrimSpace(req.Name),
+		Scope:    req.Scope,
+		Optional: req.Optional,
+	}
+	switch req.Scope {
+	case ScopeNational:
+		return holiday, nil
+	case ScopeState, ScopeMunicipal:
+	default:
+		return models.Holiday{}, ErrInvalidScope
+	}
+
+	holiday.State = strings.ToUpper(strings.TrimSpace(req.State))
+	if holiday.State == "" {
+		return models.Holiday{}, ErrMissingState
+	}
+	if req.Scope == ScopeMunicipal {
+		holiday.City = strings.ToUpper(strings.TrimSpace(req.City))
+		if holiday.City == "" {
+			return models.Holiday{}, ErrMissingCity
+		}
+	}
+	return holiday, nil
+}
@@ -0,0 +1,85 @@
+package schedule
+
+import (
+	"address-api/internal/models"
+	"testing"
+)
+
+func TestEaster(t *testing.T) {
+	tests := map[int]string{
+		2000: "2000-04-23",
+		2019: "2019-04-21",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+		2038: "2038-04-25",
+	}
+	for year, want := range tests {
+		if got := DateOf(Easter(year)); string(got) != want {
+			t.Errorf("Easter(%d) = %s, want %s", year, got, want)
+		}
+	}
+}
+
+func TestNationalHolidays(t *testing.T) {
+	days := NationalHolidays(2025, true)
+	byDate := map[models.Date]models.Holiday{}
+	for i, day := range days {
+		byDate[day.Date] = day
+		if i > 0 && days[i-1].Date > day.Date {
+			t.Errorf("expected holidays in date order, got %s after %s", day.Date, days[i-1].Date)
+		}
+		if day.Scope != ScopeNational {
+			t.Errorf("expected national scope, got %q", day.Scope)
+		}
+	}
+	if len(days) != 13 {
+		t.Errorf("expected 13 holidays in 2025, got %d: %+v", len(days), days)
+	}
+
+	expected := map[models.Date]string{
+		"2025-03-03": "Carnaval",
+		"2025-03-04": "Carnaval",
+		"2025-04-18": "Sexta-feira Santa",
+		"2025-06-19": "Corpus Christi",
+		"2025-11-20": "Dia Nacional de Zumbi e da Consciência Negra",
+		"2025-12-25": "Natal",
+	}
+	for date, name := range expected {
+		if byDate[date].Name != name {
+			t.Errorf("expected %s on %s, got %+v", name, date, byDate[date])
+		}
+	}
+	if !byDate["2025-06-19"].Optional || byDate["2025-04-18"].Optional {
+		t.Error("expected Corpus Christi to be optional and Good Friday not")
+	}
+
+	if days := NationalHolidays(2025, false); len(days) != 10 {
+		t.Errorf("expected 10 holidays without the optional ones, got %d", len(days))
+	}
+	// O 20 de novembro so virou feriado nacional em 2024
+	for _, day := range NationalHolidays(2023, false) {
+		if day.Date == "2023-11-20" {
+			t.Error("expected no national holiday on 2023-11-20")
+		}
+	}
+}
+
+func TestApplies(t *testing.T) {
+	ubs := models.UBS{City: "SÃO CARLOS", State: "SP"}
+	tests := []struct {
+		holiday models.Holiday
+		want    bool
+	}{
+		{models.Holiday{Scope: ScopeNational}, true},
+		{models.Holiday{Scope: ScopeState, State: "SP"}, true},
+		{models.Holiday{Scope: ScopeState, State: "RJ"}, false},
+		{models.Holiday{Scope: ScopeMunicipal, State: "SP", City: "Sao Carlos"}, true},
+		{models.Holiday{Scope: ScopeMunicipal, State: "SP", City: "CAMPINAS"}, false},
+	}
+	for _, tt := range tests {
+		if got := Applies(tt.holiday, ubs); got != tt.want {
+			t.Errorf("Applies(%+v) = %v, want %v", tt.holiday, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package schedule responde se uma UBS esta aberta a partir do seu horario
+// semanal, dos feriados e dos fechamentos temporarios, e quando ela abre de
+// novo. Os horarios sao sempre interpretados no fuso de Sao Paulo,
+// independente do fuso do servidor.
+package schedule
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	// A imagem final nao tem o banco de fusos do sistema
+	_ "time/tzdata"
+)
+
+var (
+	ErrInvalidHours = errors.New("invalid opening hours")
+	ErrInvalidDate  = errors.New("date must be in the format YYYY-MM-DD")
+)
+
+// Motivos de uma UBS estar fechada
+const (
+	ReasonOutsideHours = "outside_hours"
+	ReasonHoliday      = "holiday"
+	ReasonClosure      = "closure"
+	ReasonNoHours      = "no_hours"
+)
+
+// LookaheadDays limita a procura pela proxima abertura; uma UBS fechada por
+// mais tempo que isso fica sem proxima abertura
+const LookaheadDays = 90
+
+// Location e o fuso em que os horarios das UBS sao cadastrados
+var Location = mustLoad("America/Sao_Paulo")
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Validate confere o dia da semana e os horarios de cada intervalo. O
+// fechamento precisa ser depois da abertura: atendimentos que viram a noite
+// sao cadastrados como dois intervalos.
+func Validate(periods []models.OpeningPeriod) error {
+	for _, period := range periods {
+		if period.Weekday < 0 || period.Weekday > 6 {
+			return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6 (Saturday), got %d", ErrInvalidHours, period.Weekday)
+		}
+		opens, err := parseClock(period.Opens)
+		if err != nil {
+			return err
+		}
+		closes, err := parseClock(period.Closes)
+		if err != nil {
+			return err
+		}
+		if closes <= opens {
+			return fmt.Errorf("%w: %s-%s closes before it opens", ErrInvalidHours, period.Opens, period.Closes)
+		}
+	}
+	return nil
+}
+
+// IsOpen diz se algum intervalo cobre o instante t, convertido para o fuso
+// de Sao Paulo. O horario de fechamento nao conta como aberto. Feriados e
+// fechamentos ficam de fora; para eles use Status.
+func IsOpen(periods []models.OpeningPeriod, t time.Time) bool {
+	_, open := current(periods, t.In(Location))
+	return open
+}
+
+// Calendar reune as excecoes ao horario semanal de uma UBS
+type Calendar struct {
+	Holidays []models.Holiday
+	Closures []models.UBSClosure
+}
+
+// ForUBS separa, dos feriados e fechamentos de todas as UBS, os que valem
+// para a UBS
+func ForUBS(ubs models.UBS, holidays []models.Holiday, closures []models.UBSClosure) Calendar {
+	var calendar Calendar
+	for _, holiday := range holidays {
+		if Applies(holiday, ubs) {
+			calendar.Holidays = append(calendar.Holidays, holiday)
+		}
+	}
+	for _, closure := range closures {
+		if closure.UBSID == ubs.ID {
+			calendar.Closures = append(calendar.Closures, closure)
+		}
+	}
+	return calendar
+}
+
+// closedOn diz se a UBS fica fechada o dia todo e por que
+func (c Calendar) closedOn(day models.Date) (reason, detail string, closed bool) {
+	for _, holiday := range c.Holidays {
+		if holiday.Date == day {
+			return ReasonHoliday, holiday.Name, true
+		}
+	}
+	for _, closure := range c.Closures {
+		if closure.StartDate <= day && day <= closure.EndDate {
+			return ReasonClosure, closure.Reason, true
+		}
+	}
+	return "", "", false
+}
+
+// Status diz se a UBS esta aberta no instante t. Aberta, traz o horario em
+// que fecha; fechada, o motivo e a proxima abertura.
+func Status(ubs models.UBS, calendar Calendar, t time.Time) models.OpeningStatus {
+	local := t.In(Location)
+	status := models.OpeningStatus{UBSID: ubs.ID, At: local}
+	if len(ubs.OpeningHours) == 0 {
+		status.Reason = ReasonNoHours
+		return status
+	}
+
+	reason, detail, closed := calendar.closedOn(DateOf(local))
+	if !closed {
+		if period, open := current(ubs.OpeningHours, local); open {
+			closes := closingTime(ubs.OpeningHours, local, period)
+			status.Open = true
+			status.ClosesAt = &closes
+			return status
+		}
+		reason = ReasonOutsideHours
+	}
+
+	status.Reason, status.Detail = reason, detail
+	status.NextOpening = nextOpening(ubs.OpeningHours, calendar, local)
+	return status
+}
+
+// current devolve o intervalo do dia que cobre o horario local
+func current(periods []models.OpeningPeriod, local time.Time) (models.OpeningPeriod, bool) {
+	minute := local.Hour()*60 + local.Minute()
+	for _, period := range periods {
+		if time.Weekday(period.Weekday) != local.Weekday() {
+			continue
+		}
+		opens, err := parseClock(period.Opens)
+		if err != nil {
+			continue
+		}
+		closes, err := parseClock(period.Closes)
+		if err != nil {
+			continue
+		}
+		if minute >= opens && minute < closes {
+			return period, true
+		}
+	}
+	return models.OpeningPeriod{}, false
+}
+
+// closingTime e o fechamento do intervalo atual, emendando os intervalos
+// seguintes que comecam exatamente quando ele termina
+func closingTime(periods []models.OpeningPeriod, local time.Time, period models.OpeningPeriod) time.Time {
+	closes := period.Closes
+	for extended := true; extended; {
+		extended = false
+		for _, next := range periods {
+			if time.Weekday(next.Weekday) == local.Weekday() && next.Opens == closes && next.Closes != closes {
+				closes, extended = next.Closes, true
+				break
+			}
+		}
+	}
+	return clockOn(local, closes)
+}
+
+// nextOpening procura, a partir do instante local, o primeiro intervalo em
+// um dia sem feriado nem fechamento
+func nextOpening(periods []models.OpeningPeriod, calendar Calendar, local time.Time) *time.Time {
+	for i := 0; i <= LookaheadDays; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, Location)
+		if _, _, closed := calendar.closedOn(DateOf(day)); closed {
+			continue
+		}
+
+		var starts []time.Time
+		for _, period := range periods {
+			if time.Weekday(period.Weekday) != day.Weekday() {
+				continue
+			}
+			if start := clockOn(day, period.Opens); start.After(local) {
+				starts = append(starts, start)
+			}
+		}
+		if len(starts) > 0 {
+			sort.Slice(starts, func(a, b int) bool { return starts[a].Before(starts[b]) })
+			return &starts[0]
+		}
+	}
+	return nil
+}
+
+// clockOn combina o dia com um horario HH:MM ja validado
+func clockOn(day time.Time, clock string) time.Time {
+	minutes, _ := parseClock(clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, Location)
+}
+
+// DateOf e o dia do instante no fuso de Sao Paulo
+func DateOf(t time.Time) models.Date {
+	return models.Date(t.In(Location).Format("2006-01-02"))
+}
+
+// ParseDate valida uma data AAAA-MM-DD
+func ParseDate(date models.Date) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", string(date), Location)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return day, nil
+}
+
+// AddDays soma dias a uma data valida; days negativo volta no calendario
+func AddDays(date models.Date, days int) models.Date {
+	day, err := ParseDate(date)
+	if err != nil {
+		return date
+	}
+	return DateOf(day.AddDate(0, 0, days))
+}
+
+// parseClock converte HH:MM em minutos desde a meia-noite. 24:00 e aceito
+// como fechamento a meia-noite.
+func parseClock(s string) (int, error) {
+	clock, err := time.Parse("15:04", s)
+	if err != nil {
+		if s == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("%w: %q is not a HH:MM time", ErrInvalidHours, s)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
@@ -0,0 +1,148 @@
+package schedule
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+// weekdays atende de segunda a sexta, das 7h as 12h e das 13h as 17h
+var weekdays = func() []models.OpeningPeriod {
+	var periods []models.OpeningPeriod
+	for day := 1; day <= 5; day++ {
+		periods = append(periods,
+			models.OpeningPeriod{Weekday: day, Opens: "07:00", Closes: "12:00"},
+			models.OpeningPeriod{Weekday: day, Opens: "13:00", Closes: "17:00"},
+		)
+	}
+	return periods
+}()
+
+func TestIsOpen(t *testing.T) {
+	tests := map[string]struct {
+		at   time.Time
+		open bool
+	}{
+		"monday morning":         {time.Date(2024, 3, 4, 8, 30, 0, 0, Location), true},
+		"lunch break":            {time.Date(2024, 3, 4, 12, 30, 0, 0, Location), false},
+		"closing time":           {time.Date(2024, 3, 4, 17, 0, 0, 0, Location), false},
+		"saturday":               {time.Date(2024, 3, 9, 9, 0, 0, 0, Location), false},
+		"UTC converted to local": {time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC), true}, // 8h em Sao Paulo
+		"UTC still the day":      {time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC), false}, // 22h de segunda
+		"UTC next day local":     {time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC), false}, // 23h de sexta
+		"before opening":         {time.Date(2024, 3, 8, 6, 59, 0, 0, Location), false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := IsOpen(weekdays, tt.at); got != tt.open {
+				t.Errorf("expected open=%v at %s, got %v", tt.open, tt.at, got)
+			}
+		})
+	}
+
+	if IsOpen(nil, time.Now()) {
+		t.Error("expected a UBS without hours to be closed")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(weekdays); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Validate([]models.OpeningPeriod{{Weekday: 6, Opens: "18:00", Closes: "24:00"}}); err != nil {
+		t.Errorf("expected 24:00 to be accepted as closing time, got %v", err)
+	}
+
+	invalid := map[string]models.OpeningPeriod{
+		"weekday":        {Weekday: 7, Opens: "07:00", Closes: "17:00"},
+		"format":         {Weekday: 1, Opens: "7h", Closes: "17:00"},
+		"closes earlier": {Weekday: 1, Opens: "17:00", Closes: "07:00"},
+		"empty":          {Weekday: 1, Opens: "07:00", Closes: "07:00"},
+	}
+	for name, period := range invalid {
+		if err := Validate([]models.OpeningPeriod{period}); !errors.Is(err, ErrInvalidHours) {
+			t.Errorf("%s: expected ErrInvalidHours, got %v", name, err)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ubs := models.UBS{ID: 1, City: "SAO CARLOS", State: "SP", OpeningHours: weekdays}
+	calendar := Calendar{
+		Holidays: []models.Holiday{{Date: "2024-03-29", Name: "Sexta-feira Santa", Scope: ScopeNational}},
+		Closures: []models.UBSClosure{{UBSID: 1, StartDate: "2024-04-01", EndDate: "2024-04-02", Reason: "Dedetização"}},
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, Location)
+	}
+
+	tests := map[string]struct {
+		at          time.Time
+		open        bool
+		reason      string
+		closesAt    time.Time
+		nextOpening time.Time
+	}{
+		"open in the morning": {
+			at: at(time.March, 27, 9, 0), open: true, closesAt: at(time.March, 27, 12, 0),
+		},
+		"lunch break opens in the afternoon": {
+			at: at(time.March, 27, 12, 30), reason: ReasonOutsideHours, nextOpening: at(time.March, 27, 13, 0),
+		},
+		"evening opens next morning": {
+			at: at(time.March, 27, 20, 0), reason: ReasonOutsideHours, nextOpening: at(time.March, 28, 7, 0),
+		},
+		// Feriado na sexta, fim de semana e fechamento na segunda e terca
+		"holiday skips weekend and closure": {
+			at: at(time.March, 29, 9, 0), reason: ReasonHoliday, nextOpening: at(time.April, 3, 7, 0),
+		},
+		"closure": {
+			at: at(time.April, 2, 9, 0), reason: ReasonClosure, nextOpening: at(time.April, 3, 7, 0),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := Status(ubs, calendar, tt.at)
+			if status.Open != tt.open || status.Reason != tt.reason {
+				t.Fatalf("expected open=%v reason=%q, got %+v", tt.open, tt.reason, status)
+			}
+			if tt.open && (status.ClosesAt == nil || !status.ClosesAt.Equal(tt.closesAt)) {
+				t.Errorf("expected to close at %s, got %v", tt.closesAt, status.ClosesAt)
+			}
+			if !tt.open && (status.NextOpening == nil || !status.NextOpening.Equal(tt.nextOpening)) {
+				t.Errorf("expected next opening at %s, got %v", tt.nextOpening, status.NextOpening)
+			}
+		})
+	}
+
+	if status := Status(ubs, calendar, at(time.March, 29, 9, 0)); status.Detail != "Sexta-feira Santa" {
+		t.Errorf("expected the holiday name as detail, got %q", status.Detail)
+	}
+	if status := Status(models.UBS{ID: 2}, Calendar{}, at(time.March, 27, 9, 0)); status.Open || status.Reason != ReasonNoHours || status.NextOpening != nil {
+		t.Errorf("expected a UBS without hours to have no status, got %+v", status)
+	}
+
+	// Intervalos emendados fecham no fim do ultimo
+	continuous := models.UBS{OpeningHours: []models.OpeningPeriod{
+		{Weekday: 3, Opens: "07:00", Closes: "12:00"},
+		{Weekday: 3, Opens: "12:00", Closes: "19:00"},
+	}}
+	if status := Status(continuous, Calendar{}, at(time.March, 27, 9, 0)); status.ClosesAt == nil || !status.ClosesAt.Equal(at(time.March, 27, 19, 0)) {
+		t.Errorf("expected adjoining periods to close at 19:00, got %v", status.ClosesAt)
+	}
+}
+
+func TestForUBS(t *testing.T) {
+	ubs := models.UBS{ID: 1, City: "SAO CARLOS", State: "SP"}
+	holidays := []models.Holiday{
+		{Date: "2024-11-04", Scope: ScopeMunicipal, State: "SP", City: "SAO CARLOS", Name: "Aniversário da cidade"},
+		{Date: "2024-07-09", Scope: ScopeState, State: "RJ", Name: "Outro estado"},
+	}
+	closures := []models.UBSClosure{{UBSID: 1}, {UBSID: 2}}
+
+	calendar := ForUBS(ubs, holidays, closures)
+	if len(calendar.Holidays) != 1 || len(calendar.Closures) != 1 {
+		t.Errorf("expected one holiday and one closure for the UBS, got %+v", calendar)
+	}
+}
@@ -0,0 +1,91 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"errors"
+	"shared/normalize"
+	"sort"
+	"strings"
+)
+
+// Tipos de alias de rua
+const (
+	AliasOld         = "old"         // nome anterior a uma mudanca oficial
+	AliasPopular     = "popular"     // como os moradores chamam a rua
+	AliasMisspelling = "misspelling" // grafia errada que aparece com frequencia
+)
+
+var (
+	ErrInvalidAliasKind  = errors.New("kind must be 'old', 'popular' or 'misspelling'")
+	ErrAliasIsStreetName = errors.New("alias is the street name itself")
+)
+
+// aliasWeights descontam a similaridade de uma rua encontrada pelo alias. Um
+// nome antigo foi oficial e identifica a rua quase tao bem quanto o atual; uma
+// grafia errada pode ser parecida com o nome de outra rua.
+var aliasWeights = map[string]float64{
+	AliasOld:         0.95,
+	AliasPopular:     0.9,
+	AliasMisspelling: 0.8,
+}
+
+// BuildStreetAlias normaliza o alias e valida o tipo, que e 'popular' quando
+// vazio. O alias nao pode ser o proprio nome da rua do segmento.
+func BuildStreetAlias(segment models.StreetSegment, alias, kind string) (models.StreetAlias, error) {
+	result := models.StreetAlias{
+		StreetSegmentID: segment.ID,
+		Alias:           normalize.StreetName(alias),
+		OriginalAlias:   strings.TrimSpace(alias),
+		Kind:            strings.ToLower(strings.TrimSpace(kind)),
+	}
+	if result.Kind == "" {
+		result.Kind = AliasPopular
+	}
+
+	if result.Alias == "" {
+		return result, &FieldError{Field: "alias", Err: ErrMissingField}
+	}
+	if _, ok := aliasWeights[result.Kind]; !ok {
+		return result, &FieldError{Field: "kind", Err: ErrInvalidAliasKind}
+	}
+	if result.Alias == segment.StreetName {
+		return result, &FieldError{Field: "alias", Err: ErrAliasIsStreetName}
+	}
+	return result, nil
+}
+
+// MergeAliasResults junta os segmentos encontrados pelo nome com os
+// encontrados por alias. Um segmento que aparece nos dois fica com o que
+// tiver a maior similaridade ja descontado o peso do alias.
+func MergeAliasResults(results, aliasResults []repository.SearchResult) []repository.SearchResult {
+	if len(aliasResults) == 0 {
+		return results
+	}
+
+	merged := make([]repository.SearchResult, 0, len(results)+len(aliasResults))
+	position := map[uint]int{}
+	for _, result := range append(append([]repository.SearchResult{}, results...), aliasResults...) {
+		i, seen := position[result.Segment.ID]
+		switch {
+		case !seen:
+			position[result.Segment.ID] = len(merged)
+			merged = append(merged, result)
+		case streetSimilarity(result) > streetSimilarity(merged[i]):
+			merged[i] = result
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return streetSimilarity(merged[i]) > streetSimilarity(merged[j])
+	})
+	return merged
+}
+
+// streetSimilarity e a similaridade do nome da rua, com o peso do alias
+// quando o segmento foi encontrado por um
+func streetSimilarity(result repository.SearchResult) float64 {
+	if result.Alias == nil {
+		return result.Similarity
+	}
+	return round(result.Similarity * aliasWeights[result.Alias.Kind])
+}
@@ -0,0 +1,63 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"errors"
+	"testing"
+)
+
+func TestBuildStreetAlias(t *testing.T) {
+	segment := models.StreetSegment{ID: 3, StreetName: "15 NOVEMBRO"}
+
+	alias, err := BuildStreetAlias(segment, " Rua do Comércio ", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if alias.StreetSegmentID != 3 || alias.Alias != "COMERCIO" || alias.OriginalAlias != "Rua do Comércio" || alias.Kind != AliasPopular {
+		t.Errorf("alias not normalized: %+v", alias)
+	}
+
+	tests := []struct {
+		name, alias, kind string
+		want              error
+	}{
+		{"blank alias", "  ", "old", ErrMissingField},
+		{"unknown kind", "Rua do Comercio", "apelido", ErrInvalidAliasKind},
+		{"same as the street", "Rua XV de Novembro", "OLD", ErrAliasIsStreetName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildStreetAlias(segment, tt.alias, tt.kind); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMergeAliasResults(t *testing.T) {
+	byName := result(1, 1, "DAS FLORES", "CENTRO", "13560")
+	byName.Similarity = 0.5
+	popular := result(1, 1, "DAS FLORES", "CENTRO", "13560")
+	popular.Alias = &models.StreetAlias{ID: 7, Alias: "CAMINHO FLORES", Kind: AliasPopular}
+	popular.Similarity = 1
+	misspelled := result(2, 2, "DOS CRAVOS", "CENTRO", "13560")
+	misspelled.Alias = &models.StreetAlias{ID: 8, Alias: "CRAVOS", Kind: AliasMisspelling}
+	misspelled.Similarity = 1
+
+	merged := MergeAliasResults([]repository.SearchResult{byName}, []repository.SearchResult{misspelled, popular})
+	if len(merged) != 2 {
+		t.Fatalf("expected each segment once, got %+v", merged)
+	}
+	if merged[0].Segment.ID != 1 || merged[0].Alias == nil || merged[0].Alias.ID != 7 {
+		t.Errorf("expected the popular alias to beat the weaker name match, got %+v", merged[0])
+	}
+	if merged[1].Segment.ID != 2 || streetSimilarity(merged[1]) != 0.8 {
+		t.Errorf("expected misspelling weight on segment 2, got %+v (%.2f)", merged[1], streetSimilarity(merged[1]))
+	}
+
+	got := Rank(SearchQuery{Street: "CAMINHO FLORES", Number: 10}, merged, nil)
+	if got.Team.ID != 1 || got.Score != 0.9 || got.Candidates[0].Alias == nil || got.Candidates[0].Reasons[0].Field != "alias" {
+		t.Errorf("unexpected ranking %+v", got)
+	}
+}
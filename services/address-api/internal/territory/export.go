@@ -0,0 +1,235 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/schedule"
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Formato de exportacao para impressao, alem de CSV e GeoJSON
+const FormatHTML = "html"
+
+// exportColumns segue o layout da importacao, entao o CSV exportado pode ser
+// importado de volta; team_name e ubs_name sao ignorados na importacao
+var exportColumns = []string{
+	"street_type", "street_name", "neighborhood", "city", "state",
+	"start_number", "end_number", "cep", "even_odd", "team_id", "team_name", "ubs_name",
+}
+
+// SortForExport ordena os segmentos por equipe, rua e numeracao
+func SortForExport(segments []models.StreetSegment) {
+	sort.SliceStable(segments, func(i, j int) bool {
+		a, b := segments[i], segments[j]
+		if a.Team.Name != b.Team.Name {
+			return a.Team.Name < b.Team.Name
+		}
+		if a.StreetName != b.StreetName {
+			return a.StreetName < b.StreetName
+		}
+		return a.StartNumber < b.StartNumber
+	})
+}
+
+// exportStreetName e o nome da rua como foi cadastrado, o mesmo no CSV e no
+// GeoJSON
+func exportStreetName(segment models.StreetSegment) string {
+	if segment.OriginalStreetName != "" {
+		return segment.OriginalStreetName
+	}
+	return segment.StreetName
+}
+
+func exportValues(segment models.StreetSegment) map[string]string {
+	return map[string]string{
+		"street_type":  segment.StreetType,
+		"street_name":  exportStreetName(segment),
+		"neighborhood": segment.Neighborhood,
+		"city":         segment.City,
+		"state":        segment.State,
+		"start_number": strconv.Itoa(segment.StartNumber),
+		"end_number":   strconv.Itoa(segment.EndNumber),
+		"cep":          segment.CEPPrefix,
+		"even_odd":     segment.EvenOdd,
+		"team_id":      strconv.FormatUint(uint64(segment.TeamID), 10),
+		"team_name":    segment.Team.Name,
+		"ubs_name":     segment.Team.UBS.Name,
+	}
+}
+
+// WriteCSV escreve os segmentos no layout da importacao
+func WriteCSV(w io.Writer, segments []models.StreetSegment) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		values := exportValues(segment)
+		record := make([]string, len(exportColumns))
+		for i, column := range exportColumns {
+			record[i] = values[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type exportFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// WriteGeoJSON escreve uma FeatureCollection com uma feature por segmento.
+// Os segmentos ainda nao tem geometria, que sai como null.
+func WriteGeoJSON(w io.Writer, segments []models.StreetSegment) error {
+	features := make([]exportFeature, 0, len(segments))
+	for _, segment := range segments {
+		features = append(features, exportFeature{
+			Type:     "Feature",
+			Geometry: json.RawMessage("null"),
+			Properties: map[string]interface{}{
+				"id":           segment.ID,
+				"street_type":  segment.StreetType,
+				"street_name":  exportStreetName(segment),
+				"neighborhood": segment.Neighborhood,
+				"city":         segment.City,
+				"state":        segment.State,
+				"start_number": segment.StartNumber,
+				"end_number":   segment.EndNumber,
+				"cep":          segment.CEPPrefix,
+				"even_odd":     segment.EvenOdd,
+				"team_id":      segment.TeamID,
+				"team_name":    segment.Team.Name,
+				"ubs_name":     segment.Team.UBS.Name,
+			},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// Summary e o resumo impresso do territorio de uma UBS ou equipe
+type Summary struct {
+	Title       string
+	Subtitle    string
+	GeneratedAt time.Time
+	Teams       []TeamTerritory
+}
+
+// TeamTerritory sao os segmentos de rua de uma equipe
+type TeamTerritory struct {
+	Team     models.Team
+	Segments []models.StreetSegment
+}
+
+// Streets conta as ruas distintas do territorio
+func (t TeamTerritory) Streets() int {
+	streets := map[string]bool{}
+	for _, segment := range t.Segments {
+		streets[segment.StreetType+" "+segment.StreetName] = true
+	}
+	return len(streets)
+}
+
+// NewSummary agrupa os segmentos por equipe; equipes sem segmentos tambem
+// aparecem, para mostrar que estao sem territorio
+func NewSummary(title, subtitle string, teams []models.Team, segments []models.StreetSegment) Summary {
+	summary := Summary{Title: title, Subtitle: subtitle, GeneratedAt: time.Now().In(schedule.Location)}
+
+	sort.SliceStable(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	index := map[uint]int{}
+	for _, team := range teams {
+		index[team.ID] = len(summary.Teams)
+		summary.Teams = append(summary.Teams, TeamTerritory{Team: team})
+	}
+	for _, segment := range segments {
+		if i, ok := index[segment.TeamID]; ok {
+			summary.Teams[i].Segments = append(summary.Teams[i].Segments, segment)
+		}
+	}
+	return summary
+}
+
+var evenOddLabels = map[string]string{
+	"even": "Pares",
+	"odd":  "Ímpares",
+	"all":  "Todos",
+}
+
+var summaryTemplate = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"evenOdd": func(value string) string { return evenOddLabels[value] },
+	"date":    func(t time.Time) string { return t.Format("02/01/2006 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: sans-serif; font-size: 12px; margin: 24px; }
+  h1 { font-size: 20px; margin-bottom: 0; }
+  h2 { font-size: 16px; margin-top: 24px; border-bottom: 1px solid #999; }
+  .meta { color: #555; }
+  table { width: 100%; border-collapse: collapse; }
+  th, td { border: 1px solid #ccc; padding: 4px 6px; text-align: left; }
+  th { background: #eee; }
+  td.number { text-align: right; }
+  .empty { font-style: italic; color: #777; }
+  @media print {
+    body { margin: 0; }
+    section { page-break-inside: avoid; }
+    section + section { page-break-before: always; }
+  }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Subtitle}}<p class="meta">{{.}}</p>{{end}}
+<p class="meta">Gerado em {{date .GeneratedAt}}</p>
+{{range .Teams}}
+<section>
+  <h2>{{.Team.Name}}</h2>
+  {{if .Segments}}
+  <p class="meta">{{len .Segments}} trechos em {{.Streets}} ruas</p>
+  <table>
+    <thead>
+      <tr><th>Logradouro</th><th>Bairro</th><th>De</th><th>Até</th><th>Números</th><th>CEP</th></tr>
+    </thead>
+    <tbody>
+      {{range .Segments}}
+      <tr>
+        <td>{{.StreetType}} {{.StreetName}}</td>
+        <td>{{.Neighborhood}}</td>
+        <td class="number">{{.StartNumber}}</td>
+        <td class="number">{{.EndNumber}}</td>
+        <td>{{evenOdd .EvenOdd}}</td>
+        <td>{{.CEPPrefix}}</td>
+      </tr>
+      {{end}}
+    </tbody>
+  </table>
+  {{else}}
+  <p class="empty">Nenhum trecho de rua atribuído a esta equipe.</p>
+  {{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML escreve o resumo em uma pagina pronta para imprimir (ou salvar
+// como PDF pelo navegador)
+func WriteHTML(w io.Writer, summary Summary) error {
+	return summaryTemplate.Execute(w, summary)
+}
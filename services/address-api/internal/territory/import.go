@@ -0,0 +1,362 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoRows            = errors.New("no rows to import")
+	ErrUnsupportedFormat = errors.New("unsupported format, use csv or geojson")
+)
+
+// Formatos aceitos na importacao
+const (
+	FormatCSV     = "csv"
+	FormatGeoJSON = "geojson"
+)
+
+// Row e uma linha do arquivo importado. Err guarda problemas de leitura da
+// linha (por exemplo um numero invalido), que entram no relatorio.
+type Row struct {
+	Line    int
+	Request models.CreateStreetSegmentRequest
+	Err     error
+}
+
+// columnAliases mapeia os nomes de coluna aceitos, inclusive os usados nas
+// planilhas da prefeitura, para os campos de CreateStreetSegmentRequest
+var columnAliases = map[string]string{
+	"street_type":  "street_type",
+	"tipo":         "street_type",
+	"street_name":  "street_name",
+	"logradouro":   "street_name",
+	"rua":          "street_name",
+	"neighborhood": "neighborhood",
+	"bairro":       "neighborhood",
+	"city":         "city",
+	"cidade":       "city",
+	"municipio":    "city",
+	"state":        "state",
+	"uf":           "state",
+	"start_number": "start_number",
+	"inicio":       "start_number",
+	"end_number":   "end_number",
+	"fim":          "end_number",
+	"cep":          "cep",
+	"cep_prefix":   "cep",
+	"even_odd":     "even_odd",
+	"paridade":     "even_odd",
+	"team_id":      "team_id",
+	"equipe_id":    "team_id",
+}
+
+var requiredColumns = []string{"street_name", "city", "state", "start_number", "end_number", "team_id"}
+
+// evenOddAliases aceita a paridade em portugues; vazio vale para todos os numeros
+var evenOddAliases = map[string]string{
+	"":        "all",
+	"todos":   "all",
+	"ambos":   "all",
+	"par":     "even",
+	"pares":   "even",
+	"impar":   "odd",
+	"ímpar":   "odd",
+	"impares": "odd",
+	"ímpares": "odd",
+}
+
+// Parse le o arquivo no formato indicado
+func Parse(format string, r io.Reader) ([]Row, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatGeoJSON, "json":
+		return ParseGeoJSON(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ParseCSV le um CSV com cabecalho, separado por virgula ou ponto e virgula
+// (como exportam as planilhas em portugues)
+func ParseCSV(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Excel grava o BOM do UTF-8 no inicio do arquivo
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	found := map[string]bool{}
+	for i, name := range header {
+		columns[i] = columnAliases[strings.ToLower(strings.TrimSpace(name))]
+		found[columns[i]] = true
+	}
+	var missing []string
+	for _, column := range requiredColumns {
+		if !found[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing CSV columns: %s", strings.Join(missing, ", "))
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rows = append(rows, Row{Line: line, Err: err})
+			continue
+		}
+
+		values := map[string]string{}
+		empty := true
+		for i, value := range record {
+			if i < len(columns) && columns[i] != "" {
+				values[columns[i]] = strings.TrimSpace(value)
+				empty = empty && values[columns[i]] == ""
+			}
+		}
+		// Planilhas costumam terminar com linhas em branco
+		if empty {
+			continue
+		}
+		rows = append(rows, rowFromValues(line, values))
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+type featureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Type       string                 `json:"type"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+}
+
+// ParseGeoJSON le uma FeatureCollection cujas propriedades usam as mesmas
+// colunas do CSV. A geometria e ignorada; Line e a posicao da feature.
+func ParseGeoJSON(r io.Reader) ([]Row, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("invalid GeoJSON: expected a FeatureCollection, got %q", collection.Type)
+	}
+	if len(collection.Features) == 0 {
+		return nil, ErrNoRows
+	}
+
+	rows := make([]Row, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		values := map[string]string{}
+		for key, value := range feature.Properties {
+			column := columnAliases[strings.ToLower(key)]
+			if column == "" || value == nil {
+				continue
+			}
+			switch v := value.(type) {
+			case float64:
+				values[column] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				values[column] = strings.TrimSpace(fmt.Sprint(v))
+			}
+		}
+		rows = append(rows, rowFromValues(i+1, values))
+	}
+	return rows, nil
+}
+
+func rowFromValues(line int, values map[string]string) Row {
+	row := Row{Line: line}
+	row.Request = models.CreateStreetSegmentRequest{
+		StreetName:   values["street_name"],
+		StreetType:   values["street_type"],
+		Neighborhood: values["neighborhood"],
+		City:         values["city"],
+		State:        values["state"],
+		CEPPrefix:    values["cep"],
+		EvenOdd:      values["even_odd"],
+	}
+	if evenOdd, ok := evenOddAliases[strings.ToLower(row.Request.EvenOdd)]; ok {
+		row.Request.EvenOdd = evenOdd
+	}
+
+	var err error
+	if row.Request.StartNumber, err = parseInt(values, "start_number"); err != nil {
+		row.Err = err
+		return row
+	}
+	if row.Request.EndNumber, err = parseInt(values, "end_number"); err != nil {
+		row.Err = err
+		return row
+	}
+	teamID, err := parseInt(values, "team_id")
+	if err != nil {
+		row.Err = err
+		return row
+	}
+	row.Request.TeamID = uint(teamID)
+	return row
+}
+
+func parseInt(values map[string]string, field string) (int, error) {
+	value := values[field]
+	if value == "" {
+		return 0, &FieldError{Field: field, Err: ErrMissingField}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, &FieldError{Field: field, Err: fmt.Errorf("invalid number %q", value)}
+	}
+	return n, nil
+}
+
+// Importer valida as linhas importadas e grava os segmentos de uma vez
+type Importer struct {
+	teams    repository.TeamRepository
+	segments repository.StreetSegmentRepository
+}
+
+func NewImporter(teams repository.TeamRepository, segments repository.StreetSegmentRepository) *Importer {
+	return &Importer{teams: teams, segments: segments}
+}
+
+// Import normaliza e valida todas as linhas. Os segmentos so sao gravados se
+// nenhuma linha tiver erro e dryRun for falso, em uma unica transacao.
+func (i *Importer) Import(rows []Row, dryRun bool) (*models.ImportReport, error) {
+	report := &models.ImportReport{
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		Errors:    []models.ImportRowError{},
+	}
+
+	teamExists := map[uint]bool{}
+	existing := map[streetKey][]models.StreetSegment{}
+	segments := make([]models.StreetSegment, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		if row.Err != nil {
+			report.Errors = append(report.Errors, rowError(row.Line, row.Err))
+			continue
+		}
+
+		segment, err := BuildStreetSegment(row.Request)
+		if err != nil {
+			report.Errors = append(report.Errors, rowError(row.Line, err))
+			continue
+		}
+
+		exists, checked := teamExists[segment.TeamID]
+		if !checked {
+			_, err := i.teams.Get(segment.TeamID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			exists = err == nil
+			teamExists[segment.TeamID] = exists
+		}
+		if !exists {
+			report.Errors = append(report.Errors, models.ImportRowError{
+				Row:     row.Line,
+				Field:   "team_id",
+				Message: fmt.Sprintf("team %d not found", segment.TeamID),
+			})
+			continue
+		}
+
+		// Sobreposicoes com segmentos ja gravados e com as linhas anteriores
+		key := keyOf(segment)
+		if _, ok := existing[key]; !ok {
+			stored, err := i.segments.ListByStreet(segment.StreetName, segment.City, segment.State)
+			if err != nil {
+				return nil, err
+			}
+			existing[key] = stored
+		}
+		if overlaps := Overlaps(segment, existing[key]); len(overlaps) > 0 {
+			o := overlaps[0]
+			report.Errors = append(report.Errors, rowError(row.Line, overlapError(
+				"segment %d of team %d, numbers %d-%d", o.ConflictingSegmentID, o.ConflictingTeamID, o.StartNumber, o.EndNumber)))
+			continue
+		}
+		if j := overlappingRow(segment, segments); j >= 0 {
+			o, _ := Overlap(segment, segments[j])
+			report.Errors = append(report.Errors, rowError(row.Line, overlapError(
+				"row %d of team %d, numbers %d-%d", lines[j], o.ConflictingTeamID, o.StartNumber, o.EndNumber)))
+			continue
+		}
+
+		segments = append(segments, segment)
+		lines = append(lines, row.Line)
+	}
+	report.ValidRows = len(segments)
+
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	if err := i.segments.CreateBatch(segments); err != nil {
+		return nil, err
+	}
+	report.Created = len(segments)
+	return report, nil
+}
+
+func overlappingRow(segment models.StreetSegment, accepted []models.StreetSegment) int {
+	for j, other := range accepted {
+		if _, ok := Overlap(segment, other); ok {
+			return j
+		}
+	}
+	return -1
+}
+
+func overlapError(format string, args ...interface{}) error {
+	return &FieldError{Field: "start_number", Err: fmt.Errorf("%w: "+format, append([]interface{}{ErrOverlap}, args...)...)}
+}
+
+func rowError(line int, err error) models.ImportRowError {
+	rowErr := models.ImportRowError{Row: line, Message: err.Error()}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		rowErr.Field = fieldErr.Field
+		rowErr.Message = fieldErr.Err.Error()
+	}
+	return rowErr
+}
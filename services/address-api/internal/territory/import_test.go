@@ -0,0 +1,165 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := "\xef\xbb\xbfTipo;Logradouro;Bairro;Cidade;UF;Inicio;Fim;CEP;Paridade;Equipe_ID\n" +
+		"Av.;São Carlos;Centro;São Carlos;sp;1;499;13560-001;ímpar;7\n" +
+		";;;;;;;;;\n" +
+		"R;das Flores;Centro;São Carlos;SP;10;x;13560-000;;7\n"
+
+	rows, err := ParseCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected blank lines to be skipped, got %d rows", len(rows))
+	}
+
+	want := models.CreateStreetSegmentRequest{
+		StreetType: "Av.", StreetName: "São Carlos", Neighborhood: "Centro", City: "São Carlos", State: "sp",
+		StartNumber: 1, EndNumber: 499, CEPPrefix: "13560-001", EvenOdd: "odd", TeamID: 7,
+	}
+	if rows[0].Line != 2 || rows[0].Err != nil || rows[0].Request != want {
+		t.Errorf("unexpected first row %+v", rows[0])
+	}
+	if rows[1].Line != 4 || rows[1].Err == nil || !strings.Contains(rows[1].Err.Error(), "end_number") {
+		t.Errorf("expected invalid end_number on line 4, got %+v", rows[1])
+	}
+}
+
+func TestParseCSVMissingColumns(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("street_name,city\nFlores,Sao Carlos\n"))
+	if err == nil || !strings.Contains(err.Error(), "state, start_number, end_number, team_id") {
+		t.Errorf("expected missing columns error, got %v", err)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("")); err != ErrNoRows {
+		t.Errorf("expected ErrNoRows, got %v", err)
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	data := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":null,"properties":{"street_type":"Rua","street_name":"das Flores","city":"Sao Carlos","state":"SP","start_number":1,"end_number":99,"even_odd":"all","team_id":3}},
+		{"type":"Feature","geometry":null,"properties":{"street_name":"Sem Fim","city":"Sao Carlos","state":"SP","start_number":1,"team_id":3}}
+	]}`
+
+	rows, err := ParseGeoJSON(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Err != nil || rows[0].Request.EndNumber != 99 || rows[0].Request.TeamID != 3 {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	if rows[1].Line != 2 || rows[1].Err == nil {
+		t.Errorf("expected missing end_number on feature 2, got %+v", rows[1])
+	}
+
+	if _, err := ParseGeoJSON(strings.NewReader(`{"type":"Feature"}`)); err == nil {
+		t.Error("expected error for a single Feature")
+	}
+}
+
+func TestImport(t *testing.T) {
+	store := repository.NewMemoryStore()
+	ubs := models.UBS{Name: "UBS Centro", Address: "Rua Central, 1", City: "SAO CARLOS", State: "SP", CEP: "13560000"}
+	store.UBS().Create(&ubs)
+	team := models.Team{Name: "Equipe Azul", UBSID: ubs.ID}
+	store.Teams().Create(&team)
+
+	valid := Row{Line: 2, Request: models.CreateStreetSegmentRequest{
+		StreetType: "R.", StreetName: "  das   Flores ", City: "Sao Carlos", State: "sp",
+		StartNumber: 1, EndNumber: 99, CEPPrefix: "13560-000", EvenOdd: "ALL", TeamID: team.ID,
+	}}
+	inverted := valid
+	inverted.Line = 3
+	inverted.Request.StartNumber = 200
+	unknownTeam := valid
+	unknownTeam.Line = 4
+	unknownTeam.Request.TeamID = 999
+
+	importer := NewImporter(store.Teams(), store.StreetSegments())
+
+	t.Run("errors abort the whole import", func(t *testing.T) {
+		report, err := importer.Import([]Row{valid, inverted, unknownTeam}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.ValidRows != 1 || report.Created != 0 || len(report.Errors) != 2 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if report.Errors[0] != (models.ImportRowError{Row: 3, Field: "end_number", Message: ErrInvalidRange.Error()}) {
+			t.Errorf("unexpected range error %+v", report.Errors[0])
+		}
+		if report.Errors[1].Row != 4 || report.Errors[1].Field != "team_id" {
+			t.Errorf("unexpected team error %+v", report.Errors[1])
+		}
+		if segments, _ := store.StreetSegments().List(false); len(segments) != 0 {
+			t.Errorf("expected nothing stored, got %d segments", len(segments))
+		}
+	})
+
+	t.Run("dry run does not store", func(t *testing.T) {
+		report, err := importer.Import([]Row{valid}, true)
+		if err != nil || !report.DryRun || report.ValidRows != 1 || report.Created != 0 {
+			t.Fatalf("unexpected report %+v (%v)", report, err)
+		}
+		if segments, _ := store.StreetSegments().List(false); len(segments) != 0 {
+			t.Errorf("expected nothing stored, got %d segments", len(segments))
+		}
+	})
+
+	t.Run("valid rows are normalized and stored", func(t *testing.T) {
+		report, err := importer.Import([]Row{valid}, false)
+		if err != nil || report.Created != 1 {
+			t.Fatalf("unexpected report %+v (%v)", report, err)
+		}
+		segments, _ := store.StreetSegments().List(false)
+		if len(segments) != 1 {
+			t.Fatalf("expected 1 segment, got %d", len(segments))
+		}
+		got := segments[0]
+		if got.StreetName != "FLORES" || got.StreetType != "RUA" || got.City != "SAO CARLOS" ||
+			got.State != "SP" || got.CEPPrefix != "13560" || got.EvenOdd != "all" {
+			t.Errorf("segment not normalized: %+v", got)
+		}
+	})
+	t.Run("overlaps with stored segments and earlier rows are rejected", func(t *testing.T) {
+		other := models.Team{Name: "Equipe Verde", UBSID: ubs.ID}
+		store.Teams().Create(&other)
+
+		stored := valid
+		stored.Request.TeamID = other.ID
+		stored.Request.StartNumber, stored.Request.EndNumber = 50, 150
+		stored.Request.EvenOdd = "even"
+		first := valid
+		first.Line = 5
+		first.Request.TeamID = other.ID
+		first.Request.StartNumber, first.Request.EndNumber = 201, 299
+		second := first
+		second.Line = 6
+		second.Request.TeamID = team.ID
+		second.Request.StartNumber, second.Request.EndNumber = 250, 260
+
+		report, err := importer.Import([]Row{stored, first, second}, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(report.Errors) != 2 || report.ValidRows != 1 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if got := report.Errors[0]; got.Row != 2 || got.Field != "start_number" ||
+			!strings.Contains(got.Message, "numbers 50-98") {
+			t.Errorf("unexpected stored overlap error %+v", got)
+		}
+		if got := report.Errors[1]; got.Row != 6 || !strings.Contains(got.Message, "row 5") {
+			t.Errorf("unexpected row overlap error %+v", got)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"errors"
+	"shared/normalize"
+)
+
+// Motivos para uma busca ir para a fila de revisao
+const (
+	LookupNotFound      = "not_found"
+	LookupAmbiguous     = "ambiguous"
+	LookupLowConfidence = "low_confidence"
+)
+
+// Acoes de resolucao da fila de revisao e como cada uma fica registrada
+const (
+	ActionSegment = "segment"
+	ActionAlias   = "alias"
+	ActionDismiss = "dismiss"
+
+	ResolutionDismissed = "dismissed"
+)
+
+var ErrInvalidAction = errors.New("action must be 'segment', 'alias' or 'dismiss'")
+
+// NewLookup registra a busca ja normalizada. originalStreet e a rua como o
+// cidadao informou, usada se a busca virar um segmento ou alias.
+func NewLookup(query SearchQuery, originalStreet, reason string) models.AddressLookup {
+	return models.AddressLookup{
+		OriginalStreet: originalStreet,
+		Street:         query.Street,
+		Number:         query.Number,
+		Neighborhood:   query.Neighborhood,
+		City:           query.City,
+		State:          query.State,
+		CEP:            query.CEP,
+		Reason:         reason,
+		Candidates:     []models.LookupCandidate{},
+	}
+}
+
+// ReviewReason diz se a resposta da busca precisa de revisao e por que.
+// Buscas ambiguas pedem mais dados ao cidadao, mas tambem mostram onde o
+// territorio esta mal definido.
+func ReviewReason(response models.AddressSearchResponse) (string, bool) {
+	switch {
+	case response.Status == StatusAmbiguous:
+		return LookupAmbiguous, true
+	case response.Confidence == ConfidenceLow:
+		return LookupLowConfidence, true
+	}
+	return "", false
+}
+
+// LookupCandidates resume os candidatos ranqueados da busca
+func LookupCandidates(candidates []models.AddressCandidate) []models.LookupCandidate {
+	result := make([]models.LookupCandidate, len(candidates))
+	for i, candidate := range candidates {
+		result[i] = lookupCandidate(candidate.StreetSegment, candidate.Team.Name, candidate.Score)
+	}
+	return result
+}
+
+// StreetCandidates resume os segmentos da rua buscada quando nenhum cobre o
+// numero, para o revisor ver quais equipes ja atendem a rua
+func StreetCandidates(segments []models.StreetSegment) []models.LookupCandidate {
+	if len(segments) > MaxCandidates {
+		segments = segments[:MaxCandidates]
+	}
+	result := make([]models.LookupCandidate, len(segments))
+	for i, segment := range segments {
+		result[i] = lookupCandidate(segment, segment.Team.Name, 0)
+	}
+	return result
+}
+
+func lookupCandidate(segment models.StreetSegment, teamName string, score float64) models.LookupCandidate {
+	return models.LookupCandidate{
+		StreetSegmentID: segment.ID,
+		StreetName:      segment.StreetName,
+		StartNumber:     segment.StartNumber,
+		EndNumber:       segment.EndNumber,
+		EvenOdd:         segment.EvenOdd,
+		TeamID:          segment.TeamID,
+		TeamName:        teamName,
+		Score:           score,
+	}
+}
+
+// LookupSegment monta o segmento que resolve a busca: a rua, o bairro e a
+// cidade buscados, cobrindo apenas o numero buscado se a requisicao nao
+// trouxer outra faixa
+func LookupSegment(lookup models.AddressLookup, req models.ResolveLookupRequest) models.CreateStreetSegmentRequest {
+	segment := models.CreateStreetSegmentRequest{
+		StreetName:   lookup.OriginalStreet,
+		StreetType:   req.StreetType,
+		Neighborhood: lookup.Neighborhood,
+		City:         lookup.City,
+		State:        lookup.State,
+		StartNumber:  lookup.Number,
+		EndNumber:    lookup.Number,
+		CEPPrefix:    req.CEPPrefix,
+		EvenOdd:      req.EvenOdd,
+		TeamID:       req.TeamID,
+	}
+	if segment.StreetName == "" {
+		segment.StreetName = lookup.Street
+	}
+	if segment.StreetType == "" {
+		segment.StreetType, _ = normalize.SplitStreetType(segment.StreetName)
+	}
+	if segment.CEPPrefix == "" {
+		segment.CEPPrefix = lookup.CEP
+	}
+	if segment.EvenOdd == "" {
+		segment.EvenOdd = "all"
+	}
+	if req.StartNumber != nil {
+		segment.StartNumber = *req.StartNumber
+	}
+	if req.EndNumber != nil {
+		segment.EndNumber = *req.EndNumber
+	}
+	return segment
+}
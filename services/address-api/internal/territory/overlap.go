@@ -0,0 +1,200 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"sort"
+)
+
+// streetKey identifica uma rua; segmentos so se sobrepoem dentro da mesma rua
+type streetKey struct {
+	street, city, state string
+}
+
+func keyOf(segment models.StreetSegment) streetKey {
+	return streetKey{segment.StreetName, segment.City, segment.State}
+}
+
+// Overlap verifica se dois segmentos de equipes diferentes cobrem algum
+// numero em comum, considerando a paridade de cada um
+func Overlap(a, b models.StreetSegment) (models.SegmentOverlap, bool) {
+	if keyOf(a) != keyOf(b) || a.TeamID == b.TeamID {
+		return models.SegmentOverlap{}, false
+	}
+
+	parity := a.EvenOdd
+	switch {
+	case a.EvenOdd == "all":
+		parity = b.EvenOdd
+	case b.EvenOdd != "all" && b.EvenOdd != a.EvenOdd:
+		return models.SegmentOverlap{}, false
+	}
+
+	start, end, ok := parityRange(max(a.StartNumber, b.StartNumber), min(a.EndNumber, b.EndNumber), parity)
+	if !ok {
+		return models.SegmentOverlap{}, false
+	}
+	return models.SegmentOverlap{
+		StreetName:           a.StreetName,
+		City:                 a.City,
+		State:                a.State,
+		StartNumber:          start,
+		EndNumber:            end,
+		EvenOdd:              parity,
+		SegmentID:            a.ID,
+		TeamID:               a.TeamID,
+		ConflictingSegmentID: b.ID,
+		ConflictingTeamID:    b.TeamID,
+	}, true
+}
+
+// Overlaps compara o segmento com os demais, ignorando ele mesmo quando ja
+// esta gravado (atualizacao)
+func Overlaps(segment models.StreetSegment, others []models.StreetSegment) []models.SegmentOverlap {
+	overlaps := []models.SegmentOverlap{}
+	for _, other := range others {
+		if segment.ID != 0 && other.ID == segment.ID {
+			continue
+		}
+		if overlap, ok := Overlap(segment, other); ok {
+			overlaps = append(overlaps, overlap)
+		}
+	}
+	return overlaps
+}
+
+// Analyze procura sobreposicoes entre equipes e faixas sem cobertura entre
+// segmentos de uma mesma rua. Numeros antes do primeiro ou depois do ultimo
+// segmento nao contam como buraco, pois o tamanho da rua nao e conhecido.
+func Analyze(segments []models.StreetSegment) models.TerritoryAnalysis {
+	streets := map[streetKey][]models.StreetSegment{}
+	var keys []streetKey
+	for _, segment := range segments {
+		key := keyOf(segment)
+		if _, ok := streets[key]; !ok {
+			keys = append(keys, key)
+		}
+		streets[key] = append(streets[key], segment)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].state != keys[j].state {
+			return keys[i].state < keys[j].state
+		}
+		if keys[i].city != keys[j].city {
+			return keys[i].city < keys[j].city
+		}
+		return keys[i].street < keys[j].street
+	})
+
+	analysis := models.TerritoryAnalysis{
+		Streets:  len(keys),
+		Segments: len(segments),
+		Overlaps: []models.SegmentOverlap{},
+		Gaps:     []models.SegmentGap{},
+	}
+	for _, key := range keys {
+		street := streets[key]
+		sort.SliceStable(street, func(i, j int) bool {
+			if street[i].StartNumber != street[j].StartNumber {
+				return street[i].StartNumber < street[j].StartNumber
+			}
+			return street[i].ID < street[j].ID
+		})
+
+		for i := range street {
+			for j := i + 1; j < len(street); j++ {
+				if overlap, ok := Overlap(street[i], street[j]); ok {
+					analysis.Overlaps = append(analysis.Overlaps, overlap)
+				}
+			}
+		}
+		analysis.Gaps = append(analysis.Gaps, gaps(street)...)
+	}
+	return analysis
+}
+
+// gaps calcula os buracos de pares e impares separadamente; um buraco com os
+// mesmos limites nas duas paridades e informado uma unica vez como "all"
+func gaps(street []models.StreetSegment) []models.SegmentGap {
+	even := parityGaps(street, "even")
+	odd := parityGaps(street, "odd")
+
+	var result []models.SegmentGap
+	for _, gap := range even {
+		if i := indexOfGap(odd, gap); i >= 0 {
+			odd = append(odd[:i], odd[i+1:]...)
+			gap.EvenOdd = "all"
+		}
+		result = append(result, gap)
+	}
+	result = append(result, odd...)
+
+	for i := range result {
+		result[i].StartNumber, result[i].EndNumber, _ = parityRange(result[i].StartNumber, result[i].EndNumber, result[i].EvenOdd)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartNumber < result[j].StartNumber
+	})
+	return result
+}
+
+// parityGaps devolve os buracos de uma paridade com os limites ainda sem
+// ajuste, para que os de pares e impares possam ser comparados
+func parityGaps(street []models.StreetSegment, parity string) []models.SegmentGap {
+	var result []models.SegmentGap
+	var last *models.StreetSegment
+	for i := range street {
+		segment := street[i]
+		if segment.EvenOdd != "all" && segment.EvenOdd != parity {
+			continue
+		}
+		if last != nil {
+			if _, _, ok := parityRange(last.EndNumber+1, segment.StartNumber-1, parity); ok {
+				result = append(result, models.SegmentGap{
+					StreetName:        segment.StreetName,
+					City:              segment.City,
+					State:             segment.State,
+					StartNumber:       last.EndNumber + 1,
+					EndNumber:         segment.StartNumber - 1,
+					EvenOdd:           parity,
+					PreviousSegmentID: last.ID,
+					NextSegmentID:     segment.ID,
+				})
+			}
+		}
+		if last == nil || segment.EndNumber > last.EndNumber {
+			last = &street[i]
+		}
+	}
+	return result
+}
+
+func indexOfGap(gaps []models.SegmentGap, gap models.SegmentGap) int {
+	for i, g := range gaps {
+		if g.StartNumber == gap.StartNumber && g.EndNumber == gap.EndNumber {
+			return i
+		}
+	}
+	return -1
+}
+
+// parityRange reduz a faixa aos primeiros e ultimos numeros da paridade;
+// ok e falso se nenhum numero da faixa tiver essa paridade
+func parityRange(start, end int, parity string) (int, int, bool) {
+	want := map[string]int{"even": 0, "odd": 1}
+	if p, specific := want[parity]; specific {
+		if abs(start%2) != p {
+			start++
+		}
+		if abs(end%2) != p {
+			end--
+		}
+	}
+	return start, end, start <= end
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"testing"
+)
+
+func segment(id, team uint, start, end int, evenOdd string) models.StreetSegment {
+	return models.StreetSegment{
+		ID: id, StreetName: "DAS FLORES", City: "SAO CARLOS", State: "SP",
+		StartNumber: start, EndNumber: end, EvenOdd: evenOdd, TeamID: team,
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       models.StreetSegment
+		want       bool
+		start, end int
+		evenOdd    string
+	}{
+		{"same parity", segment(1, 1, 1, 99, "all"), segment(2, 2, 50, 150, "all"), true, 50, 99, "all"},
+		{"all against even", segment(1, 1, 1, 99, "all"), segment(2, 2, 51, 150, "even"), true, 52, 98, "even"},
+		{"even against odd", segment(1, 1, 1, 99, "even"), segment(2, 2, 1, 99, "odd"), false, 0, 0, ""},
+		{"no odd number in common", segment(1, 1, 1, 10, "all"), segment(2, 2, 10, 20, "odd"), false, 0, 0, ""},
+		{"touching ranges", segment(1, 1, 1, 99, "all"), segment(2, 2, 100, 200, "all"), false, 0, 0, ""},
+		{"same team", segment(1, 1, 1, 99, "all"), segment(2, 1, 50, 150, "all"), false, 0, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Overlap(tt.a, tt.b)
+			if ok != tt.want {
+				t.Fatalf("expected overlap %v, got %+v", tt.want, got)
+			}
+			if ok && (got.StartNumber != tt.start || got.EndNumber != tt.end || got.EvenOdd != tt.evenOdd ||
+				got.SegmentID != tt.a.ID || got.ConflictingTeamID != tt.b.TeamID) {
+				t.Errorf("unexpected overlap %+v", got)
+			}
+		})
+	}
+
+	other := segment(1, 1, 1, 99, "all")
+	other.StreetName = "DAS ROSAS"
+	if _, ok := Overlap(other, segment(2, 2, 1, 99, "all")); ok {
+		t.Error("segments of different streets must not overlap")
+	}
+}
+
+func TestOverlapsIgnoresTheSegmentItself(t *testing.T) {
+	updated := segment(1, 2, 1, 99, "all")
+	if got := Overlaps(updated, []models.StreetSegment{segment(1, 1, 1, 99, "all")}); len(got) != 0 {
+		t.Errorf("expected no overlaps, got %+v", got)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	rosas := segment(6, 1, 1, 99, "all")
+	rosas.StreetName = "DAS ROSAS"
+
+	analysis := Analyze([]models.StreetSegment{
+		segment(1, 1, 1, 99, "all"),
+		segment(2, 2, 80, 120, "all"),
+		segment(3, 1, 201, 299, "all"),
+		segment(4, 2, 121, 199, "odd"),
+		segment(5, 2, 300, 400, "even"),
+		rosas,
+	})
+
+	if analysis.Streets != 2 || analysis.Segments != 6 {
+		t.Errorf("unexpected totals %+v", analysis)
+	}
+	if len(analysis.Overlaps) != 1 || analysis.Overlaps[0].SegmentID != 1 ||
+		analysis.Overlaps[0].StartNumber != 80 || analysis.Overlaps[0].EndNumber != 99 {
+		t.Errorf("unexpected overlaps %+v", analysis.Overlaps)
+	}
+
+	want := []models.SegmentGap{
+		{StreetName: "DAS FLORES", City: "SAO CARLOS", State: "SP", StartNumber: 122, EndNumber: 200,
+			EvenOdd: "even", PreviousSegmentID: 2, NextSegmentID: 3},
+	}
+	if len(analysis.Gaps) != len(want) {
+		t.Fatalf("unexpected gaps %+v", analysis.Gaps)
+	}
+	for i := range want {
+		if analysis.Gaps[i] != want[i] {
+			t.Errorf("gap %d: got %+v, want %+v", i, analysis.Gaps[i], want[i])
+		}
+	}
+}
+
+func TestAnalyzeMergesGapsOfBothParities(t *testing.T) {
+	analysis := Analyze([]models.StreetSegment{
+		segment(1, 1, 1, 99, "all"),
+		segment(2, 1, 200, 300, "all"),
+	})
+	if len(analysis.Gaps) != 1 || analysis.Gaps[0].EvenOdd != "all" ||
+		analysis.Gaps[0].StartNumber != 100 || analysis.Gaps[0].EndNumber != 199 {
+		t.Errorf("expected a single gap 100-199 for all numbers, got %+v", analysis.Gaps)
+	}
+}
@@ -0,0 +1,64 @@
+package territory
+
+import (
+	"address-api/internal/geo"
+	"address-api/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NewTerritory valida a Feature recebida e devolve o territorio com a
+// geometria convertida para MultiPolygon
+func NewTerritory(feature models.TerritoryFeature) (models.Territory, error) {
+	if feature.Type != "" && feature.Type != "Feature" {
+		return models.Territory{}, fmt.Errorf("%w: expected a Feature, got %q", geo.ErrInvalidGeometry, feature.Type)
+	}
+	data, err := json.Marshal(feature.Geometry)
+	if err != nil {
+		return models.Territory{}, err
+	}
+	shape, err := geo.Parse(data)
+	if err != nil {
+		return models.Territory{}, err
+	}
+
+	var geometry models.GeoJSON
+	if err := json.Unmarshal(shape.GeoJSON(), &geometry); err != nil {
+		return models.Territory{}, err
+	}
+	return models.Territory{
+		TeamID:   feature.Properties.TeamID,
+		Name:     feature.Properties.Name,
+		Geometry: geometry,
+	}, nil
+}
+
+// WriteTerritoriesGeoJSON escreve uma FeatureCollection com uma feature por
+// territorio, pronta para abrir em um mapa
+func WriteTerritoriesGeoJSON(w io.Writer, territories []models.Territory) error {
+	features := make([]exportFeature, 0, len(territories))
+	for _, territory := range territories {
+		geometry, err := json.Marshal(territory.Geometry)
+		if err != nil {
+			return err
+		}
+		features = append(features, exportFeature{
+			Type:     "Feature",
+			Geometry: geometry,
+			Properties: map[string]interface{}{
+				"id":        territory.ID,
+				"name":      territory.Name,
+				"team_id":   territory.TeamID,
+				"team_name": territory.Team.Name,
+				"ubs_id":    territory.Team.UBSID,
+				"ubs_name":  territory.Team.UBS.Name,
+			},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
@@ -0,0 +1,72 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"address-api/internal/schedule"
+	"errors"
+	"log"
+	"time"
+)
+
+var (
+	ErrPastReassignment    = errors.New("valid_from cannot be in the past")
+	ErrSameTeam            = errors.New("street segment already belongs to this team")
+	ErrPendingReassignment = errors.New("street segment already has a scheduled reassignment, cancel it first")
+)
+
+// CheckReassignment valida a mudanca do segmento para teamID a partir de
+// from contra o seu historico. O historico nao e reescrito: a mudanca vale de
+// hoje em diante, e so uma pode ficar agendada por vez.
+func CheckReassignment(history []models.SegmentAssignment, teamID uint, from, today models.Date) error {
+	if from < today {
+		return ErrPastReassignment
+	}
+	for _, assignment := range history {
+		if assignment.AppliedAt == nil {
+			return ErrPendingReassignment
+		}
+	}
+	if n := len(history); n > 0 && history[n-1].TeamID == teamID {
+		return ErrSameTeam
+	}
+	return nil
+}
+
+// AsOf troca a equipe de cada resultado pela que cobria o segmento na data
+// da busca historica. Segmentos que ainda nao existiam na data saem.
+func AsOf(results []repository.SearchResult, assignments map[uint]models.SegmentAssignment) []repository.SearchResult {
+	var dated []repository.SearchResult
+	for _, result := range results {
+		assignment, ok := assignments[result.Segment.ID]
+		if !ok || assignment.Team == nil {
+			continue
+		}
+		result.Segment.TeamID = assignment.TeamID
+		result.Segment.Team = *assignment.Team
+		// A micro-area e a atual e pode nao ser da equipe da data
+		result.Segment.MicroAreaID = nil
+		dated = append(dated, result)
+	}
+	return dated
+}
+
+// StartReassignmentJob aplica periodicamente, em background, as
+// reatribuicoes agendadas cujo dia chegou
+func StartReassignmentJob(assignments repository.SegmentAssignmentRepository, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			applied, err := assignments.ApplyDue(schedule.DateOf(time.Now()))
+			if err != nil {
+				log.Printf("Error applying scheduled reassignments: %v", err)
+			}
+			for _, assignment := range applied {
+				log.Printf("Street segment %d moved to team %d from %s", assignment.StreetSegmentID, assignment.TeamID, assignment.ValidFrom)
+			}
+			<-ticker.C
+		}
+	}()
+}
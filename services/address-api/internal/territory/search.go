@@ -0,0 +1,272 @@
+package territory
+
+import (
+	"address-api/internal/geo"
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"fmt"
+	"math"
+	"shared/normalize"
+	"sort"
+	"strings"
+)
+
+const (
+	StatusMatched   = "matched"
+	StatusAmbiguous = "ambiguous"
+
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+
+	// AmbiguityMargin e a diferenca de pontuacao abaixo da qual dois
+	// candidatos de equipes diferentes sao considerados empatados
+	AmbiguityMargin = 0.1
+	// MaxCandidates limita quantos candidatos voltam na resposta
+	MaxCandidates = 5
+
+	// O CEP identifica a regiao melhor que o bairro, que costuma ser informado
+	// de formas diferentes pelo cidadao
+	cepWeight          = 0.15
+	neighborhoodWeight = 0.1
+	streetMatchMinimum = 0.5
+
+	// Um ponto dentro do poligono da equipe confirma o segmento; dentro do
+	// poligono de outra equipe, contradiz
+	territoryWeight = 0.2
+	// Pontuacao da equipe encontrada so pelo poligono, sem segmento de rua
+	territoryOnlyScore = 0.7
+)
+
+// SearchQuery e o endereco buscado. CEP e bairro sao opcionais e so ajudam a
+// desempatar os candidatos. Point, quando informado, sao as coordenadas do
+// endereco, comparadas com os poligonos das equipes.
+type SearchQuery struct {
+	Street       string
+	Number       int
+	City         string
+	State        string
+	CEP          string
+	Neighborhood string
+	Point        *geo.Point
+}
+
+// Rank pontua os resultados da busca, explica cada pontuacao e decide se o
+// melhor candidato e confiavel ou se o endereco e ambiguo. territories sao
+// os poligonos que contem query.Point; equipes com poligono mas sem segmento
+// entram como candidatas. Resultados e territorios nao podem estar ambos
+// vazios.
+func Rank(query SearchQuery, results []repository.SearchResult, territories []models.Territory) models.AddressSearchResponse {
+	candidates := make([]models.AddressCandidate, len(results))
+	for i, result := range results {
+		candidates[i] = score(query, result)
+	}
+	if query.Point != nil {
+		candidates = applyTerritories(*query.Point, candidates, territories)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].StreetSegment.ID < candidates[j].StreetSegment.ID
+	})
+	if len(candidates) > MaxCandidates {
+		candidates = candidates[:MaxCandidates]
+	}
+
+	best := candidates[0]
+	response := models.AddressSearchResponse{
+		StreetSegment: best.StreetSegment,
+		Team:          best.Team,
+		UBS:           best.UBS,
+		Status:        StatusMatched,
+		Confidence:    best.Confidence,
+		Score:         best.Score,
+		Candidates:    candidates,
+	}
+	// Dois trechos da mesma equipe levam a mesma resposta, nao sao ambiguos
+	for _, candidate := range candidates[1:] {
+		if candidate.Team.ID != best.Team.ID && best.Score-candidate.Score < AmbiguityMargin {
+			response.Status = StatusAmbiguous
+			break
+		}
+	}
+	return response
+}
+
+// applyTerritories ajusta a pontuacao dos candidatos pelo poligono que
+// contem o ponto. Sem nenhum poligono no ponto nada muda, pois a equipe pode
+// so nao ter desenhado o seu.
+func applyTerritories(point geo.Point, candidates []models.AddressCandidate, territories []models.Territory) []models.AddressCandidate {
+	if len(territories) == 0 {
+		return candidates
+	}
+	// O primeiro territorio de cada equipe e o menor
+	byTeam := map[uint]models.Territory{}
+	for _, territory := range territories {
+		if _, ok := byTeam[territory.TeamID]; !ok {
+			byTeam[territory.TeamID] = territory
+		}
+	}
+
+	covered := map[uint]bool{}
+	for i := range candidates {
+		candidate := &candidates[i]
+		territory, matched := byTeam[candidate.Team.ID]
+		reason := models.MatchReason{Field: "territory", Matched: matched}
+		if matched {
+			covered[territory.TeamID] = true
+			candidate.TerritoryID = territory.ID
+			reason.Detail = fmt.Sprintf("point %s is inside territory %q of the team", pointDetail(point), territory.Name)
+		} else {
+			reason.Detail = fmt.Sprintf("point %s is inside the territory of another team", pointDetail(point))
+		}
+		candidate.Score = round(math.Max(0, math.Min(1, candidate.Score+weight(matched, territoryWeight))))
+		candidate.Confidence = confidence(candidate.Score)
+		candidate.Reasons = append(candidate.Reasons, reason)
+	}
+
+	for _, territory := range territories {
+		if covered[territory.TeamID] {
+			continue
+		}
+		covered[territory.TeamID] = true
+		candidates = append(candidates, models.AddressCandidate{
+			Team:        territory.Team,
+			UBS:         territory.Team.UBS,
+			TerritoryID: territory.ID,
+			Score:       territoryOnlyScore,
+			Confidence:  confidence(territoryOnlyScore),
+			Reasons: []models.MatchReason{{
+				Field:   "territory",
+				Matched: true,
+				Detail:  fmt.Sprintf("point %s is inside territory %q; no street segment of the team matched", pointDetail(point), territory.Name),
+			}},
+		})
+	}
+	return candidates
+}
+
+func pointDetail(point geo.Point) string {
+	return fmt.Sprintf("(%.6f, %.6f)", point.Lat, point.Lng)
+}
+
+// NarrowByCEP descarta os segmentos com prefixo de CEP diferente do buscado,
+// a menos que isso elimine todos. Segmentos sem CEP cadastrado sao mantidos.
+func NarrowByCEP(cep string, results []repository.SearchResult) []repository.SearchResult {
+	prefix := CEPPrefix(cep)
+	if prefix == "" {
+		return results
+	}
+
+	var narrowed []repository.SearchResult
+	for _, result := range results {
+		if result.Segment.CEPPrefix == "" || sameCEPRegion(prefix, result.Segment.CEPPrefix) {
+			narrowed = append(narrowed, result)
+		}
+	}
+	if len(narrowed) == 0 {
+		return results
+	}
+	return narrowed
+}
+
+// sameCEPRegion compara prefixos que podem ter tamanhos diferentes
+func sameCEPRegion(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+func score(query SearchQuery, result repository.SearchResult) models.AddressCandidate {
+	segment := result.Segment
+	value := streetSimilarity(result)
+
+	street := models.MatchReason{
+		Field:   "street",
+		Matched: value >= streetMatchMinimum,
+		Detail:  fmt.Sprintf("similarity %.2f between %q and %q", result.Similarity, query.Street, segment.StreetName),
+	}
+	if result.Alias != nil {
+		street.Field = "alias"
+		street.Detail = fmt.Sprintf("similarity %.2f between %q and %s name %q of %q, weighted %.2f",
+			result.Similarity, query.Street, result.Alias.Kind, result.Alias.Alias, segment.StreetName, value)
+	}
+
+	reasons := []models.MatchReason{
+		street,
+		{
+			Field:   "number_range",
+			Matched: true,
+			Detail:  fmt.Sprintf("%d is within %d-%d", query.Number, segment.StartNumber, segment.EndNumber),
+		},
+		{
+			Field:   "parity",
+			Matched: true,
+			Detail:  parityDetail(segment.EvenOdd),
+		},
+	}
+
+	if cep := CEPPrefix(query.CEP); cep != "" && segment.CEPPrefix != "" {
+		matched := sameCEPRegion(cep, segment.CEPPrefix)
+		value += weight(matched, cepWeight)
+		reasons = append(reasons, models.MatchReason{
+			Field:   "cep_prefix",
+			Matched: matched,
+			Detail:  fmt.Sprintf("CEP prefix %s, segment has %s", cep, segment.CEPPrefix),
+		})
+	}
+
+	if neighborhood := normalize.Text(query.Neighborhood); neighborhood != "" && segment.Neighborhood != "" {
+		matched := neighborhood == normalize.Text(segment.Neighborhood)
+		value += weight(matched, neighborhoodWeight)
+		reasons = append(reasons, models.MatchReason{
+			Field:   "neighborhood",
+			Matched: matched,
+			Detail:  fmt.Sprintf("neighborhood %q, segment has %q", neighborhood, segment.Neighborhood),
+		})
+	}
+
+	value = round(math.Max(0, math.Min(1, value)))
+	return models.AddressCandidate{
+		StreetSegment: segment,
+		Team:          segment.Team,
+		UBS:           segment.Team.UBS,
+		Similarity:    round(result.Similarity),
+		Alias:         result.Alias,
+		Score:         value,
+		Confidence:    confidence(value),
+		Reasons:       reasons,
+	}
+}
+
+func confidence(score float64) string {
+	switch {
+	case score >= 0.8:
+		return ConfidenceHigh
+	case score >= 0.5:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+func weight(matched bool, w float64) float64 {
+	if matched {
+		return w
+	}
+	return -w
+}
+
+func parityDetail(evenOdd string) string {
+	switch evenOdd {
+	case "even":
+		return "segment covers even numbers"
+	case "odd":
+		return "segment covers odd numbers"
+	default:
+		return "segment covers all numbers"
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
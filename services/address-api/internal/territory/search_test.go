@@ -0,0 +1,95 @@
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/repository"
+	"address-api/internal/utils"
+	"testing"
+)
+
+func result(id, team uint, street, neighborhood, cep string) repository.SearchResult {
+	segment := models.StreetSegment{
+		ID: id, StreetName: street, Neighborhood: neighborhood, CEPPrefix: cep,
+		StartNumber: 1, EndNumber: 99, EvenOdd: "all", TeamID: team,
+		Team: models.Team{ID: team, UBS: models.UBS{ID: 1}},
+	}
+	return repository.SearchResult{Segment: segment, Similarity: utils.Similarity(street, "DAS FLORES")}
+}
+
+func TestRank(t *testing.T) {
+	query := SearchQuery{Street: "DAS FLORES", Number: 10, City: "SAO CARLOS", State: "SP"}
+
+	t.Run("exact street is a confident match", func(t *testing.T) {
+		got := Rank(query, []repository.SearchResult{
+			result(1, 1, "DAS FLORES DO CAMPO", "CENTRO", "13560"),
+			result(2, 2, "DAS FLORES", "CENTRO", "13560"),
+		}, nil)
+		if got.Status != StatusMatched || got.Confidence != ConfidenceHigh || got.Team.ID != 2 || got.Score != 1 {
+			t.Errorf("unexpected response %+v", got)
+		}
+		if len(got.Candidates) != 2 || got.Candidates[1].StreetSegment.ID != 1 || got.Candidates[1].Score >= got.Score {
+			t.Errorf("unexpected candidates %+v", got.Candidates)
+		}
+		if reasons := got.Candidates[0].Reasons; len(reasons) != 3 || reasons[0].Field != "street" || !reasons[0].Matched {
+			t.Errorf("unexpected reasons %+v", reasons)
+		}
+	})
+
+	t.Run("close candidates of different teams are ambiguous", func(t *testing.T) {
+		got := Rank(query, []repository.SearchResult{
+			result(1, 1, "DAS FLORES", "CENTRO", "13560"),
+			result(2, 2, "DAS FLORES", "VILA NERY", "13573"),
+		}, nil)
+		if got.Status != StatusAmbiguous || len(got.Candidates) != 2 {
+			t.Errorf("expected ambiguous response, got %+v", got)
+		}
+	})
+
+	t.Run("close candidates of the same team are not ambiguous", func(t *testing.T) {
+		got := Rank(query, []repository.SearchResult{
+			result(1, 1, "DAS FLORES", "CENTRO", "13560"),
+			result(2, 1, "DAS FLORES", "CENTRO", "13560"),
+		}, nil)
+		if got.Status != StatusMatched {
+			t.Errorf("expected matched response, got %+v", got)
+		}
+	})
+
+	t.Run("CEP and neighborhood break the tie", func(t *testing.T) {
+		q := query
+		q.CEP, q.Neighborhood = "13573-000", "Vila Nery"
+		got := Rank(q, []repository.SearchResult{
+			result(1, 1, "DAS FLORES", "CENTRO", "13560"),
+			result(2, 2, "DAS FLORES", "VILA NERY", "13573"),
+		}, nil)
+		if got.Status != StatusMatched || got.Team.ID != 2 {
+			t.Fatalf("expected team 2 to win, got %+v", got)
+		}
+		loser := got.Candidates[1]
+		if loser.Score != 0.75 || loser.Confidence != ConfidenceMedium {
+			t.Errorf("unexpected loser score %+v", loser)
+		}
+		for _, reason := range loser.Reasons[3:] {
+			if reason.Matched {
+				t.Errorf("expected %s not to match: %+v", reason.Field, reason)
+			}
+		}
+	})
+
+	t.Run("weak street similarity has low confidence", func(t *testing.T) {
+		got := Rank(query, []repository.SearchResult{result(1, 1, "FLORIANO PEIXOTO", "", "")}, nil)
+		if got.Confidence != ConfidenceLow || got.Candidates[0].Reasons[0].Matched {
+			t.Errorf("unexpected response %+v", got)
+		}
+	})
+
+	t.Run("candidates are limited", func(t *testing.T) {
+		var results []repository.SearchResult
+		for i := uint(1); i <= MaxCandidates+2; i++ {
+			results = append(results, result(i, 1, "DAS FLORES", "", ""))
+		}
+		if got := Rank(query, results, nil); len(got.Candidates) != MaxCandidates {
+			t.Errorf("expected %d candidates, got %d", MaxCandidates, len(got.Candidates))
+		}
+	})
+}
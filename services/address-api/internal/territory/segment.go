@@ -0,0 +1,85 @@
+// Package territory concentra as regras sobre os segmentos de rua que
+// definem o territorio de cada equipe.
+package territory
+
+import (
+	"address-api/internal/models"
+	"address-api/internal/utils"
+	"errors"
+	"shared/normalize"
+	"strings"
+)
+
+var (
+	ErrMissingField   = errors.New("required field is missing")
+	ErrInvalidRange   = errors.New("start number cannot be greater than end number")
+	ErrInvalidEvenOdd = errors.New("even/odd must be 'even', 'odd' or 'all'")
+	ErrOverlap        = errors.New("number range overlaps a segment of another team")
+)
+
+// FieldError indica qual campo do segmento causou o erro
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Err.Error() }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// BuildStreetSegment normaliza os campos da requisicao e valida o segmento.
+// A existencia do time e verificada por quem chama.
+func BuildStreetSegment(req models.CreateStreetSegmentRequest) (models.StreetSegment, error) {
+	segment := models.StreetSegment{
+		StreetName:         normalize.StreetName(req.StreetName),
+		OriginalStreetName: strings.TrimSpace(req.StreetName),
+		StreetType:         normalize.StreetType(req.StreetType),
+		Neighborhood:       normalize.Text(req.Neighborhood),
+		City:               normalize.Text(req.City),
+		State:              strings.ToUpper(strings.TrimSpace(req.State)),
+		StartNumber:        req.StartNumber,
+		EndNumber:          req.EndNumber,
+		CEPPrefix:          CEPPrefix(req.CEPPrefix),
+		EvenOdd:            strings.ToLower(strings.TrimSpace(req.EvenOdd)),
+		TeamID:             req.TeamID,
+	}
+
+	required := []struct{ field, value string }{
+		{"street_name", segment.StreetName},
+		{"city", segment.City},
+		{"state", segment.State},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return segment, &FieldError{Field: r.field, Err: ErrMissingField}
+		}
+	}
+	if segment.TeamID == 0 {
+		return segment, &FieldError{Field: "team_id", Err: ErrMissingField}
+	}
+	if segment.StartNumber > segment.EndNumber {
+		return segment, &FieldError{Field: "end_number", Err: ErrInvalidRange}
+	}
+	if !IsValidEvenOdd(segment.EvenOdd) {
+		return segment, &FieldError{Field: "even_odd", Err: ErrInvalidEvenOdd}
+	}
+	return segment, nil
+}
+
+// CEPPrefix guarda apenas os 5 primeiros digitos do CEP, que identificam a
+// regiao; aceita tanto o prefixo quanto o CEP completo
+func CEPPrefix(cep string) string {
+	cep = utils.NormalizeCEP(cep)
+	if len(cep) > 5 {
+		return cep[:5]
+	}
+	return cep
+}
+
+func IsValidEvenOdd(value string) bool {
+	validValues := map[string]bool{
+		"even": true,
+		"odd":  true,
+		"all":  true,
+	}
+	return validValues[value]
+}
@@ -5,44 +5,35 @@ import (
 	"unicode"
 )
 
-var streetTypeMap = map[string]string{
-	"R":     "RUA",
-	"R.":    "RUA",
-	"RUA":   "RUA",
-	"AV":    "AVENIDA",
-	"AV.":   "AVENIDA",
-	"AVEN":  "AVENIDA",
-	"AVE":   "AVENIDA",
-	"AL":    "ALAMEDA",
-	"AL.":   "ALAMEDA",
-	"EST":   "ESTRADA",
-	"EST.":  "ESTRADA",
-	"PÇ":    "PRACA",
-	"PC":    "PRACA",
-	"PCA":   "PRACA",
-	"PÇA":   "PRACA",
-	"PRAÇA": "PRACA",
-}
-
-func NormalizeStreetType(streetType string) string {
-	normalized := strings.ToUpper(strings.TrimSpace(streetType))
-	if standardType, exists := streetTypeMap[normalized]; exists {
-		return standardType
+// Similarity imita a funcao similarity do pg_trgm: a proporcao de trigramas
+// em comum entre as palavras dos dois textos, de 0 a 1
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
 	}
-	return normalized
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
 }
 
-func NormalizeStreetName(name string) string {
-	// Remove espacos extras e deixa tudo em MAIUSCULO
-	name = strings.TrimSpace(strings.ToUpper(name))
-
-	// Remove acentuacoes
-	name = removeAccents(name)
-
-	// Troca varios espacos por um unico
-	name = strings.Join(strings.Fields(name), " ")
-
-	return name
+// trigrams separa as palavras como o pg_trgm, com dois espacos antes e um depois
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
 }
 
 func NormalizeNumber(number string) string {
@@ -61,27 +52,6 @@ func NormalizeCEP(cep string) string {
 	return NormalizeNumber(cep)
 }
 
-func removeAccents(s string) string {
-	replacements := map[rune]rune{
-		'Á': 'A', 'À': 'A', 'Ã': 'A', 'Â': 'A',
-		'É': 'E', 'È': 'E', 'Ê': 'E',
-		'Í': 'I', 'Ì': 'I', 'Î': 'I',
-		'Ó': 'O', 'Ò': 'O', 'Õ': 'O', 'Ô': 'O',
-		'Ú': 'U', 'Ù': 'U', 'Û': 'U',
-		'Ç': 'C',
-	}
-
-	var result strings.Builder
-	for _, r := range s {
-		if replacement, ok := replacements[r]; ok {
-			result.WriteRune(replacement)
-		} else {
-			result.WriteRune(r)
-		}
-	}
-	return result.String()
-}
-
 func ExtractCEPPrefix(cep string) string {
 	normalized := NormalizeCEP(cep)
 	if len(normalized) >= 5 {
@@ -4,35 +4,74 @@ import (
 	"address-api/internal/config"
 	"address-api/internal/database"
 	"address-api/internal/handlers"
+	"address-api/internal/repository"
+	"address-api/internal/territory"
 	"log"
 	"net/http"
+	"os"
 )
 
 func main() {
 	cfg := config.Load()
 
+	// Subcomando de gerenciamento do schema: main migrate <up|down|status|force>
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Importacao de segmentos de rua: main import [-dry-run] <arquivo>
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+		return
+	}
+
+	// Carga da base local de CEPs: main import-ceps [-dry-run] <arquivo.csv>
+	if len(os.Args) > 1 && os.Args[1] == "import-ceps" {
+		if err := runImportCEPs(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("CEP import failed: %v", err)
+		}
+		return
+	}
+
+	// Calendario de feriados nacionais: main import-holidays [-optional] <ano>
+	if len(os.Args) > 1 && os.Args[1] == "import-holidays" {
+		if err := runImportHolidays(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Holiday import failed: %v", err)
+		}
+		return
+	}
+
 	// Inicializar BD
-	_, err := database.InitDB(
+	db, err := database.InitDB(
 		cfg.PostgresHost,
 		cfg.PostgresUser,
 		cfg.PostgresPassword,
 		cfg.PostgresDB,
 		cfg.PostgresPort,
+		cfg.MigrateOnStart,
 	)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Inicializar o router
-	mux := http.NewServeMux()
+	// Remocao definitiva de registros apagados ha mais tempo que a retencao
+	database.StartPurgeJob(db, cfg.DeletedRetention, cfg.PurgeInterval)
+
+	store := repository.NewStore(db)
+
+	// Reatribuicoes de segmentos agendadas para hoje ou antes
+	territory.StartReassignmentJob(store.SegmentAssignments(), cfg.ReassignmentInterval)
 
-	// Registrando os Handlers
-	mux.HandleFunc("/ubs/", handlers.HandleUBS)
-	mux.HandleFunc("/teams/", handlers.HandleTeams)
-	mux.HandleFunc("/streets/", handlers.HandleStreetSegments)
+	// Criando os handlers com os repositorios
+	handler := handlers.NewHandler(store)
 
 	log.Printf("Starting server on port %s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
+	if err := http.ListenAndServe(":"+cfg.Port, handler.Routes(cfg.CORSAllowedOrigins)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"address-api/internal/config"
+	"address-api/internal/database"
+	"fmt"
+	"shared/migrate"
+)
+
+// runMigrate implementa o subcomando "migrate"
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrate.Usage)
+	}
+
+	db, err := database.Open(
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+	)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Run(args)
+}
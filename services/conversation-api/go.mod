@@ -15,4 +15,7 @@ require (
 	golang.org/x/crypto v0.26.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
+	shared v0.0.0
 )
+
+replace shared => ../../shared
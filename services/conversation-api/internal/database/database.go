@@ -10,30 +10,22 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var (
-	client     *mongo.Client
-	collection *mongo.Collection
-)
-
-func InitMongoDB(uri, database, collectionName string) (*mongo.Client, error) {
+// InitMongoDB connects to MongoDB and returns the client along with the
+// conversations collection
+func InitMongoDB(uri, database, collectionName string) (*mongo.Client, *mongo.Collection, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var err error
-	client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
+		return nil, nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
-		return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
+		return nil, nil, fmt.Errorf("failed to ping MongoDB: %v", err)
 	}
 
-	collection = client.Database(database).Collection(collectionName)
+	collection := client.Database(database).Collection(collectionName)
 	log.Println("Successfully connected to MongoDB")
-	return client, nil
-}
-
-func GetCollection() *mongo.Collection {
-	return collection
+	return client, collection, nil
 }
@@ -1,123 +1,107 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strings"
 	"time"
 
-	"conversation-api/internal/database"
 	"conversation-api/internal/models"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"conversation-api/internal/repository"
+	"shared/middleware"
+	"shared/openapi"
 )
 
-func HandleConversations(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		saveMessage(w, r)
-	case http.MethodGet:
-		if r.URL.Path == "/conversations/" {
-			getAllConversations(w, r)
-			return
-		}
-		getConversation(w, r)
-	default:
-		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
+// Handler holds the HTTP handlers and the repository they use
+type Handler struct {
+	conversations repository.ConversationRepository
 }
 
-func getAllConversations(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	collection := database.GetCollection()
+func NewHandler(conversations repository.ConversationRepository) *Handler {
+	return &Handler{conversations: conversations}
+}
 
-	// Define the projection to only get ID and UserID
-	projection := bson.D{
-		{Key: "_id", Value: 1},
-		{Key: "user_id", Value: 1},
-		{Key: "start_time", Value: 1},
-		{Key: "end_time", Value: 1},
-	}
+// Routes registers the conversation endpoints, documents them in the
+// OpenAPI document and wraps them in the common middleware chain
+func (h *Handler) Routes(allowedOrigins string) http.Handler {
+	mux := http.NewServeMux()
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Conversation API",
+		Version:     "1.0.0",
+		Description: "Histórico das conversas do WhatsApp",
+	})
+	api := openapi.NewRouter(mux, doc)
 
-	// Configure options for sorting by start_time in descending order
-	findOptions := options.Find().
-		SetProjection(projection).
-		SetSort(bson.D{{Key: "start_time", Value: -1}})
+	api.Handle("GET /health", h.health, openapi.Operation{
+		Summary:  "Service health",
+		Tag:      "health",
+		Response: models.HealthStatus{},
+	})
+	api.Handle("GET /conversations", h.getAllConversations, openapi.Operation{
+		Summary:  "List conversations without their messages",
+		Tag:      "conversations",
+		Response: models.ConversationList{},
+	})
+	api.Handle("POST /conversations", h.saveMessage, openapi.Operation{
+		Summary:  "Append a message to the user's open conversation, starting one if needed",
+		Tag:      "conversations",
+		Request:  models.Message{},
+		Response: models.Conversation{},
+		Status:   http.StatusCreated,
+	})
+	api.Handle("GET /conversations/{id}", h.getConversation, openapi.Operation{
+		Summary:  "Get a conversation with its messages",
+		Tag:      "conversations",
+		Params:   []openapi.Param{{Name: "id", In: "path", Description: "MongoDB ObjectID"}},
+		Response: models.Conversation{},
+	})
+	api.ServeDocs()
+
+	return middleware.Chain(mux,
+		middleware.Recovery,
+		middleware.RequestID,
+		middleware.Logging,
+		middleware.CORS(allowedOrigins),
+		middleware.StripTrailingSlash,
+		middleware.JSONErrors,
+	)
+}
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.HealthStatus{Status: "ok"},
+	})
+}
 
-	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
+func (h *Handler) getAllConversations(w http.ResponseWriter, r *http.Request) {
+	conversations, err := h.conversations.ListSummaries(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to fetch conversations")
 		return
 	}
-	defer cursor.Close(ctx)
-
-	type ConversationSummary struct {
-		ID        primitive.ObjectID `json:"id" bson:"_id"`
-		UserID    string             `json:"user_id" bson:"user_id"`
-		StartTime time.Time          `json:"start_time" bson:"start_time"`
-		EndTime   *time.Time         `json:"end_time,omitempty" bson:"end_time"`
-		Status    string             `json:"status"`
-	}
-
-	var conversations []ConversationSummary
-	for cursor.Next(ctx) {
-		var conv ConversationSummary
-		if err := cursor.Decode(&conv); err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Error decoding conversation")
-			return
-		}
 
-		// Determine conversation status
+	// Determine conversation status
+	response := make([]models.ConversationSummary, len(conversations))
+	for i, conv := range conversations {
 		if conv.EndTime == nil {
 			conv.Status = "active"
 		} else {
 			conv.Status = "closed"
 		}
-
-		conversations = append(conversations, conv)
-	}
-
-	if err := cursor.Err(); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error iterating conversations")
-		return
-	}
-
-	// Create response with string IDs
-	type ConversationResponse struct {
-		ID        string     `json:"id"`
-		UserID    string     `json:"user_id"`
-		StartTime time.Time  `json:"start_time"`
-		EndTime   *time.Time `json:"end_time,omitempty"`
-		Status    string     `json:"status"`
-	}
-
-	response := make([]ConversationResponse, len(conversations))
-	for i, conv := range conversations {
-		response[i] = ConversationResponse{
-			ID:        conv.ID.Hex(),
-			UserID:    conv.UserID,
-			StartTime: conv.StartTime,
-			EndTime:   conv.EndTime,
-			Status:    conv.Status,
-		}
+		response[i] = conv
 	}
 
 	respondWithJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data: struct {
-			TotalConversations int                    `json:"total_conversations"`
-			Conversations      []ConversationResponse `json:"conversations"`
-		}{
-			TotalConversations: len(conversations),
+		Data: models.ConversationList{
+			TotalConversations: len(response),
 			Conversations:      response,
 		},
 	})
 }
 
-func saveMessage(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) saveMessage(w http.ResponseWriter, r *http.Request) {
 	var msg models.Message
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -125,62 +109,33 @@ func saveMessage(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	collection := database.GetCollection()
-	ctx := context.Background()
+	ctx := r.Context()
 
 	// Find the latest conversation for this user
-	var conversation models.Conversation
-	opts := options.FindOne().SetSort(bson.D{{Key: "start_time", Value: -1}})
-	err := collection.FindOne(ctx,
-		bson.M{
-			"user_id":  msg.UserID,
-			"end_time": nil, // Only find conversations that haven't ended
-		},
-		opts,
-	).Decode(&conversation)
-
+	conversation, err := h.conversations.FindOpenByUser(ctx, msg.UserID)
 	if err != nil {
 		// Create new conversation if none exists or last one is closed
-		conversation = models.Conversation{
+		conversation = &models.Conversation{
 			UserID:    msg.UserID,
 			StartTime: time.Now(),
 			Messages:  []models.Message{msg},
 		}
 
-		result, err := collection.InsertOne(ctx, conversation)
-		if err != nil {
+		if err := h.conversations.Create(ctx, conversation); err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Failed to create conversation")
 			return
 		}
-
-		if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-			conversation.ID = oid.Hex()
-		}
 	} else {
 		// Add message to existing conversation
-		objectID, err := primitive.ObjectIDFromHex(conversation.ID)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Invalid conversation ID")
-			return
-		}
-
-		// Update the existing conversation with the new message
-		update := bson.M{
-			"$push": bson.M{"messages": msg},
-		}
-
-		_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+		conversation, err = h.conversations.AppendMessage(ctx, conversation.ID, msg)
 		if err != nil {
+			if errors.Is(err, repository.ErrInvalidID) {
+				respondWithError(w, http.StatusInternalServerError, "Invalid conversation ID")
+				return
+			}
 			respondWithError(w, http.StatusInternalServerError, "Failed to add message")
 			return
 		}
-
-		// Get the updated conversation
-		err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&conversation)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Failed to fetch updated conversation")
-			return
-		}
 	}
 
 	respondWithJSON(w, http.StatusCreated, models.APIResponse{
@@ -189,23 +144,13 @@ func saveMessage(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getConversation(w http.ResponseWriter, r *http.Request) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
-		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID")
-		return
-	}
-
-	id := parts[2]
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID format")
-		return
-	}
-
-	var conversation models.Conversation
-	err = database.GetCollection().FindOne(context.Background(), bson.M{"_id": objID}).Decode(&conversation)
+func (h *Handler) getConversation(w http.ResponseWriter, r *http.Request) {
+	conversation, err := h.conversations.Get(r.Context(), r.PathValue("id"))
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidID) {
+			respondWithError(w, http.StatusBadRequest, "Invalid conversation ID format")
+			return
+		}
 		respondWithError(w, http.StatusNotFound, "Conversation not found")
 		return
 	}
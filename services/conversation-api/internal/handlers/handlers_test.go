@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"conversation-api/internal/models"
+	"conversation-api/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testResponse mirrors models.APIResponse keeping Data raw so each test can
+// decode it into the type it expects
+type testResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+type fixture struct {
+	repo    *repository.MemoryConversationRepository
+	handler *Handler
+	open    models.Conversation
+	closed  models.Conversation
+}
+
+// newFixture seeds an open conversation for user "5511999990000" and an older
+// closed one for user "5511888880000"
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	repo := repository.NewMemoryConversationRepository()
+	ended := time.Now().Add(-time.Hour)
+	f := &fixture{
+		repo:    repo,
+		handler: NewHandler(repo),
+		open: models.Conversation{
+			UserID:    "5511999990000",
+			StartTime: time.Now().Add(-time.Minute),
+			Messages:  []models.Message{{UserID: "5511999990000", Sender: "user", Text: "Oi"}},
+		},
+		closed: models.Conversation{
+			UserID:    "5511888880000",
+			StartTime: time.Now().Add(-2 * time.Hour),
+			EndTime:   &ended,
+			Messages:  []models.Message{{UserID: "5511888880000", Sender: "user", Text: "Tchau"}},
+		},
+	}
+
+	for _, conversation := range []*models.Conversation{&f.open, &f.closed} {
+		if err := repo.Create(context.Background(), conversation); err != nil {
+			t.Fatalf("failed to seed conversation: %v", err)
+		}
+	}
+	return f
+}
+
+func doRequest(t *testing.T, h *Handler, method, target string, body interface{}) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		payload = []byte(b)
+	default:
+		var err error
+		if payload, err = json.Marshal(b); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.Routes("*").ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewReader(payload)))
+
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec, resp
+}
+
+func decodeData(t *testing.T, resp testResponse, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		t.Fatalf("failed to decode response data %s: %v", resp.Data, err)
+	}
+}
+
+func TestHandleConversations(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       func(f *fixture) string
+		body       interface{}
+		wantStatus int
+		check      func(t *testing.T, f *fixture, resp testResponse)
+	}{
+		{
+			name:       "list returns summaries with status",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/conversations/" },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var data struct {
+					TotalConversations int                          `json:"total_conversations"`
+					Conversations      []models.ConversationSummary `json:"conversations"`
+				}
+				decodeData(t, resp, &data)
+				if data.TotalConversations != 2 || len(data.Conversations) != 2 {
+					t.Fatalf("expected 2 conversations, got %+v", data)
+				}
+				if data.Conversations[0].ID != f.open.ID || data.Conversations[0].Status != "active" {
+					t.Errorf("expected newest active conversation first, got %+v", data.Conversations[0])
+				}
+				if data.Conversations[1].Status != "closed" {
+					t.Errorf("expected closed conversation, got %+v", data.Conversations[1])
+				}
+			},
+		},
+		{
+			name:       "get returns conversation",
+			method:     http.MethodGet,
+			path:       func(f *fixture) string { return "/conversations/" + f.open.ID },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var conversation models.Conversation
+				decodeData(t, resp, &conversation)
+				if conversation.ID != f.open.ID || len(conversation.Messages) != 1 {
+					t.Errorf("unexpected conversation: %+v", conversation)
+				}
+			},
+		},
+		{
+			name:       "get rejects malformed ID",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/conversations/not-an-id" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "get returns 404 for unknown conversation",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/conversations/" + primitive.NewObjectID().Hex() },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "save appends to the open conversation",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/conversations/" },
+			body:       models.Message{UserID: "5511999990000", Sender: "bot", Text: "Olá!"},
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var conversation models.Conversation
+				decodeData(t, resp, &conversation)
+				if conversation.ID != f.open.ID || len(conversation.Messages) != 2 {
+					t.Errorf("expected message appended to %s, got %+v", f.open.ID, conversation)
+				}
+			},
+		},
+		{
+			name:       "save starts a new conversation when the last one is closed",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/conversations/" },
+			body:       models.Message{UserID: "5511888880000", Sender: "user", Text: "Voltei"},
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, resp testResponse) {
+				var conversation models.Conversation
+				decodeData(t, resp, &conversation)
+				if conversation.ID == "" || conversation.ID == f.closed.ID || len(conversation.Messages) != 1 {
+					t.Errorf("expected a new conversation, got %+v", conversation)
+				}
+			},
+		},
+		{
+			name:       "save rejects invalid payload",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/conversations/" },
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "save requires user and text",
+			method:     http.MethodPost,
+			path:       func(*fixture) string { return "/conversations" },
+			body:       models.Message{Sender: "user"},
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, _ *fixture, resp testResponse) {
+				if resp.Error != "Invalid request: text is required; user_id is required" {
+					t.Errorf("unexpected error %q", resp.Error)
+				}
+			},
+		},
+		{
+			name:       "health",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/health" },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodDelete,
+			path:       func(*fixture) string { return "/conversations/" },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			path:       func(*fixture) string { return "/messages" },
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec, resp := doRequest(t, f.handler, tt.method, tt.path(f), tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if resp.Success != (tt.wantStatus < 300) {
+				t.Errorf("unexpected success flag %t", resp.Success)
+			}
+			if tt.check != nil {
+				tt.check(t, f, resp)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	f := newFixture(t)
+
+	rec := httptest.NewRecorder()
+	f.handler.Routes("*").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if _, ok := doc.Paths["/conversations"]["post"]; !ok {
+		t.Error("expected POST /conversations to be documented")
+	}
+	for _, name := range []string{"Message", "Conversation", "ConversationList"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("expected schema %s", name)
+		}
+	}
+}
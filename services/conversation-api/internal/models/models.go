@@ -3,9 +3,9 @@ package models
 import "time"
 
 type Message struct {
-	UserID    string    `json:"user_id" bson:"user_id"`
+	UserID    string    `json:"user_id" bson:"user_id" binding:"required"`
 	Sender    string    `json:"sender" bson:"sender"`
-	Text      string    `json:"text" bson:"text"`
+	Text      string    `json:"text" bson:"text" binding:"required"`
 	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
 }
 
@@ -17,6 +17,26 @@ type Conversation struct {
 	Messages  []Message  `json:"messages" bson:"messages"`
 }
 
+// ConversationSummary is a conversation without its messages
+type ConversationSummary struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+	Status    string     `json:"status"`
+}
+
+// ConversationList is returned when listing conversations
+type ConversationList struct {
+	TotalConversations int                   `json:"total_conversations"`
+	Conversations      []ConversationSummary `json:"conversations"`
+}
+
+// HealthStatus is returned by the health check endpoint
+type HealthStatus struct {
+	Status string `json:"status"`
+}
+
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"conversation-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryConversationRepository keeps conversations in memory. It is used by
+// the tests in place of MongoDB.
+type MemoryConversationRepository struct {
+	mu            sync.Mutex
+	conversations map[string]models.Conversation
+}
+
+func NewMemoryConversationRepository() *MemoryConversationRepository {
+	return &MemoryConversationRepository{conversations: map[string]models.Conversation{}}
+}
+
+// sorted returns the conversations newest first, like the MongoDB queries
+func (r *MemoryConversationRepository) sorted() []models.Conversation {
+	conversations := make([]models.Conversation, 0, len(r.conversations))
+	for _, conversation := range r.conversations {
+		conversations = append(conversations, conversation)
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].StartTime.After(conversations[j].StartTime)
+	})
+	return conversations
+}
+
+func (r *MemoryConversationRepository) ListSummaries(_ context.Context) ([]models.ConversationSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var summaries []models.ConversationSummary
+	for _, conversation := range r.sorted() {
+		summaries = append(summaries, models.ConversationSummary{
+			ID:        conversation.ID,
+			UserID:    conversation.UserID,
+			StartTime: conversation.StartTime,
+			EndTime:   conversation.EndTime,
+		})
+	}
+	return summaries, nil
+}
+
+func (r *MemoryConversationRepository) FindOpenByUser(_ context.Context, userID string) (*models.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, conversation := range r.sorted() {
+		if conversation.UserID == userID && conversation.EndTime == nil {
+			return &conversation, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryConversationRepository) Create(_ context.Context, conversation *models.Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if conversation.ID == "" {
+		conversation.ID = primitive.NewObjectID().Hex()
+	}
+	r.conversations[conversation.ID] = *conversation
+	return nil
+}
+
+func (r *MemoryConversationRepository) AppendMessage(ctx context.Context, id string, msg models.Message) (*models.Conversation, error) {
+	r.mu.Lock()
+	conversation, ok := r.conversations[id]
+	if ok {
+		conversation.Messages = append(conversation.Messages, msg)
+		r.conversations[id] = conversation
+	}
+	r.mu.Unlock()
+
+	return r.Get(ctx, id)
+}
+
+func (r *MemoryConversationRepository) Get(_ context.Context, id string) (*models.Conversation, error) {
+	if !primitive.IsValidObjectID(id) {
+		return nil, ErrInvalidID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conversation, ok := r.conversations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &conversation, nil
+}
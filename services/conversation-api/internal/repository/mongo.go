@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"conversation-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoConversationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewConversationRepository(collection *mongo.Collection) ConversationRepository {
+	return &mongoConversationRepository{collection: collection}
+}
+
+func translateError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *mongoConversationRepository) ListSummaries(ctx context.Context) ([]models.ConversationSummary, error) {
+	// Define the projection to only get ID and UserID
+	projection := bson.D{
+		{Key: "_id", Value: 1},
+		{Key: "user_id", Value: 1},
+		{Key: "start_time", Value: 1},
+		{Key: "end_time", Value: 1},
+	}
+
+	// Configure options for sorting by start_time in descending order
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "start_time", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []models.ConversationSummary
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			UserID    string             `bson:"user_id"`
+			StartTime time.Time          `bson:"start_time"`
+			EndTime   *time.Time         `bson:"end_time"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, models.ConversationSummary{
+			ID:        doc.ID.Hex(),
+			UserID:    doc.UserID,
+			StartTime: doc.StartTime,
+			EndTime:   doc.EndTime,
+		})
+	}
+
+	return summaries, cursor.Err()
+}
+
+func (r *mongoConversationRepository) FindOpenByUser(ctx context.Context, userID string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	opts := options.FindOne().SetSort(bson.D{{Key: "start_time", Value: -1}})
+	err := r.collection.FindOne(ctx,
+		bson.M{
+			"user_id":  userID,
+			"end_time": nil, // Only find conversations that haven't ended
+		},
+		opts,
+	).Decode(&conversation)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &conversation, nil
+}
+
+func (r *mongoConversationRepository) Create(ctx context.Context, conversation *models.Conversation) error {
+	result, err := r.collection.InsertOne(ctx, conversation)
+	if err != nil {
+		return err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		conversation.ID = oid.Hex()
+	}
+	return nil
+}
+
+func (r *mongoConversationRepository) AppendMessage(ctx context.Context, id string, msg models.Message) (*models.Conversation, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	update := bson.M{
+		"$push": bson.M{"messages": msg},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return nil, err
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *mongoConversationRepository) Get(ctx context.Context, id string) (*models.Conversation, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var conversation models.Conversation
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&conversation); err != nil {
+		return nil, translateError(err)
+	}
+	return &conversation, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"conversation-api/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when no conversation matches
+	ErrNotFound = errors.New("conversation not found")
+	// ErrInvalidID is returned when the ID is not a valid ObjectID
+	ErrInvalidID = errors.New("invalid conversation ID")
+)
+
+type ConversationRepository interface {
+	// ListSummaries returns every conversation, newest first, without messages
+	ListSummaries(ctx context.Context) ([]models.ConversationSummary, error)
+	// FindOpenByUser returns the latest conversation of the user that has not ended
+	FindOpenByUser(ctx context.Context, userID string) (*models.Conversation, error)
+	// Create stores the conversation and sets its ID
+	Create(ctx context.Context, conversation *models.Conversation) error
+	// AppendMessage adds a message and returns the updated conversation
+	AppendMessage(ctx context.Context, id string, msg models.Message) (*models.Conversation, error)
+	Get(ctx context.Context, id string) (*models.Conversation, error)
+}
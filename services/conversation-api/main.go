@@ -4,6 +4,7 @@ import (
 	"conversation-api/internal/config"
 	"conversation-api/internal/database"
 	"conversation-api/internal/handlers"
+	"conversation-api/internal/repository"
 	"log"
 	"net/http"
 )
@@ -13,7 +14,7 @@ func main() {
 
 	cfg := config.Load()
 
-	client, err := database.InitMongoDB(
+	client, collection, err := database.InitMongoDB(
 		cfg.MongoURI,
 		cfg.MongoDBName,
 		cfg.MongoCollection,
@@ -23,12 +24,10 @@ func main() {
 	}
 	defer client.Disconnect(nil)
 
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/conversations/", handlers.HandleConversations)
+	handler := handlers.NewHandler(repository.NewConversationRepository(collection))
 
 	log.Printf("Starting server on port %s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
+	if err := http.ListenAndServe(":"+cfg.Port, handler.Routes(cfg.CORSAllowedOrigins)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
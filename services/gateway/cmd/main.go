@@ -1,36 +1,61 @@
-package main
-
-import (
-	"log"
-	"net/http"
-
-	"gateway/internal/config"
-	"gateway/internal/handlers"
-	"gateway/internal/utils"
-)
-
-func main() {
-	// Configurando os logs
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-
-	// Carregando as configs
-	cfg := config.Load()
-
-	// Check API connections
-	utils.CheckAPIConnections(cfg)
-
-	// Criando o Handler com os clients
-	handler := handlers.NewHandler(cfg)
-
-	mux := http.NewServeMux()
-
-	// Apenas uma rota para lidar com as mensagens recebidas da twilio
-	mux.HandleFunc("POST /", handler.HandlePost)
-
-	// Iniciando o server
-	log.Printf("Starting server on port %s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
-		log.Fatalf("Error starting server: %v", err)
-	}
-}
-
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"gateway/internal/config"
+	"gateway/internal/handlers"
+	"gateway/internal/models"
+	"gateway/internal/utils"
+	"shared/middleware"
+	"shared/openapi"
+)
+
+func main() {
+	// Configurando os logs
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+	// Carregando as configs
+	cfg := config.Load()
+
+	// Check API connections
+	utils.CheckAPIConnections(cfg)
+
+	// Criando o Handler com os clients
+	handler := handlers.NewHandler(cfg)
+
+	mux := http.NewServeMux()
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Gateway",
+		Version:     "1.0.0",
+		Description: "Recebe as mensagens do WhatsApp enviadas pela Twilio e responde em TwiML",
+	})
+	api := openapi.NewRouter(mux, doc)
+
+	// Apenas uma rota para lidar com as mensagens recebidas da twilio
+	api.Handle("POST /", handler.HandlePost, openapi.Operation{
+		Summary:     "Webhook de mensagens da Twilio",
+		Tag:         "webhook",
+		Request:     models.TwilioMessage{},
+		ContentType: "application/x-www-form-urlencoded",
+	})
+	api.Handle("GET /health", handler.HandleHealth, openapi.Operation{
+		Summary: "Estado do gateway",
+		Tag:     "health",
+	})
+	api.ServeDocs()
+
+	// Webhook da Twilio nao precisa de CORS, apenas recuperacao de panics e logs
+	server := middleware.Chain(mux,
+		middleware.Recovery,
+		middleware.RequestID,
+		middleware.Logging,
+	)
+
+	// Iniciando o server
+	log.Printf("Starting server on port %s", cfg.Port)
+	if err := http.ListenAndServe(":"+cfg.Port, server); err != nil {
+		log.Fatalf("Error starting server: %v", err)
+	}
+}
@@ -1,3 +1,7 @@
 module gateway
 
 go 1.23.2
+
+require shared v0.0.0
+
+replace shared => ../../shared
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HandleHealth responde se o gateway esta de pe, sem consultar as outras APIs
+func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
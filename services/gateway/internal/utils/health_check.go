@@ -10,7 +10,7 @@ import (
 
 func CheckAPIConnections(cfg *config.Config) {
 	apis := map[string]string{
-		"User API":         fmt.Sprintf("http://%s:%s/users/1", cfg.UserAPIHost, cfg.UserAPIPort),
+		"User API":         fmt.Sprintf("http://%s:%s/health", cfg.UserAPIHost, cfg.UserAPIPort),
 		"Address API":      fmt.Sprintf("http://%s:%s/health", cfg.AddressAPIHost, cfg.AddressAPIPort),
 		"Conversation API": fmt.Sprintf("http://%s:%s/health", cfg.ConversationAPIHost, cfg.ConversationAPIPort),
 	}
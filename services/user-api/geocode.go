@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"user-api/internal/config"
+	"user-api/internal/database"
+	"user-api/internal/geocoding"
+	"user-api/internal/repository"
+)
+
+const geocodeUsage = "usage: geocode [-all] [-dry-run]"
+
+// newGeocoder picks the geocoder from the configuration: the gazetteer file
+// when set, otherwise the Nominatim server. It returns nil when neither is
+// configured.
+func newGeocoder(cfg *config.Config) (geocoding.Geocoder, error) {
+	switch {
+	case cfg.GeocoderFile != "":
+		file, err := os.Open(cfg.GeocoderFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return geocoding.LoadGazetteer(file)
+	case cfg.NominatimURL != "":
+		return geocoding.NewNominatim(cfg.NominatimURL), nil
+	}
+	return nil, nil
+}
+
+// runGeocode implements the "geocode" subcommand, which geocodes the users
+// never geocoded or whose last attempt failed, or every user with -all
+func runGeocode(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("geocode", flag.ContinueOnError)
+	all := flags.Bool("all", false, "geocode every user again")
+	dryRun := flags.Bool("dry-run", false, "only report, without saving")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf(geocodeUsage)
+	}
+
+	geocoder, err := newGeocoder(cfg)
+	if err != nil {
+		return err
+	}
+	if geocoder == nil {
+		return fmt.Errorf("no geocoder configured, set GEOCODER_FILE or NOMINATIM_URL")
+	}
+
+	db, err := database.InitDB(
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+
+	report, err := geocoding.Batch(repository.NewUserRepository(db), geocoder, *all, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	qualities := make([]string, 0, len(report.ByQuality))
+	for quality := range report.ByQuality {
+		qualities = append(qualities, quality)
+	}
+	sort.Strings(qualities)
+	for _, quality := range qualities {
+		fmt.Printf("%-14s %d\n", quality, report.ByQuality[quality])
+	}
+	fmt.Printf("users: %d, geocoded: %d, errors: %d\n", report.Total, report.Geocoded, report.Errors)
+	return nil
+}
@@ -3,6 +3,7 @@ module user-api
 go 1.23.2
 
 require (
+	golang.org/x/text v0.14.0
 	gorm.io/driver/postgres v1.5.11
 	gorm.io/gorm v1.25.12
 )
@@ -16,5 +17,7 @@ require (
 	github.com/jinzhu/now v1.1.5 // indirect
 	golang.org/x/crypto v0.17.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
+	shared v0.0.0
 )
+
+replace shared => ../../shared
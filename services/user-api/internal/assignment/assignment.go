@@ -0,0 +1,182 @@
+// Package assignment keeps on each user the healthcare team responsible for
+// their address. The team is resolved through address-api when the address
+// changes, when address-api moves the street to another team and by the
+// resolve-teams batch, so reading a user does not depend on address-api.
+package assignment
+
+import (
+	"log"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// Resolution status of the team of a user. An empty status means the team
+// was never resolved.
+const (
+	StatusResolved  = "resolved"
+	StatusAmbiguous = "ambiguous" // best candidate kept, but another team scored close
+	StatusNotFound  = "not_found" // address-api has no team for the address
+	StatusFailed    = "failed"    // address-api could not be reached
+	StatusPending   = ""
+)
+
+// Lookup finds the team responsible for the user address. A nil team means
+// the address has none.
+type Lookup func(user models.User) (*models.TeamInfo, error)
+
+// BatchLookup finds the team of many users at once, with one result per user
+// in the same order. The error means no team could be looked up at all.
+type BatchLookup func(users []models.User) ([]models.TeamResult, error)
+
+// Query is the address of the user as searched in address-api
+func Query(user models.User) models.AddressQuery {
+	return models.AddressQuery{
+		UserID:     user.ID,
+		StreetName: user.StreetName,
+		Number:     user.StreetNumber,
+		City:       user.City,
+		State:      user.State,
+	}
+}
+
+// Queries is Query for each user
+func Queries(users []models.User) []models.AddressQuery {
+	queries := make([]models.AddressQuery, len(users))
+	for i, user := range users {
+		queries[i] = Query(user)
+	}
+	return queries
+}
+
+// OneByOne turns a Lookup into a BatchLookup that searches each user in turn
+func OneByOne(lookup Lookup) BatchLookup {
+	return func(users []models.User) ([]models.TeamResult, error) {
+		results := make([]models.TeamResult, len(users))
+		for i, user := range users {
+			results[i].Team, results[i].Err = lookup(user)
+		}
+		return results, nil
+	}
+}
+
+// Set stores the team found for the user, or marks the address as having no
+// team when it is nil
+func Set(user *models.User, team *models.TeamInfo, at time.Time) {
+	user.TeamResolvedAt = &at
+	if team == nil || team.ID == 0 {
+		user.TeamID, user.UBSID, user.Team = nil, nil, nil
+		user.TeamStatus, user.TeamConfidence = StatusNotFound, ""
+		return
+	}
+
+	id := team.ID
+	stored := *team
+	user.TeamID, user.Team = &id, &stored
+	user.UBSID = nil
+	if team.UBSID != 0 {
+		ubsID := team.UBSID
+		user.UBSID = &ubsID
+	}
+	user.TeamConfidence = team.Confidence
+	user.TeamStatus = StatusResolved
+	if team.Status == StatusAmbiguous {
+		user.TeamStatus = StatusAmbiguous
+	}
+}
+
+// Resolve looks up and stores the team of the user. When the lookup fails
+// the user is marked as failed, without the previous team, which may belong
+// to an old address, and the error is returned so the caller can log it.
+func Resolve(lookup Lookup, user *models.User) error {
+	team, err := lookup(*user)
+	now := time.Now()
+	if err != nil {
+		user.TeamID, user.UBSID, user.Team = nil, nil, nil
+		user.TeamStatus, user.TeamConfidence = StatusFailed, ""
+		user.TeamResolvedAt = &now
+		return err
+	}
+	Set(user, team, now)
+	return nil
+}
+
+// Resolved reports whether the user has a team, even an ambiguous one
+func Resolved(user models.User) bool {
+	return user.TeamStatus == StatusResolved || user.TeamStatus == StatusAmbiguous
+}
+
+// Changed reports whether the stored team of the user differs
+func Changed(before, after models.User) bool {
+	teamOf := func(user models.User) uint {
+		if user.TeamID == nil {
+			return 0
+		}
+		return *user.TeamID
+	}
+	return teamOf(before) != teamOf(after) || before.TeamStatus != after.TeamStatus
+}
+
+// BatchReport counts what the batch did with each user
+type BatchReport struct {
+	Total    int            `json:"total"`
+	Changed  int            `json:"changed"`
+	ByStatus map[string]int `json:"by_status"`
+	Errors   int            `json:"errors"`
+}
+
+// Batch resolves again the team of the users never resolved or whose last
+// attempt failed or found no team, or of every active user when all is true
+// (after a territory was redrawn, for example). With dryRun nothing is saved.
+// The teams are looked up all at once; when that fails nothing is saved and
+// the error is returned. Errors on a user are logged and counted, and the
+// batch moves on.
+func Batch(users repository.UserRepository, lookup BatchLookup, all, dryRun bool) (*BatchReport, error) {
+	var (
+		pending []models.User
+		err     error
+	)
+	if all {
+		pending, err = users.List(false)
+	} else {
+		pending, err = users.ListByTeamStatus(StatusPending, StatusNotFound, StatusFailed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BatchReport{Total: len(pending), ByStatus: map[string]int{}}
+	if len(pending) == 0 {
+		return report, nil
+	}
+	results, err := lookup(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range pending {
+		user := &pending[i]
+		before := *user
+		// A failure keeps what is stored, so an address-api outage during
+		// the batch does not wipe the teams already known
+		if err := results[i].Err; err != nil {
+			log.Printf("Error resolving team of user %d: %v", user.ID, err)
+			report.Errors++
+			continue
+		}
+		Set(user, results[i].Team, now)
+		report.ByStatus[user.TeamStatus]++
+		if Changed(before, *user) {
+			report.Changed++
+		}
+		if dryRun {
+			continue
+		}
+		if err := users.UpdateTeam(user); err != nil {
+			log.Printf("Error saving team of user %d: %v", user.ID, err)
+			report.Errors++
+		}
+	}
+	return report, nil
+}
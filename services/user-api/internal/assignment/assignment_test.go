@@ -0,0 +1,133 @@
+package assignment
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+func TestResolve(t *testing.T) {
+	team := &models.TeamInfo{ID: 4, Name: "Equipe Azul", Confidence: "medium", Status: "ambiguous"}
+	tests := []struct {
+		name           string
+		team           *models.TeamInfo
+		err            error
+		wantStatus     string
+		wantConfidence string
+		wantTeam       bool
+	}{
+		{"found", &models.TeamInfo{ID: 4, Confidence: "high", Status: "matched"}, nil, StatusResolved, "high", true},
+		{"ambiguous", team, nil, StatusAmbiguous, "medium", true},
+		{"no team", nil, nil, StatusNotFound, "", false},
+		{"address-api down", nil, errors.New("connection refused"), StatusFailed, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The previous team must never survive a new resolution
+			previous := uint(9)
+			user := models.User{TeamID: &previous, Team: &models.TeamInfo{ID: previous}, TeamStatus: StatusResolved}
+			err := Resolve(func(models.User) (*models.TeamInfo, error) { return tt.team, tt.err }, &user)
+			if (err != nil) != (tt.err != nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if user.TeamStatus != tt.wantStatus || user.TeamConfidence != tt.wantConfidence || user.TeamResolvedAt == nil {
+				t.Errorf("unexpected resolution %q %q %v", user.TeamStatus, user.TeamConfidence, user.TeamResolvedAt)
+			}
+			if got := user.TeamID != nil && *user.TeamID == 4 && user.Team != nil; got != tt.wantTeam {
+				t.Errorf("expected team stored: %v, got %v %+v", tt.wantTeam, user.TeamID, user.Team)
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	resolved := uint(1)
+	now := time.Now()
+	for _, user := range []*models.User{
+		{CPF: "1", StreetName: "Flores"},
+		{CPF: "2", StreetName: "Ipes", TeamStatus: StatusFailed},
+		{CPF: "3", StreetName: "Flores", TeamID: &resolved, TeamStatus: StatusResolved, TeamResolvedAt: &now},
+		{CPF: "4", StreetName: "Desconhecida"},
+	} {
+		if err := users.Create(user); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+	}
+	lookup := func(user models.User) (*models.TeamInfo, error) {
+		switch user.StreetName {
+		case "Flores":
+			return &models.TeamInfo{ID: 2}, nil
+		case "Ipes":
+			return nil, errors.New("timeout")
+		}
+		return nil, nil
+	}
+
+	report, err := Batch(users, OneByOne(lookup), false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Total != 3 || report.Errors != 1 || report.Changed != 2 || report.ByStatus[StatusResolved] != 1 || report.ByStatus[StatusNotFound] != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if user, _ := users.Get(3); *user.TeamID != 1 {
+		t.Errorf("expected the resolved user to be left alone without -all, got team %d", *user.TeamID)
+	}
+
+	report, err = Batch(users, OneByOne(lookup), true, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Total != 4 || report.Changed != 1 {
+		t.Errorf("expected every user checked and user 3 changed, got %+v", report)
+	}
+	if user, _ := users.Get(3); *user.TeamID != 1 {
+		t.Errorf("expected nothing saved with dry run, got team %d", *user.TeamID)
+	}
+}
+
+func TestBatchLookupFailure(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	team := uint(1)
+	if err := users.Create(&models.User{CPF: "1", TeamID: &team, TeamStatus: StatusResolved}); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	lookup := func([]models.User) ([]models.TeamResult, error) { return nil, errors.New("connection refused") }
+
+	if _, err := Batch(users, lookup, true, false); err == nil {
+		t.Fatal("expected the lookup error")
+	}
+	if user, _ := users.Get(1); user.TeamStatus != StatusResolved || *user.TeamID != 1 {
+		t.Errorf("expected the stored team kept, got %q %v", user.TeamStatus, user.TeamID)
+	}
+}
+
+func TestBatchKeepsConcurrentEdits(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	if err := users.Create(&models.User{CPF: "1", PhoneNumber: "16999990000", StreetName: "Flores"}); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	// The user changes phone and address while the batch looks up the team
+	lookup := func(pending []models.User) ([]models.TeamResult, error) {
+		edited, _ := users.Get(1)
+		edited.PhoneNumber, edited.StreetName = "16988880000", "Ipes"
+		if err := users.Update(edited); err != nil {
+			t.Fatalf("failed to edit user: %v", err)
+		}
+		return []models.TeamResult{{Team: &models.TeamInfo{ID: 2}}}, nil
+	}
+
+	if _, err := Batch(users, lookup, true, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	user, _ := users.Get(1)
+	if user.PhoneNumber != "16988880000" || user.StreetName != "Ipes" {
+		t.Errorf("expected the edit kept, got %q %q", user.PhoneNumber, user.StreetName)
+	}
+	if user.TeamID == nil || *user.TeamID != 2 || user.TeamStatus != StatusResolved {
+		t.Errorf("expected the team stored, got %v %q", user.TeamID, user.TeamStatus)
+	}
+}
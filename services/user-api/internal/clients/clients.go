@@ -1,10 +1,12 @@
 package clients
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"user-api/internal/config"
 	"user-api/internal/models"
 )
@@ -27,21 +29,34 @@ type addressAPIResponse struct {
 
 type searchData struct {
 	Team struct {
-		ID   uint   `json:"id"`
-		Name string `json:"name"`
-		UBS  struct {
+		ID    uint   `json:"id"`
+		Name  string `json:"name"`
+		UBSID uint   `json:"ubs_id"`
+		UBS   struct {
 			Name string `json:"name"`
 		} `json:"ubs"`
 	} `json:"team"`
+	StreetSegment struct {
+		ID uint `json:"id"`
+	} `json:"street_segment"`
+	Roster     []models.TeamMember   `json:"roster"`
+	MicroArea  *models.MicroAreaInfo `json:"micro_area"`
+	Status     string                `json:"status"`
+	Confidence string                `json:"confidence"`
 }
 
-func (c *AddressClient) GetTeamInfo(streetName, number, city, state string) (*models.TeamInfo, error) {
+// GetTeamInfo searches the team for the address. The user ID goes along so
+// that address-api can record it when the search ends up in its review queue.
+func (c *AddressClient) GetTeamInfo(userID uint, streetName, number, city, state string) (*models.TeamInfo, error) {
 	// Build query URL
 	query := url.Values{}
 	query.Add("street", streetName)
 	query.Add("number", number)
 	query.Add("city", city)
 	query.Add("state", state)
+	if userID != 0 {
+		query.Add("user_id", strconv.FormatUint(uint64(userID), 10))
+	}
 
 	url := fmt.Sprintf("%s/streets/search?%s", c.baseURL, query.Encode())
 
@@ -70,13 +85,214 @@ func (c *AddressClient) GetTeamInfo(streetName, number, city, state string) (*mo
 		return nil, fmt.Errorf("address API error: %s", apiResp.Error)
 	}
 
-	// Map to TeamInfo
-	teamInfo := &models.TeamInfo{
-		ID:      apiResp.Data.Team.ID,
-		Name:    apiResp.Data.Team.Name,
-		UBSName: apiResp.Data.Team.UBS.Name,
+	return apiResp.Data.teamInfo(), nil
+}
+
+// teamInfo maps the search response to TeamInfo
+func (d searchData) teamInfo() *models.TeamInfo {
+	return &models.TeamInfo{
+		ID:        d.Team.ID,
+		Name:      d.Team.Name,
+		UBSID:     d.Team.UBSID,
+		UBSName:   d.Team.UBS.Name,
+		Roster:    d.Roster,
+		MicroArea: d.MicroArea,
+
+		StreetSegmentID: d.StreetSegment.ID,
+		Status:          d.Status,
+		Confidence:      d.Confidence,
+	}
+}
+
+// batchSize is the largest batch address-api accepts in a single request
+const batchSize = 500
+
+type batchAddress struct {
+	Street string `json:"street"`
+	Number string `json:"number"`
+	City   string `json:"city"`
+	State  string `json:"state"`
+	UserID *uint  `json:"user_id,omitempty"`
+}
+
+type batchResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	Result *searchData `json:"result"`
+	Error  string      `json:"error"`
+}
+
+// GetTeamInfoBatch searches the team of many addresses at once, in requests
+// of up to batchSize addresses, and returns one result per address in the
+// same order. An address address-api rejects gets its own error; the error
+// returned means address-api could not be reached and nothing was found.
+func (c *AddressClient) GetTeamInfoBatch(addresses []models.AddressQuery) ([]models.TeamResult, error) {
+	results := make([]models.TeamResult, 0, len(addresses))
+	for start := 0; start < len(addresses); start += batchSize {
+		end := start + batchSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		chunk, err := c.searchBatch(addresses[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunk...)
+	}
+	return results, nil
+}
+
+func (c *AddressClient) searchBatch(addresses []models.AddressQuery) ([]models.TeamResult, error) {
+	body := struct {
+		Addresses []batchAddress `json:"addresses"`
+	}{Addresses: make([]batchAddress, len(addresses))}
+	for i, address := range addresses {
+		body.Addresses[i] = batchAddress{
+			Street: address.StreetName,
+			Number: address.Number,
+			City:   address.City,
+			State:  address.State,
+		}
+		if address.UserID != 0 {
+			userID := address.UserID
+			body.Addresses[i].UserID = &userID
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(c.baseURL+"/streets/search/batch", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("error making request to address API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("address API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp struct {
+		Success bool          `json:"success"`
+		Data    []batchResult `json:"data"`
+		Error   string        `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("address API error: %s", apiResp.Error)
+	}
+	if len(apiResp.Data) != len(addresses) {
+		return nil, fmt.Errorf("address API returned %d results for %d addresses", len(apiResp.Data), len(addresses))
+	}
+
+	results := make([]models.TeamResult, len(addresses))
+	for _, item := range apiResp.Data {
+		if item.Index < 0 || item.Index >= len(results) {
+			return nil, fmt.Errorf("address API returned an unknown index %d", item.Index)
+		}
+		switch {
+		case item.Status == http.StatusOK && item.Result != nil:
+			results[item.Index].Team = item.Result.teamInfo()
+		case item.Status == http.StatusNotFound:
+			// No team found for this address
+		default:
+			results[item.Index].Err = fmt.Errorf("address API returned status %d: %s", item.Status, item.Error)
+		}
+	}
+	return results, nil
+}
+
+type assignmentTeam struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	UBS  struct {
+		Name string `json:"name"`
+	} `json:"ubs"`
+}
+
+func (t *assignmentTeam) info() models.TeamInfo {
+	if t == nil {
+		return models.TeamInfo{}
+	}
+	return models.TeamInfo{ID: t.ID, Name: t.Name, UBSName: t.UBS.Name}
+}
+
+type assignmentData struct {
+	ID              uint   `json:"id"`
+	StreetSegmentID uint   `json:"street_segment_id"`
+	ValidFrom       string `json:"valid_from"`
+	StreetSegment   *struct {
+		City        string `json:"city"`
+		State       string `json:"state"`
+		StartNumber int    `json:"start_number"`
+		EndNumber   int    `json:"end_number"`
+		EvenOdd     string `json:"even_odd"`
+	} `json:"street_segment"`
+	Team         *assignmentTeam `json:"team"`
+	PreviousTeam *assignmentTeam `json:"previous_team"`
+}
+
+// UnnotifiedReassignments lists the street segments that changed team and
+// whose residents were not notified yet
+func (c *AddressClient) UnnotifiedReassignments() ([]models.Reassignment, error) {
+	resp, err := http.Get(c.baseURL + "/reassignments?status=unnotified")
+	if err != nil {
+		return nil, fmt.Errorf("error making request to address API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("address API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp struct {
+		Success bool             `json:"success"`
+		Data    []assignmentData `json:"data"`
+		Error   string           `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	if !apiResp.Success {
+		return nil, fmt.Errorf("address API error: %s", apiResp.Error)
 	}
 
-	return teamInfo, nil
+	reassignments := make([]models.Reassignment, 0, len(apiResp.Data))
+	for _, data := range apiResp.Data {
+		reassignment := models.Reassignment{
+			ID:              data.ID,
+			StreetSegmentID: data.StreetSegmentID,
+			ValidFrom:       data.ValidFrom,
+			Team:            data.Team.info(),
+			PreviousTeam:    data.PreviousTeam.info(),
+		}
+		if segment := data.StreetSegment; segment != nil {
+			reassignment.City = segment.City
+			reassignment.State = segment.State
+			reassignment.StartNumber = segment.StartNumber
+			reassignment.EndNumber = segment.EndNumber
+			reassignment.EvenOdd = segment.EvenOdd
+		}
+		reassignments = append(reassignments, reassignment)
+	}
+	return reassignments, nil
 }
 
+// MarkReassignmentNotified tells address-api that the residents affected by
+// the reassignment were notified, so it is not listed again
+func (c *AddressClient) MarkReassignmentNotified(id uint) error {
+	url := fmt.Sprintf("%s/reassignments/%d/notified", c.baseURL, id)
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("error making request to address API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("address API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package config
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 type Config struct {
 	Port             string
@@ -11,18 +14,51 @@ type Config struct {
 	PostgresPort     string
 	AddressAPIHost   string
 	AddressAPIPort   string
+
+	// Comma separated origins allowed by CORS, "*" allows any
+	CORSAllowedOrigins string
+
+	// Apply pending migrations before checking the schema version on startup
+	MigrateOnStart bool
+
+	// Geocoder used for user addresses: a gazetteer CSV file, or else the
+	// URL of a Nominatim server. With neither, addresses are not geocoded.
+	GeocoderFile string
+	NominatimURL string
+
+	// Users affected by a team change are notified through a webhook when
+	// set, otherwise the notifications are only logged
+	NotifyWebhookURL string
+	NotifyInterval   time.Duration
+
+	// Token the other services send in X-Service-Token to list every user;
+	// empty, the listings only answer requests with a caller scope
+	ServiceToken string
+
+	// How long deleted users stay available for restore
+	DeletedRetention time.Duration
+	PurgeInterval    time.Duration
 }
 
 func Load() *Config {
 	return &Config{
-		Port:             getEnv("USER_API_PORT", "8081"),
-		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
-		PostgresUser:     getEnv("POSTGRES_USER", "postgres"),
-		PostgresPassword: getEnv("POSTGRES_PASSWORD", "postgres"),
-		PostgresDB:       getEnv("POSTGRES_DB", "postgres"),
-		PostgresPort:     getEnv("POSTGRES_PORT", "5432"),
-		AddressAPIHost:   getEnv("ADDRESS_API_HOST", "localhost"),
-		AddressAPIPort:   getEnv("ADDRESS_API_PORT", "8083"),
+		Port:               getEnv("USER_API_PORT", "8081"),
+		PostgresHost:       getEnv("POSTGRES_HOST", "localhost"),
+		PostgresUser:       getEnv("POSTGRES_USER", "postgres"),
+		PostgresPassword:   getEnv("POSTGRES_PASSWORD", "postgres"),
+		PostgresDB:         getEnv("POSTGRES_DB", "postgres"),
+		PostgresPort:       getEnv("POSTGRES_PORT", "5432"),
+		AddressAPIHost:     getEnv("ADDRESS_API_HOST", "localhost"),
+		AddressAPIPort:     getEnv("ADDRESS_API_PORT", "8083"),
+		CORSAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
+		MigrateOnStart:     getEnv("MIGRATE_ON_START", "false") == "true",
+		GeocoderFile:       getEnv("GEOCODER_FILE", ""),
+		NominatimURL:       getEnv("NOMINATIM_URL", ""),
+		NotifyWebhookURL:   getEnv("NOTIFY_WEBHOOK_URL", ""),
+		NotifyInterval:     getEnvDuration("NOTIFY_INTERVAL", time.Hour),
+		ServiceToken:       getEnv("SERVICE_TOKEN", ""),
+		DeletedRetention:   getEnvDuration("DELETED_RETENTION", 90*24*time.Hour),
+		PurgeInterval:      getEnvDuration("PURGE_INTERVAL", 24*time.Hour),
 	}
 }
 
@@ -32,3 +68,12 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
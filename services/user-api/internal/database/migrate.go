@@ -0,0 +1,19 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"shared/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsTable keeps the current schema version. It is namespaced because
+// user-api and address-api may share the same Postgres database.
+const migrationsTable = "user_api_schema_migrations"
+
+// NewMigrator returns a migrator for the embedded migrations
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrationsTable, migrationFiles, nil)
+}
@@ -0,0 +1,3 @@
+-- update_updated_at_column() is kept because address-api triggers may use it
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP TABLE IF EXISTS users;
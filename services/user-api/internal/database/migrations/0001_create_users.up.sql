@@ -1,7 +1,3 @@
--- /postgres/init/02-users.sql
-\c users;
-
--- Create users table
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     name VARCHAR(200) NOT NULL,
@@ -19,10 +15,9 @@ CREATE TABLE IF NOT EXISTS users (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Create index for CPF searches
 CREATE INDEX IF NOT EXISTS idx_users_cpf ON users(cpf);
 
--- Create update trigger for updated_at
+-- Shared with address-api when both services use the same database
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
 BEGIN
@@ -31,6 +26,9 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
+-- Databases built by the old init scripts or by AutoMigrate may have the
+-- trigger already, so it is recreated
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
 CREATE TRIGGER update_users_updated_at
     BEFORE UPDATE ON users
     FOR EACH ROW
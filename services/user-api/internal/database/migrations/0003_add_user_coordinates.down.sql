@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_pending_geocode;
+
+ALTER TABLE users DROP COLUMN IF EXISTS geocoded_at;
+ALTER TABLE users DROP COLUMN IF EXISTS geocode_quality;
+ALTER TABLE users DROP COLUMN IF EXISTS longitude;
+ALTER TABLE users DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,10 @@
+-- Geocoded position of the user address
+ALTER TABLE users ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS geocode_quality VARCHAR(20) NOT NULL DEFAULT ''
+    CHECK (geocode_quality IN ('', 'house', 'street', 'cep', 'neighborhood', 'city', 'failed'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS geocoded_at TIMESTAMP WITH TIME ZONE;
+
+-- Users the geocode batch still has to process
+CREATE INDEX IF NOT EXISTS idx_users_pending_geocode ON users(id)
+    WHERE geocoded_at IS NULL OR geocode_quality = 'failed';
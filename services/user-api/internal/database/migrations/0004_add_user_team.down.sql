@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_unresolved_team;
+DROP INDEX IF EXISTS idx_users_team_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS team;
+ALTER TABLE users DROP COLUMN IF EXISTS team_resolved_at;
+ALTER TABLE users DROP COLUMN IF EXISTS team_confidence;
+ALTER TABLE users DROP COLUMN IF EXISTS team_status;
+ALTER TABLE users DROP COLUMN IF EXISTS team_id;
@@ -0,0 +1,15 @@
+-- Healthcare team resolved for the user address, so reads no longer search
+-- address-api. team keeps the whole answer (UBS, roster, micro-area).
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_id INTEGER;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_status VARCHAR(20) NOT NULL DEFAULT ''
+    CHECK (team_status IN ('', 'resolved', 'ambiguous', 'not_found', 'failed'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_confidence VARCHAR(10) NOT NULL DEFAULT ''
+    CHECK (team_confidence IN ('', 'high', 'medium', 'low'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_resolved_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_users_team_id ON users(team_id);
+
+-- Users whose team is still unknown, listed for review and retried in batch
+CREATE INDEX IF NOT EXISTS idx_users_unresolved_team ON users(id)
+    WHERE team_status IN ('', 'not_found', 'failed');
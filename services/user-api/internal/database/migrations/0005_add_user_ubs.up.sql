@@ -0,0 +1,5 @@
+-- UBS of the team of the user, to list the citizens of a UBS. Users
+-- resolved before this migration get it on the next resolve-teams -all.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS ubs_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_users_ubs_id ON users(ubs_id);
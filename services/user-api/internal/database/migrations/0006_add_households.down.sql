@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_managed_by_id;
+DROP INDEX IF EXISTS idx_users_household_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS managed_by_id;
+ALTER TABLE users DROP COLUMN IF EXISTS relationship;
+ALTER TABLE users DROP COLUMN IF EXISTS household_id;
+
+DROP TABLE IF EXISTS households;
@@ -0,0 +1,32 @@
+-- Users living in the same dwelling, followed together by the team
+CREATE TABLE IF NOT EXISTS households (
+    id SERIAL PRIMARY KEY,
+    head_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    street_name VARCHAR(200) NOT NULL,
+    street_number VARCHAR(20) NOT NULL,
+    complement VARCHAR(100),
+    neighborhood VARCHAR(100) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(2) NOT NULL,
+    cep VARCHAR(8) NOT NULL,
+    address_key VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_households_address_key ON households(address_key);
+
+CREATE TRIGGER update_households_updated_at
+    BEFORE UPDATE ON households
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS household_id INTEGER REFERENCES households(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS relationship VARCHAR(20) NOT NULL DEFAULT ''
+    CHECK (relationship IN ('', 'head', 'spouse', 'child', 'grandchild', 'parent', 'sibling', 'other_relative', 'non_relative'));
+
+-- Dependents without a phone of their own, answered for by another user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS managed_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_household_id ON users(household_id);
+CREATE INDEX IF NOT EXISTS idx_users_managed_by_id ON users(managed_by_id);
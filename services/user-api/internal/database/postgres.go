@@ -3,15 +3,13 @@ package database
 import (
 	"fmt"
 	"log"
-	"user-api/internal/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-func InitDB(host, user, password, dbname, port string) (*gorm.DB, error) {
+// Open connects to the database without checking or changing its schema
+func Open(host, user, password, dbname, port string) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=America/Sao_Paulo",
 		host, user, password, dbname, port)
 
@@ -19,24 +17,38 @@ func InitDB(host, user, password, dbname, port string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
+	return db, nil
+}
 
-	// Run migrations
-	err = db.AutoMigrate(&models.User{})
+// InitDB connects to the database and refuses to continue unless the schema
+// is at the version expected by this binary. With migrateOnStart the pending
+// migrations are applied first.
+func InitDB(host, user, password, dbname, port string, migrateOnStart bool) (*gorm.DB, error) {
+	db, err := Open(host, user, password, dbname, port)
 	if err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %v", err)
+		return nil, err
 	}
 
-	// Create index for CPF searches
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_cpf ON users(cpf);").Error; err != nil {
-		return nil, fmt.Errorf("failed to create CPF index: %v", err)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %v", err)
 	}
 
-	DB = db
-	log.Println("Successfully connected to database and created indexes")
-	return DB, nil
-}
+	migrator, err := NewMigrator(sqlDB)
+	if err != nil {
+		return nil, err
+	}
 
-func GetDB() *gorm.DB {
-	return DB
-}
+	if migrateOnStart {
+		if err := migrator.Up(); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %v", err)
+		}
+	}
 
+	if err := migrator.CheckVersion(); err != nil {
+		return nil, fmt.Errorf("unexpected database schema: %v", err)
+	}
+
+	log.Printf("Successfully connected to database (schema version %d)", migrator.LatestVersion())
+	return db, nil
+}
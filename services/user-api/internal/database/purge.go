@@ -0,0 +1,33 @@
+package database
+
+import (
+	"log"
+	"time"
+	"user-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PurgeDeleted permanently removes users deleted before cutoff
+func PurgeDeleted(db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.User{})
+	return result.RowsAffected, result.Error
+}
+
+// StartPurgeJob runs PurgeDeleted periodically in the background
+func StartPurgeJob(db *gorm.DB, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := PurgeDeleted(db, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Error purging deleted users: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d users deleted more than %s ago", purged, retention)
+			}
+			<-ticker.C
+		}
+	}()
+}
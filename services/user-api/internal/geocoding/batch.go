@@ -0,0 +1,47 @@
+package geocoding
+
+import (
+	"log"
+	"user-api/internal/repository"
+)
+
+// BatchReport counts what the batch did with each user
+type BatchReport struct {
+	Total     int            `json:"total"`
+	Geocoded  int            `json:"geocoded"`
+	ByQuality map[string]int `json:"by_quality"`
+	Errors    int            `json:"errors"`
+}
+
+// Batch geocodes the users that were never geocoded or whose last attempt
+// failed, or every active user when all is true (after the gazetteer is
+// updated, for example). With dryRun nothing is saved. Errors on a user are
+// logged and counted, and the batch moves on to the next one.
+func Batch(users repository.UserRepository, g Geocoder, all, dryRun bool) (*BatchReport, error) {
+	pending, err := users.ListForGeocoding(all)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BatchReport{Total: len(pending), ByQuality: map[string]int{}}
+	for i := range pending {
+		user := &pending[i]
+		if err := Apply(g, user); err != nil {
+			log.Printf("Error geocoding user %d: %v", user.ID, err)
+			report.Errors++
+			continue
+		}
+		report.ByQuality[user.GeocodeQuality]++
+		if user.GeocodeQuality != QualityFailed {
+			report.Geocoded++
+		}
+		if dryRun {
+			continue
+		}
+		if err := users.Update(user); err != nil {
+			log.Printf("Error saving coordinates of user %d: %v", user.ID, err)
+			report.Errors++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,196 @@
+package geocoding
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"shared/normalize"
+	"strconv"
+	"strings"
+)
+
+// Gazetteer is an offline geocoder over a list of street segments with the
+// coordinates of their first and last numbers, like a small local Nominatim.
+// House numbers are interpolated along their segment; addresses whose street
+// is unknown fall back to the centre of their CEP, neighborhood or city.
+type Gazetteer struct {
+	entries []gazetteerEntry
+}
+
+type gazetteerEntry struct {
+	street       string
+	neighborhood string
+	city         string
+	state        string
+	cep          string
+	startNumber  int
+	endNumber    int
+	start        point
+	end          point
+}
+
+type point struct {
+	lat, lng float64
+}
+
+// gazetteerColumns lists the CSV header. The end_* columns are optional, a
+// row without them is a single point such as a square or a CEP centre.
+var gazetteerColumns = []string{
+	"street_name", "start_number", "end_number", "start_lat", "start_lng",
+	"end_lat", "end_lng", "neighborhood", "city", "state", "cep",
+}
+
+// LoadGazetteer reads the CSV, comma or semicolon separated, with a header
+// naming the columns in gazetteerColumns in any order
+func LoadGazetteer(r io.Reader) (*Gazetteer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading gazetteer header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"street_name", "start_lat", "start_lng", "city", "state"} {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("gazetteer is missing the %s column", name)
+		}
+	}
+
+	g := &Gazetteer{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer row %d: %w", row, err)
+		}
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		entry := gazetteerEntry{
+			street:       normalize.StreetName(field("street_name")),
+			neighborhood: normalize.Text(field("neighborhood")),
+			city:         normalize.Text(field("city")),
+			state:        normalize.Text(field("state")),
+			cep:          onlyDigits(field("cep")),
+		}
+		if entry.start, err = parsePoint(field("start_lat"), field("start_lng")); err != nil {
+			return nil, fmt.Errorf("gazetteer row %d: %w", row, err)
+		}
+		entry.end = entry.start
+		if field("end_lat") != "" || field("end_lng") != "" {
+			if entry.end, err = parsePoint(field("end_lat"), field("end_lng")); err != nil {
+				return nil, fmt.Errorf("gazetteer row %d: %w", row, err)
+			}
+		}
+		entry.startNumber, _ = strconv.Atoi(field("start_number"))
+		entry.endNumber, _ = strconv.Atoi(field("end_number"))
+		if entry.endNumber < entry.startNumber {
+			entry.endNumber = entry.startNumber
+		}
+		g.entries = append(g.entries, entry)
+	}
+	return g, nil
+}
+
+func parsePoint(lat, lng string) (point, error) {
+	p := point{}
+	var errLat, errLng error
+	p.lat, errLat = strconv.ParseFloat(lat, 64)
+	p.lng, errLng = strconv.ParseFloat(lng, 64)
+	if errLat != nil || errLng != nil || p.lat < -90 || p.lat > 90 || p.lng < -180 || p.lng > 180 {
+		return p, fmt.Errorf("invalid coordinates %q, %q", lat, lng)
+	}
+	return p, nil
+}
+
+func (g *Gazetteer) Geocode(address Address) (*Result, error) {
+	city, state := normalize.Text(address.City), normalize.Text(address.State)
+	street := normalize.StreetName(address.Street)
+	neighborhood := normalize.Text(address.Neighborhood)
+	cep := onlyDigits(address.CEP)
+
+	var onStreet, inCEP, inNeighborhood, inCity []gazetteerEntry
+	for _, entry := range g.entries {
+		if entry.city != city || entry.state != state {
+			continue
+		}
+		inCity = append(inCity, entry)
+		if street != "" && entry.street == street {
+			onStreet = append(onStreet, entry)
+		}
+		if len(cep) == 8 && entry.cep == cep {
+			inCEP = append(inCEP, entry)
+		}
+		if neighborhood != "" && entry.neighborhood == neighborhood {
+			inNeighborhood = append(inNeighborhood, entry)
+		}
+	}
+
+	if number, ok := houseNumber(address.Number); ok {
+		for _, entry := range onStreet {
+			if entry.startNumber <= number && number <= entry.endNumber && entry.endNumber > 0 {
+				p := entry.interpolate(number)
+				return &Result{Lat: p.lat, Lng: p.lng, Quality: QualityHouse}, nil
+			}
+		}
+	}
+
+	for _, fallback := range []struct {
+		entries []gazetteerEntry
+		quality string
+	}{
+		{onStreet, QualityStreet},
+		{inCEP, QualityCEP},
+		{inNeighborhood, QualityNeighborhood},
+		{inCity, QualityCity},
+	} {
+		if len(fallback.entries) > 0 {
+			p := centre(fallback.entries)
+			return &Result{Lat: p.lat, Lng: p.lng, Quality: fallback.quality}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// interpolate places the number proportionally between the segment ends
+func (e gazetteerEntry) interpolate(number int) point {
+	if e.endNumber == e.startNumber {
+		return e.start
+	}
+	t := float64(number-e.startNumber) / float64(e.endNumber-e.startNumber)
+	return point{
+		lat: e.start.lat + t*(e.end.lat-e.start.lat),
+		lng: e.start.lng + t*(e.end.lng-e.start.lng),
+	}
+}
+
+// centre is the mean of the segment midpoints, good enough inside a city
+func centre(entries []gazetteerEntry) point {
+	var sum point
+	for _, entry := range entries {
+		sum.lat += (entry.start.lat + entry.end.lat) / 2
+		sum.lng += (entry.start.lng + entry.end.lng) / 2
+	}
+	n := float64(len(entries))
+	return point{lat: sum.lat / n, lng: sum.lng / n}
+}
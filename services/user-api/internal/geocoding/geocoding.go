@@ -0,0 +1,116 @@
+// Package geocoding turns user addresses into coordinates. The Geocoder is
+// pluggable: an offline Gazetteer loaded from a CSV file, or a Nominatim
+// server for deployments that run one.
+package geocoding
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"user-api/internal/models"
+)
+
+// Geocode quality, from the most to the least precise
+const (
+	QualityHouse        = "house"        // the house number was located on its street
+	QualityStreet       = "street"       // the street was found, but not the number
+	QualityCEP          = "cep"          // centre of the CEP
+	QualityNeighborhood = "neighborhood" // centre of the neighborhood
+	QualityCity         = "city"         // centre of the city
+	QualityFailed       = "failed"       // nothing matched the address
+)
+
+// ErrNotFound is returned by a Geocoder when nothing matches the address
+var ErrNotFound = errors.New("address not found")
+
+// Address is what a Geocoder needs to know about an address
+type Address struct {
+	Street       string
+	Number       string
+	Neighborhood string
+	City         string
+	State        string
+	CEP          string
+}
+
+// Result is a geocoded position and how precise it is
+type Result struct {
+	Lat     float64
+	Lng     float64
+	Quality string
+}
+
+type Geocoder interface {
+	Geocode(address Address) (*Result, error)
+}
+
+// AddressOf returns the address of the user
+func AddressOf(user models.User) Address {
+	return Address{
+		Street:       user.StreetName,
+		Number:       user.StreetNumber,
+		Neighborhood: user.Neighborhood,
+		City:         user.City,
+		State:        user.State,
+		CEP:          user.CEP,
+	}
+}
+
+// Apply geocodes the user's address and stores the result on the user. An
+// address that matches nothing is marked as failed. Any other error clears
+// the previous coordinates, which may belong to an old address, and is
+// returned so the caller can log it; the batch command retries those users.
+func Apply(g Geocoder, user *models.User) error {
+	result, err := g.Geocode(AddressOf(*user))
+	now := time.Now()
+	switch {
+	case errors.Is(err, ErrNotFound):
+		user.Latitude, user.Longitude = nil, nil
+		user.GeocodeQuality = QualityFailed
+		user.GeocodedAt = &now
+		return nil
+	case err != nil:
+		Clear(user)
+		return err
+	}
+
+	user.Latitude, user.Longitude = &result.Lat, &result.Lng
+	user.GeocodeQuality = result.Quality
+	user.GeocodedAt = &now
+	return nil
+}
+
+// Clear removes the coordinates of the user, marking them as not geocoded
+func Clear(user *models.User) {
+	user.Latitude, user.Longitude = nil, nil
+	user.GeocodeQuality = ""
+	user.GeocodedAt = nil
+}
+
+// AddressChanged reports whether any field used for geocoding differs
+func AddressChanged(before, after models.User) bool {
+	return AddressOf(before) != AddressOf(after)
+}
+
+// houseNumber reads the leading digits of the number: "10A" is 10, "s/n"
+// has no number
+func houseNumber(number string) (int, bool) {
+	number = strings.TrimSpace(number)
+	end := 0
+	for end < len(number) && number[end] >= '0' && number[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(number[:end])
+	return n, err == nil
+}
+
+// onlyDigits keeps the digits of a CEP
+func onlyDigits(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text)
+}
@@ -0,0 +1,161 @@
+package geocoding
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+const gazetteerCSV = "street_name,start_number,end_number,start_lat,start_lng,end_lat,end_lng,neighborhood,city,state,cep\n" +
+	"Rua das Flores,1,99,-22.0100,-47.8900,-22.0120,-47.8920,Centro,São Carlos,SP,13560000\n" +
+	"Rua das Flores,101,199,-22.0120,-47.8920,-22.0140,-47.8940,Centro,São Carlos,SP,13560000\n" +
+	"Avenida São Carlos,1,1999,-22.0000,-47.8800,-22.0200,-47.8800,Vila Nery,São Carlos,SP,13560001\n" +
+	"Praça XV,,,-22.0050,-47.8850,,,Centro,São Carlos,SP,\n"
+
+func loadGazetteer(t *testing.T) *Gazetteer {
+	t.Helper()
+	g, err := LoadGazetteer(strings.NewReader(gazetteerCSV))
+	if err != nil {
+		t.Fatalf("failed to load gazetteer: %v", err)
+	}
+	return g
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestGazetteer(t *testing.T) {
+	g := loadGazetteer(t)
+
+	tests := []struct {
+		name        string
+		address     Address
+		wantQuality string
+		wantLat     float64
+	}{
+		{"number interpolated", Address{Street: "R. das Flores", Number: "50", City: "Sao Carlos", State: "sp"}, QualityHouse, -22.0100 - 0.002*49/98},
+		{"number with letter", Address{Street: "Rua das Flores", Number: "101A", City: "São Carlos", State: "SP"}, QualityHouse, -22.0120},
+		{"number outside the segments", Address{Street: "Rua das Flores", Number: "500", City: "São Carlos", State: "SP"}, QualityStreet, -22.0120},
+		{"unknown street with CEP", Address{Street: "Rua Nova", Number: "1", City: "São Carlos", State: "SP", CEP: "13560-001"}, QualityCEP, -22.0100},
+		{"unknown street in a known neighborhood", Address{Street: "Rua Nova", Number: "1", Neighborhood: "vila nery", City: "São Carlos", State: "SP"}, QualityNeighborhood, -22.0100},
+		{"only the city", Address{Street: "Rua Nova", Number: "1", City: "São Carlos", State: "SP"}, QualityCity, -22.0099},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Geocode(tt.address)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Quality != tt.wantQuality || !near(got.Lat, tt.wantLat) && tt.wantQuality != QualityCity {
+				t.Errorf("got %+v, want quality %s at lat %v", got, tt.wantQuality, tt.wantLat)
+			}
+		})
+	}
+
+	if _, err := g.Geocode(Address{Street: "Rua das Flores", Number: "10", City: "Ibaté", State: "SP"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown city, got %v", err)
+	}
+
+	if _, err := LoadGazetteer(strings.NewReader("street_name;city\nRua A;X\n")); err == nil {
+		t.Error("expected an error for missing columns")
+	}
+	if _, err := LoadGazetteer(strings.NewReader("street_name,start_lat,start_lng,city,state\nRua A,x,1,X,SP\n")); err == nil {
+		t.Error("expected an error for invalid coordinates")
+	}
+}
+
+func TestNominatim(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		if r.URL.Query().Get("city") == "Nowhere" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat":"-22.01","lon":"-47.89","addresstype":"road"}]`))
+	}))
+	defer server.Close()
+
+	n := NewNominatim(server.URL + "/")
+	got, err := n.Geocode(Address{Street: "Rua das Flores", Number: "10", City: "São Carlos", State: "SP", CEP: "13560000"})
+	if err != nil || got.Quality != QualityStreet || got.Lat != -22.01 || got.Lng != -47.89 {
+		t.Fatalf("unexpected result %+v (%v)", got, err)
+	}
+	if !strings.Contains(query, "postalcode=13560-000") || !strings.Contains(query, "street=10+Rua+das+Flores") {
+		t.Errorf("unexpected query %s", query)
+	}
+
+	if _, err := n.Geocode(Address{City: "Nowhere"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// failingGeocoder simulates an unavailable geocoding server
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(Address) (*Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestApply(t *testing.T) {
+	g := loadGazetteer(t)
+	user := models.User{StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP"}
+
+	if err := Apply(g, &user); err != nil || user.Latitude == nil || user.GeocodeQuality != QualityHouse || user.GeocodedAt == nil {
+		t.Fatalf("expected the user to be geocoded, got %+v (%v)", user, err)
+	}
+
+	if err := Apply(failingGeocoder{}, &user); err == nil || user.Latitude != nil || user.GeocodeQuality != "" || user.GeocodedAt != nil {
+		t.Errorf("expected the coordinates to be cleared on errors, got %+v (%v)", user, err)
+	}
+
+	user.City = "Ibaté"
+	if err := Apply(g, &user); err != nil || user.Latitude != nil || user.GeocodeQuality != QualityFailed || user.GeocodedAt == nil {
+		t.Errorf("expected the user to be marked as failed, got %+v (%v)", user, err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	now := time.Now()
+	lat, lng := -22.0, -47.0
+	seed := []models.User{
+		{CPF: "1", StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP"},
+		{CPF: "2", StreetName: "Rua Nova", StreetNumber: "10", City: "Ibaté", State: "SP"},
+		// Already geocoded, only picked up with all
+		{CPF: "3", StreetName: "Avenida São Carlos", StreetNumber: "1000", City: "São Carlos", State: "SP",
+			Latitude: &lat, Longitude: &lng, GeocodeQuality: QualityCity, GeocodedAt: &now},
+	}
+	for i := range seed {
+		if err := users.Create(&seed[i]); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+	}
+	g := loadGazetteer(t)
+
+	report, err := Batch(users, g, false, true)
+	if err != nil || report.Total != 2 || report.Geocoded != 1 || report.ByQuality[QualityFailed] != 1 {
+		t.Fatalf("unexpected dry run report %+v (%v)", report, err)
+	}
+	if user, _ := users.Get(seed[0].ID); user.GeocodedAt != nil {
+		t.Errorf("dry run saved the user %+v", user)
+	}
+
+	if report, err = Batch(users, g, false, false); err != nil || report.Geocoded != 1 {
+		t.Fatalf("unexpected report %+v (%v)", report, err)
+	}
+	if user, _ := users.Get(seed[0].ID); user.GeocodeQuality != QualityHouse {
+		t.Errorf("expected the user to be saved, got %+v", user)
+	}
+
+	if report, err = Batch(users, g, true, false); err != nil || report.Total != 3 || report.ByQuality[QualityHouse] != 2 {
+		t.Errorf("unexpected report with all %+v (%v)", report, err)
+	}
+}
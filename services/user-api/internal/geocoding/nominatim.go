@@ -0,0 +1,88 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nominatim geocodes through the search API of a Nominatim server. It is
+// meant for a server run next to the services; the public one does not allow
+// batch use.
+type Nominatim struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewNominatim(baseURL string) *Nominatim {
+	return &Nominatim{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	AddressType string `json:"addresstype"`
+}
+
+// nominatimQuality maps the Nominatim address types to our qualities
+var nominatimQuality = map[string]string{
+	"house":         QualityHouse,
+	"building":      QualityHouse,
+	"road":          QualityStreet,
+	"postcode":      QualityCEP,
+	"suburb":        QualityNeighborhood,
+	"neighbourhood": QualityNeighborhood,
+	"quarter":       QualityNeighborhood,
+	"city":          QualityCity,
+	"town":          QualityCity,
+	"village":       QualityCity,
+	"municipality":  QualityCity,
+}
+
+func (n *Nominatim) Geocode(address Address) (*Result, error) {
+	query := url.Values{}
+	query.Set("format", "jsonv2")
+	query.Set("limit", "1")
+	query.Set("countrycodes", "br")
+	query.Set("street", strings.TrimSpace(address.Number+" "+address.Street))
+	query.Set("city", address.City)
+	query.Set("state", address.State)
+	if cep := onlyDigits(address.CEP); len(cep) == 8 {
+		query.Set("postalcode", cep[:5]+"-"+cep[5:])
+	}
+
+	resp, err := n.client.Get(n.baseURL + "/search?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error making request to nominatim: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim returned status %d", resp.StatusCode)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return nil, fmt.Errorf("error decoding nominatim response: %v", err)
+	}
+	if len(places) == 0 {
+		return nil, ErrNotFound
+	}
+
+	lat, errLat := strconv.ParseFloat(places[0].Lat, 64)
+	lng, errLng := strconv.ParseFloat(places[0].Lon, 64)
+	if errLat != nil || errLng != nil {
+		return nil, fmt.Errorf("nominatim returned invalid coordinates %q, %q", places[0].Lat, places[0].Lon)
+	}
+	quality, ok := nominatimQuality[places[0].AddressType]
+	if !ok {
+		quality = QualityCity
+	}
+	return &Result{Lat: lat, Lng: lng, Quality: quality}, nil
+}
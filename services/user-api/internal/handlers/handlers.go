@@ -2,44 +2,240 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"shared/middleware"
+	"shared/openapi"
 	"strconv"
 	"strings"
-	"user-api/internal/clients"
-	"user-api/internal/database"
+	"user-api/internal/assignment"
+	"user-api/internal/geocoding"
+	"user-api/internal/households"
 	"user-api/internal/models"
+	"user-api/internal/repository"
 )
 
-func HandleUsers(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		createUser(w, r)
-	case http.MethodGet:
-		path := strings.TrimPrefix(r.URL.Path, "/users/")
+// TeamLookup finds the healthcare team responsible for an address. It is
+// implemented by clients.AddressClient. userID identifies who the search is
+// for, so that failed searches can be traced back to the user.
+type TeamLookup interface {
+	GetTeamInfo(userID uint, streetName, number, city, state string) (*models.TeamInfo, error)
+}
 
-		if path == "" {
-			getAllUsers(w, r)
-			return
-		}
+// Handler holds the HTTP handlers and their dependencies
+type Handler struct {
+	users      repository.UserRepository
+	households repository.HouseholdRepository
+	teams      TeamLookup
+	geocoder   geocoding.Geocoder
+	// serviceToken lets the other services list every user, see callerScope
+	serviceToken string
+}
 
-		if strings.HasPrefix(path, "cpf/") {
-			getUserByCPF(w, r)
-		} else {
-			getUser(w, r)
-		}
-	case http.MethodPut:
-		updateUser(w, r)
-	case http.MethodDelete:
-		deleteUser(w, r)
-	default:
-		log.Printf("Method not allowed: %s", r.Method)
-		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+// NewHandler creates the handler. geocoder may be nil, in which case user
+// addresses are stored without coordinates. Without a serviceToken the user
+// listings only answer requests with a caller scope.
+func NewHandler(users repository.UserRepository, households repository.HouseholdRepository, teams TeamLookup, geocoder geocoding.Geocoder, serviceToken string) *Handler {
+	return &Handler{
+		users:        users,
+		households:   households,
+		teams:        teams,
+		geocoder:     geocoder,
+		serviceToken: serviceToken,
 	}
 }
 
-func createUser(w http.ResponseWriter, r *http.Request) {
+// Routes registers the user endpoints, documents them in the OpenAPI
+// document and wraps them in the common middleware chain
+func (h *Handler) Routes(allowedOrigins string) http.Handler {
+	mux := http.NewServeMux()
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "User API",
+		Version:     "1.0.0",
+		Description: "Cadastro dos usuários atendidos e da equipe responsável pelo seu endereço",
+	})
+	api := openapi.NewRouter(mux, doc)
+
+	id := openapi.Param{Name: "id", In: "path", Type: "integer", Description: "User ID"}
+	deleted := openapi.Param{Name: "deleted", Type: "boolean", Description: "List only deleted users"}
+
+	api.Handle("GET /health", h.health, openapi.Operation{
+		Summary:  "Service health",
+		Tag:      "health",
+		Response: models.HealthStatus{},
+	})
+	filters := []openapi.Param{
+		deleted,
+		{Name: "team_id", Type: "integer", Description: "Only the users of this team"},
+		{Name: "ubs_id", Type: "integer", Description: "Only the users of the teams of this UBS"},
+		{Name: "neighborhood", Description: "Only the users of this neighborhood, case insensitive"},
+		{Name: "min_age", Type: "integer", Description: "Minimum age in years"},
+		{Name: "max_age", Type: "integer", Description: "Maximum age in years"},
+		{Name: "complete", Type: "boolean", Description: "Only users with (true) or missing (false) phone, team and coordinates"},
+		{Name: "created_from", Description: "Registered on or after this day, YYYY-MM-DD"},
+		{Name: "created_to", Description: "Registered on or before this day, YYYY-MM-DD"},
+		{Name: "sort", Description: "id (default), name, date_of_birth, neighborhood or created_at, with - for descending"},
+	}
+	api.Handle("GET /users", h.getAllUsers, openapi.Operation{
+		Summary: "List users a page at a time, within the caller's teams",
+		Tag:     "users",
+		Params: append(filters,
+			openapi.Param{Name: "page", Type: "integer", Description: "Page number, from 1"},
+			openapi.Param{Name: "page_size", Type: "integer", Description: "Users per page (default 50, at most 500)"},
+		),
+		Response: models.UserPage{},
+	})
+	api.Handle("POST /users", h.createUser, openapi.Operation{
+		Summary:  "Create a user",
+		Tag:      "users",
+		Request:  models.CreateUserRequest{},
+		Response: models.User{},
+		Status:   http.StatusCreated,
+	})
+	// Literal routes, take precedence over /users/{id}
+	api.Handle("GET /users/export", h.exportUsers, openapi.Operation{
+		Summary: "Export the users of the listing filters as CSV",
+		Tag:     "users",
+		Params:  filters,
+	})
+	api.Handle("GET /users/unresolved", h.getUnresolvedUsers, openapi.Operation{
+		Summary: "List users whose team could not be resolved",
+		Tag:     "users",
+		Params: []openapi.Param{
+			{Name: "status", Description: "pending, not_found or failed (default: all three), or ambiguous"},
+		},
+		Response: []models.User{},
+	})
+	api.Handle("GET /users/{id}", h.getUser, openapi.Operation{
+		Summary:  "Get a user and the team responsible for their address",
+		Tag:      "users",
+		Params:   []openapi.Param{id},
+		Response: models.UserWithTeam{},
+	})
+	api.Handle("PUT /users/{id}", h.updateUser, openapi.Operation{
+		Summary:  "Update the provided user fields",
+		Tag:      "users",
+		Params:   []openapi.Param{id},
+		Request:  models.UpdateUserRequest{},
+		Response: models.User{},
+	})
+	api.Handle("DELETE /users/{id}", h.deleteUser, openapi.Operation{
+		Summary:  "Soft delete a user",
+		Tag:      "users",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+	api.Handle("POST /users/{id}/restore", h.restoreUser, openapi.Operation{
+		Summary:  "Restore a deleted user",
+		Tag:      "users",
+		Params:   []openapi.Param{id},
+		Response: models.User{},
+	})
+	api.Handle("GET /users/cpf/{cpf}", h.getUserByCPF, openapi.Operation{
+		Summary:  "Get a user by CPF",
+		Tag:      "users",
+		Response: models.UserWithTeam{},
+	})
+	api.Handle("GET /users/phone/{phone}", h.getUserByPhone, openapi.Operation{
+		Summary:  "Get a user by phone number",
+		Tag:      "users",
+		Response: models.UserWithTeam{},
+	})
+	api.Handle("PUT /users/{id}/manager", h.setManager, openapi.Operation{
+		Summary:  "Let another user's phone number answer for this user",
+		Tag:      "users",
+		Params:   []openapi.Param{id},
+		Request:  models.SetManagerRequest{},
+		Response: models.User{},
+	})
+	api.Handle("DELETE /users/{id}/manager", h.removeManager, openapi.Operation{
+		Summary:  "Stop another user's phone number from answering for this user",
+		Tag:      "users",
+		Params:   []openapi.Param{id},
+		Response: models.User{},
+	})
+	api.Handle("GET /households", h.listHouseholds, openapi.Operation{
+		Summary:  "List households",
+		Tag:      "households",
+		Response: []models.Household{},
+	})
+	api.Handle("POST /households", h.createHousehold, openapi.Operation{
+		Summary:  "Create a household at the address of its head",
+		Tag:      "households",
+		Request:  models.CreateHouseholdRequest{},
+		Response: models.Household{},
+		Status:   http.StatusCreated,
+	})
+	// Literal route, takes precedence over /households/{id}
+	api.Handle("GET /households/suggestions", h.householdSuggestions, openapi.Operation{
+		Summary:  "Suggest households from users registered at the same address",
+		Tag:      "households",
+		Response: []models.HouseholdSuggestion{},
+	})
+	api.Handle("GET /households/{id}", h.getHousehold, openapi.Operation{
+		Summary:  "Get a household and its members",
+		Tag:      "households",
+		Params:   []openapi.Param{id},
+		Response: models.Household{},
+	})
+	api.Handle("PUT /households/{id}", h.updateHousehold, openapi.Operation{
+		Summary:  "Change the head of a household",
+		Tag:      "households",
+		Params:   []openapi.Param{id},
+		Request:  models.UpdateHouseholdRequest{},
+		Response: models.Household{},
+	})
+	api.Handle("DELETE /households/{id}", h.deleteHousehold, openapi.Operation{
+		Summary:  "Dissolve a household, keeping its members",
+		Tag:      "households",
+		Params:   []openapi.Param{id},
+		Response: "",
+	})
+	api.Handle("POST /households/{id}/members", h.addHouseholdMember, openapi.Operation{
+		Summary:  "Add a member to a household or change their relationship",
+		Tag:      "households",
+		Params:   []openapi.Param{id},
+		Request:  models.HouseholdMemberInput{},
+		Response: models.Household{},
+	})
+	api.Handle("DELETE /households/{id}/members/{user_id}", h.removeHouseholdMember, openapi.Operation{
+		Summary: "Remove a member from a household",
+		Tag:     "households",
+		Params: []openapi.Param{id,
+			{Name: "user_id", In: "path", Type: "integer", Description: "User ID"},
+		},
+		Response: models.Household{},
+	})
+	api.Handle("GET /reports/micro-areas", h.microAreaReport, openapi.Operation{
+		Summary: "Count households and residents per micro-area",
+		Tag:     "reports",
+		Params: []openapi.Param{
+			{Name: "team_id", Type: "integer", Description: "Only the micro-areas of this team"},
+		},
+		Response: households.Report{},
+	})
+	api.ServeDocs()
+
+	return middleware.Chain(mux,
+		middleware.Recovery,
+		middleware.RequestID,
+		middleware.Logging,
+		middleware.CORS(allowedOrigins),
+		middleware.StripTrailingSlash,
+		middleware.JSONErrors,
+	)
+}
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.HealthStatus{Status: "ok"},
+	})
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Error decoding request body: %v", err)
@@ -68,11 +264,17 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 		State:        req.State,
 		CEP:          req.CEP,
 	}
+	h.geocode(&user)
 
 	// Save to database
-	if err := database.GetDB().Create(&user).Error; err != nil {
+	if err := h.users.Create(&user); err != nil {
 		log.Printf("Error creating user: %v", err)
-		if strings.Contains(err.Error(), "duplicate key") {
+		if errors.Is(err, repository.ErrDuplicateCPF) {
+			// The CPF may belong to a deleted user that can still be restored
+			if deleted, err := h.users.GetDeletedByCPF(req.CPF); err == nil {
+				respondWithError(w, http.StatusConflict, fmt.Sprintf("CPF belongs to deleted user %d, restore it instead", deleted.ID))
+				return
+			}
 			respondWithError(w, http.StatusConflict, "CPF already exists")
 			return
 		}
@@ -80,64 +282,82 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The team is resolved after the user has an ID, which address-api
+	// records when the search goes to its review queue
+	h.resolveTeam(&user)
+	if err := h.users.Update(&user); err != nil {
+		log.Printf("Error saving team of user %d: %v", user.ID, err)
+	}
+
 	respondWithJSON(w, http.StatusCreated, models.APIResponse{
 		Success: true,
 		Data:    user,
 	})
 }
 
-func getUser(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	id, err := strconv.Atoi(path)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
 		return
 	}
 
-	var user models.User
-	if err := database.GetDB().First(&user, id).Error; err != nil {
-		respondWithError(w, http.StatusNotFound, "User not found")
+	scope, ok := h.callerScope(w, r)
+	if !ok {
 		return
 	}
 
-	// Get team information based on address
-	teamInfo, err := lookupTeamInfo(user)
-	if err != nil {
-		log.Printf("Error looking up team info: %v", err)
-		// Still return user info even if team lookup fails
-		respondWithJSON(w, http.StatusOK, models.APIResponse{
-			Success: true,
-			Data:    user,
-		})
+	user, err := h.users.Get(id)
+	if err != nil || !scope.Allows(*user) {
+		respondWithError(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	response := models.UserWithTeam{
-		User: user,
-		Team: teamInfo,
-	}
-
-	respondWithJSON(w, http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    response,
-	})
+	h.respondWithTeam(w, user)
 }
 
-func getUserByCPF(w http.ResponseWriter, r *http.Request) {
-	cpf := strings.TrimPrefix(r.URL.Path, "/users/cpf/")
-	cpf = strings.TrimSpace(cpf)
+func (h *Handler) getUserByCPF(w http.ResponseWriter, r *http.Request) {
+	scope, ok := h.callerScope(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.users.GetByCPF(strings.TrimSpace(r.PathValue("cpf")))
+	if err != nil || !scope.Allows(*user) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	h.respondWithTeam(w, user)
+}
 
-	var user models.User
-	if err := database.GetDB().Where("cpf = ?", cpf).First(&user).Error; err != nil {
+// getUserByPhone finds who the phone number belongs to. The dependents it
+// also answers for come along, so the conversation can ask whom a message is
+// about.
+func (h *Handler) getUserByPhone(w http.ResponseWriter, r *http.Request) {
+	user, err := h.users.GetByPhone(strings.TrimSpace(r.PathValue("phone")))
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "User not found")
 		return
 	}
+	h.respondWithTeam(w, user)
+}
 
-	// Get team information based on address
-	teamInfo, err := lookupTeamInfo(user)
+// respondWithTeam answers with the user and the team stored for their
+// address. Users registered before teams were stored are resolved on their
+// first read. While the resolution is failing the bare user is returned.
+// The users whose phone number is the one of this user come as dependents.
+func (h *Handler) respondWithTeam(w http.ResponseWriter, user *models.User) {
+	if user.TeamStatus == assignment.StatusPending {
+		h.resolveTeam(user)
+		if err := h.users.Update(user); err != nil {
+			log.Printf("Error saving team of user %d: %v", user.ID, err)
+		}
+	}
+	dependents, err := h.users.ListDependents(user.ID)
 	if err != nil {
-		log.Printf("Error looking up team info: %v", err)
-		// Still return user info even if team lookup fails
+		log.Printf("Error fetching dependents of user %d: %v", user.ID, err)
+	}
+	user.Dependents = dependents
+
+	if user.TeamStatus == assignment.StatusFailed {
 		respondWithJSON(w, http.StatusOK, models.APIResponse{
 			Success: true,
 			Data:    user,
@@ -145,9 +365,9 @@ func getUserByCPF(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := models.UserWithTeam{
-		User: user,
-		Team: teamInfo,
+	response := models.UserWithTeam{User: *user}
+	if user.Team != nil {
+		response.Team = *user.Team
 	}
 
 	respondWithJSON(w, http.StatusOK, models.APIResponse{
@@ -156,11 +376,9 @@ func getUserByCPF(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func updateUser(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	id, err := strconv.Atoi(path)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
 		return
 	}
 
@@ -171,11 +389,12 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	var user models.User
-	if err := database.GetDB().First(&user, id).Error; err != nil {
+	user, err := h.users.Get(id)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "User not found")
 		return
 	}
+	before := *user
 
 	// Update only provided fields
 	if req.Name != "" {
@@ -183,6 +402,8 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.PhoneNumber != "" {
 		user.PhoneNumber = req.PhoneNumber
+		// A dependent with a phone of their own answers for themselves
+		user.ManagedByID = nil
 	}
 	if req.StreetName != "" {
 		user.StreetName = req.StreetName
@@ -205,8 +426,12 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	if req.CEP != "" {
 		user.CEP = req.CEP
 	}
+	if geocoding.AddressChanged(before, *user) {
+		h.geocode(user)
+		h.resolveTeam(user)
+	}
 
-	if err := database.GetDB().Save(&user).Error; err != nil {
+	if err := h.users.Update(user); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
 		return
 	}
@@ -217,78 +442,139 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func deleteUser(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	id, err := strconv.Atoi(path)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
 		return
 	}
 
-	var user models.User
-	if err := database.GetDB().First(&user, id).Error; err != nil {
+	if _, err := h.users.Get(id); err != nil {
 		respondWithError(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	if err := database.GetDB().Delete(&user).Error; err != nil {
+	if err := h.users.Delete(id); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete user")
 		return
 	}
 
+	// Nobody answers for the dependents anymore; they show up as users
+	// without a phone until someone else does
+	dependents, err := h.users.ListDependents(id)
+	if err != nil {
+		log.Printf("Error fetching dependents of user %d: %v", id, err)
+	}
+	for i := range dependents {
+		dependents[i].ManagedByID = nil
+		if err := h.users.Update(&dependents[i]); err != nil {
+			log.Printf("Error releasing dependent %d of user %d: %v", dependents[i].ID, id, err)
+		}
+	}
+
 	respondWithJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    "User successfully deleted",
 	})
 }
 
-// addressClient is a package-level variable that will be initialized in main
-var addressClient *clients.AddressClient
-
-// SetAddressClient initializes the address client
-func SetAddressClient(client *clients.AddressClient) {
-	addressClient = client
-}
+func (h *Handler) restoreUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
 
-// Helper function to look up team information from the address-api
-func lookupTeamInfo(user models.User) (models.TeamInfo, error) {
-	if addressClient == nil {
-		log.Printf("Address client not initialized")
-		return models.TeamInfo{}, fmt.Errorf("address client not initialized")
+	if _, err := h.users.GetDeleted(id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Deleted user not found")
+		return
 	}
 
-	log.Printf("Looking up team info for address: %s, %s, %s, %s",
-		user.StreetName, user.StreetNumber, user.City, user.State)
+	if err := h.users.Restore(id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore user")
+		return
+	}
 
-	teamInfo, err := addressClient.GetTeamInfo(
-		user.StreetName,
-		user.StreetNumber,
-		user.City,
-		user.State,
-	)
+	user, err := h.users.Get(id)
 	if err != nil {
-		log.Printf("Error getting team info: %v", err)
-		return models.TeamInfo{}, fmt.Errorf("error getting team info: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch user data")
+		return
 	}
 
-	if teamInfo == nil {
-		log.Printf("No team found for address")
-		return models.TeamInfo{}, nil
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// geocode stores the coordinates of the user address. Geocoding never blocks
+// saving the user: on errors the user is left without coordinates for the
+// geocode batch to retry.
+func (h *Handler) geocode(user *models.User) {
+	if h.geocoder == nil {
+		geocoding.Clear(user)
+		return
 	}
+	if err := geocoding.Apply(h.geocoder, user); err != nil {
+		log.Printf("Error geocoding address of user %d: %v", user.ID, err)
+	}
+}
+
+// resolveTeam stores the team responsible for the user address. Like
+// geocoding, it never blocks saving the user: when address-api fails the
+// user is marked as failed and the resolve-teams batch retries it.
+func (h *Handler) resolveTeam(user *models.User) {
+	if h.teams == nil {
+		log.Printf("Address client not initialized")
+		user.TeamID, user.Team = nil, nil
+		user.TeamStatus, user.TeamConfidence, user.TeamResolvedAt = assignment.StatusFailed, "", nil
+		return
+	}
+	if err := assignment.Resolve(h.lookupTeam, user); err != nil {
+		log.Printf("Error resolving team of user %d: %v", user.ID, err)
+	}
+}
+
+// lookupTeam searches address-api for the team of the user address
+func (h *Handler) lookupTeam(user models.User) (*models.TeamInfo, error) {
+	return h.teams.GetTeamInfo(user.ID, user.StreetName, user.StreetNumber, user.City, user.State)
+}
 
-	log.Printf("Found team: %+v", teamInfo)
-	return *teamInfo, nil
+// unresolvedStatuses maps the ?status= of the unresolved listing to the
+// stored statuses
+var unresolvedStatuses = map[string][]string{
+	"":          {assignment.StatusPending, assignment.StatusNotFound, assignment.StatusFailed},
+	"pending":   {assignment.StatusPending},
+	"not_found": {assignment.StatusNotFound},
+	"failed":    {assignment.StatusFailed},
+	"ambiguous": {assignment.StatusAmbiguous},
 }
 
-func getAllUsers(w http.ResponseWriter, _ *http.Request) {
-	var users []models.User
+// getUnresolvedUsers lists the active users without a team: never resolved,
+// with an address address-api has no team for, or whose last lookup failed.
+// ?status=ambiguous lists instead the users whose team may be the wrong one.
+// A caller restricted to some teams only sees the ambiguous users among them.
+func (h *Handler) getUnresolvedUsers(w http.ResponseWriter, r *http.Request) {
+	statuses, ok := unresolvedStatuses[r.URL.Query().Get("status")]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Invalid status. Must be 'pending', 'not_found', 'failed' or 'ambiguous'")
+		return
+	}
+	scope, ok := h.callerScope(w, r)
+	if !ok {
+		return
+	}
 
-	result := database.GetDB().Find(&users)
-	if result.Error != nil {
-		log.Printf("Error fetching users: %v", result.Error)
+	all, err := h.users.ListByTeamStatus(statuses...)
+	if err != nil {
+		log.Printf("Error fetching unresolved users: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to fetch users")
 		return
 	}
+	users := []models.User{}
+	for _, user := range all {
+		if scope.Allows(user) {
+			users = append(users, user)
+		}
+	}
 
 	respondWithJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
@@ -296,6 +582,73 @@ func getAllUsers(w http.ResponseWriter, _ *http.Request) {
 	})
 }
 
+// microAreaReport counts the households of each micro-area from the team
+// stored on the active users within the caller's scope. users and
+// unresolved always count all of them, even with ?team_id=.
+func (h *Handler) microAreaReport(w http.ResponseWriter, r *http.Request) {
+	var teamID uint64
+	if value := r.URL.Query().Get("team_id"); value != "" {
+		var err error
+		if teamID, err = strconv.ParseUint(value, 10, 0); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+			return
+		}
+	}
+
+	users, ok := h.scopedUsers(w, r)
+	if !ok {
+		return
+	}
+
+	report := households.ByMicroArea(users)
+	if teamID != 0 {
+		areas := report.MicroAreas[:0]
+		for _, area := range report.MicroAreas {
+			if area.TeamID == uint(teamID) {
+				areas = append(areas, area)
+			}
+		}
+		report.MicroAreas = areas
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// scopedUsers lists the active users within the caller's scope, answering
+// the error and returning false when they cannot be read
+func (h *Handler) scopedUsers(w http.ResponseWriter, r *http.Request) ([]models.User, bool) {
+	scope, ok := h.callerScope(w, r)
+	if !ok {
+		return nil, false
+	}
+	all, err := h.users.List(false)
+	if err != nil {
+		log.Printf("Error fetching users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch users")
+		return nil, false
+	}
+	users := []models.User{}
+	for _, user := range all {
+		if scope.Allows(user) {
+			users = append(users, user)
+		}
+	}
+	return users, true
+}
+
+// parseID reads the {id} path value, answering 400 when it is not a number
+func parseID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 0)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
 // Helper functions for response handling
 func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, models.APIResponse{
@@ -0,0 +1,534 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"user-api/internal/assignment"
+	"user-api/internal/geocoding"
+	"user-api/internal/households"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// fakeTeamLookup returns a fixed team, or err when set
+type fakeTeamLookup struct {
+	team *models.TeamInfo
+	err  error
+}
+
+func (f *fakeTeamLookup) GetTeamInfo(_ uint, _, _, _, _ string) (*models.TeamInfo, error) {
+	return f.team, f.err
+}
+
+// testResponse mirrors models.APIResponse keeping Data raw so each test can
+// decode it into the type it expects
+type testResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+func newTestUser(cpf string) models.User {
+	return models.User{
+		Name:         "Maria da Silva",
+		CPF:          cpf,
+		DateOfBirth:  time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		PhoneNumber:  "16999999999",
+		StreetName:   "Rua das Flores",
+		StreetNumber: "10",
+		Neighborhood: "Centro",
+		City:         "São Carlos",
+		State:        "SP",
+		CEP:          "13560000",
+	}
+}
+
+func newTestHandler(t *testing.T, teams TeamLookup) (*Handler, *repository.MemoryUserRepository, models.User) {
+	t.Helper()
+
+	users := repository.NewMemoryUserRepository()
+	user := newTestUser("12345678900")
+	if err := users.Create(&user); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	return NewHandler(users, repository.NewMemoryHouseholdRepository(users), teams, nil, testServiceToken), users, user
+}
+
+// testServiceToken is the service token of the test handlers. doRequest sends
+// it, as the other services do.
+const testServiceToken = "service-token"
+
+func doRequest(t *testing.T, h *Handler, method, target string, body interface{}) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		payload = []byte(b)
+	default:
+		var err error
+		if payload, err = json.Marshal(b); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+	req.Header.Set(ServiceTokenHeader, testServiceToken)
+	rec := httptest.NewRecorder()
+	h.Routes("*").ServeHTTP(rec, req)
+
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec, resp
+}
+
+func decodeData(t *testing.T, resp testResponse, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		t.Fatalf("failed to decode response data %s: %v", resp.Data, err)
+	}
+}
+
+func TestHandleUsers(t *testing.T) {
+	team := &models.TeamInfo{ID: 7, Name: "Equipe Azul", UBSName: "UBS Centro", Roster: []models.TeamMember{
+		{ProfessionalID: 3, Name: "Ana Souza", Role: "doctor", Since: "2024-02-01"},
+	}}
+
+	tests := []struct {
+		name       string
+		teams      TeamLookup
+		method     string
+		path       func(u models.User) string
+		body       interface{}
+		wantStatus int
+		check      func(t *testing.T, users *repository.MemoryUserRepository, resp testResponse)
+	}{
+		{
+			name:       "create user",
+			method:     http.MethodPost,
+			path:       func(models.User) string { return "/users/" },
+			body:       newTestUser("98765432100"),
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, users *repository.MemoryUserRepository, _ testResponse) {
+				if _, err := users.GetByCPF("98765432100"); err != nil {
+					t.Errorf("expected user to be stored: %v", err)
+				}
+			},
+		},
+		{
+			name:       "create requires name and CPF",
+			method:     http.MethodPost,
+			path:       func(models.User) string { return "/users/" },
+			body:       models.CreateUserRequest{Name: "Sem CPF"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "create rejects invalid payload",
+			method:     http.MethodPost,
+			path:       func(models.User) string { return "/users/" },
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "create rejects duplicate CPF",
+			method:     http.MethodPost,
+			path:       func(models.User) string { return "/users/" },
+			body:       newTestUser("12345678900"),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "list users",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/users/" },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *repository.MemoryUserRepository, resp testResponse) {
+				var page models.UserPage
+				decodeData(t, resp, &page)
+				if len(page.Users) != 1 || page.Total != 1 {
+					t.Errorf("expected 1 user, got %+v", page)
+				}
+			},
+		},
+		{
+			name:       "get user with team",
+			teams:      &fakeTeamLookup{team: team},
+			method:     http.MethodGet,
+			path:       func(u models.User) string { return fmt.Sprintf("/users/%d", u.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *repository.MemoryUserRepository, resp testResponse) {
+				var result models.UserWithTeam
+				decodeData(t, resp, &result)
+				if !reflect.DeepEqual(result.Team, *team) || result.User.CPF != "12345678900" {
+					t.Errorf("unexpected result: %+v", result)
+				}
+			},
+		},
+		{
+			name:       "get user falls back when team lookup fails",
+			teams:      &fakeTeamLookup{err: errors.New("address-api down")},
+			method:     http.MethodGet,
+			path:       func(u models.User) string { return fmt.Sprintf("/users/%d", u.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *repository.MemoryUserRepository, resp testResponse) {
+				var user models.User
+				decodeData(t, resp, &user)
+				if user.CPF != "12345678900" {
+					t.Errorf("expected bare user, got %s", resp.Data)
+				}
+			},
+		},
+		{
+			name:       "get rejects invalid ID",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/users/abc" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "get returns 404 for unknown user",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/users/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "get user by CPF",
+			teams:      &fakeTeamLookup{team: team},
+			method:     http.MethodGet,
+			path:       func(u models.User) string { return "/users/cpf/" + u.CPF },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "get by CPF returns 404 for unknown CPF",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/users/cpf/00000000000" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "get user by phone",
+			teams:      &fakeTeamLookup{team: team},
+			method:     http.MethodGet,
+			path:       func(u models.User) string { return "/users/phone/" + u.PhoneNumber },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, _ *repository.MemoryUserRepository, resp testResponse) {
+				var got models.UserWithTeam
+				decodeData(t, resp, &got)
+				if got.User.PhoneNumber != "16999999999" || got.Team.Name != team.Name {
+					t.Errorf("unexpected user with team %+v", got)
+				}
+			},
+		},
+		{
+			name:       "get by phone returns 404 for unknown phone",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/users/phone/0000" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "create rejects wrong field types",
+			method:     http.MethodPost,
+			path:       func(models.User) string { return "/users" },
+			body:       `{"name":"Ana","cpf":12345678900,"date_of_birth":"01/01/1990"}`,
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, _ *repository.MemoryUserRepository, resp testResponse) {
+				for _, want := range []string{"cpf must be a string", "date_of_birth must be an RFC 3339 date-time", "street_name is required"} {
+					if !strings.Contains(resp.Error, want) {
+						t.Errorf("expected %q in error %q", want, resp.Error)
+					}
+				}
+			},
+		},
+		{
+			name:       "health",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/health" },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "update only provided fields",
+			method:     http.MethodPut,
+			path:       func(u models.User) string { return fmt.Sprintf("/users/%d", u.ID) },
+			body:       models.UpdateUserRequest{PhoneNumber: "16988888888"},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, users *repository.MemoryUserRepository, _ testResponse) {
+				user, _ := users.GetByCPF("12345678900")
+				if user.PhoneNumber != "16988888888" || user.Name != "Maria da Silva" {
+					t.Errorf("unexpected user after update: %+v", user)
+				}
+			},
+		},
+		{
+			name:       "update returns 404 for unknown user",
+			method:     http.MethodPut,
+			path:       func(models.User) string { return "/users/999" },
+			body:       models.UpdateUserRequest{Name: "X"},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "delete user",
+			method:     http.MethodDelete,
+			path:       func(u models.User) string { return fmt.Sprintf("/users/%d", u.ID) },
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, users *repository.MemoryUserRepository, _ testResponse) {
+				if _, err := users.GetDeletedByCPF("12345678900"); err != nil {
+					t.Errorf("expected user to be soft deleted: %v", err)
+				}
+			},
+		},
+		{
+			name:       "delete returns 404 for unknown user",
+			method:     http.MethodDelete,
+			path:       func(models.User) string { return "/users/999" },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "restore returns 404 for active user",
+			method:     http.MethodPost,
+			path:       func(u models.User) string { return fmt.Sprintf("/users/%d/restore", u.ID) },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodPatch,
+			path:       func(models.User) string { return "/users/" },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "restore requires POST",
+			method:     http.MethodGet,
+			path:       func(u models.User) string { return fmt.Sprintf("/users/%d/restore", u.ID) },
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			path:       func(models.User) string { return "/people" },
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, users, user := newTestHandler(t, tt.teams)
+			rec, resp := doRequest(t, h, tt.method, tt.path(user), tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if resp.Success != (tt.wantStatus < 300) {
+				t.Errorf("unexpected success flag %t", resp.Success)
+			}
+			if tt.check != nil {
+				tt.check(t, users, resp)
+			}
+		})
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	h, users, user := newTestHandler(t, nil)
+
+	rec, _ := doRequest(t, h, http.MethodDelete, fmt.Sprintf("/users/%d", user.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete failed: %s", rec.Body.String())
+	}
+
+	// A deleted user's CPF cannot be registered again, it must be restored
+	rec, resp := doRequest(t, h, http.MethodPost, "/users/", newTestUser(user.CPF))
+	if rec.Code != http.StatusConflict || resp.Error != fmt.Sprintf("CPF belongs to deleted user %d, restore it instead", user.ID) {
+		t.Fatalf("unexpected response for deleted CPF: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec, resp = doRequest(t, h, http.MethodGet, "/users/?deleted=true", nil)
+	var deleted models.UserPage
+	decodeData(t, resp, &deleted)
+	if rec.Code != http.StatusOK || len(deleted.Users) != 1 {
+		t.Fatalf("expected 1 deleted user, got %d", len(deleted.Users))
+	}
+
+	rec, _ = doRequest(t, h, http.MethodPost, fmt.Sprintf("/users/%d/restore", user.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore failed: %s", rec.Body.String())
+	}
+	if _, err := users.Get(user.ID); err != nil {
+		t.Errorf("expected user to be active again: %v", err)
+	}
+}
+
+// fakeGeocoder places every address at the same point and counts the calls
+type fakeGeocoder struct {
+	calls int
+}
+
+func (f *fakeGeocoder) Geocode(geocoding.Address) (*geocoding.Result, error) {
+	f.calls++
+	return &geocoding.Result{Lat: -22.01, Lng: -47.89, Quality: geocoding.QualityHouse}, nil
+}
+
+func TestGeocodeUserAddress(t *testing.T) {
+	h, users, user := newTestHandler(t, nil)
+	geocoder := &fakeGeocoder{}
+	h.geocoder = geocoder
+
+	rec, resp := doRequest(t, h, http.MethodPost, "/users/", newTestUser("98765432100"))
+	var created models.User
+	decodeData(t, resp, &created)
+	if rec.Code != http.StatusCreated || created.Latitude == nil || *created.Latitude != -22.01 || created.GeocodeQuality != geocoding.QualityHouse {
+		t.Fatalf("expected the new user to be geocoded, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	path := fmt.Sprintf("/users/%d", user.ID)
+	doRequest(t, h, http.MethodPut, path, models.UpdateUserRequest{Name: "Maria Souza"})
+	if geocoder.calls != 1 {
+		t.Errorf("expected no geocoding when the address is unchanged, got %d calls", geocoder.calls)
+	}
+
+	doRequest(t, h, http.MethodPut, path, models.UpdateUserRequest{StreetNumber: "20"})
+	if stored, _ := users.Get(user.ID); geocoder.calls != 2 || stored.GeocodedAt == nil {
+		t.Errorf("expected the new address to be geocoded, got %d calls and %+v", geocoder.calls, stored)
+	}
+}
+
+// countingTeamLookup answers from a map of street to team and counts the calls
+type countingTeamLookup struct {
+	teams map[string]*models.TeamInfo
+	err   error
+	calls int
+}
+
+func (c *countingTeamLookup) GetTeamInfo(_ uint, street, _, _, _ string) (*models.TeamInfo, error) {
+	c.calls++
+	return c.teams[street], c.err
+}
+
+func TestStoredUserTeam(t *testing.T) {
+	azul := &models.TeamInfo{ID: 1, Name: "Equipe Azul", UBSName: "UBS Centro", Status: "matched", Confidence: "high"}
+	teams := &countingTeamLookup{teams: map[string]*models.TeamInfo{"Rua das Flores": azul}}
+	h, users, seeded := newTestHandler(t, teams)
+
+	rec, resp := doRequest(t, h, http.MethodPost, "/users/", newTestUser("98765432100"))
+	var created models.User
+	decodeData(t, resp, &created)
+	if rec.Code != http.StatusCreated || created.TeamID == nil || *created.TeamID != 1 || created.TeamStatus != assignment.StatusResolved || created.TeamConfidence != "high" || created.TeamResolvedAt == nil {
+		t.Fatalf("expected the team resolved on create, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Reads use the stored team, even with address-api down
+	teams.err = errors.New("connection refused")
+	path := fmt.Sprintf("/users/%d", created.ID)
+	_, resp = doRequest(t, h, http.MethodGet, path, nil)
+	var got models.UserWithTeam
+	decodeData(t, resp, &got)
+	if teams.calls != 1 || got.Team.Name != "Equipe Azul" {
+		t.Errorf("expected the stored team without a new lookup, got %d calls and %+v", teams.calls, got.Team)
+	}
+
+	// A user registered before teams were stored is resolved on the first
+	// read; its failure leaves the user in the unresolved list
+	_, resp = doRequest(t, h, http.MethodGet, fmt.Sprintf("/users/%d", seeded.ID), nil)
+	if stored, _ := users.Get(seeded.ID); teams.calls != 2 || stored.TeamStatus != assignment.StatusFailed {
+		t.Errorf("expected a failed lookup stored on the first read, got %d calls and %q", teams.calls, stored.TeamStatus)
+	}
+
+	teams.err = nil
+	doRequest(t, h, http.MethodPut, path, models.UpdateUserRequest{StreetName: "Rua Sem Equipe"})
+	if stored, _ := users.Get(created.ID); teams.calls != 3 || stored.TeamID != nil || stored.TeamStatus != assignment.StatusNotFound {
+		t.Errorf("expected the team resolved again after the address change, got %+v", stored)
+	}
+
+	for query, want := range map[string]int{"": 2, "?status=failed": 1, "?status=not_found": 1, "?status=ambiguous": 0} {
+		rec, resp := doRequest(t, h, http.MethodGet, "/users/unresolved"+query, nil)
+		var list []models.User
+		decodeData(t, resp, &list)
+		if rec.Code != http.StatusOK || len(list) != want {
+			t.Errorf("expected %d unresolved users for %q, got %d (%d)", want, query, len(list), rec.Code)
+		}
+	}
+	if rec, _ := doRequest(t, h, http.MethodGet, "/users/unresolved?status=resolved", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid status, got %d", rec.Code)
+	}
+}
+
+func TestMicroAreaReport(t *testing.T) {
+	area := &models.MicroAreaInfo{ID: 4, Code: "01", Agent: &models.TeamMember{ProfessionalID: 7, Name: "Bia Lima", Role: "acs"}}
+	teams := &fakeTeamLookup{team: &models.TeamInfo{ID: 1, Name: "Equipe Azul", UBSName: "UBS Centro", MicroArea: area}}
+	h, users, seeded := newTestHandler(t, teams)
+	// The seeded user is resolved on their first read
+	doRequest(t, h, http.MethodGet, fmt.Sprintf("/users/%d", seeded.ID), nil)
+	neighbor := newTestUser("98765432100")
+	neighbor.StreetName = "R. das Flores" // same dwelling as the seeded user
+	doRequest(t, h, http.MethodPost, "/users/", neighbor)
+	unresolved := newTestUser("11122233344")
+	if err := users.Create(&unresolved); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+
+	// The report reads the stored teams, even with address-api down
+	teams.err = errors.New("connection refused")
+	rec, resp := doRequest(t, h, http.MethodGet, "/reports/micro-areas", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, resp.Error)
+	}
+	var report households.Report
+	decodeData(t, resp, &report)
+	if report.Users != 3 || report.Unresolved != 1 || len(report.MicroAreas) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if row := report.MicroAreas[0]; row.Households != 1 || row.Residents != 2 || !reflect.DeepEqual(row.MicroArea, area) {
+		t.Errorf("expected 1 household with 2 residents in micro-area 01, got %+v", row)
+	}
+
+	_, resp = doRequest(t, h, http.MethodGet, "/reports/micro-areas?team_id=2", nil)
+	decodeData(t, resp, &report)
+	if len(report.MicroAreas) != 0 {
+		t.Errorf("expected no micro-areas of team 2, got %+v", report.MicroAreas)
+	}
+
+	if rec, _ := doRequest(t, h, http.MethodGet, "/reports/micro-areas?team_id=x", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid team ID, got %d", rec.Code)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	h, _, _ := newTestHandler(t, nil)
+
+	rec := httptest.NewRecorder()
+	h.Routes("*").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	for path, method := range map[string]string{
+		"/users":               "post",
+		"/users/{id}":          "put",
+		"/users/{id}/restore":  "post",
+		"/users/phone/{phone}": "get",
+		"/health":              "get",
+	} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("expected %s %s to be documented", method, path)
+		}
+	}
+	for _, name := range []string{"CreateUserRequest", "UpdateUserRequest", "UserWithTeam"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("expected schema %s", name)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"user-api/internal/households"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// listHouseholds lists the households with a member within the caller's
+// scope
+func (h *Handler) listHouseholds(w http.ResponseWriter, r *http.Request) {
+	users, ok := h.scopedUsers(w, r)
+	if !ok {
+		return
+	}
+	all, err := h.households.List()
+	if err != nil {
+		log.Printf("Error fetching households: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch households")
+		return
+	}
+
+	visible := map[uint]bool{}
+	for _, user := range users {
+		if user.HouseholdID != nil {
+			visible[*user.HouseholdID] = true
+		}
+	}
+	list := []models.Household{}
+	for _, household := range all {
+		if visible[household.ID] {
+			list = append(list, household)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    list,
+	})
+}
+
+// createHousehold creates the household at the address of the head, with
+// the head and the other members. Users already in a household must leave
+// it first.
+func (h *Handler) createHousehold(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateHouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	head, ok := h.householdCandidate(w, req.HeadUserID, 0)
+	if !ok {
+		return
+	}
+	seen := map[uint]bool{head.ID: true}
+	for _, input := range req.Members {
+		if seen[input.UserID] {
+			respondWithError(w, http.StatusBadRequest, "Each user can be listed only once")
+			return
+		}
+		seen[input.UserID] = true
+		if !households.Relationships[input.Relationship] {
+			respondWithError(w, http.StatusBadRequest, households.ErrInvalidRelationship.Error())
+			return
+		}
+		if _, ok := h.householdCandidate(w, input.UserID, 0); !ok {
+			return
+		}
+	}
+
+	// The members are checked again when stored, in the same transaction as
+	// the household, since one of them may have joined another meanwhile
+	household := households.New(*head)
+	inputs := append([]models.HouseholdMemberInput{{UserID: head.ID, Relationship: households.RelationshipHead}}, req.Members...)
+	if err := h.households.CreateWithMembers(&household, inputs); err != nil {
+		if errors.Is(err, repository.ErrInHousehold) {
+			respondWithError(w, http.StatusConflict, "A member already belongs to another household")
+			return
+		}
+		log.Printf("Error creating household: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create household")
+		return
+	}
+
+	h.respondWithHousehold(w, http.StatusCreated, &household)
+}
+
+// householdCandidate loads an active user who can join the household,
+// answering 404 or 409 and returning false otherwise. Members of the
+// household itself can, so their relationship can be changed.
+func (h *Handler) householdCandidate(w http.ResponseWriter, userID, householdID uint) (*models.User, bool) {
+	user, err := h.users.Get(userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User "+strconv.FormatUint(uint64(userID), 10)+" not found")
+		return nil, false
+	}
+	if user.HouseholdID != nil && *user.HouseholdID != householdID {
+		respondWithError(w, http.StatusConflict, "User "+strconv.FormatUint(uint64(userID), 10)+" already belongs to another household")
+		return nil, false
+	}
+	return user, true
+}
+
+// getHousehold answers with the household and its members, when one of them
+// is within the caller's scope
+func (h *Handler) getHousehold(w http.ResponseWriter, r *http.Request) {
+	scope, ok := h.callerScope(w, r)
+	if !ok {
+		return
+	}
+	household, ok := h.findHousehold(w, r)
+	if !ok {
+		return
+	}
+	members, err := h.users.ListByHousehold(household.ID)
+	if err != nil {
+		log.Printf("Error fetching members of household %d: %v", household.ID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch household members")
+		return
+	}
+	visible := false
+	for _, member := range members {
+		visible = visible || scope.Allows(member)
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "Household not found")
+		return
+	}
+	h.respondWithHousehold(w, http.StatusOK, household)
+}
+
+// updateHousehold hands the household over to another member, as when the
+// head dies or moves out. The previous head, if still a member, takes the
+// relationship given to the new one; the relationships of the others are
+// kept and can be corrected one by one.
+func (h *Handler) updateHousehold(w http.ResponseWriter, r *http.Request) {
+	household, ok := h.findHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateHouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if !households.Relationships[req.Relationship] {
+		respondWithError(w, http.StatusBadRequest, households.ErrInvalidRelationship.Error())
+		return
+	}
+
+	head, err := h.users.Get(req.HeadUserID)
+	if err != nil || head.HouseholdID == nil || *head.HouseholdID != household.ID {
+		respondWithError(w, http.StatusBadRequest, "The new head must be a member of the household")
+		return
+	}
+
+	if previous := household.HeadUserID; previous != nil && *previous != head.ID {
+		if user, err := h.users.Get(*previous); err == nil && user.HouseholdID != nil && *user.HouseholdID == household.ID {
+			user.Relationship = req.Relationship
+			if err := h.users.Update(user); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to update household")
+				return
+			}
+		}
+	}
+	head.Relationship = households.RelationshipHead
+	if err := h.users.Update(head); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update household")
+		return
+	}
+	household.HeadUserID = &head.ID
+	if err := h.households.Update(household); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update household")
+		return
+	}
+
+	h.respondWithHousehold(w, http.StatusOK, household)
+}
+
+// deleteHousehold dissolves the household; its members stay registered
+func (h *Handler) deleteHousehold(w http.ResponseWriter, r *http.Request) {
+	household, ok := h.findHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.users.ListByHousehold(household.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch household members")
+		return
+	}
+	for i := range members {
+		households.Leave(&members[i])
+		if err := h.users.Update(&members[i]); err != nil {
+			log.Printf("Error removing user %d from household %d: %v", members[i].ID, household.ID, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to delete household")
+			return
+		}
+	}
+	if err := h.households.Delete(household.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete household")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Household successfully deleted",
+	})
+}
+
+// addHouseholdMember adds a user to the household, or changes the
+// relationship of a member
+func (h *Handler) addHouseholdMember(w http.ResponseWriter, r *http.Request) {
+	household, ok := h.findHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HouseholdMemberInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if !households.Relationships[req.Relationship] {
+		respondWithError(w, http.StatusBadRequest, households.ErrInvalidRelationship.Error())
+		return
+	}
+	if household.HeadUserID != nil && *household.HeadUserID == req.UserID {
+		respondWithError(w, http.StatusConflict, "The head of the household cannot take another relationship, change the head first")
+		return
+	}
+
+	user, ok := h.householdCandidate(w, req.UserID, household.ID)
+	if !ok {
+		return
+	}
+	households.Join(user, *household, req.Relationship)
+	if err := h.users.Update(user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to add household member")
+		return
+	}
+
+	h.respondWithHousehold(w, http.StatusOK, household)
+}
+
+func (h *Handler) removeHouseholdMember(w http.ResponseWriter, r *http.Request) {
+	household, ok := h.findHousehold(w, r)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(r.PathValue("user_id"), 10, 0)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.users.Get(uint(userID))
+	if err != nil || user.HouseholdID == nil || *user.HouseholdID != household.ID {
+		respondWithError(w, http.StatusNotFound, "User is not a member of the household")
+		return
+	}
+	if household.HeadUserID != nil && *household.HeadUserID == user.ID {
+		respondWithError(w, http.StatusConflict, "The head cannot leave the household, change the head or delete the household")
+		return
+	}
+	households.Leave(user)
+	if err := h.users.Update(user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to remove household member")
+		return
+	}
+
+	h.respondWithHousehold(w, http.StatusOK, household)
+}
+
+// householdSuggestions lists the users registered at the same address and
+// not grouped yet, to be confirmed by the team with POST /households or by
+// adding them to the household already at the address. Only users within
+// the caller's scope are suggested.
+func (h *Handler) householdSuggestions(w http.ResponseWriter, r *http.Request) {
+	users, ok := h.scopedUsers(w, r)
+	if !ok {
+		return
+	}
+	existing, err := h.households.List()
+	if err != nil {
+		log.Printf("Error fetching households: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch households")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    households.Suggest(users, existing),
+	})
+}
+
+func (h *Handler) findHousehold(w http.ResponseWriter, r *http.Request) (*models.Household, bool) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return nil, false
+	}
+	household, err := h.households.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Household not found")
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to fetch household")
+		}
+		return nil, false
+	}
+	return household, true
+}
+
+// respondWithHousehold answers with the household and its current members
+func (h *Handler) respondWithHousehold(w http.ResponseWriter, status int, household *models.Household) {
+	members, err := h.users.ListByHousehold(household.ID)
+	if err != nil {
+		log.Printf("Error fetching members of household %d: %v", household.ID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch household members")
+		return
+	}
+	household.Members = members
+
+	respondWithJSON(w, status, models.APIResponse{
+		Success: true,
+		Data:    household,
+	})
+}
+
+// setManager makes the phone number of another user answer for this one,
+// a child or an elderly parent without a phone of their own
+func (h *Handler) setManager(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetManagerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	user, err := h.users.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	manager, err := h.users.Get(req.ManagerID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Manager not found")
+		return
+	}
+	dependents, err := h.users.ListDependents(user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch dependents")
+		return
+	}
+
+	if err := households.CheckManager(*user, *manager, len(dependents) > 0); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, households.ErrSelfManaged) {
+			status = http.StatusBadRequest
+		}
+		respondWithError(w, status, err.Error())
+		return
+	}
+	households.Manage(user, *manager)
+	if err := h.users.Update(user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// removeManager stops another user's phone number from answering for this
+// one, who is then left without a phone number until one is registered
+func (h *Handler) removeManager(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.Get(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	user.ManagedByID = nil
+	if err := h.users.Update(user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    user,
+	})
+}
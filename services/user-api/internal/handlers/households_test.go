@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+func TestHouseholds(t *testing.T) {
+	h, users, mother := newTestHandler(t, &fakeTeamLookup{})
+	seed := func(cpf, name, phone, number string) models.User {
+		t.Helper()
+		user := newTestUser(cpf)
+		user.Name, user.PhoneNumber, user.StreetNumber = name, phone, number
+		if err := users.Create(&user); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+		return user
+	}
+	// The son was registered with his mother's phone
+	son := seed("2", "Pedro da Silva", mother.PhoneNumber, mother.StreetNumber)
+	grandfather := seed("3", "José da Silva", "", mother.StreetNumber)
+	neighbour := seed("4", "Ana Lima", "16988880000", "12")
+
+	household := func(resp testResponse) models.Household {
+		t.Helper()
+		var household models.Household
+		decodeData(t, resp, &household)
+		return household
+	}
+
+	rec, resp := doRequest(t, h, http.MethodGet, "/households/suggestions", nil)
+	var suggestions []models.HouseholdSuggestion
+	decodeData(t, resp, &suggestions)
+	if rec.Code != http.StatusOK || len(suggestions) != 1 || len(suggestions[0].Users) != 3 || suggestions[0].HeadUserID != mother.ID {
+		t.Fatalf("expected the three Silvas headed by the mother, got %d %+v", rec.Code, suggestions)
+	}
+
+	rec, resp = doRequest(t, h, http.MethodPost, "/households", models.CreateHouseholdRequest{
+		HeadUserID: mother.ID,
+		Members:    []models.HouseholdMemberInput{{UserID: son.ID, Relationship: "child"}},
+	})
+	created := household(resp)
+	if rec.Code != http.StatusCreated || *created.HeadUserID != mother.ID || created.StreetName != mother.StreetName || len(created.Members) != 2 {
+		t.Fatalf("unexpected household %d %+v", rec.Code, created)
+	}
+	path := fmt.Sprintf("/households/%d", created.ID)
+
+	// The grandfather is still suggested, now to join the household
+	_, resp = doRequest(t, h, http.MethodGet, "/households/suggestions", nil)
+	decodeData(t, resp, &suggestions)
+	if len(suggestions) != 1 || suggestions[0].HouseholdID == nil || *suggestions[0].HouseholdID != created.ID {
+		t.Errorf("expected the grandfather suggested to join, got %+v", suggestions)
+	}
+
+	rec, resp = doRequest(t, h, http.MethodPost, path+"/members", models.HouseholdMemberInput{UserID: grandfather.ID, Relationship: "parent"})
+	if got := household(resp); rec.Code != http.StatusOK || len(got.Members) != 3 {
+		t.Fatalf("expected 3 members, got %d %+v", rec.Code, got)
+	}
+
+	t.Run("rejects invalid changes", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			method string
+			path   string
+			body   interface{}
+			want   int
+		}{
+			{"member of another household", http.MethodPost, "/households", models.CreateHouseholdRequest{HeadUserID: neighbour.ID, Members: []models.HouseholdMemberInput{{UserID: son.ID, Relationship: "child"}}}, http.StatusConflict},
+			{"unknown relationship", http.MethodPost, path + "/members", models.HouseholdMemberInput{UserID: neighbour.ID, Relationship: "cousin"}, http.StatusBadRequest},
+			{"unknown user", http.MethodPost, path + "/members", models.HouseholdMemberInput{UserID: 99, Relationship: "child"}, http.StatusNotFound},
+			{"head as member", http.MethodPost, path + "/members", models.HouseholdMemberInput{UserID: mother.ID, Relationship: "child"}, http.StatusConflict},
+			{"head leaving", http.MethodDelete, fmt.Sprintf("%s/members/%d", path, mother.ID), nil, http.StatusConflict},
+			{"non member leaving", http.MethodDelete, fmt.Sprintf("%s/members/%d", path, neighbour.ID), nil, http.StatusNotFound},
+			{"head from outside", http.MethodPut, path, models.UpdateHouseholdRequest{HeadUserID: neighbour.ID, Relationship: "child"}, http.StatusBadRequest},
+			{"unknown household", http.MethodGet, "/households/99", nil, http.StatusNotFound},
+		} {
+			if rec, _ := doRequest(t, h, tt.method, tt.path, tt.body); rec.Code != tt.want {
+				t.Errorf("%s: expected %d, got %d (%s)", tt.name, tt.want, rec.Code, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("change head", func(t *testing.T) {
+		rec, resp := doRequest(t, h, http.MethodPut, path, models.UpdateHouseholdRequest{HeadUserID: son.ID, Relationship: "parent"})
+		if got := household(resp); rec.Code != http.StatusOK || *got.HeadUserID != son.ID {
+			t.Fatalf("unexpected household %d %+v", rec.Code, got)
+		}
+		if user, _ := users.Get(mother.ID); user.Relationship != "parent" {
+			t.Errorf("expected the mother to become parent of the new head, got %q", user.Relationship)
+		}
+		if user, _ := users.Get(son.ID); user.Relationship != "head" {
+			t.Errorf("expected the son to become head, got %q", user.Relationship)
+		}
+	})
+
+	t.Run("delete keeps the members", func(t *testing.T) {
+		if rec, _ := doRequest(t, h, http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		for _, id := range []uint{mother.ID, son.ID, grandfather.ID} {
+			if user, err := users.Get(id); err != nil || user.HouseholdID != nil || user.Relationship != "" {
+				t.Errorf("expected user %d out of the household, got %+v %v", id, user, err)
+			}
+		}
+	})
+}
+
+// staleUsers answers Get with copies read before a concurrent change
+type staleUsers struct {
+	repository.UserRepository
+	stale map[uint]models.User
+}
+
+func (r staleUsers) Get(id uint) (*models.User, error) {
+	if user, ok := r.stale[id]; ok {
+		return &user, nil
+	}
+	return r.UserRepository.Get(id)
+}
+
+func TestCreateHouseholdConflictLeavesNothing(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	homes := repository.NewMemoryHouseholdRepository(users)
+	seed := func(cpf, number string) models.User {
+		t.Helper()
+		user := newTestUser(cpf)
+		user.StreetNumber = number
+		if err := users.Create(&user); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+		return user
+	}
+	head, son, mother := seed("1", "12"), seed("2", "10"), seed("3", "10")
+
+	// The son joins his mother's household after the handler has read him
+	stale := map[uint]models.User{son.ID: son}
+	existing := models.Household{StreetName: mother.StreetName}
+	if err := homes.CreateWithMembers(&existing, []models.HouseholdMemberInput{
+		{UserID: mother.ID, Relationship: "head"},
+		{UserID: son.ID, Relationship: "child"},
+	}); err != nil {
+		t.Fatalf("failed to seed household: %v", err)
+	}
+	h := NewHandler(staleUsers{users, stale}, homes, &fakeTeamLookup{}, nil, testServiceToken)
+
+	rec, _ := doRequest(t, h, http.MethodPost, "/households", models.CreateHouseholdRequest{
+		HeadUserID: head.ID,
+		Members:    []models.HouseholdMemberInput{{UserID: son.ID, Relationship: "child"}},
+	})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	if all, _ := homes.List(); len(all) != 1 {
+		t.Errorf("expected only the existing household, got %+v", all)
+	}
+	if user, _ := users.Get(head.ID); user.HouseholdID != nil || user.Relationship != "" {
+		t.Errorf("expected the head left out of any household, got %+v", user)
+	}
+	if user, _ := users.Get(son.ID); user.HouseholdID == nil || *user.HouseholdID != existing.ID || user.Relationship != "child" {
+		t.Errorf("expected the son kept in his mother's household, got %+v", user)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	h, users, mother := newTestHandler(t, &fakeTeamLookup{team: &models.TeamInfo{ID: 7}})
+	child := newTestUser("2")
+	child.Name, child.PhoneNumber = "Pedro da Silva", mother.PhoneNumber
+	if err := users.Create(&child); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	managerPath := fmt.Sprintf("/users/%d/manager", child.ID)
+
+	rec, resp := doRequest(t, h, http.MethodPut, managerPath, models.SetManagerRequest{ManagerID: mother.ID})
+	var user models.User
+	decodeData(t, resp, &user)
+	if rec.Code != http.StatusOK || user.ManagedByID == nil || *user.ManagedByID != mother.ID || user.PhoneNumber != "" {
+		t.Fatalf("unexpected dependent %d %+v", rec.Code, user)
+	}
+
+	// The phone now finds only the mother, who answers for the child
+	rec, resp = doRequest(t, h, http.MethodGet, "/users/phone/"+mother.PhoneNumber, nil)
+	var found models.UserWithTeam
+	decodeData(t, resp, &found)
+	if rec.Code != http.StatusOK || found.User.ID != mother.ID || len(found.User.Dependents) != 1 || found.User.Dependents[0].ID != child.ID {
+		t.Fatalf("expected the mother with her dependent, got %d %+v", rec.Code, found.User)
+	}
+
+	for _, tt := range []struct {
+		name string
+		path string
+		body models.SetManagerRequest
+		want int
+	}{
+		{"self", managerPath, models.SetManagerRequest{ManagerID: child.ID}, http.StatusBadRequest},
+		{"manager is a dependent", fmt.Sprintf("/users/%d/manager", mother.ID), models.SetManagerRequest{ManagerID: child.ID}, http.StatusConflict},
+		{"unknown manager", managerPath, models.SetManagerRequest{ManagerID: 99}, http.StatusBadRequest},
+	} {
+		if rec, _ := doRequest(t, h, http.MethodPut, tt.path, tt.body); rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d (%s)", tt.name, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	t.Run("deleting the manager releases the dependents", func(t *testing.T) {
+		if rec, _ := doRequest(t, h, http.MethodDelete, fmt.Sprintf("/users/%d", mother.ID), nil); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if user, _ := users.Get(child.ID); user.ManagedByID != nil {
+			t.Errorf("expected the child without manager, got %v", *user.ManagedByID)
+		}
+	})
+
+	t.Run("a phone of their own ends the management", func(t *testing.T) {
+		managed := child
+		managed.ManagedByID = &mother.ID
+		if err := users.Update(&managed); err != nil {
+			t.Fatalf("failed to update user: %v", err)
+		}
+		if rec, _ := doRequest(t, h, http.MethodPut, fmt.Sprintf("/users/%d", child.ID), models.UpdateUserRequest{PhoneNumber: "16977770000"}); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if user, _ := users.Get(child.ID); user.ManagedByID != nil || user.PhoneNumber != "16977770000" {
+			t.Errorf("expected the child on their own, got %+v", user)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// Headers with the teams and UBS the caller works with, as comma separated
+// IDs. They are set by the proxy in front of user-api once it authenticated
+// a team coordinator, and restrict every read of users, households and
+// reports. A service sees everyone by sending instead the token configured
+// in SERVICE_TOKEN.
+const (
+	TeamScopeHeader    = "X-Scope-Team-IDs"
+	UBSScopeHeader     = "X-Scope-UBS-IDs"
+	ServiceTokenHeader = "X-Service-Token"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// callerScope reads the scope headers; nil means the caller is a service and
+// sees everyone. Without a scope or a valid service token the request is
+// refused, so a missing header never shows every user. It answers 400 or
+// 403 and returns false when the caller cannot read users.
+func (h *Handler) callerScope(w http.ResponseWriter, r *http.Request) (*repository.Scope, bool) {
+	teams, teamsSet := r.Header[http.CanonicalHeaderKey(TeamScopeHeader)]
+	ubs, ubsSet := r.Header[http.CanonicalHeaderKey(UBSScopeHeader)]
+	if !teamsSet && !ubsSet {
+		token := r.Header.Get(ServiceTokenHeader)
+		if h.serviceToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.serviceToken)) != 1 {
+			respondWithError(w, http.StatusForbidden, "A caller scope or service token is required")
+			return nil, false
+		}
+		return nil, true
+	}
+
+	scope := &repository.Scope{}
+	var err error
+	if scope.TeamIDs, err = parseIDList(strings.Join(teams, ",")); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s header", TeamScopeHeader))
+		return nil, false
+	}
+	if scope.UBSIDs, err = parseIDList(strings.Join(ubs, ",")); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s header", UBSScopeHeader))
+		return nil, false
+	}
+	return scope, true
+}
+
+func parseIDList(value string) ([]uint, error) {
+	var ids []uint
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(field, 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// parseUserFilter reads the filters and the sort shared by the listing and
+// the export, restricted to the caller's scope. It answers 400 and returns
+// false when one is invalid, and 403 without a scope.
+func (h *Handler) parseUserFilter(w http.ResponseWriter, r *http.Request) (repository.UserFilter, bool) {
+	query := r.URL.Query()
+	filter := repository.UserFilter{
+		Deleted:      query.Get("deleted") == "true",
+		Neighborhood: strings.TrimSpace(query.Get("neighborhood")),
+	}
+
+	scope, ok := h.callerScope(w, r)
+	if !ok {
+		return filter, false
+	}
+	filter.Scope = scope
+
+	for name, target := range map[string]*uint{"team_id": &filter.TeamID, "ubs_id": &filter.UBSID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 0)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid "+name)
+				return filter, false
+			}
+			*target = uint(id)
+		}
+	}
+
+	// An age range becomes a range of birth dates: who is min_age years old
+	// today was born up to min_age years ago, and who is max_age was born
+	// after max_age+1 years ago
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	ages := map[string]int{}
+	for _, name := range []string{"min_age", "max_age"} {
+		if value := query.Get(name); value != "" {
+			age, err := strconv.Atoi(value)
+			if err != nil || age < 0 {
+				respondWithError(w, http.StatusBadRequest, "Invalid "+name)
+				return filter, false
+			}
+			ages[name] = age
+		}
+	}
+	if minAge, ok := ages["min_age"]; ok {
+		bornTo := today.AddDate(-minAge, 0, 0)
+		filter.BornTo = &bornTo
+	}
+	if maxAge, ok := ages["max_age"]; ok {
+		if minAge, ok := ages["min_age"]; ok && minAge > maxAge {
+			respondWithError(w, http.StatusBadRequest, "min_age must not be greater than max_age")
+			return filter, false
+		}
+		bornFrom := today.AddDate(-maxAge-1, 0, 1)
+		filter.BornFrom = &bornFrom
+	}
+
+	switch value := query.Get("complete"); value {
+	case "":
+	case "true", "false":
+		complete := value == "true"
+		filter.Complete = &complete
+	default:
+		respondWithError(w, http.StatusBadRequest, "complete must be true or false")
+		return filter, false
+	}
+
+	// Dates are whole days, so created_to takes its day up to the end
+	for name, target := range map[string]**time.Time{"created_from": &filter.CreatedFrom, "created_to": &filter.CreatedTo} {
+		if value := query.Get(name); value != "" {
+			day, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid "+name+". Must be YYYY-MM-DD")
+				return filter, false
+			}
+			if name == "created_to" {
+				day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			*target = &day
+		}
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		filter.Desc = strings.HasPrefix(sort, "-")
+		filter.Sort = strings.TrimPrefix(sort, "-")
+		if !repository.SortColumns[filter.Sort] {
+			respondWithError(w, http.StatusBadRequest, "Invalid sort. Must be id, name, date_of_birth, neighborhood or created_at, with - for descending")
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+// getAllUsers lists the users a page at a time, filtered and sorted as
+// asked, and only those within the caller's scope
+func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseUserFilter(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := 1, defaultPageSize
+	if value := r.URL.Query().Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "page must be a positive number")
+			return
+		}
+		page = n
+	}
+	if value := r.URL.Query().Get("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			respondWithError(w, http.StatusBadRequest, "page_size must be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+		pageSize = n
+	}
+	filter.Limit, filter.Offset = pageSize, (page-1)*pageSize
+
+	users, total, err := h.users.Search(filter)
+	if err != nil {
+		log.Printf("Error fetching users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.UserPage{Users: users, Total: total, Page: page, PageSize: pageSize},
+	})
+}
+
+// exportColumns are the columns of the CSV export, in order
+var exportColumns = []string{
+	"id", "name", "cpf", "date_of_birth", "phone_number",
+	"street_name", "street_number", "complement", "neighborhood", "city", "state", "cep",
+	"team_id", "team_name", "ubs_id", "ubs_name", "team_status", "complete", "created_at",
+}
+
+// exportUsers answers every user of the listing filters as CSV, without
+// pages, for the coordinators to work on their panel in a spreadsheet
+func (h *Handler) exportUsers(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseUserFilter(w, r)
+	if !ok {
+		return
+	}
+
+	users, _, err := h.users.Search(filter)
+	if err != nil {
+		log.Printf("Error fetching users to export: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+
+	// Written in memory so a failure can still answer 500
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(exportColumns)
+	for _, user := range users {
+		writer.Write(exportRecord(user))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Error writing users CSV: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to export users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="usuarios.csv"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func exportRecord(user models.User) []string {
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+	var team models.TeamInfo
+	if user.Team != nil {
+		team = *user.Team
+	}
+	return []string{
+		strconv.FormatUint(uint64(user.ID), 10),
+		user.Name,
+		user.CPF,
+		user.DateOfBirth.Format("2006-01-02"),
+		user.PhoneNumber,
+		user.StreetName,
+		user.StreetNumber,
+		user.Complement,
+		user.Neighborhood,
+		user.City,
+		user.State,
+		user.CEP,
+		optional(user.TeamID),
+		team.Name,
+		optional(user.UBSID),
+		team.UBSName,
+		user.TeamStatus,
+		strconv.FormatBool(user.Complete()),
+		user.CreatedAt.Format(time.RFC3339),
+	}
+}
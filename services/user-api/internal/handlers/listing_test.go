@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-api/internal/assignment"
+	"user-api/internal/households"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+func TestListUsers(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	ids := func(values ...uint) []*uint {
+		pointers := make([]*uint, len(values))
+		for i := range values {
+			if values[i] != 0 {
+				pointers[i] = &values[i]
+			}
+		}
+		return pointers
+	}
+	latitude := -22.0
+	today := time.Now().UTC()
+	aged := func(years int) time.Time {
+		return time.Date(today.Year()-years, today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	// Carla turns 30 today; Bruno is missing a phone and Davi a team
+	for i, seed := range []struct {
+		name, neighborhood string
+		born               time.Time
+		team, ubs          *uint
+		phone              string
+	}{
+		{"Ana", "Centro", aged(76).AddDate(0, -1, 0), ids(7)[0], ids(1)[0], "16999990001"},
+		{"Bruno", "centro", aged(36).AddDate(0, -1, 0), ids(7)[0], ids(1)[0], ""},
+		{"Carla", "Vila Nery", aged(30), ids(8)[0], ids(1)[0], "16999990003"},
+		{"Davi", "Centro", aged(16).AddDate(0, -1, 0), nil, nil, "16999990004"},
+		{"Elisa", "Centro", aged(46).AddDate(0, -1, 0), ids(9)[0], ids(2)[0], "16999990005"},
+	} {
+		user := newTestUser(string(rune('1' + i)))
+		user.Name, user.Neighborhood, user.DateOfBirth = seed.name, seed.neighborhood, seed.born
+		user.TeamID, user.UBSID, user.PhoneNumber, user.Latitude = seed.team, seed.ubs, seed.phone, &latitude
+		if user.TeamID != nil {
+			user.Team = &models.TeamInfo{ID: *user.TeamID, Name: "Equipe", UBSName: "UBS Centro"}
+		}
+		if err := users.Create(&user); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+	}
+	h := NewHandler(users, repository.NewMemoryHouseholdRepository(users), &fakeTeamLookup{}, nil, testServiceToken)
+	service := map[string]string{ServiceTokenHeader: testServiceToken}
+
+	request := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		h.Routes("*").ServeHTTP(rec, req)
+		return rec
+	}
+	list := func(target string, headers map[string]string) models.UserPage {
+		t.Helper()
+		rec := request(target, headers)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d (%s)", target, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Data models.UserPage `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp.Data
+	}
+	names := func(page models.UserPage) string {
+		var names []string
+		for _, user := range page.Users {
+			names = append(names, user.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	tomorrow := today.AddDate(0, 0, 1).Format("2006-01-02")
+	for target, want := range map[string]string{
+		"/users":                              "Ana,Bruno,Carla,Davi,Elisa",
+		"/users?team_id=7":                    "Ana,Bruno",
+		"/users?ubs_id=1&sort=-name":          "Carla,Bruno,Ana",
+		"/users?neighborhood=CENTRO":          "Ana,Bruno,Davi,Elisa",
+		"/users?min_age=30&max_age=45":        "Bruno,Carla",
+		"/users?max_age=29":                   "Davi",
+		"/users?complete=false":               "Bruno,Davi",
+		"/users?sort=date_of_birth":           "Ana,Elisa,Bruno,Carla,Davi",
+		"/users?created_to=" + tomorrow:       "Ana,Bruno,Carla,Davi,Elisa",
+		"/users?created_from=" + tomorrow:     "",
+		"/users?sort=name&page=2&page_size=2": "Carla,Davi",
+	} {
+		if got := names(list(target, service)); got != want {
+			t.Errorf("%s: expected %q, got %q", target, want, got)
+		}
+	}
+
+	if page := list("/users?page=3&page_size=2", service); page.Total != 5 || page.Page != 3 || page.PageSize != 2 || len(page.Users) != 1 {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	t.Run("caller scope", func(t *testing.T) {
+		for _, tt := range []struct {
+			target  string
+			headers map[string]string
+			want    string
+		}{
+			{"/users", map[string]string{TeamScopeHeader: "7"}, "Ana,Bruno"},
+			{"/users", map[string]string{TeamScopeHeader: "8, 9"}, "Carla,Elisa"},
+			{"/users", map[string]string{UBSScopeHeader: "1"}, "Ana,Bruno,Carla"},
+			{"/users", map[string]string{TeamScopeHeader: "9", UBSScopeHeader: "1"}, "Ana,Bruno,Carla,Elisa"},
+			// Asking for another team does not widen the scope
+			{"/users?team_id=9", map[string]string{TeamScopeHeader: "7"}, ""},
+			{"/users", map[string]string{TeamScopeHeader: ""}, ""},
+		} {
+			if got := names(list(tt.target, tt.headers)); got != tt.want {
+				t.Errorf("%s %v: expected %q, got %q", tt.target, tt.headers, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("refuses callers without a scope", func(t *testing.T) {
+		for _, target := range []string{"/users", "/users/export", "/users/unresolved"} {
+			for _, headers := range []map[string]string{nil, {ServiceTokenHeader: "wrong"}} {
+				if rec := request(target, headers); rec.Code != http.StatusForbidden {
+					t.Errorf("%s %v: expected 403, got %d", target, headers, rec.Code)
+				}
+			}
+		}
+		// Without a configured token every caller needs a scope
+		unconfigured := NewHandler(users, repository.NewMemoryHouseholdRepository(users), &fakeTeamLookup{}, nil, "")
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(ServiceTokenHeader, "")
+		rec := httptest.NewRecorder()
+		unconfigured.Routes("*").ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected 403 with no service token configured, got %d", rec.Code)
+		}
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		for _, target := range []string{
+			"/users?team_id=x", "/users?min_age=-1", "/users?min_age=50&max_age=40", "/users?complete=yes",
+			"/users?created_from=01/01/2024", "/users?sort=cpf", "/users?page=0", "/users?page_size=501",
+			"/users/export?sort=phone",
+		} {
+			if rec := request(target, service); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected 400, got %d", target, rec.Code)
+			}
+		}
+		if rec := request("/users", map[string]string{UBSScopeHeader: "abc"}); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for an invalid scope header, got %d", rec.Code)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		rec := request("/users/export?complete=true&sort=name", map[string]string{UBSScopeHeader: "1"})
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") ||
+			!strings.Contains(rec.Header().Get("Content-Disposition"), "usuarios.csv") {
+			t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		if len(records) != 3 || records[0][1] != "name" || records[1][1] != "Ana" || records[2][1] != "Carla" {
+			t.Fatalf("unexpected records %v", records)
+		}
+		if row := records[2]; row[12] != "8" || row[14] != "1" || row[15] != "UBS Centro" || row[17] != "true" {
+			t.Errorf("unexpected row %v", row)
+		}
+	})
+
+	t.Run("dependents answer through the manager phone", func(t *testing.T) {
+		dependent := newTestUser("6")
+		dependent.Name, dependent.PhoneNumber, dependent.ManagedByID = "Fabio", "", ids(1)[0]
+		dependent.TeamID, dependent.UBSID, dependent.Latitude = ids(9)[0], ids(2)[0], &latitude
+		if err := users.Create(&dependent); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+		if got := names(list("/users?complete=true", service)); got != "Ana,Carla,Elisa,Fabio" {
+			t.Errorf("expected the dependent complete, got %q", got)
+		}
+		if got := names(list("/users?complete=false", service)); got != "Bruno,Davi" {
+			t.Errorf("expected only Bruno and Davi incomplete, got %q", got)
+		}
+	})
+}
+
+func TestScopedReads(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	homes := repository.NewMemoryHouseholdRepository(users)
+	h := NewHandler(users, homes, &fakeTeamLookup{}, nil, testServiceToken)
+
+	seed := func(cpf, name, street string, team uint) models.User {
+		t.Helper()
+		user := newTestUser(cpf)
+		user.Name, user.StreetName, user.PhoneNumber = name, street, "1699999000"+cpf
+		user.TeamID, user.TeamStatus = &team, assignment.StatusResolved
+		user.Team = &models.TeamInfo{ID: team, Name: fmt.Sprintf("Equipe %d", team)}
+		if err := users.Create(&user); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+		return user
+	}
+	// Ana heads a household that Bruno, at her address, has not joined yet;
+	// Carla and Davi live together in another team
+	ana := seed("1", "Ana", "Rua das Flores", 7)
+	seed("2", "Bruno", "Rua das Flores", 7)
+	carla := seed("3", "Carla", "Rua dos Ipês", 8)
+	seed("4", "Davi", "Rua dos Ipês", 8)
+	household := households.New(ana)
+	if err := homes.Create(&household); err != nil {
+		t.Fatalf("failed to seed household: %v", err)
+	}
+	households.Join(&ana, household, households.RelationshipHead)
+	if err := users.Update(&ana); err != nil {
+		t.Fatalf("failed to seed household: %v", err)
+	}
+
+	request := func(target string, team string) (*httptest.ResponseRecorder, testResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if team != "" {
+			req.Header.Set(TeamScopeHeader, team)
+		}
+		rec := httptest.NewRecorder()
+		h.Routes("*").ServeHTTP(rec, req)
+		var resp testResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+		}
+		return rec, resp
+	}
+
+	householdPath := fmt.Sprintf("/households/%d", household.ID)
+	targets := []string{
+		fmt.Sprintf("/users/%d", ana.ID), "/users/cpf/1", "/households", householdPath,
+		"/households/suggestions", "/reports/micro-areas",
+	}
+	for _, target := range targets {
+		if rec, _ := request(target, ""); rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 without a scope, got %d", target, rec.Code)
+		}
+	}
+
+	for _, tt := range []struct {
+		target, team string
+		want         int
+	}{
+		{fmt.Sprintf("/users/%d", ana.ID), "7", http.StatusOK},
+		{fmt.Sprintf("/users/%d", carla.ID), "7", http.StatusNotFound},
+		{"/users/cpf/1", "7", http.StatusOK},
+		{"/users/cpf/3", "7", http.StatusNotFound},
+		{householdPath, "7", http.StatusOK},
+		{householdPath, "8", http.StatusNotFound},
+	} {
+		if rec, _ := request(tt.target, tt.team); rec.Code != tt.want {
+			t.Errorf("%s with team %s: expected %d, got %d", tt.target, tt.team, tt.want, rec.Code)
+		}
+	}
+
+	for team, want := range map[string]int{"7": 1, "8": 0} {
+		_, resp := request("/households", team)
+		var list []models.Household
+		decodeData(t, resp, &list)
+		if len(list) != want {
+			t.Errorf("team %s: expected %d households, got %+v", team, want, list)
+		}
+	}
+
+	for team, want := range map[string]string{"7": "Bruno", "8": "Carla,Davi"} {
+		_, resp := request("/households/suggestions", team)
+		var suggestions []models.HouseholdSuggestion
+		decodeData(t, resp, &suggestions)
+		var got []string
+		for _, suggestion := range suggestions {
+			for _, user := range suggestion.Users {
+				got = append(got, user.Name)
+			}
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("team %s: expected suggestions for %q, got %+v", team, want, suggestions)
+		}
+	}
+
+	_, resp := request("/reports/micro-areas", "8")
+	var report households.Report
+	decodeData(t, resp, &report)
+	if report.Users != 2 || len(report.MicroAreas) != 1 || report.MicroAreas[0].TeamID != 8 {
+		t.Errorf("expected only the users of team 8 counted, got %+v", report)
+	}
+}
@@ -0,0 +1,160 @@
+package households
+
+import (
+	"errors"
+	"sort"
+	"user-api/internal/models"
+)
+
+// RelationshipHead is the relationship of the head to their own household
+const RelationshipHead = "head"
+
+// Relationships to the head a member of the household may have
+var Relationships = map[string]bool{
+	"spouse":         true,
+	"child":          true,
+	"grandchild":     true,
+	"parent":         true,
+	"sibling":        true,
+	"other_relative": true,
+	"non_relative":   true,
+}
+
+// Errors of grouping users and of managing dependents
+var (
+	ErrInvalidRelationship = errors.New("invalid relationship. Must be spouse, child, grandchild, parent, sibling, other_relative or non_relative")
+	ErrSelfManaged         = errors.New("a user cannot manage themselves")
+	ErrManagerWithoutPhone = errors.New("the manager has no phone number")
+	ErrManagerIsDependent  = errors.New("the manager is managed by another user")
+	ErrHasDependents       = errors.New("the user manages other users")
+	ErrOwnPhone            = errors.New("the user has a phone number of their own")
+)
+
+// New is the household of the head, at their address
+func New(head models.User) models.Household {
+	id := head.ID
+	return models.Household{
+		HeadUserID:   &id,
+		StreetName:   head.StreetName,
+		StreetNumber: head.StreetNumber,
+		Complement:   head.Complement,
+		Neighborhood: head.Neighborhood,
+		City:         head.City,
+		State:        head.State,
+		CEP:          head.CEP,
+		AddressKey:   Key(head),
+	}
+}
+
+// Join puts the user in the household with the relationship to its head
+func Join(user *models.User, household models.Household, relationship string) {
+	id := household.ID
+	user.HouseholdID, user.Relationship = &id, relationship
+}
+
+// Leave takes the user out of their household
+func Leave(user *models.User) {
+	user.HouseholdID, user.Relationship = nil, ""
+}
+
+// CheckManager tells whether the phone number of manager can answer for
+// the dependent. Dependents are one level deep, so a manager is never managed
+// and a dependent manages no one. A dependent registered with the phone of
+// the manager, as often happens with children, loses it when managed; any
+// other phone number is their own and they do not need a manager.
+func CheckManager(dependent, manager models.User, dependentHasDependents bool) error {
+	switch {
+	case dependent.ID == manager.ID:
+		return ErrSelfManaged
+	case manager.PhoneNumber == "":
+		return ErrManagerWithoutPhone
+	case manager.ManagedByID != nil:
+		return ErrManagerIsDependent
+	case dependentHasDependents:
+		return ErrHasDependents
+	case dependent.PhoneNumber != "" && dependent.PhoneNumber != manager.PhoneNumber:
+		return ErrOwnPhone
+	}
+	return nil
+}
+
+// Manage makes manager answer for the dependent, after CheckManager
+func Manage(dependent *models.User, manager models.User) {
+	id := manager.ID
+	dependent.ManagedByID = &id
+	dependent.PhoneNumber = ""
+}
+
+// Suggest groups the users registered at the same dwelling, by Key, who are
+// not in a household yet. A group of two or more is suggested as a new
+// household, headed by the oldest user with a phone number (or the oldest
+// one); a single user is suggested only when a household already exists at
+// the address. Suggestions come in address order.
+func Suggest(users []models.User, existing []models.Household) []models.HouseholdSuggestion {
+	byKey := map[string]uint{}
+	for _, household := range existing {
+		if _, ok := byKey[household.AddressKey]; !ok {
+			byKey[household.AddressKey] = household.ID
+		}
+	}
+
+	groups := map[string][]models.User{}
+	var keys []string
+	for _, user := range users {
+		if user.HouseholdID != nil {
+			continue
+		}
+		key := Key(user)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], user)
+	}
+	sort.Strings(keys)
+
+	suggestions := []models.HouseholdSuggestion{}
+	for _, key := range keys {
+		group := groups[key]
+		householdID, exists := byKey[key]
+		if len(group) < 2 && !exists {
+			continue
+		}
+
+		first := group[0]
+		suggestion := models.HouseholdSuggestion{
+			StreetName:   first.StreetName,
+			StreetNumber: first.StreetNumber,
+			Complement:   first.Complement,
+			Neighborhood: first.Neighborhood,
+			City:         first.City,
+			State:        first.State,
+			Users:        group,
+		}
+		if exists {
+			suggestion.HouseholdID = &householdID
+		} else {
+			suggestion.HeadUserID = suggestHead(group).ID
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
+// suggestHead is the oldest user who can be reached by phone, or the oldest
+// one when nobody can
+func suggestHead(group []models.User) models.User {
+	head := group[0]
+	for _, user := range group[1:] {
+		reachable, headReachable := user.PhoneNumber != "", head.PhoneNumber != ""
+		if reachable != headReachable {
+			if reachable {
+				head = user
+			}
+			continue
+		}
+		if user.DateOfBirth.Before(head.DateOfBirth) {
+			head = user
+		}
+	}
+	return head
+}
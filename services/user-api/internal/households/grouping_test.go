@@ -0,0 +1,67 @@
+package households
+
+import (
+	"testing"
+	"time"
+	"user-api/internal/models"
+)
+
+func TestSuggest(t *testing.T) {
+	born := func(year int) time.Time { return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC) }
+	grouped := uint(1)
+	users := []models.User{
+		{ID: 1, StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP", DateOfBirth: born(1940)},
+		{ID: 2, StreetName: "R. Flores", StreetNumber: "10", City: "SAO CARLOS", State: "SP", DateOfBirth: born(1970), PhoneNumber: "16999990002"},
+		{ID: 3, StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP", DateOfBirth: born(1965), PhoneNumber: "16999990003"},
+		{ID: 4, StreetName: "Rua das Flores", StreetNumber: "12", City: "São Carlos", State: "SP"}, // alone
+		{ID: 5, StreetName: "Rua dos Ipês", StreetNumber: "5", City: "São Carlos", State: "SP"},    // joins household 9
+		{ID: 6, StreetName: "Rua dos Ipês", StreetNumber: "5", City: "São Carlos", State: "SP", HouseholdID: &grouped},
+	}
+	existing := []models.Household{New(users[5])}
+	existing[0].ID = 9
+
+	suggestions := Suggest(users, existing)
+	if len(suggestions) != 2 {
+		t.Fatalf("expected 2 suggestions, got %+v", suggestions)
+	}
+	flores, ipes := suggestions[0], suggestions[1]
+	if len(flores.Users) != 3 || flores.HouseholdID != nil || flores.HeadUserID != 3 {
+		t.Errorf("expected users 1-3 headed by the oldest with a phone, got %+v", flores)
+	}
+	if len(ipes.Users) != 1 || ipes.Users[0].ID != 5 || ipes.HouseholdID == nil || *ipes.HouseholdID != 9 || ipes.HeadUserID != 0 {
+		t.Errorf("expected user 5 to join household 9, got %+v", ipes)
+	}
+}
+
+func TestCheckManager(t *testing.T) {
+	managed := uint(8)
+	manager := models.User{ID: 1, PhoneNumber: "16999990001"}
+	tests := []struct {
+		name          string
+		dependent     models.User
+		manager       models.User
+		hasDependents bool
+		want          error
+	}{
+		{"child without phone", models.User{ID: 2}, manager, false, nil},
+		{"registered with the manager's phone", models.User{ID: 2, PhoneNumber: manager.PhoneNumber}, manager, false, nil},
+		{"own phone", models.User{ID: 2, PhoneNumber: "16999990002"}, manager, false, ErrOwnPhone},
+		{"self", manager, manager, false, ErrSelfManaged},
+		{"manager without phone", models.User{ID: 2}, models.User{ID: 3}, false, ErrManagerWithoutPhone},
+		{"manager is a dependent", models.User{ID: 2}, models.User{ID: 3, PhoneNumber: "1", ManagedByID: &managed}, false, ErrManagerIsDependent},
+		{"dependent manages others", models.User{ID: 2}, manager, true, ErrHasDependents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckManager(tt.dependent, tt.manager, tt.hasDependents); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	dependent := models.User{ID: 2, PhoneNumber: manager.PhoneNumber}
+	Manage(&dependent, manager)
+	if dependent.ManagedByID == nil || *dependent.ManagedByID != 1 || dependent.PhoneNumber != "" {
+		t.Errorf("expected the dependent managed by 1 without the shared phone, got %+v", dependent)
+	}
+}
@@ -0,0 +1,102 @@
+// Package households groups registered users by the dwelling they live in:
+// it suggests and keeps the households followed by the teams, with their
+// head and the dependents answered for by another user's phone, and counts
+// the households followed by each community health agent (ACS), that is,
+// by each micro-area of the healthcare teams.
+package households
+
+import (
+	"shared/normalize"
+	"sort"
+	"strings"
+	"user-api/internal/assignment"
+	"user-api/internal/models"
+)
+
+// Key identifies the dwelling of the user. The street is compared the way
+// address-api compares it, so "Av. Dr. Arnaldo" and "Avenida Doutor Arnaldo"
+// are the same street, and the complement separates apartments in one
+// building.
+func Key(user models.User) string {
+	return strings.Join([]string{
+		normalize.StreetName(user.StreetName),
+		normalize.Text(user.StreetNumber),
+		normalize.Text(user.Complement),
+		normalize.Text(user.City),
+		normalize.Text(user.State),
+	}, "|")
+}
+
+// MicroAreaCount is the number of households and residents registered in a
+// micro-area. MicroArea is nil for the streets of the team that are not in
+// any micro-area yet.
+type MicroAreaCount struct {
+	TeamID     uint                  `json:"team_id"`
+	TeamName   string                `json:"team_name"`
+	UBSName    string                `json:"ubs_name"`
+	MicroArea  *models.MicroAreaInfo `json:"micro_area"`
+	Households int                   `json:"households"`
+	Residents  int                   `json:"residents"`
+}
+
+// Report counts the households of every micro-area with registered users
+type Report struct {
+	Users      int              `json:"users"`
+	Unresolved int              `json:"unresolved"` // users without a resolved team
+	MicroAreas []MicroAreaCount `json:"micro_areas"`
+}
+
+type areaKey struct {
+	team uint
+	area uint // 0 outside any micro-area
+}
+
+// ByMicroArea counts the distinct dwellings of each micro-area from the team
+// and micro-area stored on each user. Users whose team is not resolved yet,
+// or was not found, are only counted as unresolved.
+func ByMicroArea(users []models.User) *Report {
+	report := &Report{Users: len(users), MicroAreas: []MicroAreaCount{}}
+	counts := map[areaKey]*MicroAreaCount{}
+	dwellings := map[areaKey]map[string]bool{}
+
+	for _, user := range users {
+		team := user.Team
+		if !assignment.Resolved(user) || team == nil || team.ID == 0 {
+			report.Unresolved++
+			continue
+		}
+
+		key := areaKey{team: team.ID}
+		if team.MicroArea != nil {
+			key.area = team.MicroArea.ID
+		}
+		count, ok := counts[key]
+		if !ok {
+			count = &MicroAreaCount{TeamID: team.ID, TeamName: team.Name, UBSName: team.UBSName, MicroArea: team.MicroArea}
+			counts[key] = count
+			dwellings[key] = map[string]bool{}
+		}
+		count.Residents++
+		dwellings[key][Key(user)] = true
+	}
+
+	for key, count := range counts {
+		count.Households = len(dwellings[key])
+		report.MicroAreas = append(report.MicroAreas, *count)
+	}
+	sort.Slice(report.MicroAreas, func(i, j int) bool {
+		a, b := report.MicroAreas[i], report.MicroAreas[j]
+		if a.TeamName != b.TeamName {
+			return a.TeamName < b.TeamName
+		}
+		if a.TeamID != b.TeamID {
+			return a.TeamID < b.TeamID
+		}
+		// Streets outside any micro-area come last
+		if (a.MicroArea == nil) != (b.MicroArea == nil) {
+			return b.MicroArea == nil
+		}
+		return a.MicroArea != nil && a.MicroArea.Code < b.MicroArea.Code
+	})
+	return report
+}
@@ -0,0 +1,78 @@
+package households
+
+import (
+	"testing"
+	"user-api/internal/assignment"
+	"user-api/internal/models"
+)
+
+func TestKey(t *testing.T) {
+	base := models.User{StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP"}
+
+	same := base
+	same.StreetName, same.City = "R. Flores", "SAO CARLOS"
+	if Key(base) != Key(same) {
+		t.Errorf("expected %q and %q to be the same dwelling", Key(base), Key(same))
+	}
+
+	abbreviated, spelled := base, base
+	abbreviated.StreetName, spelled.StreetName = "Av. Dr. Carlos Botelho", "Avenida Doutor Carlos Botelho"
+	if Key(abbreviated) != Key(spelled) {
+		t.Errorf("expected %q and %q to be the same dwelling", Key(abbreviated), Key(spelled))
+	}
+
+	apartment := base
+	apartment.Complement = "Apto 2"
+	if Key(base) == Key(apartment) {
+		t.Errorf("expected the complement to separate dwellings")
+	}
+}
+
+func TestByMicroArea(t *testing.T) {
+	north := &models.MicroAreaInfo{ID: 1, Code: "01"}
+	south := &models.MicroAreaInfo{ID: 2, Code: "02"}
+	teams := map[string]*models.TeamInfo{
+		"Flores":    {ID: 1, Name: "Equipe Azul", MicroArea: south},
+		"Palmeiras": {ID: 1, Name: "Equipe Azul", MicroArea: north},
+		"Ipes":      {ID: 1, Name: "Equipe Azul"},
+	}
+	resident := func(street, number, status string) models.User {
+		user := models.User{StreetName: street, StreetNumber: number, TeamStatus: status}
+		if team, ok := teams[street]; ok {
+			user.TeamID, user.Team = &team.ID, team
+		}
+		return user
+	}
+	users := []models.User{
+		resident("Flores", "10", assignment.StatusResolved),
+		resident("Flores", "10", assignment.StatusResolved), // same dwelling
+		resident("Flores", "12", assignment.StatusAmbiguous),
+		resident("Palmeiras", "1", assignment.StatusResolved),
+		resident("Ipes", "5", assignment.StatusResolved),
+		resident("Desconhecida", "1", assignment.StatusNotFound),
+		// A stale team is not counted while the address is being resolved
+		resident("Ipes", "7", assignment.StatusPending),
+	}
+
+	report := ByMicroArea(users)
+	if report.Users != 7 || report.Unresolved != 2 {
+		t.Errorf("expected 7 users and 2 unresolved, got %+v", report)
+	}
+	want := []struct {
+		code                  string
+		households, residents int
+	}{{"01", 1, 1}, {"02", 2, 3}, {"", 1, 1}}
+	if len(report.MicroAreas) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), report.MicroAreas)
+	}
+	for i, w := range want {
+		row := report.MicroAreas[i]
+		code := ""
+		if row.MicroArea != nil {
+			code = row.MicroArea.Code
+		}
+		if code != w.code || row.Households != w.households || row.Residents != w.residents {
+			t.Errorf("row %d: expected %+v, got %+v", i, w, row)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
@@ -18,8 +22,46 @@ type User struct {
 	State        string `json:"state" gorm:"size:2;not null"`
 	CEP          string `json:"cep" gorm:"size:8;not null"`
 
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	// Geocoded position of the address. GeocodeQuality says how precise it
+	// is (house, street, cep, neighborhood, city) or that geocoding failed;
+	// it is empty while the address was not geocoded.
+	Latitude       *float64   `json:"latitude"`
+	Longitude      *float64   `json:"longitude"`
+	GeocodeQuality string     `json:"geocode_quality" gorm:"size:20;not null;default:''"`
+	GeocodedAt     *time.Time `json:"geocoded_at"`
+
+	// Healthcare team responsible for the address, resolved through
+	// address-api when the address changes and when its street moves to
+	// another team. TeamStatus says whether it was resolved (resolved,
+	// ambiguous, not_found, failed) and is empty until the first attempt;
+	// Team keeps the whole answer returned with the user. UBSID is the UBS of
+	// the team, kept apart to list the users of a UBS.
+	TeamID         *uint      `json:"team_id" gorm:"index"`
+	UBSID          *uint      `json:"ubs_id" gorm:"index"`
+	TeamStatus     string     `json:"team_status" gorm:"size:20;not null;default:''"`
+	TeamConfidence string     `json:"team_confidence" gorm:"size:10;not null;default:''"`
+	TeamResolvedAt *time.Time `json:"team_resolved_at"`
+	Team           *TeamInfo  `json:"-" gorm:"serializer:json"`
+
+	// Household the user lives in and their relationship to its head.
+	// ManagedByID is the user whose phone number answers for this one, a
+	// child or an elderly parent without a phone of their own; Dependents
+	// are the users managed by this one, filled only by the phone lookup.
+	HouseholdID  *uint  `json:"household_id" gorm:"index"`
+	Relationship string `json:"relationship" gorm:"size:20;not null;default:''"`
+	ManagedByID  *uint  `json:"managed_by_id" gorm:"index"`
+	Dependents   []User `json:"dependents,omitempty" gorm:"-"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// Complete reports whether the registration has what the team needs to
+// reach and visit the citizen: a phone number, their own or the manager's for
+// a dependent, a team and a geocoded address
+func (u User) Complete() bool {
+	return (u.PhoneNumber != "" || u.ManagedByID != nil) && u.TeamID != nil && u.Latitude != nil
 }
 
 // Request/Response structures
@@ -55,6 +97,76 @@ type APIResponse struct {
 	Error   string      `json:"error,omitempty"`
 }
 
+// Household groups the users living in the same dwelling, as primary care
+// follows families. The address is the one of the head when the household
+// was created; AddressKey is households.Key of it.
+type Household struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	HeadUserID   *uint     `json:"head_user_id"`
+	StreetName   string    `json:"street_name" gorm:"size:200;not null"`
+	StreetNumber string    `json:"street_number" gorm:"size:20;not null"`
+	Complement   string    `json:"complement" gorm:"size:100"`
+	Neighborhood string    `json:"neighborhood" gorm:"size:100;not null"`
+	City         string    `json:"city" gorm:"size:100;not null"`
+	State        string    `json:"state" gorm:"size:2;not null"`
+	CEP          string    `json:"cep" gorm:"size:8;not null"`
+	AddressKey   string    `json:"-" gorm:"size:500;not null;index"`
+	Members      []User    `json:"members,omitempty" gorm:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateHouseholdRequest creates a household with its head and the other
+// members. The head gets the relationship "head".
+type CreateHouseholdRequest struct {
+	HeadUserID uint                   `json:"head_user_id" binding:"required"`
+	Members    []HouseholdMemberInput `json:"members"`
+}
+
+// HouseholdMemberInput is a member of the household and their relationship
+// to the head: spouse, child, grandchild, parent, sibling, other_relative or
+// non_relative
+type HouseholdMemberInput struct {
+	UserID       uint   `json:"user_id" binding:"required"`
+	Relationship string `json:"relationship" binding:"required"`
+}
+
+// UpdateHouseholdRequest changes the head of the household, who must be a
+// member already. The previous head becomes Relationship of the new one.
+type UpdateHouseholdRequest struct {
+	HeadUserID   uint   `json:"head_user_id" binding:"required"`
+	Relationship string `json:"relationship" binding:"required"`
+}
+
+// HouseholdSuggestion is a group of users registered with the same address
+// and not grouped yet. HouseholdID is set when a household already exists
+// at the address, which the users can join.
+type HouseholdSuggestion struct {
+	StreetName   string `json:"street_name"`
+	StreetNumber string `json:"street_number"`
+	Complement   string `json:"complement"`
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	HouseholdID  *uint  `json:"household_id,omitempty"`
+	HeadUserID   uint   `json:"head_user_id,omitempty"` // suggested head for a new household
+	Users        []User `json:"users"`
+}
+
+// SetManagerRequest makes the phone number of ManagerID answer for the user
+type SetManagerRequest struct {
+	ManagerID uint `json:"manager_id" binding:"required"`
+}
+
+// UserPage is a page of the user listing. Total counts every user matching
+// the filters, not only those in the page.
+type UserPage struct {
+	Users    []User `json:"users"`
+	Total    int64  `json:"total"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
 // UserWithTeam represents a user with their associated healthcare team information
 type UserWithTeam struct {
 	User User     `json:"user"`
@@ -63,8 +175,79 @@ type UserWithTeam struct {
 
 // TeamInfo represents the minimal team information we need
 type TeamInfo struct {
-	ID      uint   `json:"id"`
-	Name    string `json:"name"`
-	UBSName string `json:"ubs_name"`
+	ID      uint         `json:"id"`
+	Name    string       `json:"name"`
+	UBSID   uint         `json:"ubs_id,omitempty"`
+	UBSName string       `json:"ubs_name"`
+	Roster  []TeamMember `json:"roster,omitempty"`
+	// MicroArea is the part of the team territory the address is in, with
+	// its community health agent (ACS); nil when the street was not
+	// assigned to one
+	MicroArea *MicroAreaInfo `json:"micro_area,omitempty"`
+	// StreetSegmentID is the address-api street segment the address matched
+	StreetSegmentID uint `json:"street_segment_id,omitempty"`
+	// Status is "matched", or "ambiguous" when a candidate of another team
+	// scored close to this one; Confidence is high, medium or low
+	Status     string `json:"status,omitempty"`
+	Confidence string `json:"confidence,omitempty"`
+}
+
+// AddressQuery is one address of a batch team search in address-api
+type AddressQuery struct {
+	UserID     uint
+	StreetName string
+	Number     string
+	City       string
+	State      string
 }
 
+// TeamResult is what a batch team search found for one address. Team is nil
+// when the address has no team, and Err is set when address-api rejected the
+// address, like a house number that is not a number.
+type TeamResult struct {
+	Team *TeamInfo
+	Err  error
+}
+
+// Reassignment is a street segment that address-api moved to another team
+// and whose residents were not told yet. ValidFrom is the first day of the
+// new team, as YYYY-MM-DD.
+type Reassignment struct {
+	ID              uint     `json:"id"`
+	StreetSegmentID uint     `json:"street_segment_id"`
+	City            string   `json:"city"`
+	State           string   `json:"state"`
+	StartNumber     int      `json:"start_number"`
+	EndNumber       int      `json:"end_number"`
+	EvenOdd         string   `json:"even_odd"`
+	ValidFrom       string   `json:"valid_from"`
+	Team            TeamInfo `json:"team"`
+	PreviousTeam    TeamInfo `json:"previous_team"`
+}
+
+// MicroAreaInfo is a micro-area as returned by address-api. Agent is nil
+// while the micro-area has no ACS.
+type MicroAreaInfo struct {
+	ID    uint        `json:"id"`
+	Code  string      `json:"code"`
+	Name  string      `json:"name,omitempty"`
+	Agent *TeamMember `json:"agent,omitempty"`
+}
+
+// TeamMember is a current professional of the team, as returned by
+// address-api: family doctor, nurse, community health agent (ACS), etc.
+type TeamMember struct {
+	ProfessionalID uint   `json:"professional_id"`
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	Registry       string `json:"registry,omitempty"`
+	Shift          string `json:"shift,omitempty"`
+	ContactChannel string `json:"contact_channel,omitempty"`
+	Contact        string `json:"contact,omitempty"`
+	Since          string `json:"since"`
+}
+
+// HealthStatus is returned by the health check endpoint
+type HealthStatus struct {
+	Status string `json:"status"`
+}
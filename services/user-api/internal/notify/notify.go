@@ -0,0 +1,67 @@
+// Package notify tells registered users about changes that affect them, such
+// as their address moving to another healthcare team. The Notifier is
+// pluggable: a webhook for deployments with a messaging service behind it,
+// or the log while there is none.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"user-api/internal/models"
+)
+
+// Notifier delivers a message to a user
+type Notifier interface {
+	Notify(user models.User, message string) error
+}
+
+// LogNotifier only logs the messages. It is the default, so changes are at
+// least traceable before a messaging service is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(user models.User, message string) error {
+	log.Printf("Notification to user %d (%s): %s", user.ID, user.PhoneNumber, message)
+	return nil
+}
+
+// Webhook posts each message as JSON to a URL, for a service that sends it
+// to the user, for example over WhatsApp
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type webhookPayload struct {
+	UserID      uint   `json:"user_id"`
+	PhoneNumber string `json:"phone_number"`
+	Message     string `json:"message"`
+}
+
+func (w *Webhook) Notify(user models.User, message string) error {
+	body, err := json.Marshal(webhookPayload{UserID: user.ID, PhoneNumber: user.PhoneNumber, Message: message})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error calling notification webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
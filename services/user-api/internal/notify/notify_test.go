@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+type fakeAddressAPI struct {
+	reassignments []models.Reassignment
+	segments      map[string]uint // street -> segment of the search
+	notified      []uint
+	batches       int
+}
+
+func (f *fakeAddressAPI) UnnotifiedReassignments() ([]models.Reassignment, error) {
+	return f.reassignments, nil
+}
+
+func (f *fakeAddressAPI) MarkReassignmentNotified(id uint) error {
+	f.notified = append(f.notified, id)
+	return nil
+}
+
+func (f *fakeAddressAPI) GetTeamInfoBatch(addresses []models.AddressQuery) ([]models.TeamResult, error) {
+	f.batches++
+	results := make([]models.TeamResult, len(addresses))
+	for i, address := range addresses {
+		if segment, ok := f.segments[address.StreetName]; ok {
+			results[i].Team = &models.TeamInfo{ID: 2, Name: "Verde", StreetSegmentID: segment}
+		}
+	}
+	return results, nil
+}
+
+type recorder struct {
+	messages map[uint]string
+	phones   map[uint]string
+	err      error
+}
+
+func (r *recorder) Notify(user models.User, message string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.messages[user.ID] = message
+	if r.phones != nil {
+		r.phones[user.ID] = user.PhoneNumber
+	}
+	return nil
+}
+
+func TestCandidate(t *testing.T) {
+	reassignment := models.Reassignment{City: "SAO CARLOS", State: "SP", StartNumber: 1, EndNumber: 99, EvenOdd: "even"}
+	tests := []struct {
+		name string
+		user models.User
+		want bool
+	}{
+		{"in range", models.User{City: "São Carlos", State: "sp", StreetNumber: "10"}, true},
+		{"wrong parity", models.User{City: "São Carlos", State: "SP", StreetNumber: "11"}, false},
+		{"out of range", models.User{City: "São Carlos", State: "SP", StreetNumber: "100"}, false},
+		{"other city", models.User{City: "Araraquara", State: "SP", StreetNumber: "10"}, false},
+		{"no number", models.User{City: "São Carlos", State: "SP", StreetNumber: "s/n"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Candidate(tt.user, reassignment); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNotifyTeamChanges(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	// Pedro is a dependent of Maria
+	manager := uint(1)
+	for _, user := range []*models.User{
+		{Name: "Maria Silva", CPF: "1", PhoneNumber: "+5516999990001", StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP"},
+		{Name: "João Souza", CPF: "2", PhoneNumber: "+5516999990002", StreetName: "Rua dos Ipês", StreetNumber: "10", City: "São Carlos", State: "SP"},
+		{Name: "Ana Lima", CPF: "3", StreetName: "Rua das Flores", StreetNumber: "12", City: "São Carlos", State: "SP"},
+		{Name: "Pedro Silva", CPF: "4", ManagedByID: &manager, StreetName: "Rua das Flores", StreetNumber: "10", City: "São Carlos", State: "SP"},
+	} {
+		if err := users.Create(user); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+	}
+
+	api := &fakeAddressAPI{
+		reassignments: []models.Reassignment{{
+			ID: 7, StreetSegmentID: 3, City: "SAO CARLOS", State: "SP", StartNumber: 1, EndNumber: 99, EvenOdd: "all",
+			ValidFrom: "2024-07-01", Team: models.TeamInfo{ID: 2, Name: "Verde", UBSName: "UBS Centro"},
+		}},
+		// Ipes is in the same number range, but in another segment
+		segments: map[string]uint{"Rua das Flores": 3, "Rua dos Ipês": 4},
+	}
+
+	failing := &recorder{messages: map[uint]string{}, err: errors.New("unavailable")}
+	if err := NotifyTeamChanges(users, api, failing); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(api.notified) != 0 {
+		t.Fatalf("expected the reassignment to be retried after a failed notification, got %v", api.notified)
+	}
+
+	notifier := &recorder{messages: map[uint]string{}, phones: map[uint]string{}}
+	if err := NotifyTeamChanges(users, api, notifier); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "Olá, Maria! Desde 01/07/2024 o seu endereço é atendido pela equipe Verde, da UBS Centro. Procure essa equipe para consultas e acompanhamento."
+	wantDependent := "Olá, Maria! Desde 01/07/2024 o endereço de Pedro é atendido pela equipe Verde, da UBS Centro. Procure essa equipe para consultas e acompanhamento."
+	if len(notifier.messages) != 2 || notifier.messages[1] != want || notifier.messages[4] != wantDependent {
+		t.Errorf("expected Maria to be notified for herself and for Pedro, got %v", notifier.messages)
+	}
+	if notifier.phones[4] != "+5516999990001" {
+		t.Errorf("expected Pedro's notice sent to Maria's phone, got %q", notifier.phones[4])
+	}
+	if api.batches != 2 {
+		t.Errorf("expected one batch search per run, got %d", api.batches)
+	}
+	if len(api.notified) != 1 || api.notified[0] != 7 {
+		t.Errorf("expected reassignment 7 marked as notified, got %v", api.notified)
+	}
+
+	// Ana has no phone, but her stored team changes as well
+	for id, want := range map[uint]bool{1: true, 2: false, 3: true, 4: true} {
+		user, _ := users.Get(id)
+		if got := user.TeamID != nil && *user.TeamID == 2; got != want {
+			t.Errorf("expected user %d with the new team stored: %v, got %v", id, want, user.TeamID)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	user := models.User{ID: 5, PhoneNumber: "+5516999990001"}
+	if err := NewWebhook(server.URL).Notify(user, "Olá"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.UserID != 5 || got.PhoneNumber != user.PhoneNumber || got.Message != "Olá" {
+		t.Errorf("unexpected payload %+v", got)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	if err := NewWebhook(server.URL).Notify(user, "Olá"); err == nil {
+		t.Error("expected an error for a failed webhook")
+	}
+}
@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"shared/normalize"
+	"strconv"
+	"strings"
+	"time"
+	"user-api/internal/assignment"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// AddressAPI is what the team change job needs from address-api
+type AddressAPI interface {
+	UnnotifiedReassignments() ([]models.Reassignment, error)
+	MarkReassignmentNotified(id uint) error
+	GetTeamInfoBatch(addresses []models.AddressQuery) ([]models.TeamResult, error)
+}
+
+// TeamChangeMessage is the message sent to a user whose address moved to
+// another team
+func TeamChangeMessage(user models.User, reassignment models.Reassignment) string {
+	from, team := teamChange(reassignment)
+	return fmt.Sprintf("Olá, %s! Desde %s o seu endereço é atendido pela equipe %s. Procure essa equipe para consultas e acompanhamento.", firstName(user.Name), from, team)
+}
+
+// DependentTeamChangeMessage is the message sent to the manager of a
+// dependent whose address moved to another team
+func DependentTeamChangeMessage(manager, dependent models.User, reassignment models.Reassignment) string {
+	from, team := teamChange(reassignment)
+	return fmt.Sprintf("Olá, %s! Desde %s o endereço de %s é atendido pela equipe %s. Procure essa equipe para consultas e acompanhamento.", firstName(manager.Name), from, firstName(dependent.Name), team)
+}
+
+// teamChange returns the day of the reassignment as dd/mm/yyyy and the name
+// of the new team with its UBS
+func teamChange(reassignment models.Reassignment) (string, string) {
+	from := reassignment.ValidFrom
+	if day, err := time.Parse("2006-01-02", from); err == nil {
+		from = day.Format("02/01/2006")
+	}
+	team := reassignment.Team.Name
+	if reassignment.Team.UBSName != "" {
+		team += ", da " + reassignment.Team.UBSName
+	}
+	return from, team
+}
+
+// teamChangeNotice returns who gets the team change of the user and the
+// message. A dependent's notice goes to the phone of their manager, keeping
+// the dependent as the user it is about. It is false for users nobody can
+// be reached for.
+func teamChangeNotice(user models.User, managers map[uint]models.User, reassignment models.Reassignment) (models.User, string, bool) {
+	if user.PhoneNumber != "" {
+		return user, TeamChangeMessage(user, reassignment), true
+	}
+	if user.ManagedByID == nil {
+		return user, "", false
+	}
+	manager, ok := managers[*user.ManagedByID]
+	if !ok || manager.PhoneNumber == "" {
+		return user, "", false
+	}
+	recipient := user
+	recipient.PhoneNumber = manager.PhoneNumber
+	return recipient, DependentTeamChangeMessage(manager, user, reassignment), true
+}
+
+func firstName(name string) string {
+	if fields := strings.Fields(name); len(fields) > 0 {
+		return fields[0]
+	}
+	return name
+}
+
+// Candidate tells whether the user address may be in the street segment of
+// the reassignment: same city and state, and a house number within the
+// segment range and parity. The street name is left to address-api, which
+// knows its aliases.
+func Candidate(user models.User, reassignment models.Reassignment) bool {
+	if normalize.Text(user.City) != normalize.Text(reassignment.City) ||
+		normalize.Text(user.State) != normalize.Text(reassignment.State) {
+		return false
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(user.StreetNumber))
+	if err != nil || number < reassignment.StartNumber || number > reassignment.EndNumber {
+		return false
+	}
+	switch reassignment.EvenOdd {
+	case "even":
+		return number%2 == 0
+	case "odd":
+		return number%2 == 1
+	}
+	return true
+}
+
+// Affected returns the users whose address is now served by the team of the
+// reassignment through its street segment, with the new team already set.
+// Candidates are confirmed with a batch search in address-api, so a
+// neighbour street in the same number range is left out. A candidate whose
+// address address-api rejects is logged and left out as well.
+func Affected(users []models.User, reassignment models.Reassignment, api AddressAPI) ([]models.User, error) {
+	var candidates []models.User
+	for _, user := range users {
+		if Candidate(user, reassignment) {
+			candidates = append(candidates, user)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	queries := assignment.Queries(candidates)
+	// The search is for the job, not for the user, so it is not traced back
+	// to them in the address-api review queue
+	for i := range queries {
+		queries[i].UserID = 0
+	}
+	results, err := api.GetTeamInfoBatch(queries)
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []models.User
+	now := time.Now()
+	for i, user := range candidates {
+		team := results[i].Team
+		if err := results[i].Err; err != nil {
+			log.Printf("Error searching the team of user %d: %v", user.ID, err)
+			continue
+		}
+		if team != nil && team.StreetSegmentID == reassignment.StreetSegmentID && team.ID == reassignment.Team.ID {
+			assignment.Set(&user, team, now)
+			affected = append(affected, user)
+		}
+	}
+	return affected, nil
+}
+
+// NotifyTeamChanges stores the new team of the users affected by each
+// reassignment not notified yet, notifies them and marks the reassignment as
+// notified. Dependents are notified through the phone of their manager, and
+// other users without a phone number are only updated. When saving or
+// notifying a user fails the reassignment is left unmarked and retried on
+// the next run, so the others of the same street may get the message twice;
+// that is preferred to someone never knowing.
+func NotifyTeamChanges(users repository.UserRepository, api AddressAPI, notifier Notifier) error {
+	reassignments, err := api.UnnotifiedReassignments()
+	if err != nil {
+		return err
+	}
+	if len(reassignments) == 0 {
+		return nil
+	}
+	all, err := users.List(false)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]models.User, len(all))
+	for _, user := range all {
+		byID[user.ID] = user
+	}
+
+	for _, reassignment := range reassignments {
+		affected, err := Affected(all, reassignment, api)
+		if err != nil {
+			return err
+		}
+
+		failed := false
+		for _, user := range affected {
+			if err := users.UpdateTeam(&user); err != nil {
+				log.Printf("Error saving team of user %d: %v", user.ID, err)
+				failed = true
+			}
+			recipient, message, ok := teamChangeNotice(user, byID, reassignment)
+			if !ok {
+				continue
+			}
+			if err := notifier.Notify(recipient, message); err != nil {
+				log.Printf("Error notifying user %d of team change: %v", user.ID, err)
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+		if err := api.MarkReassignmentNotified(reassignment.ID); err != nil {
+			return err
+		}
+		log.Printf("Notified %d users of street segment %d moving to team %s", len(affected), reassignment.StreetSegmentID, reassignment.Team.Name)
+	}
+	return nil
+}
+
+// StartTeamChangeJob runs NotifyTeamChanges periodically in the background
+func StartTeamChangeJob(users repository.UserRepository, api AddressAPI, notifier Notifier, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := NotifyTeamChanges(users, api, notifier); err != nil {
+				log.Printf("Error notifying team changes: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
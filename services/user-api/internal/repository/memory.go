@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"user-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryUserRepository keeps users in memory. It is used by the tests in
+// place of Postgres and follows the same soft deletion rules.
+type MemoryUserRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	users  map[uint]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: map[uint]models.User{}}
+}
+
+func (r *MemoryUserRepository) sortedIDs() []uint {
+	ids := make([]uint, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (r *MemoryUserRepository) Create(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The unique index on cpf also covers deleted users
+	for _, existing := range r.users {
+		if existing.CPF == user.CPF {
+			return ErrDuplicateCPF
+		}
+	}
+
+	now := time.Now()
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) List(deleted bool) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []models.User{}
+	for _, id := range r.sortedIDs() {
+		if user := r.users[id]; user.DeletedAt.Valid == deleted {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) find(match func(models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range r.sortedIDs() {
+		if user := r.users[id]; match(user) {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserRepository) Get(id uint) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id && !u.DeletedAt.Valid })
+}
+
+func (r *MemoryUserRepository) GetByCPF(cpf string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.CPF == cpf && !u.DeletedAt.Valid })
+}
+
+func (r *MemoryUserRepository) GetByPhone(phone string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.PhoneNumber == phone && !u.DeletedAt.Valid })
+}
+
+func (r *MemoryUserRepository) GetDeleted(id uint) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id && u.DeletedAt.Valid })
+}
+
+func (r *MemoryUserRepository) GetDeletedByCPF(cpf string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.CPF == cpf && u.DeletedAt.Valid })
+}
+
+func (r *MemoryUserRepository) Update(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) UpdateTeam(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	stored.TeamID, stored.UBSID, stored.Team = user.TeamID, user.UBSID, user.Team
+	stored.TeamStatus, stored.TeamConfidence, stored.TeamResolvedAt = user.TeamStatus, user.TeamConfidence, user.TeamResolvedAt
+	stored.UpdatedAt = time.Now()
+	user.UpdatedAt = stored.UpdatedAt
+	r.users[user.ID] = stored
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) Restore(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) ListForGeocoding(all bool) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []models.User{}
+	for _, id := range r.sortedIDs() {
+		user := r.users[id]
+		if user.DeletedAt.Valid {
+			continue
+		}
+		if all || user.GeocodedAt == nil || user.GeocodeQuality == "failed" {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) ListByTeamStatus(statuses ...string) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, status := range statuses {
+		wanted[status] = true
+	}
+	users := []models.User{}
+	for _, id := range r.sortedIDs() {
+		if user := r.users[id]; !user.DeletedAt.Valid && wanted[user.TeamStatus] {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Search(filter UserFilter) ([]models.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []models.User{}
+	for _, id := range r.sortedIDs() {
+		if user := r.users[id]; matches(user, filter) {
+			users = append(users, user)
+		}
+	}
+
+	less := func(a, b models.User) int {
+		switch filter.Sort {
+		case "name":
+			return strings.Compare(a.Name, b.Name)
+		case "neighborhood":
+			return strings.Compare(a.Neighborhood, b.Neighborhood)
+		case "date_of_birth":
+			return a.DateOfBirth.Compare(b.DateOfBirth)
+		case "created_at":
+			return a.CreatedAt.Compare(b.CreatedAt)
+		}
+		return 0
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		c := less(users[i], users[j])
+		if c == 0 {
+			c = int(users[i].ID) - int(users[j].ID)
+		}
+		if filter.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	total := int64(len(users))
+	if filter.Limit > 0 {
+		start := min(filter.Offset, len(users))
+		users = users[start:min(start+filter.Limit, len(users))]
+	}
+	return users, total, nil
+}
+
+func matches(user models.User, filter UserFilter) bool {
+	within := func(t time.Time, from, to *time.Time) bool {
+		return (from == nil || !t.Before(*from)) && (to == nil || !t.After(*to))
+	}
+	return user.DeletedAt.Valid == filter.Deleted &&
+		(filter.TeamID == 0 || user.TeamID != nil && *user.TeamID == filter.TeamID) &&
+		(filter.UBSID == 0 || user.UBSID != nil && *user.UBSID == filter.UBSID) &&
+		(filter.Neighborhood == "" || strings.EqualFold(user.Neighborhood, filter.Neighborhood)) &&
+		within(user.DateOfBirth, filter.BornFrom, filter.BornTo) &&
+		(filter.Complete == nil || user.Complete() == *filter.Complete) &&
+		within(user.CreatedAt, filter.CreatedFrom, filter.CreatedTo) &&
+		filter.Scope.Allows(user)
+}
+
+func (r *MemoryUserRepository) ListByHousehold(householdID uint) ([]models.User, error) {
+	return r.filter(func(u models.User) bool { return u.HouseholdID != nil && *u.HouseholdID == householdID })
+}
+
+func (r *MemoryUserRepository) ListDependents(managerID uint) ([]models.User, error) {
+	return r.filter(func(u models.User) bool { return u.ManagedByID != nil && *u.ManagedByID == managerID })
+}
+
+// filter returns the active users matching, in ID order
+func (r *MemoryUserRepository) filter(match func(models.User) bool) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []models.User{}
+	for _, id := range r.sortedIDs() {
+		if user := r.users[id]; !user.DeletedAt.Valid && match(user) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// MemoryHouseholdRepository keeps households in memory for the tests. Its
+// members are the users of the memory user repository.
+type MemoryHouseholdRepository struct {
+	mu         sync.Mutex
+	nextID     uint
+	households map[uint]models.Household
+	users      *MemoryUserRepository
+}
+
+func NewMemoryHouseholdRepository(users *MemoryUserRepository) *MemoryHouseholdRepository {
+	return &MemoryHouseholdRepository{households: map[uint]models.Household{}, users: users}
+}
+
+func (r *MemoryHouseholdRepository) Create(household *models.Household) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.nextID++
+	household.ID = r.nextID
+	household.CreatedAt, household.UpdatedAt = now, now
+	r.households[household.ID] = *household
+	return nil
+}
+
+func (r *MemoryHouseholdRepository) CreateWithMembers(household *models.Household, members []models.HouseholdMemberInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+
+	for _, member := range members {
+		user, ok := r.users.users[member.UserID]
+		if !ok || user.DeletedAt.Valid || user.HouseholdID != nil {
+			return fmt.Errorf("user %d: %w", member.UserID, ErrInHousehold)
+		}
+	}
+
+	now := time.Now()
+	r.nextID++
+	household.ID = r.nextID
+	household.CreatedAt, household.UpdatedAt = now, now
+	r.households[household.ID] = *household
+	for _, member := range members {
+		user := r.users.users[member.UserID]
+		id := household.ID
+		user.HouseholdID, user.Relationship, user.UpdatedAt = &id, member.Relationship, now
+		r.users.users[user.ID] = user
+	}
+	return nil
+}
+
+func (r *MemoryHouseholdRepository) List() ([]models.Household, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	households := []models.Household{}
+	for id := uint(1); id <= r.nextID; id++ {
+		if household, ok := r.households[id]; ok {
+			households = append(households, household)
+		}
+	}
+	return households, nil
+}
+
+func (r *MemoryHouseholdRepository) Get(id uint) (*models.Household, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	household, ok := r.households[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &household, nil
+}
+
+func (r *MemoryHouseholdRepository) Update(household *models.Household) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.households[household.ID]; !ok {
+		return ErrNotFound
+	}
+	household.UpdatedAt = time.Now()
+	r.households[household.ID] = *household
+	return nil
+}
+
+func (r *MemoryHouseholdRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.households[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.households, id)
+	return nil
+}
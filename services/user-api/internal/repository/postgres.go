@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"user-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type postgresUserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &postgresUserRepository{db: db}
+}
+
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *postgresUserRepository) Create(user *models.User) error {
+	err := r.db.Create(user).Error
+	if err != nil && strings.Contains(err.Error(), "duplicate key") {
+		return ErrDuplicateCPF
+	}
+	return err
+}
+
+func (r *postgresUserRepository) List(deleted bool) ([]models.User, error) {
+	db := r.db
+	if deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var users []models.User
+	err := db.Find(&users).Error
+	return users, err
+}
+
+func (r *postgresUserRepository) Get(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *postgresUserRepository) GetByCPF(cpf string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("cpf = ?", cpf).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *postgresUserRepository) GetByPhone(phone string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("phone_number = ?", phone).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *postgresUserRepository) GetDeleted(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *postgresUserRepository) GetDeletedByCPF(cpf string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Unscoped().Where("cpf = ? AND deleted_at IS NOT NULL", cpf).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *postgresUserRepository) Update(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+// teamColumns are the columns written by UpdateTeam
+var teamColumns = []string{"team_id", "ubs_id", "team_status", "team_confidence", "team_resolved_at", "team"}
+
+func (r *postgresUserRepository) UpdateTeam(user *models.User) error {
+	result := r.db.Model(user).Select(teamColumns).Updates(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresUserRepository) Delete(id uint) error {
+	return r.db.Delete(&models.User{}, id).Error
+}
+
+func (r *postgresUserRepository) Restore(id uint) error {
+	result := r.db.Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresUserRepository) ListForGeocoding(all bool) ([]models.User, error) {
+	query := r.db
+	if !all {
+		query = query.Where("geocoded_at IS NULL OR geocode_quality = ?", "failed")
+	}
+
+	var users []models.User
+	err := query.Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r *postgresUserRepository) ListByTeamStatus(statuses ...string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("team_status IN ?", statuses).Order("id").Find(&users).Error
+	return users, err
+}
+
+// completeCondition is models.User.Complete in SQL
+const completeCondition = "(phone_number <> '' OR managed_by_id IS NOT NULL) AND team_id IS NOT NULL AND latitude IS NOT NULL"
+
+func (r *postgresUserRepository) Search(filter UserFilter) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.TeamID != 0 {
+		query = query.Where("team_id = ?", filter.TeamID)
+	}
+	if filter.UBSID != 0 {
+		query = query.Where("ubs_id = ?", filter.UBSID)
+	}
+	if filter.Neighborhood != "" {
+		query = query.Where("LOWER(neighborhood) = LOWER(?)", filter.Neighborhood)
+	}
+	if filter.BornFrom != nil {
+		query = query.Where("date_of_birth >= ?", *filter.BornFrom)
+	}
+	if filter.BornTo != nil {
+		query = query.Where("date_of_birth <= ?", *filter.BornTo)
+	}
+	if filter.Complete != nil {
+		if *filter.Complete {
+			query = query.Where(completeCondition)
+		} else {
+			query = query.Where("NOT (" + completeCondition + ")")
+		}
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedTo)
+	}
+	if scope := filter.Scope; scope != nil {
+		// IN with an empty list matches nothing, as the scope says
+		query = query.Where("(team_id IN ? OR ubs_id IN ?)", nonEmpty(scope.TeamIDs), nonEmpty(scope.UBSIDs))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column := "id"
+	if SortColumns[filter.Sort] {
+		column = filter.Sort
+	}
+	direction := " ASC"
+	if filter.Desc {
+		direction = " DESC"
+	}
+	query = query.Order(column + direction)
+	if column != "id" {
+		query = query.Order("id")
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	var users []models.User
+	err := query.Find(&users).Error
+	return users, total, err
+}
+
+// nonEmpty keeps IN valid SQL for an empty list; no ID is 0
+func nonEmpty(ids []uint) []uint {
+	if len(ids) == 0 {
+		return []uint{0}
+	}
+	return ids
+}
+
+func (r *postgresUserRepository) ListByHousehold(householdID uint) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("household_id = ?", householdID).Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r *postgresUserRepository) ListDependents(managerID uint) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("managed_by_id = ?", managerID).Order("id").Find(&users).Error
+	return users, err
+}
+
+type postgresHouseholdRepository struct {
+	db *gorm.DB
+}
+
+func NewHouseholdRepository(db *gorm.DB) HouseholdRepository {
+	return &postgresHouseholdRepository{db: db}
+}
+
+func (r *postgresHouseholdRepository) Create(household *models.Household) error {
+	return r.db.Create(household).Error
+}
+
+func (r *postgresHouseholdRepository) CreateWithMembers(household *models.Household, members []models.HouseholdMemberInput) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(household).Error; err != nil {
+			return err
+		}
+		for _, member := range members {
+			// Only the household columns are written, and only while the
+			// user is still outside any household
+			result := tx.Model(&models.User{}).
+				Where("id = ? AND household_id IS NULL", member.UserID).
+				Updates(map[string]interface{}{"household_id": household.ID, "relationship": member.Relationship})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("user %d: %w", member.UserID, ErrInHousehold)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *postgresHouseholdRepository) List() ([]models.Household, error) {
+	var households []models.Household
+	err := r.db.Order("id").Find(&households).Error
+	return households, err
+}
+
+func (r *postgresHouseholdRepository) Get(id uint) (*models.Household, error) {
+	var household models.Household
+	if err := r.db.First(&household, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &household, nil
+}
+
+func (r *postgresHouseholdRepository) Update(household *models.Household) error {
+	return r.db.Save(household).Error
+}
+
+func (r *postgresHouseholdRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Household{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"user-api/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the user does not exist (or was deleted)
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicateCPF is returned when another user already has the CPF
+	ErrDuplicateCPF = errors.New("duplicate CPF")
+	// ErrInHousehold is returned when a user to be added to a household
+	// already belongs to one, or was deleted
+	ErrInHousehold = errors.New("user already belongs to a household")
+)
+
+// HouseholdRepository stores the households. Their members are the users
+// whose household_id points to them.
+type HouseholdRepository interface {
+	Create(household *models.Household) error
+	// CreateWithMembers creates the household and puts the members in it in
+	// one transaction. If one of them already belongs to a household nothing
+	// is stored and ErrInHousehold is returned.
+	CreateWithMembers(household *models.Household, members []models.HouseholdMemberInput) error
+	List() ([]models.Household, error)
+	Get(id uint) (*models.Household, error)
+	Update(household *models.Household) error
+	// Delete removes the household; its members must have left it before
+	Delete(id uint) error
+}
+
+// Columns the user listing can be sorted by
+var SortColumns = map[string]bool{
+	"id":            true,
+	"name":          true,
+	"date_of_birth": true,
+	"neighborhood":  true,
+	"created_at":    true,
+}
+
+// UserFilter selects, orders and pages the user listing. Empty fields do
+// not filter. Born and created bounds are inclusive.
+type UserFilter struct {
+	Deleted      bool
+	TeamID       uint
+	UBSID        uint
+	Neighborhood string // case insensitive
+	BornFrom     *time.Time
+	BornTo       *time.Time
+	Complete     *bool // see models.User.Complete
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+
+	// Scope restricts the listing to the users of the teams or UBS the
+	// caller works with; nil means no restriction
+	Scope *Scope
+
+	Sort   string // one of SortColumns, id by default; ties are broken by id
+	Desc   bool
+	Limit  int // 0 returns every user
+	Offset int
+}
+
+// Scope is what a caller may see: the users of any of the teams or of any
+// of the UBS. An empty scope sees no one.
+type Scope struct {
+	TeamIDs []uint
+	UBSIDs  []uint
+}
+
+// Allows reports whether the user is within the scope
+func (s *Scope) Allows(user models.User) bool {
+	if s == nil {
+		return true
+	}
+	for _, id := range s.TeamIDs {
+		if user.TeamID != nil && *user.TeamID == id {
+			return true
+		}
+	}
+	for _, id := range s.UBSIDs {
+		if user.UBSID != nil && *user.UBSID == id {
+			return true
+		}
+	}
+	return false
+}
+
+type UserRepository interface {
+	Create(user *models.User) error
+	// List returns active users, or only deleted ones when deleted is true
+	List(deleted bool) ([]models.User, error)
+	Get(id uint) (*models.User, error)
+	GetByCPF(cpf string) (*models.User, error)
+	GetByPhone(phone string) (*models.User, error)
+	GetDeleted(id uint) (*models.User, error)
+	GetDeletedByCPF(cpf string) (*models.User, error)
+	Update(user *models.User) error
+	// UpdateTeam stores only the team resolution of the user, so jobs that
+	// resolve teams in the background do not overwrite edits made meanwhile
+	UpdateTeam(user *models.User) error
+	Delete(id uint) error
+	Restore(id uint) error
+	// ListForGeocoding returns the active users never geocoded or whose
+	// geocoding failed, or every active user when all is true
+	ListForGeocoding(all bool) ([]models.User, error)
+	// ListByTeamStatus returns the active users whose team resolution has
+	// one of the statuses
+	ListByTeamStatus(statuses ...string) ([]models.User, error)
+	// Search returns the page of users matching the filter and how many
+	// users match it in total
+	Search(filter UserFilter) ([]models.User, int64, error)
+	// ListByHousehold returns the active members of the household
+	ListByHousehold(householdID uint) ([]models.User, error)
+	// ListDependents returns the active users managed by the user
+	ListDependents(managerID uint) ([]models.User, error)
+}
@@ -3,10 +3,13 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 	"user-api/internal/clients"
 	"user-api/internal/config"
 	"user-api/internal/database"
 	"user-api/internal/handlers"
+	"user-api/internal/notify"
+	"user-api/internal/repository"
 )
 
 func main() {
@@ -16,32 +19,67 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Schema management subcommand: main migrate <up|down|status|force>
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Geocode pending users: main geocode [-all] [-dry-run]
+	if len(os.Args) > 1 && os.Args[1] == "geocode" {
+		if err := runGeocode(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Geocoding failed: %v", err)
+		}
+		return
+	}
+
+	// Resolve the team of pending users: main resolve-teams [-all] [-dry-run]
+	if len(os.Args) > 1 && os.Args[1] == "resolve-teams" {
+		if err := runResolveTeams(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Team resolution failed: %v", err)
+		}
+		return
+	}
+
+	geocoder, err := newGeocoder(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load geocoder: %v", err)
+	}
+
 	// Initialize database
-	_, err := database.InitDB(
+	db, err := database.InitDB(
 		cfg.PostgresHost,
 		cfg.PostgresUser,
 		cfg.PostgresPassword,
 		cfg.PostgresDB,
 		cfg.PostgresPort,
+		cfg.MigrateOnStart,
 	)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Initialize address client
-	addressClient := clients.NewAddressClient(cfg)
-	handlers.SetAddressClient(addressClient)
+	// Permanently remove users deleted longer than the retention period
+	database.StartPurgeJob(db, cfg.DeletedRetention, cfg.PurgeInterval)
 
-	// Initialize router
-	mux := http.NewServeMux()
+	// Initialize handlers with their dependencies
+	users := repository.NewUserRepository(db)
+	addressClient := clients.NewAddressClient(cfg)
+	handler := handlers.NewHandler(users, repository.NewHouseholdRepository(db), addressClient, geocoder, cfg.ServiceToken)
 
-	// Register routes
-	mux.HandleFunc("/users/", handlers.HandleUsers)
-	mux.HandleFunc("/users/cpf/", handlers.HandleUsers)
+	// Store the new team of users whose street address-api moved to another
+	// team, and tell them about it
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if cfg.NotifyWebhookURL != "" {
+		notifier = notify.NewWebhook(cfg.NotifyWebhookURL)
+	}
+	notify.StartTeamChangeJob(users, addressClient, notifier, cfg.NotifyInterval)
 
 	// Start server
 	log.Printf("Starting server on port %s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
+	if err := http.ListenAndServe(":"+cfg.Port, handler.Routes(cfg.CORSAllowedOrigins)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
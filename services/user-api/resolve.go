@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"user-api/internal/assignment"
+	"user-api/internal/clients"
+	"user-api/internal/config"
+	"user-api/internal/database"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+const resolveUsage = "usage: resolve-teams [-all] [-dry-run]"
+
+// runResolveTeams implements the "resolve-teams" subcommand, which resolves
+// the team of the users never resolved, without a team or whose last lookup
+// failed, or of every user with -all
+func runResolveTeams(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("resolve-teams", flag.ContinueOnError)
+	all := flags.Bool("all", false, "resolve the team of every user again")
+	dryRun := flags.Bool("dry-run", false, "only report, without saving")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf(resolveUsage)
+	}
+
+	db, err := database.InitDB(
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+
+	addressClient := clients.NewAddressClient(cfg)
+	lookup := func(users []models.User) ([]models.TeamResult, error) {
+		return addressClient.GetTeamInfoBatch(assignment.Queries(users))
+	}
+	report, err := assignment.Batch(repository.NewUserRepository(db), lookup, *all, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]string, 0, len(report.ByStatus))
+	for status := range report.ByStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Printf("%-10s %d\n", status, report.ByStatus[status])
+	}
+	fmt.Printf("users: %d, changed: %d, errors: %d\n", report.Total, report.Changed, report.Errors)
+	return nil
+}
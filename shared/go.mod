@@ -0,0 +1,5 @@
+module shared
+
+go 1.23.2
+
+require golang.org/x/text v0.14.0
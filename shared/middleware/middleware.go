@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Middleware wraps an http.Handler with extra behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies the middlewares to h, the first one being the outermost
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+// RequestIDHeader is read from incoming requests and echoed in responses
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDFromContext returns the ID assigned by RequestID, if any
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// RequestID keeps the caller's X-Request-ID or generates a new one
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder remembers the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Logging logs every request with its status and duration
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		log.Printf("[%s] %s %s %d %s", RequestIDFromContext(r.Context()), r.Method, r.URL.RequestURI(), rec.status, time.Since(start))
+	})
+}
+
+// Recovery turns panics into a 500 JSON response instead of dropping the connection
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("[%s] panic: %v\n%s", RequestIDFromContext(r.Context()), err, debug.Stack())
+				writeJSONError(w, http.StatusInternalServerError, "Internal server error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORS allows browsers from allowedOrigins (comma separated, "*" for any)
+func CORS(allowedOrigins string) Middleware {
+	origins := map[string]bool{}
+	for _, origin := range strings.Split(allowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins[origin] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin != "" && (origins["*"] || origins[origin]) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+RequestIDHeader)
+				w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+				w.Header().Add("Vary", "Origin")
+			}
+
+			// Preflight requests never reach the handlers
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StripTrailingSlash makes "/users/" and "/users" reach the same route
+func StripTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+			r.URL.Path = strings.TrimRight(r.URL.Path, "/")
+			if r.URL.Path == "" {
+				r.URL.Path = "/"
+			}
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// JSONErrors replaces the plain text 404 and 405 responses written by
+// http.ServeMux with the same JSON body the handlers use
+func JSONErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&errorRewriter{ResponseWriter: w}, r)
+	})
+}
+
+type errorRewriter struct {
+	http.ResponseWriter
+	rewritten bool
+}
+
+func (e *errorRewriter) WriteHeader(code int) {
+	isPlainText := strings.HasPrefix(e.Header().Get("Content-Type"), "text/plain")
+	if isPlainText && (code == http.StatusNotFound || code == http.StatusMethodNotAllowed) {
+		e.rewritten = true
+		message := "Not found"
+		if code == http.StatusMethodNotAllowed {
+			message = "Method not allowed"
+		}
+		e.Header().Del("X-Content-Type-Options")
+		writeJSONError(e.ResponseWriter, code, message)
+		return
+	}
+	e.ResponseWriter.WriteHeader(code)
+}
+
+func (e *errorRewriter) Write(b []byte) (int, error) {
+	if e.rewritten {
+		// Discard the original plain text body
+		return len(b), nil
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, message string) {
+	response, _ := json.Marshal(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestIDFromContext(r.Context())))
+	})
+	mux.HandleFunc("GET /panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	return Chain(mux, Recovery, RequestID, Logging, CORS("http://painel.local"), StripTrailingSlash, JSONErrors)
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+		wantHeader map[string]string
+	}{
+		{
+			name:       "trailing slash reaches route",
+			method:     http.MethodGet,
+			target:     "/items/",
+			headers:    map[string]string{RequestIDHeader: "abc123"},
+			wantStatus: http.StatusOK,
+			wantBody:   "abc123",
+			wantHeader: map[string]string{RequestIDHeader: "abc123"},
+		},
+		{
+			name:       "not found is JSON",
+			method:     http.MethodGet,
+			target:     "/missing",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Not found","success":false}`,
+			wantHeader: map[string]string{"Content-Type": "application/json"},
+		},
+		{
+			name:       "method not allowed is JSON",
+			method:     http.MethodDelete,
+			target:     "/items",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method not allowed","success":false}`,
+			wantHeader: map[string]string{"Allow": "GET, HEAD"},
+		},
+		{
+			name:       "panic is recovered",
+			method:     http.MethodGet,
+			target:     "/panic",
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Internal server error","success":false}`,
+		},
+		{
+			name:   "CORS preflight",
+			method: http.MethodOptions,
+			target: "/items",
+			headers: map[string]string{
+				"Origin":                        "http://painel.local",
+				"Access-Control-Request-Method": http.MethodGet,
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": "http://painel.local"},
+		},
+		{
+			name:       "CORS ignores unknown origins",
+			method:     http.MethodGet,
+			target:     "/items",
+			headers:    map[string]string{"Origin": "http://outro.local"},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			newTestMux().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("expected body %s, got %s", tt.wantBody, rec.Body.String())
+			}
+			for k, v := range tt.wantHeader {
+				if got := rec.Header().Get(k); got != v {
+					t.Errorf("expected header %s=%q, got %q", k, v, got)
+				}
+			}
+			if rec.Header().Get(RequestIDHeader) == "" {
+				t.Errorf("expected %s header to be set", RequestIDHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+// Package migrate applies versioned SQL migrations, with optional Go data
+// steps, and implements the "migrate" subcommand of the services.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Usage documents the arguments accepted by Run
+const Usage = "usage: migrate up | down [steps] | status | force <version>"
+
+// DataStep is a Go step for data changes that SQL cannot express
+type DataStep func(tx *sql.Tx) error
+
+// Migration is a pair of up/down SQL scripts identified by a version number.
+// UpData is an optional Go step for data changes that SQL cannot express; it
+// runs after the up script, in the same transaction.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+	UpData  DataStep
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator applies a service's migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	table      string
+	migrations []Migration
+}
+
+// New loads the scripts in the migrations directory of fsys. The current
+// version is kept in table, which each service namespaces because they may
+// share the same Postgres database. data holds the Go steps by version.
+func New(db *sql.DB, table string, fsys fs.FS, data map[uint]DataStep) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{db: db, table: table, migrations: migrations}
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadMigrations reads files named <version>_<name>.(up|down).sql
+func loadMigrations(fsys fs.FS, data map[uint]DataStep) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		filename := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(filename, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration filename: %s", filename)
+		}
+		versionStr, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(versionStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", filename, err)
+		}
+
+		content, err := fs.ReadFile(fsys, "migrations/"+filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", filename, err)
+		}
+
+		migration, exists := byVersion[uint(version)]
+		if !exists {
+			migration = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d is missing its up or down script", migration.Version)
+		}
+		migration.UpData = data[migration.Version]
+		migrations = append(migrations, *migration)
+	}
+	for version := range data {
+		if byVersion[version] == nil {
+			return nil, fmt.Errorf("data migration %d has no up or down script", version)
+		}
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS ` + m.table + ` (
+		version BIGINT NOT NULL,
+		dirty BOOLEAN NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %v", err)
+	}
+	return nil
+}
+
+// LatestVersion is the version the embedded migrations bring the schema to
+func (m *Migrator) LatestVersion() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the current schema version. Dirty means the last migration
+// failed halfway and the schema must be fixed by hand and then forced.
+func (m *Migrator) Version() (uint, bool, error) {
+	var version uint
+	var dirty bool
+	err := m.db.QueryRow(`SELECT version, dirty FROM `+m.table+` LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %v", err)
+	}
+	return version, dirty, nil
+}
+
+func (m *Migrator) setVersion(exec interface {
+	Exec(string, ...any) (sql.Result, error)
+}, version uint, dirty bool) error {
+	if _, err := exec.Exec(`DELETE FROM ` + m.table); err != nil {
+		return err
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	_, err := exec.Exec(`INSERT INTO `+m.table+` (version, dirty) VALUES ($1, $2)`, version, dirty)
+	return err
+}
+
+// run executes a single script and its data step, if any. The version is
+// marked dirty first so that a failure leaves a trace even though the script
+// itself is rolled back.
+func (m *Migrator) run(script string, data DataStep, dirtyVersion, finalVersion uint) error {
+	if err := m.setVersion(m.db, dirtyVersion, true); err != nil {
+		return fmt.Errorf("failed to mark schema as dirty: %v", err)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if hasStatements(script) {
+		if _, err := tx.Exec(script); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if data != nil {
+		if err := data(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := m.setVersion(tx, finalVersion, false); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d, fix it and run 'migrate force'", current)
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version <= current {
+			continue
+		}
+		if err := m.run(migration.Up, migration.UpData, migration.Version, migration.Version); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %v", migration.Version, migration.Name, err)
+		}
+		current = migration.Version
+	}
+	return nil
+}
+
+// Down reverts the last steps applied migrations
+func (m *Migrator) Down(steps int) error {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d, fix it and run 'migrate force'", current)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current {
+			continue
+		}
+
+		var previous uint
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		if err := m.run(migration.Down, nil, migration.Version, previous); err != nil {
+			return fmt.Errorf("rollback of migration %d (%s) failed: %v", migration.Version, migration.Name, err)
+		}
+		current = previous
+		steps--
+	}
+	return nil
+}
+
+// Force sets the schema version without running any script
+func (m *Migrator) Force(version uint) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.setVersion(m.db, version, false)
+}
+
+// Status lists every embedded migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= current,
+		}
+	}
+	return statuses, nil
+}
+
+// CheckVersion fails unless the schema is clean and at the latest version
+func (m *Migrator) CheckVersion() error {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d", current)
+	}
+	if current != m.LatestVersion() {
+		return fmt.Errorf("schema is at version %d, expected %d (run 'migrate up')", current, m.LatestVersion())
+	}
+	return nil
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// hasStatements reports whether a script has anything besides comments;
+// scripts of data-only migrations just document what the Go step does
+func hasStatements(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// Run implements the "migrate" subcommand and prints the resulting version
+func (m *Migrator) Run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		if err := m.Down(steps); err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%04d %-30s %s\n", status.Version, status.Name, state)
+		}
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf(Usage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		if err := m.Force(uint(version)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf(Usage)
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version: %d (dirty: %t)\n", version, dirty)
+	return nil
+}
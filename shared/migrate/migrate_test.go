@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	step := func(*sql.Tx) error { return nil }
+	files := fstest.MapFS{
+		"migrations/0002_add_column.up.sql":   {Data: []byte("-- done by the data step\n")},
+		"migrations/0002_add_column.down.sql": {Data: []byte("SELECT 1;")},
+		"migrations/0001_create.up.sql":       {Data: []byte("CREATE TABLE t (id INT);")},
+		"migrations/0001_create.down.sql":     {Data: []byte("DROP TABLE t;")},
+	}
+
+	migrations, err := loadMigrations(files, map[uint]DataStep{2: step})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("expected versions 1 and 2 in order, got %+v", migrations)
+	}
+	if migrations[0].Name != "create" || migrations[0].UpData != nil {
+		t.Errorf("unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[1].UpData == nil {
+		t.Error("expected the data step on version 2")
+	}
+	if hasStatements(migrations[1].Up) || !hasStatements(migrations[0].Up) {
+		t.Error("expected only the first up script to have statements")
+	}
+
+	if _, err := loadMigrations(files, map[uint]DataStep{3: step}); err == nil {
+		t.Error("expected an error for a data step without scripts")
+	}
+
+	delete(files, "migrations/0001_create.down.sql")
+	if _, err := loadMigrations(files, nil); err == nil {
+		t.Error("expected an error for a missing down script")
+	}
+
+	files["migrations/create.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err := loadMigrations(files, nil); err == nil {
+		t.Error("expected an error for a file without a version")
+	}
+}
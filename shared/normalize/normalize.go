@@ -0,0 +1,155 @@
+// Package normalize padroniza nomes de ruas, tipos de logradouro, bairros e
+// cidades para que enderecos escritos de formas diferentes sejam comparaveis:
+// "Av. Dr. Carlos Botelho" e "Avenida Doutor Carlos Botelho" viram o mesmo
+// nome, assim como "R. Quinze de Novembro" e "Rua XV de Novembro".
+package normalize
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Text remove acentos (decompondo em NFD e descartando as marcas), troca
+// pontuacao por espacos e deixa tudo em maiusculo com espacos simples. E a
+// normalizacao usada para bairros e cidades.
+func Text(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// acento separado da letra pelo NFD
+		case r == 'º' || r == 'ª' || r == '°':
+			// indicadores ordinais: "1º de Maio"
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// StreetName normaliza o nome de uma rua: remove o tipo do inicio ("Rua das
+// Flores" vira "FLORES"), expande titulos abreviados, escreve numeros por
+// extenso como digitos e descarta preposicoes.
+func StreetName(name string) string {
+	words := strings.Fields(Text(name))
+	if n := streetTypeLength(words); n > 0 && n < len(words) {
+		words = words[n:]
+	}
+
+	var expanded []string
+	for _, word := range words {
+		if title, ok := titles[word]; ok {
+			expanded = append(expanded, strings.Fields(title)...)
+		} else {
+			expanded = append(expanded, word)
+		}
+	}
+
+	words = numbersToDigits(expanded)
+
+	var kept []string
+	for _, word := range words {
+		if !stopWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	// Um nome feito so de preposicoes fica como esta
+	if len(kept) == 0 {
+		kept = words
+	}
+	return strings.Join(kept, " ")
+}
+
+// StreetType devolve o tipo padronizado ("Av." vira "AVENIDA"); tipos
+// desconhecidos sao apenas normalizados como texto
+func StreetType(streetType string) string {
+	text := Text(streetType)
+	if standardType, ok := streetTypes[text]; ok {
+		return standardType
+	}
+	return text
+}
+
+// LookupStreetType diz se a palavra e um tipo de logradouro conhecido, por
+// extenso ou abreviado, e devolve o tipo padronizado
+func LookupStreetType(word string) (string, bool) {
+	standardType, ok := streetTypes[Text(word)]
+	return standardType, ok
+}
+
+// SplitStreetType separa o tipo do logradouro quando ele vem junto do nome,
+// como no ViaCEP ("Rua das Flores" vira "RUA" e "das Flores"). O nome volta
+// como foi escrito.
+func SplitStreetType(street string) (string, string) {
+	words := strings.Fields(street)
+	n := streetTypeLength(words)
+	if n == 0 || n == len(words) {
+		return "", strings.TrimSpace(street)
+	}
+	return streetTypes[Text(strings.Join(words[:n], " "))], strings.Join(words[n:], " ")
+}
+
+// streetTypeLength devolve quantas palavras do inicio formam um tipo de
+// logradouro (0, 1 ou 2, como em "Estrada Municipal")
+func streetTypeLength(words []string) int {
+	if len(words) >= 2 {
+		if _, ok := streetTypes[Text(words[0]+" "+words[1])]; ok {
+			return 2
+		}
+	}
+	if len(words) >= 1 {
+		if _, ok := streetTypes[Text(words[0])]; ok {
+			return 1
+		}
+	}
+	return 0
+}
+
+// numbersToDigits troca numeros por extenso ("VINTE E CINCO") por digitos e
+// numerais romanos de datas ("XV DE NOVEMBRO") pelo numero
+func numbersToDigits(words []string) []string {
+	var result []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if value, ok := numberWords[word]; ok {
+			// Dezena seguida de "E" e unidade: "VINTE E CINCO"
+			if value >= 20 && value%10 == 0 && i+2 < len(words) && words[i+1] == "E" {
+				if unit, ok := numberWords[words[i+2]]; ok && unit < 10 {
+					value += unit
+					i += 2
+				}
+			}
+			result = append(result, strconv.Itoa(value))
+			continue
+		}
+		if value, ok := roman(word); ok && i+1 < len(words) && words[i+1] == "DE" {
+			result = append(result, strconv.Itoa(value))
+			continue
+		}
+		result = append(result, word)
+	}
+	return result
+}
+
+// roman converte numerais romanos de I a XXXIX, o bastante para dias do mes
+func roman(word string) (int, bool) {
+	values := map[byte]int{'I': 1, 'V': 5, 'X': 10}
+	total := 0
+	for i := 0; i < len(word); i++ {
+		value, ok := values[word[i]]
+		if !ok {
+			return 0, false
+		}
+		if i+1 < len(word) && values[word[i+1]] > value {
+			total -= value
+		} else {
+			total += value
+		}
+	}
+	return total, total > 0 && total < 40
+}
@@ -0,0 +1,90 @@
+package normalize
+
+import "testing"
+
+func TestText(t *testing.T) {
+	tests := map[string]string{
+		"  São   José ":         "SAO JOSE",
+		"Vila Nery-Ávila":       "VILA NERY AVILA",
+		"D'Ávila":               "D AVILA",
+		"Jardim Paulistano II":  "JARDIM PAULISTANO II",
+		"Conceição (Centro)":    "CONCEICAO CENTRO",
+		"1º de Maio":            "1 DE MAIO",
+		"Pça. Antônio Prado nº": "PCA ANTONIO PRADO N",
+		"":                      "",
+	}
+	for input, want := range tests {
+		if got := Text(input); got != want {
+			t.Errorf("Text(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestStreetName(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"das Flores", "FLORES"},
+		{"Rua das Flores", "FLORES"},
+		{"Av. Dr. Carlos Botelho", "DOUTOR CARLOS BOTELHO"},
+		{"Doutor Carlos Botelho", "DOUTOR CARLOS BOTELHO"},
+		{"Prof. Luís Augusto de Oliveira", "PROFESSOR LUIS AUGUSTO OLIVEIRA"},
+		{"Sta. Cruz", "SANTA CRUZ"},
+		{"Cel. José Augusto de Oliveira Salles", "CORONEL JOSE AUGUSTO OLIVEIRA SALLES"},
+		{"Gal. Osório", "GENERAL OSORIO"},
+		{"Quinze de Novembro", "15 NOVEMBRO"},
+		{"XV de Novembro", "15 NOVEMBRO"},
+		{"Vinte e Cinco de Março", "25 MARCO"},
+		{"Primeiro de Maio", "1 MAIO"},
+		{"1º de Maio", "1 MAIO"},
+		{"Dom Pedro II", "DOM PEDRO II"},
+		{"Estrada Municipal Luiz Augusto", "LUIZ AUGUSTO"},
+		{"Est. Mun. Luiz Augusto", "LUIZ AUGUSTO"},
+		{"Travessa", "TRAVESSA"},
+		{"Dos", "DOS"},
+	}
+	for _, tt := range tests {
+		if got := StreetName(tt.input); got != tt.want {
+			t.Errorf("StreetName(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStreetType(t *testing.T) {
+	tests := map[string]string{
+		"R.":                "RUA",
+		"Av":                "AVENIDA",
+		"Pça.":              "PRACA",
+		"Tv.":               "TRAVESSA",
+		"viela":             "VIELA",
+		"Lgo.":              "LARGO",
+		"Rod.":              "RODOVIA",
+		"Estrada Municipal": "ESTRADA MUNICIPAL",
+		"Est. Mun.":         "ESTRADA MUNICIPAL",
+		"Servidão":          "SERVIDAO",
+		"Quadra":            "QUADRA",
+	}
+	for input, want := range tests {
+		if got := StreetType(input); got != want {
+			t.Errorf("StreetType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSplitStreetType(t *testing.T) {
+	tests := []struct {
+		input, wantType, wantName string
+	}{
+		{"Rua das Flores", "RUA", "das Flores"},
+		{"Estrada Municipal Luiz Augusto", "ESTRADA MUNICIPAL", "Luiz Augusto"},
+		{"Largo São Benedito", "LARGO", "São Benedito"},
+		{"das Flores", "", "das Flores"},
+		{"Travessa", "", "Travessa"},
+	}
+	for _, tt := range tests {
+		gotType, gotName := SplitStreetType(tt.input)
+		if gotType != tt.wantType || gotName != tt.wantName {
+			t.Errorf("SplitStreetType(%q) = %q, %q, want %q, %q", tt.input, gotType, gotName, tt.wantType, tt.wantName)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package normalize
+
+// streetTypes mapeia tipos de logradouro, por extenso e abreviados, ja
+// normalizados por Text (sem acento e sem pontuacao), para o tipo padrao
+var streetTypes = map[string]string{
+	"RUA": "RUA", "R": "RUA",
+	"AVENIDA": "AVENIDA", "AV": "AVENIDA", "AVE": "AVENIDA", "AVEN": "AVENIDA", "AVN": "AVENIDA",
+	"ALAMEDA": "ALAMEDA", "AL": "ALAMEDA",
+	"ESTRADA": "ESTRADA", "EST": "ESTRADA", "ESTR": "ESTRADA",
+	"ESTRADA MUNICIPAL": "ESTRADA MUNICIPAL", "EST MUN": "ESTRADA MUNICIPAL", "ESTR MUN": "ESTRADA MUNICIPAL",
+	"PRACA": "PRACA", "PC": "PRACA", "PCA": "PRACA", "PRC": "PRACA",
+	"TRAVESSA": "TRAVESSA", "TV": "TRAVESSA", "TRAV": "TRAVESSA", "TRV": "TRAVESSA",
+	"VIELA": "VIELA", "VLA": "VIELA",
+	"LARGO": "LARGO", "LGO": "LARGO", "LG": "LARGO",
+	"RODOVIA": "RODOVIA", "ROD": "RODOVIA",
+	"BECO": "BECO", "BC": "BECO",
+	"PASSAGEM": "PASSAGEM", "PSG": "PASSAGEM",
+	"LADEIRA": "LADEIRA", "LAD": "LADEIRA",
+	"CAMINHO": "CAMINHO", "CAM": "CAMINHO",
+	"ACESSO":  "ACESSO",
+	"VIADUTO": "VIADUTO", "VD": "VIADUTO",
+	"MARGINAL": "MARGINAL", "MARG": "MARGINAL",
+	"SERVIDAO": "SERVIDAO", "SERV": "SERVIDAO",
+	"VIA":       "VIA",
+	"RUELA":     "RUELA",
+	"CALCADAO":  "CALCADAO",
+	"ESPLANADA": "ESPLANADA",
+	"BOULEVARD": "BOULEVARD",
+}
+
+// titles expande os titulos abreviados nos nomes de ruas
+var titles = map[string]string{
+	"DR":     "DOUTOR",
+	"DRA":    "DOUTORA",
+	"PROF":   "PROFESSOR",
+	"PROFA":  "PROFESSORA",
+	"STA":    "SANTA",
+	"STO":    "SANTO",
+	"CEL":    "CORONEL",
+	"GAL":    "GENERAL",
+	"GEN":    "GENERAL",
+	"CAP":    "CAPITAO",
+	"TEN":    "TENENTE",
+	"MAL":    "MARECHAL",
+	"SGT":    "SARGENTO",
+	"BRIG":   "BRIGADEIRO",
+	"ALM":    "ALMIRANTE",
+	"COM":    "COMENDADOR",
+	"CONS":   "CONSELHEIRO",
+	"DES":    "DESEMBARGADOR",
+	"ENG":    "ENGENHEIRO",
+	"SEN":    "SENADOR",
+	"DEP":    "DEPUTADO",
+	"VER":    "VEREADOR",
+	"GOV":    "GOVERNADOR",
+	"PRES":   "PRESIDENTE",
+	"PE":     "PADRE",
+	"FR":     "FREI",
+	"MONS":   "MONSENHOR",
+	"SR":     "SENHOR",
+	"SRA":    "SENHORA",
+	"NSA":    "NOSSA SENHORA",
+	"NS":     "NOSSA SENHORA",
+	"VISC":   "VISCONDE",
+	"BAR":    "BARAO",
+	"MQS":    "MARQUES",
+	"DUQ":    "DUQUE",
+	"IMPER":  "IMPERATRIZ",
+	"PRINC":  "PRINCESA",
+	"MIN":    "MINISTRO",
+	"JORN":   "JORNALISTA",
+	"MAJ":    "MAJOR",
+	"ARQ":    "ARQUITETO",
+	"MAESTR": "MAESTRO",
+}
+
+// numberWords sao os numeros por extenso comuns em nomes de ruas e datas
+var numberWords = map[string]int{
+	"UM": 1, "UMA": 1, "PRIMEIRO": 1, "DOIS": 2, "DUAS": 2, "TRES": 3, "QUATRO": 4,
+	"CINCO": 5, "SEIS": 6, "SETE": 7, "OITO": 8, "NOVE": 9, "DEZ": 10,
+	"ONZE": 11, "DOZE": 12, "TREZE": 13, "QUATORZE": 14, "CATORZE": 14, "QUINZE": 15,
+	"DEZESSEIS": 16, "DEZESSETE": 17, "DEZOITO": 18, "DEZENOVE": 19,
+	"VINTE": 20, "TRINTA": 30, "QUARENTA": 40, "CINQUENTA": 50, "SESSENTA": 60,
+	"SETENTA": 70, "OITENTA": 80, "NOVENTA": 90, "CEM": 100,
+}
+
+// stopWords sao as preposicoes e conjuncoes que costumam ser omitidas ou
+// trocadas ("Rua das Flores", "Rua Flores")
+var stopWords = map[string]bool{
+	"D": true, "DE": true, "DA": true, "DO": true, "DAS": true, "DOS": true, "E": true,
+}
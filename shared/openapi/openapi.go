@@ -0,0 +1,228 @@
+// Package openapi builds the service's OpenAPI 3 document from the routes it
+// registers and the Go models they exchange, so the published spec cannot
+// drift from the code.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Param describes a path or query parameter
+type Param struct {
+	Name        string
+	In          string // "path" or "query"
+	Type        string // "string", "integer", "number" or "boolean"
+	Required    bool
+	Description string
+}
+
+// Operation documents one route. Request and Response are zero values of the
+// models exchanged; Response is wrapped in the APIResponse envelope.
+type Operation struct {
+	Summary     string
+	Tag         string
+	Params      []Param
+	Request     interface{}
+	ContentType string // request content type, defaults to application/json
+	Response    interface{}
+	Status      int // success status, defaults to 200
+}
+
+// Info is the document's info object
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is the OpenAPI 3 document served at /openapi.json
+type Document struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       Info                                   `json:"info"`
+	Paths      map[string]map[string]*operationObject `json:"paths"`
+	Components components                             `json:"components"`
+}
+
+type components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type operationObject struct {
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []parameterObject    `json:"parameters,omitempty"`
+	RequestBody *requestBodyObject   `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+
+	// Kept for request validation, not part of the published document
+	params      []Param
+	contentType string
+	body        *Schema
+}
+
+type parameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBodyObject struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// NewDocument creates an empty document with the shared error schema
+func NewDocument(info Info) *Document {
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*operationObject{},
+		Components: components{Schemas: map[string]*Schema{
+			"Error": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"success": {Type: "boolean"},
+					"error":   {Type: "string"},
+				},
+				Required: []string{"success", "error"},
+			},
+		}},
+	}
+	return d
+}
+
+// ServeHTTP publishes the document as JSON
+func (d *Document) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// add records op under a ServeMux pattern such as "GET /users/{id}"
+func (d *Document) add(pattern string, op Operation) *operationObject {
+	method, path, _ := strings.Cut(pattern, " ")
+	path = strings.TrimSuffix(strings.ReplaceAll(path, "...}", "}"), "/{$}")
+
+	obj := &operationObject{
+		Summary:     op.Summary,
+		Responses:   map[string]*response{},
+		params:      op.Params,
+		contentType: op.ContentType,
+	}
+	if op.Tag != "" {
+		obj.Tags = []string{op.Tag}
+	}
+
+	// Path parameters are strings unless op declares them
+	declared := map[string]bool{}
+	for _, p := range op.Params {
+		declared[p.Name] = true
+		obj.Parameters = append(obj.Parameters, p.object())
+	}
+	for _, name := range pathParams(path) {
+		if !declared[name] {
+			obj.Parameters = append(obj.Parameters, Param{Name: name, In: "path", Type: "string", Required: true}.object())
+		}
+	}
+
+	if op.Request != nil {
+		if obj.contentType == "" {
+			obj.contentType = "application/json"
+		}
+		obj.body = d.schemaOf(op.Request)
+		obj.RequestBody = &requestBodyObject{
+			Required: true,
+			Content:  map[string]mediaType{obj.contentType: {Schema: obj.body}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = map[string]mediaType{"application/json": {Schema: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"success": {Type: "boolean"},
+				"data":    d.schemaOf(op.Response),
+			},
+			Required: []string{"success"},
+		}}}
+	}
+	obj.Responses[strconv.Itoa(status)] = success
+	obj.Responses["default"] = &response{
+		Description: "Error",
+		Content:     map[string]mediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}},
+	}
+
+	if d.Paths[path] == nil {
+		d.Paths[path] = map[string]*operationObject{}
+	}
+	d.Paths[path][strings.ToLower(method)] = obj
+	return obj
+}
+
+func (p Param) object() parameterObject {
+	in := p.In
+	if in == "" {
+		in = "query"
+	}
+	typ := p.Type
+	if typ == "" {
+		typ = "string"
+	}
+	return parameterObject{
+		Name:        p.Name,
+		In:          in,
+		Required:    p.Required || in == "path",
+		Description: p.Description,
+		Schema:      &Schema{Type: typ},
+	}
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.Trim(segment, "{}"))
+		}
+	}
+	return names
+}
+
+// Router registers handlers on a ServeMux and documents them at the same time
+type Router struct {
+	mux *http.ServeMux
+	doc *Document
+}
+
+// NewRouter wraps mux, recording every route in doc
+func NewRouter(mux *http.ServeMux, doc *Document) *Router {
+	return &Router{mux: mux, doc: doc}
+}
+
+// Handle registers handler for pattern, validating requests against op
+func (r *Router) Handle(pattern string, handler http.HandlerFunc, op Operation) {
+	r.mux.Handle(pattern, validate(r.doc, r.doc.add(pattern, op), handler))
+}
+
+// ServeDocs publishes the document at /openapi.json and Swagger UI at /docs
+func (r *Router) ServeDocs() {
+	r.mux.Handle("GET /openapi.json", r.doc)
+	r.mux.Handle("GET /docs", SwaggerUI("/openapi.json", r.doc.Info.Title))
+}
@@ -0,0 +1,192 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testParent struct {
+	ID       uint          `json:"id"`
+	Name     string        `json:"name" binding:"required"`
+	Born     time.Time     `json:"born" binding:"required"`
+	Children []testChild   `json:"children,omitempty"`
+	Ignored  string        `json:"-"`
+	Extra    interface{}   `json:"extra"`
+	Parent   *testParent   `json:"parent,omitempty"`
+	Tags     []string      `json:"tags"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+type testChild struct {
+	Age int `json:"age" binding:"required"`
+}
+
+func newTestRouter() (*http.ServeMux, *Document) {
+	mux := http.NewServeMux()
+	doc := NewDocument(Info{Title: "Test API", Version: "1.0.0"})
+	router := NewRouter(mux, doc)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	router.Handle("POST /parents", ok, Operation{Request: testParent{}, Response: testParent{}, Status: http.StatusCreated})
+	router.Handle("GET /parents/{id}", ok, Operation{Params: []Param{{Name: "id", In: "path", Type: "integer"}}})
+	router.Handle("GET /search", ok, Operation{Params: []Param{
+		{Name: "q", Required: true},
+		{Name: "limit", Type: "integer"},
+	}})
+	router.ServeDocs()
+	return mux, doc
+}
+
+func TestDocument(t *testing.T) {
+	mux, _ := newTestRouter()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+
+	parent := doc.Components.Schemas["testParent"]
+	if parent == nil {
+		t.Fatalf("expected testParent component, got %v", doc.Components.Schemas)
+	}
+	if got := strings.Join(parent.Required, ","); got != "name,born" {
+		t.Errorf("unexpected required fields %q", got)
+	}
+	if _, ok := parent.Properties["-"]; ok {
+		t.Error("ignored field should not be documented")
+	}
+	if p := parent.Properties["born"]; p.Type != "string" || p.Format != "date-time" {
+		t.Errorf("unexpected time schema %+v", p)
+	}
+	if p := parent.Properties["parent"]; p.Ref != "#/components/schemas/testParent" {
+		t.Errorf("expected self reference, got %+v", p)
+	}
+	if p := parent.Properties["children"]; p.Type != "array" || p.Items.Ref != "#/components/schemas/testChild" {
+		t.Errorf("unexpected array schema %+v", p)
+	}
+
+	if _, ok := doc.Paths["/parents"]["post"].Responses["201"]; !ok {
+		t.Error("expected 201 response for POST /parents")
+	}
+	params := doc.Paths["/parents/{id}"]["get"].Parameters
+	if len(params) != 1 || params[0].In != "path" || params[0].Schema.Type != "integer" || !params[0].Required {
+		t.Errorf("unexpected path parameters %+v", params)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/openapi.json") {
+		t.Errorf("unexpected Swagger UI page: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "valid body",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `{"name":"Ana","born":"1990-01-01T00:00:00Z","children":[{"age":3}],"extra":{"a":1}}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "missing required fields",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `{"name":""}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid request: born is required; name is required",
+		},
+		{
+			name:       "wrong types",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `{"name":"Ana","born":"ontem","children":[{"age":1.5}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "born must be an RFC 3339 date-time",
+		},
+		{
+			name:       "nested required field",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `{"name":"Ana","born":"1990-01-01T00:00:00Z","children":[{}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid request: children[0].age is required",
+		},
+		{
+			name:       "keys match case-insensitively like encoding/json",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `{"Name":"Ana","BORN":"1990-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "malformed JSON",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid request payload",
+		},
+		{
+			name:       "body must be an object",
+			method:     http.MethodPost,
+			target:     "/parents",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid request: body must be an object",
+		},
+		{
+			name:       "required query parameter",
+			method:     http.MethodGet,
+			target:     "/search?limit=x",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid request: query parameter q is required; query parameter limit must be an integer",
+		},
+		{
+			name:       "valid query",
+			method:     http.MethodGet,
+			target:     "/search?q=flores&limit=5",
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	mux, _ := newTestRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantError == "" {
+				return
+			}
+			var resp struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("expected error containing %q, got %q", tt.wantError, resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+)
+
+// SwaggerUI serves a page rendering the document at specURL. The assets are
+// loaded from the swagger-ui-dist package on unpkg.
+func SwaggerUI(specURL, title string) http.Handler {
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`, html.EscapeString(title), specURL)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+}
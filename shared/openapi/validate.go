@@ -0,0 +1,201 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate rejects requests that do not match the documented query parameters
+// or JSON body before they reach handler
+func validate(doc *Document, op *operationObject, handler http.HandlerFunc) http.Handler {
+	validatesBody := op.body != nil && op.contentType == "application/json"
+	validatesQuery := false
+	for _, p := range op.params {
+		if p.In == "" || p.In == "query" {
+			validatesQuery = true
+		}
+	}
+	if !validatesBody && !validatesQuery {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problems := checkQuery(op.params, r)
+
+		if validatesBody {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				writeError(w, "Invalid request payload")
+				return
+			}
+			// Handlers decode the body again
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var payload interface{}
+			if err := json.Unmarshal(body, &payload); err != nil {
+				writeError(w, "Invalid request payload")
+				return
+			}
+			problems = append(problems, doc.check("", op.body, payload)...)
+		}
+
+		if len(problems) > 0 {
+			writeError(w, "Invalid request: "+strings.Join(problems, "; "))
+			return
+		}
+		handler(w, r)
+	})
+}
+
+func checkQuery(params []Param, r *http.Request) []string {
+	var problems []string
+	query := r.URL.Query()
+	for _, p := range params {
+		if p.In != "" && p.In != "query" {
+			continue
+		}
+		value := query.Get(p.Name)
+		if value == "" {
+			if p.Required {
+				problems = append(problems, fmt.Sprintf("query parameter %s is required", p.Name))
+			}
+			continue
+		}
+		if !scalarMatches(p.Type, value) {
+			problems = append(problems, fmt.Sprintf("query parameter %s must be %s", p.Name, article(p.Type)))
+		}
+	}
+	return problems
+}
+
+func scalarMatches(typ, value string) bool {
+	var err error
+	switch typ {
+	case "integer":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+	return err == nil
+}
+
+// check compares a decoded JSON value against s, returning one message per problem
+func (d *Document) check(path string, s *Schema, value interface{}) []string {
+	if s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	// encoding/json accepts null for any field, required ones are checked by the parent
+	if s.Type == "" || value == nil {
+		return nil
+	}
+
+	ok := true
+	switch s.Type {
+	case "object":
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			ok = false
+			break
+		}
+		var problems []string
+		for _, name := range s.Required {
+			if v, present := lookup(object, name); !present || v == nil || v == "" {
+				problems = append(problems, fmt.Sprintf("%s is required", join(path, name)))
+			}
+		}
+		for name, property := range s.Properties {
+			if v, present := lookup(object, name); present {
+				problems = append(problems, d.check(join(path, name), property, v)...)
+			}
+		}
+		sort.Strings(problems)
+		return problems
+	case "array":
+		items, isArray := value.([]interface{})
+		if !isArray {
+			ok = false
+			break
+		}
+		var problems []string
+		for i, v := range items {
+			problems = append(problems, d.check(fmt.Sprintf("%s[%d]", path, i), s.Items, v)...)
+		}
+		return problems
+	case "string":
+		str, isString := value.(string)
+		ok = isString
+		if ok && s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return []string{fmt.Sprintf("%s must be an RFC 3339 date-time", path)}
+			}
+		}
+	case "integer":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == math.Trunc(n)
+	case "number":
+		_, ok = value.(float64)
+	case "boolean":
+		_, ok = value.(bool)
+	}
+
+	if !ok {
+		if path == "" {
+			path = "body"
+		}
+		return []string{fmt.Sprintf("%s must be %s", path, article(s.Type))}
+	}
+	return nil
+}
+
+// lookup finds a key the way encoding/json does, preferring an exact match
+// but accepting any case
+func lookup(object map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := object[name]; ok {
+		return v, true
+	}
+	for key, v := range object {
+		if strings.EqualFold(key, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func article(typ string) string {
+	switch typ {
+	case "", "string":
+		return "a string"
+	case "integer", "object", "array":
+		return "an " + typ
+	default:
+		return "a " + typ
+	}
+}
+
+func writeError(w http.ResponseWriter, message string) {
+	response, _ := json.Marshal(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(response)
+}